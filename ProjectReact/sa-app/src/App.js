@@ -1,5 +1,5 @@
 import React from 'react';
-import { Routes, Route, Navigate } from 'react-router-dom'; 
+import { Routes, Route, Navigate, useLocation } from 'react-router-dom';
 import SideBar from "./components/SideBar";
 import EmployeePage from './components/EmployeePage';
 import DepartmentPage from './components/DepartmentPage';
@@ -7,8 +7,20 @@ import SalaryPage from './components/PayrollPage';
 import EmployeeCreate from './components/EmployeeCreate';
 import DepartmentCreate from './components/DepartmentCreate';
 import PayrollCreate from './components/PayrollCreate';
+import Login from './components/Login';
+import { isLoggedIn } from './api';
 
 function App() {
+  const location = useLocation();
+
+  // The login page has no sidebar; every other page needs a signed-in user
+  if (location.pathname === "/login") {
+    return <Login />;
+  }
+  if (!isLoggedIn()) {
+    return <Navigate to="/login" />;
+  }
+
   return (
     <div className="App" style={{ display: "flex" }}>
       {/* Sidebar will always be visible */}
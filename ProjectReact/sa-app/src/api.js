@@ -0,0 +1,120 @@
+// apiFetch wraps fetch for the payroll API: it sends the access token, renews it once with the
+// refresh token when the server answers 401, and sends the user to /login when that fails too.
+const API_BASE = "http://localhost:8080/api/v1";
+
+const ACCESS_KEY = "access_token";
+const REFRESH_KEY = "refresh_token";
+
+export function saveTokens(tokens) {
+  localStorage.setItem(ACCESS_KEY, tokens.access_token);
+  localStorage.setItem(REFRESH_KEY, tokens.refresh_token);
+}
+
+export function clearTokens() {
+  localStorage.removeItem(ACCESS_KEY);
+  localStorage.removeItem(REFRESH_KEY);
+}
+
+export function isLoggedIn() {
+  return localStorage.getItem(ACCESS_KEY) !== null;
+}
+
+function withToken(options) {
+  const headers = new Headers(options.headers);
+  const token = localStorage.getItem(ACCESS_KEY);
+  if (token) {
+    headers.set("Authorization", `Bearer ${token}`);
+  }
+  return { ...options, headers };
+}
+
+async function refresh() {
+  const refreshToken = localStorage.getItem(REFRESH_KEY);
+  if (!refreshToken) {
+    return false;
+  }
+  const response = await fetch(`${API_BASE}/auth/refresh`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ refresh_token: refreshToken }),
+  });
+  if (!response.ok) {
+    return false;
+  }
+  saveTokens(await response.json());
+  return true;
+}
+
+export async function apiFetch(url, options = {}) {
+  let response = await fetch(url, withToken(options));
+  if (response.status === 401 && (await refresh())) {
+    response = await fetch(url, withToken(options));
+  }
+  if (response.status === 401) {
+    clearTokens();
+    window.location.assign("/login");
+  }
+  return response;
+}
+
+// PAGE_SIZE is how many rows a list page loads at a time
+export const PAGE_SIZE = 50;
+
+// apiFetchPage reads one page of a list endpoint, PAGE_SIZE rows unless url sets a limit, starting
+// at cursor when one is given. It returns the rows, the cursor of the next page (null on the last)
+// and how many rows match in all.
+export async function apiFetchPage(url, cursor = null, options = {}) {
+  const pageURL = new URL(url);
+  if (!pageURL.searchParams.has("limit")) {
+    pageURL.searchParams.set("limit", String(PAGE_SIZE));
+  }
+  if (cursor) {
+    pageURL.searchParams.set("cursor", cursor);
+  }
+  const response = await apiFetch(pageURL.toString(), options);
+  if (!response.ok) {
+    throw new Error("Network response was not ok");
+  }
+  const rows = await response.json();
+  return {
+    rows,
+    nextCursor: response.headers.get("X-Next-Cursor") || null,
+    total: Number(response.headers.get("X-Total-Count") || rows.length),
+  };
+}
+
+// apiFetchAll reads every page of a list endpoint, following the cursor the server sends in
+// X-Next-Cursor until the last page, and returns the rows of all pages in order. It is meant for
+// short lists such as the employees to choose from; long tables page with usePagedList.
+export async function apiFetchAll(url, options = {}) {
+  const rows = [];
+  const pageURL = new URL(url);
+  if (!pageURL.searchParams.has("limit")) {
+    pageURL.searchParams.set("limit", "500");
+  }
+  let cursor = null;
+  do {
+    const page = await apiFetchPage(pageURL.toString(), cursor, options);
+    rows.push(...page.rows);
+    cursor = page.nextCursor;
+  } while (cursor);
+  return rows;
+}
+
+export async function login(username, password) {
+  const response = await fetch(`${API_BASE}/auth/login`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ username, password }),
+  });
+  if (!response.ok) {
+    const problem = await response.json().catch(() => ({}));
+    throw new Error(problem.detail || response.statusText);
+  }
+  saveTokens(await response.json());
+}
+
+export async function logout() {
+  await apiFetch(`${API_BASE}/auth/logout`, { method: "POST" }).catch(() => {});
+  clearTokens();
+}
@@ -6,6 +6,7 @@ import Grid from '@mui/material/Grid';
 import TextField from '@mui/material/TextField';
 import Button from '@mui/material/Button';
 import { useNavigate } from 'react-router-dom'; // นำเข้า useNavigate
+import { apiFetch } from '../api';
 
 export default function DepartmentCreate() {
   const [deptId, setDeptId] = useState('');
@@ -30,7 +31,7 @@ export default function DepartmentCreate() {
       redirect: "follow"
     };
 
-    fetch("http://localhost:8080/api/v1/departments", requestOptions)
+    apiFetch("http://localhost:8080/api/v1/departments", requestOptions)
       .then((response) => {
         if (!response.ok) {
           throw new Error('Network response was not ok ' + response.statusText); // ตรวจสอบสถานะการตอบกลับ
@@ -13,13 +13,14 @@ import TableHead from '@mui/material/TableHead';
 import TableRow from '@mui/material/TableRow';
 import Link from '@mui/material/Link';
 import ButtonGroup from '@mui/material/ButtonGroup';
+import { apiFetch } from '../api';
 
 export default function DepartmentPage() {
   const [items, setItems] = useState([]);
   const [error, setError] = useState(null);
 
   useEffect(() => {
-    fetch("http://localhost:8080/api/v1/departments")
+    apiFetch("http://localhost:8080/api/v1/departments")
       .then(res => {
         if (!res.ok) {
           throw new Error("Network response was not ok");
@@ -43,7 +44,7 @@ export default function DepartmentPage() {
     };
 
     try {
-      const response = await fetch(`http://localhost:8080/api/v1/departments/${dept_id}`, requestOptions);
+      const response = await apiFetch(`http://localhost:8080/api/v1/departments/${dept_id}`, requestOptions);
       if (!response.ok) {
         throw new Error("Failed to delete department");
       }
@@ -4,8 +4,10 @@ import Container from '@mui/material/Container';
 import Typography from '@mui/material/Typography';
 import Grid from '@mui/material/Grid';
 import TextField from '@mui/material/TextField';
+import MenuItem from '@mui/material/MenuItem';
 import Button from '@mui/material/Button';
 import { useNavigate } from 'react-router-dom';
+import { apiFetch } from '../api';
 
 export default function EmployeeCreate() {
   const [empId, setEmpId] = useState('');
@@ -16,13 +18,19 @@ export default function EmployeeCreate() {
   const [departments, setDepartments] = useState({});  // เก็บข้อมูลแผนกจาก API
   const [positionName, setPositionName] = useState('');
   const [baseSalary, setSalary] = useState('');
+  const [payFrequency, setPayFrequency] = useState('monthly');
+  const [payType, setPayType] = useState('salaried');
+  const [wageRate, setWageRate] = useState('');
+  const [province, setProvince] = useState('');
+  const [siteId, setSiteId] = useState('');
+  const [sites, setSites] = useState([]);  // สถานที่ทำงานและวันทำงานของแต่ละแห่ง
   const [bankAccount, setBank] = useState('');
   const [accountNum, setAccountNum] = useState('');
   const navigate = useNavigate();
 
   // ใช้ useEffect ดึงข้อมูลแผนกจาก API
   useEffect(() => {
-    fetch("http://localhost:8080/api/v1/departments")
+    apiFetch("http://localhost:8080/api/v1/departments")
       .then(response => response.json())
       .then(data => {
         const departmentData = {};
@@ -34,6 +42,13 @@ export default function EmployeeCreate() {
       .catch(error => {
         console.error('Error fetching departments:', error);
       });
+
+    apiFetch("http://localhost:8080/api/v1/work-sites")
+      .then(response => response.json())
+      .then(data => setSites(data))
+      .catch(error => {
+        console.error('Error fetching work sites:', error);
+      });
   }, []);
 
   // ฟังก์ชันสำหรับดึงชื่อแผนกตามรหัสแผนก
@@ -61,7 +76,12 @@ export default function EmployeeCreate() {
       "phone_number": phoneNumber,
       "dept_id": parseInt(deptId),         // แปลงเป็น integer
       "position_name": positionName,
-      "base_salary": parseFloat(baseSalary), // Ensure it's a float
+      "base_salary": payType === 'salaried' ? parseFloat(baseSalary) : 0, // Ensure it's a float
+      "pay_frequency": payFrequency,
+      "pay_type": payType,
+      "wage_rate": payType === 'salaried' ? 0 : parseFloat(wageRate),
+      "province": province.trim(),
+      "site_id": siteId,
       "bank_account": bankAccount,
       "account_num": accountNum
     });
@@ -73,7 +93,7 @@ export default function EmployeeCreate() {
       redirect: "follow"
     };
 
-    fetch("http://localhost:8080/api/v1/employees", requestOptions)
+    apiFetch("http://localhost:8080/api/v1/employees", requestOptions)
       .then((response) => {
         if (!response.ok) {
           return response.text().then(text => { throw new Error(text); });
@@ -179,17 +199,88 @@ export default function EmployeeCreate() {
             ข้อมูลเงินเดือน
           </Typography>
           <Grid container spacing={2}>
+            <Grid item xs={12} sm={6}>
+              <TextField
+                select
+                id="pay_type"
+                label="รูปแบบค่าจ้าง"
+                variant="outlined"
+                fullWidth
+                value={payType}
+                onChange={(e) => setPayType(e.target.value)}
+              >
+                <MenuItem value="salaried">รายเดือน</MenuItem>
+                <MenuItem value="daily">รายวัน</MenuItem>
+                <MenuItem value="hourly">รายชั่วโมง</MenuItem>
+              </TextField>
+            </Grid>
+            <Grid item xs={12} sm={6}>
+              <TextField
+                select
+                id="pay_frequency"
+                label="รอบการจ่าย"
+                variant="outlined"
+                fullWidth
+                value={payFrequency}
+                onChange={(e) => setPayFrequency(e.target.value)}
+              >
+                <MenuItem value="monthly">เดือนละครั้ง</MenuItem>
+                <MenuItem value="semi_monthly">เดือนละสองครั้ง</MenuItem>
+                <MenuItem value="weekly">ทุกสัปดาห์</MenuItem>
+                <MenuItem value="biweekly">ทุกสองสัปดาห์</MenuItem>
+              </TextField>
+            </Grid>
+            <Grid item xs={12}>
+              {payType === 'salaried' ? (
+                <TextField
+                  id="base_salary"
+                  label="เงินเดือน"
+                  variant="outlined"
+                  fullWidth
+                  required
+                  value={baseSalary}
+                  onChange={(e) => setSalary(e.target.value)}
+                />
+              ) : (
+                <TextField
+                  id="wage_rate"
+                  label={payType === 'daily' ? 'ค่าจ้างต่อวัน' : 'ค่าจ้างต่อชั่วโมง'}
+                  variant="outlined"
+                  fullWidth
+                  required
+                  value={wageRate}
+                  onChange={(e) => setWageRate(e.target.value)}
+                />
+              )}
+            </Grid>
             <Grid item xs={12}>
               <TextField
-                id="base_salary"
-                label="เงินเดือน"
+                id="province"
+                label="จังหวัดที่ทำงาน"
                 variant="outlined"
                 fullWidth
-                required
-                value={baseSalary}
-                onChange={(e) => setSalary(e.target.value)}
+                helperText="ใช้ตรวจสอบค่าจ้างขั้นต่ำของจังหวัด เช่น กรุงเทพมหานคร"
+                value={province}
+                onChange={(e) => setProvince(e.target.value)}
               />
             </Grid>
+            <Grid item xs={12}>
+              <TextField
+                id="site_id"
+                select
+                label="สถานที่ทำงาน"
+                variant="outlined"
+                fullWidth
+                helperText="กำหนดวันทำงานและวันหยุดบริษัทที่ใช้คำนวณเงินเดือน"
+                value={siteId}
+                onChange={(e) => setSiteId(e.target.value)}
+              >
+                <MenuItem value="">ไม่ระบุ (จันทร์–ศุกร์)</MenuItem>
+                {sites.map(site => (
+                  <MenuItem key={site.site_id} value={site.site_id}>{site.site_name}</MenuItem>
+                ))}
+              </TextField>
+            </Grid>
             <Grid item xs={12} sm={6}>
               <TextField
                 id="bank_account"
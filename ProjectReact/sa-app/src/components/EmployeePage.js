@@ -1,4 +1,4 @@
-import React, { useEffect, useState } from 'react';
+import React from 'react';
 import CssBaseline from '@mui/material/CssBaseline';
 import Box from '@mui/material/Box';
 import Container from '@mui/material/Container';
@@ -13,27 +13,13 @@ import TableHead from '@mui/material/TableHead';
 import TableRow from '@mui/material/TableRow';
 import Link from '@mui/material/Link';
 import ButtonGroup from '@mui/material/ButtonGroup';
+import { apiFetch } from '../api';
+import usePagedList from '../usePagedList';
 
 export default function EmployeePage() {
-  const [employees, setEmployees] = useState([]);
-
-  useEffect(() => {
-    const fetchEmployees = async () => {
-      try {
-        const response = await fetch('http://localhost:8080/api/v1/employees');
-        if (!response.ok) {
-          throw new Error('Network response was not ok');
-        }
-        const data = await response.json();
-        console.log(data);
-        setEmployees(data);
-      } catch (error) {
-        console.error('Error fetching employees:', error);
-      }
-    };
-
-    fetchEmployees();
-  }, []);
+  // โหลดรายชื่อพนักงานทีละหน้า
+  const { rows: employees, setRows: setEmployees, total, hasMore, loading, loadMore } =
+    usePagedList('http://localhost:8080/api/v1/employees');
 
   const handleDelete = async (emp_id) => {
     // แสดงการยืนยันก่อนลบ
@@ -48,7 +34,7 @@ export default function EmployeePage() {
     };
   
     try {
-      const response = await fetch(`http://localhost:8080/api/v1/employees/${emp_id}`, requestOptions);
+      const response = await apiFetch(`http://localhost:8080/api/v1/employees/${emp_id}`, requestOptions);
       if (!response.ok) {
         throw new Error('Network response was not ok');
       }
@@ -114,6 +100,17 @@ export default function EmployeePage() {
               </TableBody>
             </Table>
           </TableContainer>
+
+          <Box sx={{ p: 2 }}>
+            <Typography variant="body2">
+              แสดง {employees.length} จาก {total} คน
+            </Typography>
+            {hasMore && (
+              <Button variant="outlined" onClick={loadMore} disabled={loading} sx={{ mt: 1 }}>
+                โหลดเพิ่มเติม
+              </Button>
+            )}
+          </Box>
         </Paper>
       </Container>
     </React.Fragment>
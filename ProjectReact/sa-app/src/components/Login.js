@@ -0,0 +1,76 @@
+import React, { useState } from 'react';
+import CssBaseline from '@mui/material/CssBaseline';
+import Container from '@mui/material/Container';
+import Typography from '@mui/material/Typography';
+import Grid from '@mui/material/Grid';
+import TextField from '@mui/material/TextField';
+import Button from '@mui/material/Button';
+import { useNavigate } from 'react-router-dom';
+import { login } from '../api';
+
+export default function Login() {
+  const [username, setUsername] = useState('');
+  const [password, setPassword] = useState('');
+  const [error, setError] = useState('');
+  const navigate = useNavigate();
+
+  const handleSubmit = async (event) => {
+    event.preventDefault();
+    try {
+      await login(username, password);
+      navigate("/Page1");
+    } catch (err) {
+      setError(`เข้าสู่ระบบไม่สำเร็จ: ${err.message}`);
+    }
+  };
+
+  return (
+    <React.Fragment>
+      <CssBaseline />
+      <Container maxWidth="xs" sx={{ p: 2 }}>
+        <Typography variant="h6" gutterBottom component="div">
+          เข้าสู่ระบบ
+        </Typography>
+        <form onSubmit={handleSubmit}>
+          <Grid container spacing={2}>
+            <Grid item xs={12}>
+              <TextField
+                id="username"
+                label="ชื่อผู้ใช้"
+                variant="outlined"
+                fullWidth
+                required
+                autoComplete="username"
+                value={username}
+                onChange={(e) => setUsername(e.target.value)}
+              />
+            </Grid>
+            <Grid item xs={12}>
+              <TextField
+                id="password"
+                label="รหัสผ่าน"
+                type="password"
+                variant="outlined"
+                fullWidth
+                required
+                autoComplete="current-password"
+                value={password}
+                onChange={(e) => setPassword(e.target.value)}
+              />
+            </Grid>
+            {error && (
+              <Grid item xs={12}>
+                <Typography color="error">{error}</Typography>
+              </Grid>
+            )}
+            <Grid item xs={12}>
+              <Button variant="contained" fullWidth type="submit">
+                เข้าสู่ระบบ
+              </Button>
+            </Grid>
+          </Grid>
+        </form>
+      </Container>
+    </React.Fragment>
+  );
+}
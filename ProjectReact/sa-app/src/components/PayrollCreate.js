@@ -1,4 +1,4 @@
-import React, { useState, useEffect } from 'react';
+import React, { useState, useEffect, useRef } from 'react';
 import CssBaseline from '@mui/material/CssBaseline';
 import Container from '@mui/material/Container';
 import Typography from '@mui/material/Typography';
@@ -7,27 +7,33 @@ import Paper from '@mui/material/Paper';
 import TextField from '@mui/material/TextField';
 import MenuItem from '@mui/material/MenuItem';
 import { useNavigate } from 'react-router-dom';
+import { apiFetch, apiFetchAll } from '../api';
 
 export default function CreatePayroll() {
   const [employees, setEmployees] = useState([]);
   const [selectedEmp, setSelectedEmp] = useState(null);
+  const [periods, setPeriods] = useState([]);
   const [payMonth, setPayMonth] = useState('');
   const [payDate, setPayDate] = useState('');
-  const [baseSalary, setBaseSalary] = useState(0);
+  const [daysWorked, setDaysWorked] = useState(0);
+  const [hoursWorked, setHoursWorked] = useState(0);
   const [commission, setCommission] = useState(0);
   const [overtime, setOvertime] = useState(0);
-  const [totalAdditions, setTotalAdditions] = useState(0);
-  const [totalDeductions, setTotalDeductions] = useState(0);
-  const [netSalary, setNetSalary] = useState(0);
-  const [tax, setTax] = useState(0);
+  const [deductions, setDeductions] = useState(0);
+  const [calculation, setCalculation] = useState(null);
+  const [submitting, setSubmitting] = useState(false);
+  // ส่ง Idempotency-Key เดิมเมื่อส่งซ้ำ เซิร์ฟเวอร์จะคืนผลเดิมแทนการบันทึกซ้ำ
+  const idempotencyKey = useRef(crypto.randomUUID());
   const navigate = useNavigate();
 
   // Fetching employees on component mount
   useEffect(() => {
-    fetch("http://localhost:8080/api/v1/employees")
-      .then(res => res.json())
+    apiFetchAll("http://localhost:8080/api/v1/employees")
       .then(result => {
         setEmployees(result);
+      })
+      .catch(error => {
+        console.error("Error fetching employees:", error);
       });
   }, []);
 
@@ -35,81 +41,89 @@ export default function CreatePayroll() {
   const handleEmployeeSelect = (e) => {
     const emp = employees.find(emp => emp.emp_id === e.target.value);
     setSelectedEmp(emp);
-    setBaseSalary(emp ? emp.base_salary : 0);
+    setPayMonth('');
+    setPayDate('');
+    setPeriods([]);
+    setCalculation(null);
+    if (emp) {
+      // ดึงรอบการจ่ายของปีนี้ตามความถี่การจ่ายของพนักงาน
+      const year = new Date().getFullYear();
+      apiFetch(`http://localhost:8080/api/v1/pay-calendars/${emp.pay_frequency || 'monthly'}/${year}`)
+        .then(res => res.json())
+        .then(result => setPeriods(Array.isArray(result) ? result : []))
+        .catch(error => console.error('Error fetching pay calendar:', error));
+    }
   };
 
-  // Calculate total additions
-  const calculateTotalAdditions = () => {
-    const total = commission + overtime;
-    setTotalAdditions(total);
-    return total;
+  const handlePeriodSelect = (e) => {
+    const period = periods.find(p => p.period === e.target.value);
+    setPayMonth(e.target.value);
+    setPayDate(period ? period.pay_date : '');
+    setCalculation(null);
   };
 
-  // Calculate tax based on annual salary
-  const calculateTax = (annualSalary) => {
-    const personalDeduction = 60000;
-    const personalExpense = Math.min(annualSalary * 0.5, 100000);
-    const net = annualSalary - personalDeduction - personalExpense;
-
-    let taxAmount = 0;
-    if (net > 0) {
-      if (net <= 150000) {
-        taxAmount = 0; // No tax
-      } else if (net <= 300000) {
-        taxAmount = (net - 150000) * 0.05; // 5% tax
-      } else {
-        taxAmount = (net - 300000) * 0.1 + (150000 * 0.05); // 10% tax for exceeding
+  // Clear the calculation whenever an input changes, so a stale result is never saved
+  useEffect(() => {
+    setCalculation(null);
+  }, [daysWorked, hoursWorked, commission, overtime, deductions, payDate]);
+
+  // เซิร์ฟเวอร์คำนวณรายได้ตามรูปแบบค่าจ้าง ประกันสังคม และภาษีหัก ณ ที่จ่ายตามรอบการจ่าย
+  const calculateNetSalary = async () => {
+    if (!selectedEmp || !payMonth) {
+      alert('กรุณาเลือกพนักงานและรอบการจ่าย');
+      return;
+    }
+    try {
+      const response = await apiFetch('http://localhost:8080/api/v1/payrolls/calculate', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+          emp_id: selectedEmp.emp_id,
+          pay_month: payMonth,
+          pay_date: payDate || null,
+          days_worked: selectedEmp.pay_type === 'daily' ? daysWorked : 0,
+          hours_worked: selectedEmp.pay_type === 'hourly' ? hoursWorked : 0,
+          total_additions: commission + overtime,
+          total_deductions: deductions,
+        }),
+      });
+      const result = await response.json();
+      if (!response.ok) {
+        throw new Error(result.detail || 'Failed to calculate payroll');
       }
+      setCalculation(result);
+    } catch (error) {
+      console.error('Error:', error);
+      alert('ไม่สามารถคำนวณเงินเดือนได้: ' + error.message);
     }
-    return taxAmount;
   };
 
-  // Calculate net salary
-  const calculateNetSalary = () => {
-    const total = calculateTotalAdditions();
-    const calculatedNetSalary = baseSalary + total - totalDeductions;
-
-    // Calculate annual salary
-    const annualSalary = baseSalary * 12;
-
-    // Calculate tax
-    const taxAmount = calculateTax(annualSalary);
-
-    // Update state
-    setNetSalary(calculatedNetSalary - taxAmount / 12); // Subtract tax from net salary
-    setTax(taxAmount / 12); // Monthly tax
-  };
-
-  // Update net salary on relevant state changes
-  useEffect(() => {
-    calculateNetSalary();
-  }, [baseSalary, commission, overtime, totalDeductions]);
-
   const handleSubmit = async (event) => {
     event.preventDefault();
-    
-    const payrollData = {
-      emp_id: selectedEmp ? selectedEmp.emp_id : null,
-      pay_month: payMonth,
-      pay_date: payDate,
-      base_salary: baseSalary,
-      total_additions: totalAdditions,
-      total_deductions: totalDeductions,
-      net_salary: netSalary,
-      tax_amount: tax // ส่ง tax_amount ไปยัง backend
-    };
-  
+    if (submitting) {
+      return;
+    }
+    if (!calculation) {
+      alert('กรุณาคำนวณเงินเดือนสุทธิก่อนบันทึก');
+      return;
+    }
+    setSubmitting(true);
+
     try {
-      const response = await fetch('http://localhost:8080/api/v1/payrolls', {
+      const response = await apiFetch('http://localhost:8080/api/v1/payrolls', {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
+          'Idempotency-Key': idempotencyKey.current,
         },
-        body: JSON.stringify(payrollData),
+        body: JSON.stringify(calculation.payroll),
       });
+      // The server has answered; the next submission is a new request
+      idempotencyKey.current = crypto.randomUUID();
   
       if (!response.ok) {
-        throw new Error('Failed to add payroll');
+        const problem = await response.json().catch(() => ({}));
+        throw new Error(problem.detail || 'Failed to add payroll');
       }
   
       const data = await response.json();
@@ -119,6 +133,8 @@ export default function CreatePayroll() {
     } catch (error) {
       console.error('Error:', error);
       alert('ไม่สามารถบันทึกข้อมูลเงินเดือนได้: ' + error.message); // Alert เมื่อลงทะเบียนไม่สำเร็จ
+    } finally {
+      setSubmitting(false);
     }
   };
 
@@ -152,33 +168,51 @@ export default function CreatePayroll() {
           </TextField>
 
           <TextField
-            label="รอบเดือน"
+            select
+            label="รอบการจ่าย"
             value={payMonth}
-            onChange={(e) => setPayMonth(e.target.value)}
+            onChange={handlePeriodSelect}
             fullWidth
             sx={{ mt: 2 }}
-          />
+          >
+            {periods.map(p => (
+              <MenuItem key={p.period} value={p.period}>
+                {p.period} ({p.start} - {p.end})
+              </MenuItem>
+            ))}
+          </TextField>
 
           <TextField
             label="วันที่ต้องจ่าย"
+            type="date"
+            InputLabelProps={{ shrink: true }}
             value={payDate}
             onChange={(e) => setPayDate(e.target.value)}
             fullWidth
             sx={{ mt: 2 }}
           />
 
-          <Typography variant="body1" sx={{ mt: 2 }}>
-            ฐานเงินเดือน: {baseSalary}
-          </Typography>
+          {selectedEmp && selectedEmp.pay_type === 'daily' && (
+            <TextField
+              label="จำนวนวันทำงาน"
+              type="number"
+              value={daysWorked}
+              onChange={(e) => setDaysWorked(parseFloat(e.target.value) || 0)}
+              fullWidth
+              sx={{ mt: 2 }}
+            />
+          )}
 
-          <TextField
-            label="ภาษี"
-            type="number"
-            value={tax}
-            InputProps={{ readOnly: true }} // Make it read-only
-            fullWidth
-            sx={{ mt: 2 }}
-          />
+          {selectedEmp && selectedEmp.pay_type === 'hourly' && (
+            <TextField
+              label="จำนวนชั่วโมงทำงาน"
+              type="number"
+              value={hoursWorked}
+              onChange={(e) => setHoursWorked(parseFloat(e.target.value) || 0)}
+              fullWidth
+              sx={{ mt: 2 }}
+            />
+          )}
 
           <TextField
             label="ค่าคอมมิชชั่น"
@@ -199,19 +233,10 @@ export default function CreatePayroll() {
           />
 
           <TextField
-            label="จำนวนการเพิ่มเติม"
-            type="number"
-            value={totalAdditions}
-            InputProps={{ readOnly: true }}
-            fullWidth
-            sx={{ mt: 2 }}
-          />
-
-          <TextField
-            label="จำนวนการหัก"
+            label="จำนวนการหัก (ไม่รวมประกันสังคม)"
             type="number"
-            value={totalDeductions}
-            onChange={(e) => setTotalDeductions(parseFloat(e.target.value) || 0)}
+            value={deductions}
+            onChange={(e) => setDeductions(parseFloat(e.target.value) || 0)}
             fullWidth
             sx={{ mt: 2 }}
           />
@@ -219,12 +244,34 @@ export default function CreatePayroll() {
           <Button variant="contained" onClick={calculateNetSalary} sx={{ mt: 2 }}>
             คำนวณเงินเดือนสุทธิ
           </Button>
-          
-          <Typography variant="body1" sx={{ mt: 2 }}>
-            เงินเดือนสุทธิ: {netSalary}
-          </Typography>
 
-          <Button variant="contained" onClick={handleSubmit} sx={{ mt: 2 }}>
+          {calculation && (
+            <React.Fragment>
+              <Typography variant="body1" sx={{ mt: 2 }}>
+                รายได้ตามรอบ: {calculation.earnings}
+              </Typography>
+              <Typography variant="body1">
+                วันทำงานในรอบ: {calculation.working_days}
+                {calculation.working_days_paid < calculation.working_days && ` (จ่ายจริง ${calculation.working_days_paid} วัน)`}
+              </Typography>
+              <Typography variant="body1">
+                ประกันสังคม: {calculation.social_security}
+              </Typography>
+              <Typography variant="body1">
+                ภาษี: {calculation.payroll.tax_amount}
+              </Typography>
+              <Typography variant="body1">
+                เงินเดือนสุทธิ: {calculation.payroll.net_salary}
+              </Typography>
+              {calculation.minimum_wage && !calculation.minimum_wage.compliant && (
+                <Typography variant="body1" color="error">
+                  ค่าจ้างวันละ {calculation.minimum_wage.daily_rate} ต่ำกว่าค่าจ้างขั้นต่ำของ{calculation.minimum_wage.province} ({calculation.minimum_wage.minimum_daily_rate} บาท)
+                </Typography>
+              )}
+            </React.Fragment>
+          )}
+
+          <Button variant="contained" onClick={handleSubmit} disabled={submitting} sx={{ mt: 2 }}>
             บันทึก
           </Button>
           <Button variant="outlined" onClick={handleCancel} sx={{ mt: 2, ml: 1 }}>
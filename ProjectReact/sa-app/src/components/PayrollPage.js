@@ -1,4 +1,4 @@
-import React, { useEffect, useState } from 'react';
+import React from 'react';
 import CssBaseline from '@mui/material/CssBaseline';
 import Container from '@mui/material/Container';
 import Typography from '@mui/material/Typography';
@@ -14,27 +14,15 @@ import { useNavigate } from 'react-router-dom';
 import Select from '@mui/material/Select';
 import MenuItem from '@mui/material/MenuItem';
 import FormControl from '@mui/material/FormControl';
+import { apiFetch } from '../api';
+import usePagedList from '../usePagedList';
 
 export default function PayrollPage() {
-  const [payrolls, setPayrolls] = useState([]);
+  // โหลดทีละหน้า รายการล่าสุดก่อน
+  const { rows: payrolls, setRows: setPayrolls, total, hasMore, loading, loadMore } =
+    usePagedList("http://localhost:8080/api/v1/payrolls?sort=-payroll_id");
   const navigate = useNavigate();
 
-  useEffect(() => {
-    fetch("http://localhost:8080/api/v1/payrolls")
-      .then((res) => {
-        if (!res.ok) {
-          throw new Error("Network response was not ok");
-        }
-        return res.json();
-      })
-      .then((result) => {
-        setPayrolls(result); // เก็บข้อมูลทั้งหมดที่ได้รับใน state
-      })
-      .catch((error) => {
-        console.error("Fetch error:", error);
-      });
-  }, []);
-
   const handleCreatePayroll = () => {
     navigate('/createPayroll'); // ไปยังหน้าสร้างเงินเดือน
   };
@@ -52,7 +40,7 @@ export default function PayrollPage() {
     return; // ถ้าไม่ยืนยัน ให้หยุดการลบ
   }
 
-  fetch(`http://localhost:8080/api/v1/payrolls/${payroll_id}`, {
+  apiFetch(`http://localhost:8080/api/v1/payrolls/${payroll_id}`, {
     method: 'DELETE',
   })
     .then((res) => {
@@ -137,6 +125,15 @@ export default function PayrollPage() {
             </TableBody>
           </Table>
         </TableContainer>
+
+        <Typography variant="body2" sx={{ mt: 2 }}>
+          แสดง {payrolls.length} จาก {total} รายการ
+        </Typography>
+        {hasMore && (
+          <Button variant="outlined" onClick={loadMore} disabled={loading} sx={{ mt: 1 }}>
+            โหลดเพิ่มเติม
+          </Button>
+        )}
       </Container>
     </React.Fragment>
   );
@@ -1,13 +1,22 @@
 import React from "react";
 import { Sidebar, Menu, MenuItem } from "react-pro-sidebar";
 import { Typography } from "@mui/material";
-import { Link } from "react-router-dom";
+import { Link, useNavigate } from "react-router-dom";
+import { logout } from "../api";
 
 import BadgeIcon from '@mui/icons-material/Badge';
 import ApartmentIcon from '@mui/icons-material/Apartment';
 import AccountBalanceIcon from '@mui/icons-material/AccountBalance';
+import LogoutIcon from '@mui/icons-material/Logout';
 
 const SideBar = () => {
+  const navigate = useNavigate();
+
+  const handleLogout = async () => {
+    await logout();
+    navigate("/login");
+  };
+
   return (
     <div style={{ display: "flex", height: "100vh" }}>
       <Sidebar
@@ -33,6 +42,7 @@ const SideBar = () => {
               <Link to="/Page3" className="menu-bars" style={{ textDecoration: "none", color: "#000" }}>
                 <MenuItem icon={<AccountBalanceIcon style={{ color: '#000' }} />}>รายการเงินเดือน</MenuItem>
               </Link>
+              <MenuItem icon={<LogoutIcon style={{ color: '#000' }} />} onClick={handleLogout}>ออกจากระบบ</MenuItem>
             </Menu>
           </div>
         </div>
@@ -0,0 +1,40 @@
+import { useCallback, useEffect, useState } from "react";
+import { apiFetchPage } from "./api";
+
+// usePagedList loads a list endpoint a page at a time: the first page when the component mounts,
+// and the next one each time loadMore is called while the server sends a cursor in X-Next-Cursor
+export default function usePagedList(url) {
+  const [rows, setRows] = useState([]);
+  const [cursor, setCursor] = useState(null);
+  const [total, setTotal] = useState(0);
+  const [loading, setLoading] = useState(false);
+
+  const load = useCallback(
+    async (from) => {
+      setLoading(true);
+      try {
+        const page = await apiFetchPage(url, from);
+        setRows((prev) => (from ? [...prev, ...page.rows] : page.rows));
+        setCursor(page.nextCursor);
+        setTotal(page.total);
+      } catch (error) {
+        console.error("Fetch error:", error);
+      } finally {
+        setLoading(false);
+      }
+    },
+    [url]
+  );
+
+  useEffect(() => {
+    load(null);
+  }, [load]);
+
+  const loadMore = () => {
+    if (cursor && !loading) {
+      load(cursor);
+    }
+  };
+
+  return { rows, setRows, total, hasMore: cursor !== null, loading, loadMore };
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"payrollproject/internal/config"
+	"payrollproject/internal/keyring"
+	"payrollproject/internal/payroll"
+)
+
+// runKeys handles the `keys generate [id] | rotate` subcommand. To rotate, append a new key to
+// ENCRYPTION.KEY_FILE or ENCRYPTION.KEYS (the last key listed becomes primary) and run rotate;
+// an old key can be removed once rotate reports nothing left under it.
+func runKeys(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: keys generate [id] | rotate")
+	}
+
+	switch args[0] {
+	case "generate":
+		id := "k1"
+		if len(args) > 1 {
+			id = args[1]
+		}
+		key, err := keyring.GenerateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s:%s\n", id, key)
+		return nil
+	case "rotate":
+		return rotateKeys(ctx, cfg)
+	default:
+		return fmt.Errorf("unknown keys command %q", args[0])
+	}
+}
+
+// rotateKeys re-encrypts every employee whose sensitive fields are in plaintext or sealed under a
+// key other than the primary one
+func rotateKeys(ctx context.Context, cfg config.Config) error {
+	if cfg.DatabaseDriver == "memory" {
+		return fmt.Errorf("database driver %q keeps nothing to rotate", cfg.DatabaseDriver)
+	}
+	kr, err := loadKeyring(cfg)
+	if err != nil {
+		return err
+	}
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	stale := func(values ...string) bool {
+		for _, v := range values {
+			if v != "" && keyring.KeyID(v) != kr.PrimaryKeyID() {
+				return true
+			}
+		}
+		return false
+	}
+
+	rotated := 0
+	err = store.WithTx(ctx, func(tx payroll.PayrollDatabase) error {
+		stored, err := tx.GetAllEmployees(ctx)
+		if err != nil {
+			return err
+		}
+		enc := payroll.NewEncryptedPayrollDB(tx, kr)
+		for _, s := range stored {
+			if !stale(s.BankAccount, s.AccountNum, s.NationalID) {
+				continue
+			}
+			emp, err := enc.GetEmployee(ctx, s.EmployeeID)
+			if err != nil {
+				return err
+			}
+			if err := enc.UpdateEmployee(ctx, emp); err != nil {
+				return fmt.Errorf("employee %d: %w", emp.EmployeeID, err)
+			}
+			rotated++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Re-encrypted %d employee(s) under key %q", rotated, kr.PrimaryKeyID())
+
+	// Profile change requests are a record of what was asked for and are not rewritten; report the
+	// ones still holding the keys they were filed under
+	changes, err := store.ListProfileChanges(ctx, payroll.RequestQuery{})
+	if err != nil {
+		return err
+	}
+	byKey := map[string]int{}
+	for _, c := range changes {
+		for _, v := range []*string{c.BankAccount, c.AccountNum} {
+			if v != nil && stale(*v) {
+				byKey[keyring.KeyID(*v)]++
+				break
+			}
+		}
+	}
+	for id, n := range byKey {
+		if id == "" {
+			id = "plaintext"
+		}
+		log.Printf("%d profile change request(s) remain under %s", n, id)
+	}
+	return nil
+}
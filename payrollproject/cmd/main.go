@@ -2,11 +2,20 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"payrollproject/internal/auth"
 	"payrollproject/internal/config"
+	"payrollproject/internal/documents"
 	"payrollproject/internal/handlers"
+	"payrollproject/internal/keyring"
 	"payrollproject/internal/payroll"
+	"payrollproject/internal/reporting"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -23,12 +32,53 @@ func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
 	}
 }
 
-// CORSMiddleware handles CORS
-func CORSMiddleware() gin.HandlerFunc {
+// RequestIDMiddleware tags each request with an ID, echoed in X-Request-ID and recorded in the
+// audit log. A caller-supplied ID is kept when it is short and printable so entries can be traced
+// back to a gateway or client log.
+func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		id := c.GetHeader("X-Request-ID")
+		if !validRequestID(id) {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				c.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+			id = hex.EncodeToString(b)
+		}
+		c.Header("X-Request-ID", id)
+		ctx := payroll.WithAuditContext(c.Request.Context(), payroll.AuditContext{RequestID: id})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 100 {
+		return false
+	}
+	for _, r := range id {
+		if r <= ' ' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// CORSMiddleware handles CORS for the configured origins; other origins get no CORS headers
+func CORSMiddleware(origins []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(origins))
+	for _, o := range origins {
+		allowed[o] = true
+	}
+	return func(c *gin.Context) {
+		c.Header("Vary", "Origin")
+		if origin := c.GetHeader("Origin"); allowed[origin] || allowed["*"] {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, If-None-Match, X-Request-ID, Idempotency-Key")
+		c.Header("Access-Control-Expose-Headers", "ETag, X-Total-Count, X-Next-Cursor, Link, X-Request-ID, Content-Disposition, Idempotent-Replayed")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusOK)
@@ -39,6 +89,92 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
+// openDatabase creates the PayrollDatabase selected by DATABASE.DRIVER, with sensitive employee
+// fields encrypted when encryption keys are configured
+func openDatabase(cfg config.Config) (payroll.PayrollDatabase, error) {
+	// Bring the schema up to date before serving
+	if cfg.AutoMigrate && cfg.DatabaseDriver != "memory" {
+		if err := runMigrate(context.Background(), cfg, []string{"up"}); err != nil {
+			return nil, fmt.Errorf("migration failed: %v", err)
+		}
+	}
+
+	kr, err := loadKeyring(cfg)
+	if errors.Is(err, keyring.ErrNoKeys) {
+		log.Println("WARNING: ENCRYPTION.KEY_FILE and ENCRYPTION.KEYS are not set; bank details and national IDs are stored in plaintext")
+	} else if err != nil {
+		return nil, err
+	}
+
+	db, err := openStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if kr != nil {
+		db = payroll.NewEncryptedPayrollDB(db, kr)
+	}
+	if cfg.DatabaseDriver == "memory" {
+		// In-memory data with the sample records, for demos without Docker
+		if err := payroll.SeedSampleData(context.Background(), db); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// openStore connects to the database selected by DATABASE.DRIVER as is, without encryption
+func openStore(cfg config.Config) (payroll.PayrollDatabase, error) {
+	switch cfg.DatabaseDriver {
+	case "postgres":
+		return payroll.NewPostgresPayrollDB(cfg.GetConnectionString())
+	case "sqlite":
+		return payroll.NewSQLitePayrollDB(cfg.SQLitePath)
+	case "memory":
+		return payroll.NewMemoryPayrollDB(), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.DatabaseDriver)
+	}
+}
+
+// loadKeyring reads the master keys from ENCRYPTION.KEY_FILE and ENCRYPTION.KEYS
+func loadKeyring(cfg config.Config) (*keyring.Keyring, error) {
+	kr, err := keyring.Load(cfg.KeyFile, cfg.Keys, cfg.PrimaryKeyID)
+	if err != nil && !errors.Is(err, keyring.ErrNoKeys) {
+		return nil, fmt.Errorf("failed to load encryption keys: %v", err)
+	}
+	return kr, err
+}
+
+// newAuthService configures token signing and makes sure an administrator account exists
+func newAuthService(ps *payroll.PayrollSystem, cfg config.Config) (*auth.Service, error) {
+	secret := []byte(cfg.JWTSecret)
+	if len(secret) == 0 {
+		// Tokens will not survive a restart, which is fine for development only
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		log.Println("WARNING: AUTH.JWT_SECRET is not set; using a random key for this process")
+	}
+	svc := auth.NewService(ps, auth.Config{Secret: secret, AccessTTL: cfg.AccessTokenTTL, RefreshTTL: cfg.RefreshTokenTTL})
+	if err := svc.EnsureDefaultRoles(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to create default roles: %v", err)
+	}
+
+	username, password := cfg.AdminUsername, cfg.AdminPassword
+	if password == "" && cfg.DatabaseDriver == "memory" {
+		username, password = "admin", "admin"
+		log.Println("WARNING: signing in with the demo account admin/admin")
+	}
+	if username == "" || password == "" {
+		return svc, nil
+	}
+	if err := svc.EnsureUser(context.Background(), username, password, "admin"); err != nil {
+		return nil, fmt.Errorf("failed to create admin user: %v", err)
+	}
+	return svc, nil
+}
+
 func main() {
 	// Load config
 	cfg, err := config.LoadConfig()
@@ -46,8 +182,24 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Run the migrate subcommand and exit
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	// Run the keys subcommand and exit
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		if err := runKeys(context.Background(), cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Key command failed: %v", err)
+		}
+		return
+	}
+
 	// Connect to the database
-	db, err := payroll.NewPostgresPayrollDB(cfg.GetConnectionString())
+	db, err := openDatabase(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -55,7 +207,24 @@ func main() {
 
 	// Create an instance of the payroll system
 	bs := payroll.NewPayrollSystem(db)
+	bs.SetAnomalyRules(payroll.AnomalyRules{
+		NetPayChangePercent:      cfg.NetPayChangePercent,
+		NetPayChangeErrorPercent: cfg.NetPayChangeErrorPercent,
+		TaxTolerance:             cfg.TaxTolerance,
+	})
+	bs.SetMinimumWagePolicy(payroll.MinimumWagePolicy(cfg.MinimumWagePolicy))
+	if err := bs.EnsurePublicHolidays(context.Background()); err != nil {
+		log.Fatalf("Failed to load public holidays: %v", err)
+	}
 	h := handlers.NewPayrollHandler(bs)
+	authSvc, err := newAuthService(bs, cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up authentication: %v", err)
+	}
+	authH := handlers.NewAuthHandler(authSvc, bs)
+	retH := handlers.NewRetentionHandler(bs, cfg.RetentionYears)
+	reportH := handlers.NewReportHandler(reporting.New(db))
+	meH := handlers.NewSelfServiceHandler(bs, documents.Company{Name: cfg.CompanyName, TaxID: cfg.CompanyTaxID, Address: cfg.CompanyAddress})
 
 	// Set Gin to Release mode
 	gin.SetMode(gin.ReleaseMode)
@@ -63,18 +232,130 @@ func main() {
 
 	// Use middleware
 	r.Use(TimeoutMiddleware(10 * time.Second))
-	r.Use(CORSMiddleware())
+	r.Use(RequestIDMiddleware())
+	r.Use(CORSMiddleware(cfg.CORSOrigins))
+	r.Use(handlers.ErrorMiddleware())
 
-	// API v1 group
-	v1 := r.Group("/api/v1")
+	// Sign-in endpoints are the only ones reachable without an access token
+	public := r.Group("/api/v1/auth")
 	{
+		public.POST("/login", authH.LoginHandler)
+		public.POST("/refresh", authH.RefreshHandler)
+	}
+
+	// API v1 group; each route names the permission it needs, and handlers narrow it to the rows
+	// the caller's roles cover. POST requests may carry an Idempotency-Key to be retried safely.
+	v1 := r.Group("/api/v1", authH.RequireAuth(), handlers.Idempotency(bs))
+	can := authH.Require
+	{
+		v1.POST("/auth/logout", authH.LogoutHandler)
+		v1.GET("/auth/whoami", authH.WhoAmIHandler)
+
 		// Route for departments API
-		v1.GET("/departments", h.GetAllDepartmentsHandler) // Fetch all departments
-		v1.GET("/employees", h.GetAllEmployeesHandler)
-		v1.GET("/payrolls", h.GetAllPayrollHandler)
-		v1.POST("/departments", h.AddDepartmentHandler) // Add new department
-		v1.POST("/employees", h.AddEmployeeHandler)
-		v1.POST("/payrolls", h.AddPayrollHandler)
+		v1.GET("/departments", can(auth.PermDepartmentRead), h.GetAllDepartmentsHandler) // Fetch all departments
+		v1.GET("/employees", can(auth.PermEmployeeRead), h.GetAllEmployeesHandler)
+		v1.GET("/payrolls", can(auth.PermPayrollRead), h.GetAllPayrollHandler)
+		v1.POST("/departments", can(auth.PermDepartmentWrite), h.AddDepartmentHandler) // Add new department
+		v1.POST("/employees", can(auth.PermEmployeeWrite), h.AddEmployeeHandler)
+		v1.POST("/employees/import", can(auth.PermEmployeeWrite), h.ImportEmployeesHandler)
+
+		// Single-record reads and updates; PUT replaces and needs the ETag in If-Match or the version in its
+		// body, PATCH takes a JSON merge patch
+		v1.GET("/departments/:dept_id", can(auth.PermDepartmentRead), h.GetDepartmentHandler)
+		v1.PUT("/departments/:dept_id", can(auth.PermDepartmentWrite), h.UpdateDepartmentHandler)
+		v1.PATCH("/departments/:dept_id", can(auth.PermDepartmentWrite), h.PatchDepartmentHandler)
+		v1.GET("/employees/:emp_id", can(auth.PermEmployeeRead), h.GetEmployeeHandler)
+		v1.PUT("/employees/:emp_id", can(auth.PermEmployeeWrite), h.UpdateEmployeeHandler)
+		v1.PATCH("/employees/:emp_id", can(auth.PermEmployeeWrite), h.PatchEmployeeHandler)
+		v1.GET("/payrolls/:payroll_id", can(auth.PermPayrollRead), h.GetPayrollHandler)
+		v1.PUT("/payrolls/:payroll_id", can(auth.PermPayrollWrite), h.UpdatePayrollHandler)
+		v1.PATCH("/payrolls/:payroll_id", can(auth.PermPayrollWrite), h.PatchPayrollHandler)
+		v1.POST("/payrolls", can(auth.PermPayrollWrite), h.AddPayrollHandler)
+		v1.POST("/payrolls/batch", can(auth.PermPayrollWrite), h.AddPayrollBatchHandler)
+		v1.POST("/payrolls/calculate", can(auth.PermPayrollWrite), h.CalculatePayrollHandler) // works out pay without storing it
+		v1.GET("/payrolls/workbook", can(auth.PermPayrollRead), h.PayrollWorkbookHandler)
+		v1.GET("/payrolls/runs/:pay_month/checks", can(auth.PermPayrollRead), h.CheckPayRunHandler) // anomalies to clear before approval
+		v1.POST("/payrolls/:payroll_id/approve", can(auth.PermPayrollApprove), h.ApprovePayrollHandler)
+		v1.GET("/pay-calendars", can(auth.PermPayrollRead), h.ListPayCalendarsHandler)
+		v1.PUT("/pay-calendars/:frequency", can(auth.PermPayrollWrite), h.SavePayCalendarHandler)
+		v1.GET("/pay-calendars/:frequency/:year", can(auth.PermPayrollRead), h.PayScheduleHandler) // periods and pay dates of a year
+		v1.GET("/minimum-wages", can(auth.PermPayrollRead), h.ListMinimumWagesHandler)
+		v1.POST("/minimum-wages", can(auth.PermPayrollWrite), h.SaveMinimumWagesHandler) // a provincial wage announcement
+		v1.DELETE("/minimum-wages/:province/:effective_date", can(auth.PermPayrollWrite), h.DeleteMinimumWageHandler)
+
+		// Holidays, work sites and their working weeks
+		v1.GET("/holidays", can(auth.PermPayrollRead), h.ListHolidaysHandler)
+		v1.POST("/holidays", can(auth.PermPayrollWrite), h.AddHolidayHandler)
+		v1.POST("/holidays/import", can(auth.PermPayrollWrite), h.ImportHolidaysHandler) // .ics, .csv or .xlsx
+		v1.DELETE("/holidays/:holiday_id", can(auth.PermPayrollWrite), h.DeleteHolidayHandler)
+		v1.GET("/work-sites", can(auth.PermPayrollRead), h.ListWorkSitesHandler)
+		v1.PUT("/work-sites/:site_id", can(auth.PermPayrollWrite), h.SaveWorkSiteHandler)
+		v1.DELETE("/work-sites/:site_id", can(auth.PermPayrollWrite), h.DeleteWorkSiteHandler)
+		v1.GET("/working-days", can(auth.PermPayrollRead), h.WorkingDaysHandler)
+
+		// Tax allowance declarations (ล.ย.01) and withholding
+		v1.GET("/employees/:emp_id/allowances/:tax_year", can(auth.PermAllowanceRead), h.GetAllowanceDeclarationHandler)
+		v1.PUT("/employees/:emp_id/allowances/:tax_year", can(auth.PermAllowanceWrite), h.SaveAllowanceDeclarationHandler)
+		v1.GET("/employees/:emp_id/withholding/:tax_year", can(auth.PermAllowanceRead), h.GetWithholdingHandler)
+
+		// Review of the profile changes and leave employees request through /me
+		v1.GET("/profile-changes", can(auth.PermEmployeeWrite), h.ListProfileChangesHandler)
+		v1.POST("/profile-changes/:change_id/approve", can(auth.PermEmployeeWrite), h.ApproveProfileChangeHandler)
+		v1.POST("/profile-changes/:change_id/reject", can(auth.PermEmployeeWrite), h.RejectProfileChangeHandler)
+		v1.GET("/leave-requests", can(auth.PermLeaveApprove), h.ListLeaveRequestsHandler)
+		v1.POST("/leave-requests/:leave_id/approve", can(auth.PermLeaveApprove), h.ApproveLeaveRequestHandler)
+		v1.POST("/leave-requests/:leave_id/reject", can(auth.PermLeaveApprove), h.RejectLeaveRequestHandler)
+
+		// Users and roles
+		v1.GET("/users", can(auth.PermUserManage), authH.ListUsersHandler)
+		v1.POST("/users", can(auth.PermUserManage), authH.AddUserHandler)
+		v1.PUT("/users/:user_id", can(auth.PermUserManage), authH.UpdateUserHandler)
+		v1.GET("/roles", can(auth.PermRoleManage), authH.ListRolesHandler)
+		v1.GET("/roles/:role_name", can(auth.PermRoleManage), authH.GetRoleHandler)
+		v1.PUT("/roles/:role_name", can(auth.PermRoleManage), authH.SaveRoleHandler)
+		v1.DELETE("/roles/:role_name", can(auth.PermRoleManage), authH.DeleteRoleHandler)
+		v1.GET("/permissions", can(auth.PermRoleManage), authH.ListPermissionsHandler)
+
+		// Audit log
+		v1.GET("/audit", can(auth.PermAuditRead), h.ListAuditHandler)
+		v1.GET("/audit/verify", can(auth.PermAuditRead), h.VerifyAuditHandler)
+
+		// PDPA data subject access and retention
+		v1.GET("/employees/:emp_id/export", can(auth.PermPrivacyManage), h.ExportEmployeeHandler)
+		v1.GET("/retention", can(auth.PermPrivacyManage), retH.ReportHandler) // dry run
+		v1.POST("/retention/apply", can(auth.PermPrivacyManage), retH.ApplyHandler)
+
+		// General ledger: chart of accounts and the journal entry of each month's pay run
+		v1.GET("/ledger/accounts", can(auth.PermLedgerManage), h.GetChartOfAccountsHandler)
+		v1.PUT("/ledger/accounts", can(auth.PermLedgerManage), h.SaveChartOfAccountsHandler)
+		v1.GET("/ledger/journals/:pay_month", can(auth.PermLedgerManage), h.PayRunJournalHandler)
+
+		// Department cost and headcount reports
+		v1.GET("/reports/labour-cost", can(auth.PermReportRead), reportH.LabourCostHandler)
+		v1.GET("/reports/headcount", can(auth.PermReportRead), reportH.HeadcountHandler)
+		v1.GET("/reports/salary-by-position", can(auth.PermReportRead), reportH.SalaryByPositionHandler)
+		v1.GET("/reports/variance", can(auth.PermReportRead), reportH.VarianceHandler)
+	}
+
+	// Employee self-service; every route acts on the employee linked to the caller's account
+	me := v1.Group("/me", handlers.RequireEmployee())
+	{
+		me.GET("", meH.GetProfileHandler)
+		me.PATCH("", meH.RequestProfileChangeHandler) // phone and bank details, applied once HR approves
+		me.GET("/profile-changes", meH.ListProfileChangesHandler)
+		me.GET("/profile-changes/:change_id", meH.GetProfileChangeHandler)
+		me.GET("/payrolls", meH.ListPayrollsHandler)
+		me.GET("/payrolls/:payroll_id", meH.GetPayrollHandler)
+		me.GET("/payrolls/:payroll_id/payslip", meH.PayslipHandler)
+		me.GET("/tax-certificates/:tax_year", meH.TaxCertificateHandler) // 50 Tawi
+		me.GET("/allowances/:tax_year", meH.GetAllowanceDeclarationHandler)
+		me.PUT("/allowances/:tax_year", meH.SaveAllowanceDeclarationHandler)
+		me.GET("/withholding/:tax_year", meH.GetWithholdingHandler)
+		me.GET("/leave-requests", meH.ListLeaveRequestsHandler)
+		me.POST("/leave-requests", meH.RequestLeaveHandler)
+		me.GET("/leave-requests/:leave_id", meH.GetLeaveRequestHandler)
+		me.POST("/leave-requests/:leave_id/cancel", meH.CancelLeaveRequestHandler)
+		me.GET("/export", meH.ExportHandler) // everything held about the caller, as JSON
 	}
 
 	// Start the server
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+
+	"payrollproject/internal/config"
+	"payrollproject/internal/migrate"
+	"payrollproject/internal/payroll"
+)
+
+// runMigrate handles the `migrate up|down [steps]|status` subcommand
+func runMigrate(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up | down [steps] | status")
+	}
+
+	var db *sql.DB
+	var err error
+	switch cfg.DatabaseDriver {
+	case "postgres":
+		db, err = sql.Open("postgres", cfg.GetConnectionString())
+	case "sqlite":
+		db, err = sql.Open("sqlite", payroll.SQLiteDSN(cfg.SQLitePath))
+	default:
+		return fmt.Errorf("database driver %q does not use migrations", cfg.DatabaseDriver)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	m, err := migrate.New(db, cfg.DatabaseDriver)
+	if err != nil {
+		return err
+	}
+	m.Before(payroll.PeriodMigration, payroll.NormalisePayPeriods)
+
+	switch args[0] {
+	case "up":
+		n, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Applied %d migration(s)", n)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps: %q", args[1])
+			}
+		}
+		n, err := m.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("Rolled back %d migration(s)", n)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, applied)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+// Package auth signs users in with a password and issues short-lived JWT access tokens
+// together with rotating refresh tokens backed by a session row that logout revokes.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"payrollproject/internal/payroll"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidCredentials is returned when the username or password is wrong
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrInvalidToken is returned for a malformed, expired or revoked access or refresh token
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
+
+// Config holds the signing key and token lifetimes
+type Config struct {
+	Secret     []byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// Claims are carried in an access token; Subject is the user ID
+type Claims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid"`
+	Username  string `json:"username"`
+	EmpID     int    `json:"emp_id,omitempty"` // employee the account is linked to, for self-service
+}
+
+// UserID returns the numeric user ID from the subject claim
+func (c *Claims) UserID() int {
+	id, _ := strconv.Atoi(c.Subject)
+	return id
+}
+
+// Tokens is the response to a login or refresh
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Service issues and checks tokens for users stored in the payroll system
+type Service struct {
+	ps  *payroll.PayrollSystem
+	cfg Config
+	now func() time.Time
+}
+
+// NewService creates a Service
+func NewService(ps *payroll.PayrollSystem, cfg Config) *Service {
+	return &Service{ps: ps, cfg: cfg, now: time.Now}
+}
+
+// dummyHash is compared against when the username does not exist so that both cases take as long
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+
+// HashPassword hashes a password for storage in the users table
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// Login checks the password and starts a new session
+func (s *Service) Login(ctx context.Context, username, password string) (Tokens, error) {
+	user, err := s.ps.GetUserByUsername(ctx, username)
+	if errors.Is(err, payroll.ErrNotFound) {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return Tokens{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return Tokens{}, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return Tokens{}, ErrInvalidCredentials
+	}
+
+	sessionID, err := randomToken(16)
+	if err != nil {
+		return Tokens{}, err
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return Tokens{}, err
+	}
+	session := payroll.Session{
+		SessionID:   sessionID,
+		UserID:      user.UserID,
+		RefreshHash: hashSecret(secret),
+		ExpiresAt:   s.now().Add(s.cfg.RefreshTTL),
+	}
+	if err := s.ps.AddSession(ctx, session); err != nil {
+		return Tokens{}, err
+	}
+	return s.issue(user, sessionID, secret)
+}
+
+// Refresh exchanges a refresh token for a new token pair. Each refresh token works once; presenting
+// an old one means it was copied, so the whole session is revoked.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (Tokens, error) {
+	sessionID, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return Tokens{}, ErrInvalidToken
+	}
+	session, err := s.ps.GetSession(ctx, sessionID)
+	if errors.Is(err, payroll.ErrNotFound) {
+		return Tokens{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Tokens{}, err
+	}
+	if !session.Active(s.now()) {
+		return Tokens{}, ErrInvalidToken
+	}
+	oldHash := hashSecret(secret)
+	if subtle.ConstantTimeCompare([]byte(oldHash), []byte(session.RefreshHash)) != 1 {
+		if err := s.ps.RevokeSession(ctx, sessionID); err != nil {
+			return Tokens{}, err
+		}
+		return Tokens{}, ErrInvalidToken
+	}
+
+	newSecret, err := randomToken(32)
+	if err != nil {
+		return Tokens{}, err
+	}
+	err = s.ps.RotateSession(ctx, sessionID, oldHash, hashSecret(newSecret), s.now().Add(s.cfg.RefreshTTL))
+	if errors.Is(err, payroll.ErrConflict) {
+		// Another request rotated the same token first
+		return Tokens{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Tokens{}, err
+	}
+	user, err := s.ps.GetUser(ctx, session.UserID)
+	if err != nil {
+		return Tokens{}, err
+	}
+	return s.issue(user, sessionID, newSecret)
+}
+
+// Logout revokes the session; access tokens issued for it stop working immediately
+func (s *Service) Logout(ctx context.Context, sessionID string) error {
+	return s.ps.RevokeSession(ctx, sessionID)
+}
+
+// Authenticate verifies an access token and checks that its session has not been revoked
+func (s *Service) Authenticate(ctx context.Context, accessToken string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(accessToken, claims, func(*jwt.Token) (any, error) {
+		return s.cfg.Secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired(), jwt.WithTimeFunc(s.now))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	session, err := s.ps.GetSession(ctx, claims.SessionID)
+	if errors.Is(err, payroll.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !session.Active(s.now()) || session.UserID != claims.UserID() {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// issue signs an access token and pairs it with the refresh token "<session>.<secret>"
+func (s *Service) issue(user payroll.User, sessionID, secret string) (Tokens, error) {
+	now := s.now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.UserID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.cfg.AccessTTL)),
+		},
+		SessionID: sessionID,
+		Username:  user.Username,
+	}
+	if user.EmpID != nil {
+		claims.EmpID = *user.EmpID
+	}
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.cfg.Secret)
+	if err != nil {
+		return Tokens{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return Tokens{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.cfg.AccessTTL.Seconds()),
+		RefreshToken: sessionID + "." + secret,
+	}, nil
+}
+
+// EnsureUser creates the user with the given password unless the username is already taken,
+// then makes sure they hold roles
+func (s *Service) EnsureUser(ctx context.Context, username, password string, roles ...string) error {
+	user, err := s.ps.GetUserByUsername(ctx, username)
+	if errors.Is(err, payroll.ErrNotFound) {
+		hash, err := HashPassword(password)
+		if err != nil {
+			return err
+		}
+		_, err = s.ps.AddUser(ctx, payroll.User{Username: username, PasswordHash: hash, Roles: roles})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	missing := false
+	for _, role := range roles {
+		missing = missing || !slices.Contains(user.Roles, role)
+	}
+	if !missing {
+		return nil
+	}
+	user.Roles = append(user.Roles, roles...)
+	_, err = s.ps.UpdateUser(ctx, user)
+	return err
+}
+
+// CreateUser hashes the password and stores a new user with their employee link and roles
+func (s *Service) CreateUser(ctx context.Context, u payroll.User, password string) (payroll.User, error) {
+	if len(password) < 8 {
+		return payroll.User{}, &payroll.ValidationError{Fields: []payroll.FieldError{{Field: "password", Message: "must be at least 8 characters"}}}
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return payroll.User{}, err
+	}
+	u.PasswordHash = hash
+	return s.ps.AddUser(ctx, u)
+}
+
+// randomToken returns n random bytes, hex encoded
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashSecret is what the sessions table stores in place of the refresh token secret
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"payrollproject/internal/payroll"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	ps := payroll.NewPayrollSystem(payroll.NewMemoryPayrollDB())
+	s := NewService(ps, Config{Secret: []byte("test secret"), AccessTTL: time.Minute, RefreshTTL: time.Hour})
+	if err := s.EnsureUser(context.Background(), "hr", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestLogin(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	if _, err := s.Login(ctx, "hr", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("wrong password error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := s.Login(ctx, "nobody", "correct horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("unknown user error = %v, want ErrInvalidCredentials", err)
+	}
+
+	tokens, err := s.Login(ctx, "hr", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.Authenticate(ctx, tokens.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Username != "hr" || claims.UserID() == 0 {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	s.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := s.Authenticate(ctx, tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expired token error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	first, err := s.Login(ctx, "hr", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	// Replaying the first token revokes the session, so the rotated one stops working too
+	if _, err := s.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("reused refresh token error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("refresh after reuse error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.Authenticate(ctx, second.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("access token after reuse error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestLogoutRevokesAccessToken(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	tokens, err := s.Login(ctx, "hr", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.Authenticate(ctx, tokens.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Logout(ctx, claims.SessionID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(ctx, tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("access token after logout error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := s.Refresh(ctx, tokens.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("refresh after logout error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func claimsFor(userID int) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{Subject: strconv.Itoa(userID)}
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"payrollproject/internal/payroll"
+)
+
+// ErrForbidden is returned when the caller lacks the permission a request needs
+var ErrForbidden = errors.New("you do not have permission to perform this action")
+
+// ErrNoEmployee is returned for self-service requests from an account not linked to an employee
+var ErrNoEmployee = fmt.Errorf("%w: the account is not linked to an employee", ErrForbidden)
+
+// Permission names an action a role can grant
+type Permission string
+
+// Permissions checked by the API routes
+const (
+	PermDepartmentRead  Permission = "department:read"
+	PermDepartmentWrite Permission = "department:write"
+	PermEmployeeRead    Permission = "employee:read"
+	PermEmployeeWrite   Permission = "employee:write"
+	PermSalaryRead      Permission = "salary:read" // see base salaries on employee records
+	PermPayrollRead     Permission = "payroll:read"
+	PermPayrollWrite    Permission = "payroll:write"
+	PermPayrollApprove  Permission = "payroll:approve"
+	PermAllowanceRead   Permission = "allowance:read"
+	PermAllowanceWrite  Permission = "allowance:write"
+	PermUserManage      Permission = "user:manage"
+	PermRoleManage      Permission = "role:manage"
+	PermLeaveApprove    Permission = "leave:approve" // review the leave requests employees file through /me
+	PermAuditRead       Permission = "audit:read"
+	PermPIIReveal       Permission = "pii:reveal"     // see account numbers and national IDs unmasked
+	PermPrivacyManage   Permission = "privacy:manage" // export an employee's data and run retention
+	PermLedgerManage    Permission = "ledger:manage"  // map ledger accounts and export pay run journals
+	PermReportRead      Permission = "report:read"    // see labour cost and headcount reports across departments
+)
+
+// Permissions lists every permission a role may grant
+var Permissions = []Permission{
+	PermDepartmentRead, PermDepartmentWrite,
+	PermEmployeeRead, PermEmployeeWrite, PermSalaryRead, PermPIIReveal,
+	PermPayrollRead, PermPayrollWrite, PermPayrollApprove,
+	PermAllowanceRead, PermAllowanceWrite,
+	PermLeaveApprove,
+	PermUserManage, PermRoleManage, PermAuditRead, PermPrivacyManage,
+	PermLedgerManage, PermReportRead,
+}
+
+// DefaultRoles are created at startup when missing; afterwards they can be edited like any other role
+var DefaultRoles = []payroll.Role{
+	{RoleName: "admin", Description: "Full access", Scope: payroll.ScopeAll, Permissions: names(Permissions...)},
+	{RoleName: "hr_clerk", Description: "Maintains departments, employees and tax allowances", Scope: payroll.ScopeAll, Permissions: names(
+		PermDepartmentRead, PermDepartmentWrite, PermEmployeeRead, PermEmployeeWrite, PermSalaryRead,
+		PermPIIReveal, PermAllowanceRead, PermAllowanceWrite, PermLeaveApprove)},
+	{RoleName: "payroll_officer", Description: "Prepares pay runs", Scope: payroll.ScopeAll, Permissions: names(
+		PermDepartmentRead, PermEmployeeRead, PermSalaryRead, PermPIIReveal, PermPayrollRead, PermPayrollWrite, PermAllowanceRead, PermReportRead)},
+	{RoleName: "finance_approver", Description: "Reviews and approves pay runs", Scope: payroll.ScopeAll, Permissions: names(
+		PermDepartmentRead, PermEmployeeRead, PermSalaryRead, PermPayrollRead, PermPayrollApprove, PermLedgerManage, PermReportRead)},
+	{RoleName: "department_manager", Description: "Views the staff of their own department and reviews their leave", Scope: payroll.ScopeDepartment, Permissions: names(
+		PermDepartmentRead, PermEmployeeRead, PermLeaveApprove)},
+	{RoleName: "employee", Description: "Views their own record and payslips and declares allowances", Scope: payroll.ScopeSelf, Permissions: names(
+		PermEmployeeRead, PermSalaryRead, PermPIIReveal, PermPayrollRead, PermAllowanceRead, PermAllowanceWrite)},
+}
+
+func names(perms ...Permission) []string {
+	out := make([]string, len(perms))
+	for i, p := range perms {
+		out[i] = string(p)
+	}
+	return out
+}
+
+// RowFilter is the set of rows a permission applies to for one caller. Department scope sets
+// DeptID; self scope sets EmpID and the caller's DeptID. The zero value allows nothing.
+type RowFilter struct {
+	All    bool
+	DeptID int
+	EmpID  int
+}
+
+// AllowsEmployee reports whether the filter covers an employee in a department
+func (f RowFilter) AllowsEmployee(empID, deptID int) bool {
+	switch {
+	case f.All:
+		return true
+	case f.EmpID != 0:
+		return empID == f.EmpID
+	case f.DeptID != 0:
+		return deptID == f.DeptID
+	}
+	return false
+}
+
+// AllowsDepartment reports whether the filter covers a department
+func (f RowFilter) AllowsDepartment(deptID int) bool {
+	return f.All || (f.DeptID != 0 && deptID == f.DeptID)
+}
+
+// Principal is an authenticated user with the permissions their roles grant
+type Principal struct {
+	UserID   int
+	Username string
+	EmpID    int // 0 when the account is not linked to an employee
+	DeptID   int // department of EmpID
+	Roles    []string
+	grants   map[Permission]payroll.Scope
+}
+
+// Can reports whether any role grants perm, over any scope
+func (p *Principal) Can(perm Permission) bool {
+	_, ok := p.grants[perm]
+	return ok
+}
+
+// Rows returns the rows perm applies to, taking the widest scope among the caller's roles
+func (p *Principal) Rows(perm Permission) RowFilter {
+	switch p.grants[perm] {
+	case payroll.ScopeAll:
+		return RowFilter{All: true}
+	case payroll.ScopeDepartment:
+		return RowFilter{DeptID: p.DeptID}
+	case payroll.ScopeSelf:
+		return RowFilter{DeptID: p.DeptID, EmpID: p.EmpID}
+	}
+	return RowFilter{}
+}
+
+// Permissions lists the permissions the caller holds
+func (p *Principal) Permissions() []string {
+	var out []string
+	for _, perm := range Permissions {
+		if p.Can(perm) {
+			out = append(out, string(perm))
+		}
+	}
+	return out
+}
+
+// scopeRank orders scopes from narrowest to widest
+var scopeRank = map[payroll.Scope]int{payroll.ScopeSelf: 1, payroll.ScopeDepartment: 2, payroll.ScopeAll: 3}
+
+// Principal loads the caller's roles. Department and self scopes are only granted to accounts
+// linked to an employee, since there is nothing to resolve them against otherwise.
+func (s *Service) Principal(ctx context.Context, claims *Claims) (*Principal, error) {
+	user, err := s.ps.GetUser(ctx, claims.UserID())
+	if errors.Is(err, payroll.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := &Principal{UserID: user.UserID, Username: user.Username, Roles: user.Roles, grants: map[Permission]payroll.Scope{}}
+	if user.EmpID != nil {
+		emp, err := s.ps.GetEmployee(ctx, *user.EmpID)
+		if err != nil {
+			return nil, err
+		}
+		p.EmpID, p.DeptID = emp.EmployeeID, emp.DeptID
+	}
+	for _, name := range user.Roles {
+		role, err := s.ps.GetRole(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if role.Scope != payroll.ScopeAll && p.EmpID == 0 {
+			continue
+		}
+		for _, perm := range role.Permissions {
+			if current, ok := p.grants[Permission(perm)]; !ok || scopeRank[role.Scope] > scopeRank[current] {
+				p.grants[Permission(perm)] = role.Scope
+			}
+		}
+	}
+	return p, nil
+}
+
+// SaveRole checks that every permission is known and stores the role
+func (s *Service) SaveRole(ctx context.Context, r payroll.Role) (payroll.Role, error) {
+	known := map[string]bool{}
+	for _, perm := range Permissions {
+		known[string(perm)] = true
+	}
+	invalid := &payroll.ValidationError{}
+	for _, perm := range r.Permissions {
+		if !known[perm] {
+			invalid.Add("permissions", "unknown permission %q", perm)
+		}
+	}
+	if err := invalid.Err(); err != nil {
+		return payroll.Role{}, err
+	}
+	return s.ps.SaveRole(ctx, r)
+}
+
+// EnsureDefaultRoles creates any of DefaultRoles that does not exist yet
+func (s *Service) EnsureDefaultRoles(ctx context.Context) error {
+	for _, r := range DefaultRoles {
+		_, err := s.ps.GetRole(ctx, r.RoleName)
+		if errors.Is(err, payroll.ErrNotFound) {
+			_, err = s.ps.SaveRole(ctx, r)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"payrollproject/internal/payroll"
+)
+
+func TestPrincipalScopes(t *testing.T) {
+	ctx := context.Background()
+	db := payroll.NewMemoryPayrollDB()
+	if err := payroll.SeedSampleData(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	s := NewService(payroll.NewPayrollSystem(db), Config{Secret: []byte("test secret")})
+	if err := s.EnsureDefaultRoles(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	empID := 1 // Marketing, dept 1001
+	manager, err := s.CreateUser(ctx, payroll.User{Username: "manager", EmpID: &empID, Roles: []string{"department_manager", "employee"}}, "password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := s.Principal(ctx, &Claims{RegisteredClaims: claimsFor(manager.UserID)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// employee:read is granted by both roles; the wider department scope wins
+	if f := p.Rows(PermEmployeeRead); !f.AllowsEmployee(99, 1001) || f.AllowsEmployee(2, 1002) {
+		t.Fatalf("employee:read rows = %+v, want department 1001", f)
+	}
+	if f := p.Rows(PermSalaryRead); !f.AllowsEmployee(1, 1001) || f.AllowsEmployee(99, 1001) {
+		t.Fatalf("salary:read rows = %+v, want only employee 1", f)
+	}
+	if p.Can(PermPayrollApprove) {
+		t.Fatal("manager should not approve payrolls")
+	}
+
+	// Scoped roles mean nothing without an employee record to resolve them against
+	unlinked, err := s.CreateUser(ctx, payroll.User{Username: "unlinked", Roles: []string{"employee"}}, "password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err = s.Principal(ctx, &Claims{RegisteredClaims: claimsFor(unlinked.UserID)}); err != nil || p.Can(PermEmployeeRead) {
+		t.Fatalf("unlinked principal = %+v, %v", p, err)
+	}
+}
+
+func TestSaveRoleRejectsUnknownPermission(t *testing.T) {
+	s := newTestService(t)
+	_, err := s.SaveRole(context.Background(), payroll.Role{RoleName: "x", Scope: payroll.ScopeAll, Permissions: []string{"salary:write"}})
+	if !errors.Is(err, payroll.ErrValidation) {
+		t.Fatalf("SaveRole error = %v, want ErrValidation", err)
+	}
+}
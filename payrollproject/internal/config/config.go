@@ -3,18 +3,43 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	AppPort          string
+	DatabaseDriver   string
+	SQLitePath       string
 	DatabaseHost     string
 	DatabasePort     int
 	DatabaseUser     string
 	DatabasePassword string
 	DatabaseName     string
 	DatabaseSSLMode  string
+	AutoMigrate      bool
+	CORSOrigins      []string
+	JWTSecret        string
+	AccessTokenTTL   time.Duration
+	RefreshTokenTTL  time.Duration
+	AdminUsername    string
+	AdminPassword    string
+	CompanyName      string
+	CompanyTaxID     string
+	CompanyAddress   string
+	KeyFile          string
+	Keys             string
+	PrimaryKeyID     string
+	RetentionYears   int
+
+	// Pre-approval checks of a pay run
+	NetPayChangePercent      float64
+	NetPayChangeErrorPercent float64
+	TaxTolerance             float64
+
+	// "block" refuses pay below the provincial minimum wage; "warn" only flags it
+	MinimumWagePolicy string
 }
 
 func LoadConfig() (Config, error) {
@@ -22,24 +47,60 @@ func LoadConfig() (Config, error) {
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	// Set default values
+	viper.SetDefault("DATABASE.DRIVER", "postgres")
+	viper.SetDefault("DATABASE.SQLITE_PATH", "payroll.db")
 	viper.SetDefault("POSTGRES.HOST", "localhost")
 	viper.SetDefault("POSTGRES.PORT", 5435)
 	viper.SetDefault("POSTGRES.USER", "postgres")
 	viper.SetDefault("POSTGRES.PASSWORD", "")
 	viper.SetDefault("POSTGRES.DBNAME", "payroll")
 	viper.SetDefault("POSTGRES.SSLMODE", "disable")
+	viper.SetDefault("DATABASE.AUTO_MIGRATE", true)
+	viper.SetDefault("APP.CORS_ORIGINS", "http://localhost:3000")
+	viper.SetDefault("AUTH.ACCESS_TTL", "15m")
+	viper.SetDefault("AUTH.REFRESH_TTL", "720h")
+	viper.SetDefault("RETENTION.YEARS", 10)
+	viper.SetDefault("ANOMALY.NET_PAY_CHANGE_PERCENT", 30)
+	viper.SetDefault("ANOMALY.NET_PAY_CHANGE_ERROR_PERCENT", 200)
+	viper.SetDefault("ANOMALY.TAX_TOLERANCE", 1)
+	viper.SetDefault("MINIMUM_WAGE.POLICY", "block")
 
 	// Set config values
 	config := Config{
 		AppPort:          viper.GetString("APP.PORT"),
+		DatabaseDriver:   viper.GetString("DATABASE.DRIVER"),
+		SQLitePath:       viper.GetString("DATABASE.SQLITE_PATH"),
 		DatabaseHost:     viper.GetString("POSTGRES.HOST"),
 		DatabasePort:     viper.GetInt("POSTGRES.PORT"),
 		DatabaseUser:     viper.GetString("POSTGRES.USER"),
 		DatabasePassword: viper.GetString("POSTGRES.PASSWORD"),
 		DatabaseName:     viper.GetString("POSTGRES.DBNAME"),
 		DatabaseSSLMode:  viper.GetString("POSTGRES.SSLMODE"),
+		AutoMigrate:      viper.GetBool("DATABASE.AUTO_MIGRATE"),
+		CORSOrigins:      strings.Fields(strings.ReplaceAll(viper.GetString("APP.CORS_ORIGINS"), ",", " ")),
+		JWTSecret:        viper.GetString("AUTH.JWT_SECRET"),
+		AccessTokenTTL:   viper.GetDuration("AUTH.ACCESS_TTL"),
+		RefreshTokenTTL:  viper.GetDuration("AUTH.REFRESH_TTL"),
+		AdminUsername:    viper.GetString("AUTH.ADMIN_USERNAME"),
+		AdminPassword:    viper.GetString("AUTH.ADMIN_PASSWORD"),
+		CompanyName:      viper.GetString("COMPANY.NAME"),
+		CompanyTaxID:     viper.GetString("COMPANY.TAX_ID"),
+		CompanyAddress:   viper.GetString("COMPANY.ADDRESS"),
+		KeyFile:          viper.GetString("ENCRYPTION.KEY_FILE"),
+		Keys:             viper.GetString("ENCRYPTION.KEYS"),
+		PrimaryKeyID:     viper.GetString("ENCRYPTION.PRIMARY_KEY_ID"),
+		RetentionYears:   viper.GetInt("RETENTION.YEARS"),
+
+		NetPayChangePercent:      viper.GetFloat64("ANOMALY.NET_PAY_CHANGE_PERCENT"),
+		NetPayChangeErrorPercent: viper.GetFloat64("ANOMALY.NET_PAY_CHANGE_ERROR_PERCENT"),
+		TaxTolerance:             viper.GetFloat64("ANOMALY.TAX_TOLERANCE"),
+
+		MinimumWagePolicy: strings.ToLower(viper.GetString("MINIMUM_WAGE.POLICY")),
 	}
 
+	if config.MinimumWagePolicy != "block" && config.MinimumWagePolicy != "warn" {
+		return config, fmt.Errorf("MINIMUM_WAGE.POLICY must be block or warn, not %q", config.MinimumWagePolicy)
+	}
 	return config, nil
 }
 
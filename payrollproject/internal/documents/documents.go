@@ -0,0 +1,120 @@
+// Package documents renders the payslip and withholding tax certificate (50 Tawi) employees
+// download from the self-service API as printable HTML pages.
+package documents
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"payrollproject/internal/payroll"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"baht":       Baht,
+	"thaiMonth":  ThaiMonth,
+	"thaiPeriod": ThaiPeriod,
+	"be":         BuddhistYear,
+}).ParseFS(templateFS, "templates/*.html"))
+
+// Company identifies the employer printed on the documents
+type Company struct {
+	Name    string
+	TaxID   string // 13-digit taxpayer identification number
+	Address string
+}
+
+// Payslip writes an employee's payslip for one payroll record
+func Payslip(w io.Writer, company Company, emp payroll.Employee, p payroll.Payroll) error {
+	return render(w, "payslip.html", map[string]any{
+		"Company":  company,
+		"Employee": emp,
+		"Payroll":  p,
+		"Gross":    p.BaseSalary + p.TotalAdditions,
+	})
+}
+
+// TaxCertificate writes the 50 Tawi certificate for an employee's tax year
+func TaxCertificate(w io.Writer, company Company, cert payroll.TaxCertificate, issued time.Time) error {
+	return render(w, "tax_certificate.html", map[string]any{
+		"Company": company,
+		"Cert":    cert,
+		"Issued":  issued,
+	})
+}
+
+func render(w io.Writer, name string, data any) error {
+	if err := templates.ExecuteTemplate(w, name, data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return nil
+}
+
+// Baht formats an amount with thousands separators and two decimals, e.g. 1,234,567.50
+func Baht(amount float64) string {
+	s := fmt.Sprintf("%.2f", math.Abs(amount))
+	whole, frac, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	if amount < 0 && s != "0.00" {
+		b.WriteByte('-')
+	}
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return b.String() + "." + frac
+}
+
+var thaiMonths = [...]string{"มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน",
+	"กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม"}
+
+// ThaiMonth formats a "2006-01" pay month as the Thai month name and Buddhist-era year,
+// e.g. "มกราคม 2569"; other input is returned unchanged
+func ThaiMonth(payMonth string) string {
+	t, err := time.Parse("2006-01", payMonth)
+	if err != nil {
+		return payMonth
+	}
+	return thaiMonthYear(t)
+}
+
+func thaiMonthYear(t time.Time) string {
+	return fmt.Sprintf("%s %d", thaiMonths[t.Month()-1], BuddhistYear(t.Year()))
+}
+
+// ThaiPeriod formats a pay period in Thai with Buddhist-era years: "มกราคม 2569" for a month,
+// "1-15 มกราคม 2569" for half a month and "29 ธันวาคม 2568 - 4 มกราคม 2569" for a week
+func ThaiPeriod(p payroll.Period) string {
+	start, end := p.Start(), p.End()
+	switch {
+	case p.IsZero():
+		return ""
+	case p.Kind() == payroll.Monthly:
+		return thaiMonthYear(start.Time)
+	case start.Month() == end.Month():
+		return fmt.Sprintf("%d-%d %s", start.Day(), end.Day(), thaiMonthYear(end.Time))
+	}
+	return fmt.Sprintf("%d %s - %d %s", start.Day(), thaiMonthYear(start.Time), end.Day(), thaiMonthYear(end.Time))
+}
+
+// ThaiDate formats a "2006-01-02" date as day/month/Buddhist-era year, e.g. "25/01/2569"; other
+// input is returned unchanged
+func ThaiDate(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return fmt.Sprintf("%02d/%02d/%d", t.Day(), t.Month(), BuddhistYear(t.Year()))
+}
+
+// BuddhistYear converts a Gregorian year to the Buddhist era used on Thai tax forms
+func BuddhistYear(year int) int { return year + 543 }
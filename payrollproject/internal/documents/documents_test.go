@@ -0,0 +1,68 @@
+package documents
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"payrollproject/internal/payroll"
+)
+
+func TestBaht(t *testing.T) {
+	for amount, want := range map[float64]string{
+		0:           "0.00",
+		999.5:       "999.50",
+		1234567.891: "1,234,567.89",
+		-30000:      "-30,000.00",
+	} {
+		if got := Baht(amount); got != want {
+			t.Errorf("Baht(%v) = %q, want %q", amount, got, want)
+		}
+	}
+}
+
+func TestThaiMonth(t *testing.T) {
+	if got := ThaiMonth("2026-01"); got != "มกราคม 2569" {
+		t.Errorf("ThaiMonth(2026-01) = %q", got)
+	}
+	if got := ThaiMonth("bad"); got != "bad" {
+		t.Errorf("ThaiMonth(bad) = %q", got)
+	}
+	if got := ThaiDate("2026-01-25"); got != "25/01/2569" {
+		t.Errorf("ThaiDate(2026-01-25) = %q", got)
+	}
+	for period, want := range map[string]string{
+		"2026-01":    "มกราคม 2569",
+		"2026-02-H2": "16-28 กุมภาพันธ์ 2569",
+		"2026-W01":   "29 ธันวาคม 2568 - 4 มกราคม 2569",
+	} {
+		if got := ThaiPeriod(payroll.MustParsePeriod(period)); got != want {
+			t.Errorf("ThaiPeriod(%s) = %q, want %q", period, got, want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	company := Company{Name: "ACME <Thailand>", TaxID: "0105551234567"}
+	emp := payroll.Employee{EmployeeID: 1, EmpName: "สมชาย"}
+	var b strings.Builder
+	if err := Payslip(&b, company, emp, payroll.Payroll{EmpID: 1, PayMonth: payroll.MustParsePeriod("2026-02"), BaseSalary: 30000, NetSalary: 29250}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"ACME &lt;Thailand&gt;", "กุมภาพันธ์ 2569", "29,250.00"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("payslip missing %q", want)
+		}
+	}
+
+	b.Reset()
+	cert := payroll.TaxCertificate{Employee: emp, TaxYear: 2026, Income: 360000, TaxWithheld: 4500}
+	if err := TaxCertificate(&b, company, cert, time.Date(2027, 1, 15, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"50 ทวิ", "0105551234567", "360,000.00", "4,500.00", "15 มกราคม 2570"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("certificate missing %q", want)
+		}
+	}
+}
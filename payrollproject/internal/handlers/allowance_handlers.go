@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SaveAllowanceDeclarationHandler submits or updates an employee's allowance declaration for a tax year
+func (h *PayrollHandler) SaveAllowanceDeclarationHandler(c *gin.Context) {
+	empID, taxYear, ok := parseEmpTaxYear(c)
+	if !ok || !h.employeeInScope(c, empID) {
+		return
+	}
+	var decl payroll.AllowanceDeclaration
+	if err := c.ShouldBindJSON(&decl); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	decl.EmpID = empID
+	decl.TaxYear = taxYear
+	if err := decl.Validate(); err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := h.ps.SaveAllowanceDeclaration(c.Request.Context(), decl); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, decl)
+}
+
+// GetAllowanceDeclarationHandler fetches an employee's allowance declaration for a tax year
+func (h *PayrollHandler) GetAllowanceDeclarationHandler(c *gin.Context) {
+	empID, taxYear, ok := parseEmpTaxYear(c)
+	if !ok || !h.employeeInScope(c, empID) {
+		return
+	}
+	decl, err := h.ps.GetAllowanceDeclaration(c.Request.Context(), empID, taxYear)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if decl == nil {
+		respondError(c, &payroll.NotFoundError{Entity: "allowance declaration for tax year", ID: taxYear})
+		return
+	}
+	c.JSON(http.StatusOK, decl)
+}
+
+// GetWithholdingHandler computes an employee's tax withholding for a tax year
+func (h *PayrollHandler) GetWithholdingHandler(c *gin.Context) {
+	empID, taxYear, ok := parseEmpTaxYear(c)
+	if !ok || !h.employeeInScope(c, empID) {
+		return
+	}
+	w, err := h.ps.ComputeWithholding(c.Request.Context(), empID, taxYear)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+// parseEmpTaxYear reads the emp_id and tax_year path parameters, writing a 400 response if either is invalid
+func parseEmpTaxYear(c *gin.Context) (int, int, bool) {
+	empID, ok := parseIDParam(c, "emp_id", "Invalid employee ID")
+	if !ok {
+		return 0, 0, false
+	}
+	taxYear, ok := parseIDParam(c, "tax_year", "Invalid tax year")
+	if !ok {
+		return 0, 0, false
+	}
+	return empID, taxYear, true
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"payrollproject/internal/auth"
+	"payrollproject/internal/payroll"
+)
+
+func TestWithholdingHandler(t *testing.T) {
+	api := newTestAPI(t)
+	api.v1.GET("/employees/:emp_id/withholding/:tax_year", api.can(auth.PermAllowanceRead), api.h.GetWithholdingHandler)
+	api.v1.PUT("/employees/:emp_id/allowances/:tax_year", api.can(auth.PermAllowanceWrite), api.h.SaveAllowanceDeclarationHandler)
+
+	for _, tc := range []struct {
+		name       string
+		allowances *payroll.AllowanceDeclaration
+		declared   bool
+		annualTax  float64
+		withheld   float64
+	}{
+		{name: "personal allowance only", annualTax: 20600, withheld: 1716.67},
+		{name: "declared spouse", allowances: &payroll.AllowanceDeclaration{HasSpouse: true}, declared: true, annualTax: 14600, withheld: 1216.67},
+	} {
+		if tc.allowances != nil {
+			api.decode(api.do(http.MethodPut, "/api/v1/employees/1/allowances/2026", tc.allowances), http.StatusOK, nil)
+		}
+		var w payroll.Withholding
+		api.decode(api.do(http.MethodGet, "/api/v1/employees/1/withholding/2026", nil), http.StatusOK, &w)
+		if w.Declared != tc.declared || w.AnnualTax != tc.annualTax || w.PeriodWithholding != tc.withheld || w.PayFrequency != payroll.Monthly {
+			t.Errorf("%s: withholding = %+v", tc.name, w)
+		}
+	}
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/api/v1/employees/1/withholding/2569", http.StatusBadRequest}, // a Buddhist-era year
+		{http.MethodPut, "/api/v1/employees/1/allowances/2569", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/employees/1/withholding/1999", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/employees/1/withholding/next", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/employees/9/withholding/2026", http.StatusNotFound},
+	} {
+		var body any
+		if tc.method == http.MethodPut {
+			body = payroll.AllowanceDeclaration{}
+		}
+		if w := api.do(tc.method, tc.path, body); w.Code != tc.status {
+			t.Errorf("%s %s status = %d, want %d: %s", tc.method, tc.path, w.Code, tc.status, w.Body)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAuditHandler lists audit log entries, filtered by entity, id, actor_id and request_id and
+// paginated by ?limit= and ?cursor=
+func (h *PayrollHandler) ListAuditHandler(c *gin.Context) {
+	var q payroll.AuditQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	page, err := h.ps.ListAudit(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	setPageHeaders(c, page.Total, page.NextCursor)
+	c.JSON(http.StatusOK, page.Items)
+}
+
+// VerifyAuditHandler checks the audit log's hash chain and reports the first broken entry, if any
+func (h *PayrollHandler) VerifyAuditHandler(c *gin.Context) {
+	v, err := h.ps.VerifyAudit(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
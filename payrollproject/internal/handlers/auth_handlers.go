@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"payrollproject/internal/auth"
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+// claimsKey is the gin context key RequireAuth stores the caller's claims under
+const claimsKey = "auth.claims"
+
+// AuthHandler handles login, token refresh and logout, and the management of users and roles
+type AuthHandler struct {
+	auth *auth.Service
+	ps   *payroll.PayrollSystem
+}
+
+// NewAuthHandler creates a new AuthHandler instance
+func NewAuthHandler(s *auth.Service, ps *payroll.PayrollSystem) *AuthHandler {
+	return &AuthHandler{auth: s, ps: ps}
+}
+
+type loginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LoginHandler exchanges a username and password for an access and refresh token
+func (h *AuthHandler) LoginHandler(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	tokens, err := h.auth.Login(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RefreshHandler exchanges a refresh token for a new token pair
+func (h *AuthHandler) RefreshHandler(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	tokens, err := h.auth.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, tokens)
+}
+
+// LogoutHandler revokes the caller's session
+func (h *AuthHandler) LogoutHandler(c *gin.Context) {
+	if err := h.auth.Logout(c.Request.Context(), Claims(c).SessionID); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RequireAuth rejects requests without a valid "Authorization: Bearer" access token and loads
+// the caller's roles for Require. Changes made further down the chain are audited as the caller.
+func (h *AuthHandler) RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="payroll"`)
+			respondError(c, auth.ErrInvalidToken)
+			return
+		}
+		claims, err := h.auth.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="payroll", error="invalid_token"`)
+			respondError(c, err)
+			return
+		}
+		principal, err := h.auth.Principal(c.Request.Context(), claims)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.Set(claimsKey, claims)
+		c.Set(principalKey, principal)
+		a := payroll.AuditContextFrom(c.Request.Context())
+		a.UserID, a.Username = principal.UserID, principal.Username
+		c.Request = c.Request.WithContext(payroll.WithAuditContext(c.Request.Context(), a))
+		c.Next()
+	}
+}
+
+// Claims returns the claims of the authenticated caller; it is only valid behind RequireAuth
+func Claims(c *gin.Context) *auth.Claims {
+	claims, _ := c.MustGet(claimsKey).(*auth.Claims)
+	return claims
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseIDParam reads an integer path parameter, responding 400 if it is invalid
+func parseIDParam(c *gin.Context, name, message string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		respondError(c, badRequest(CodeInvalidParam, message))
+		return 0, false
+	}
+	return id, true
+}
+
+// parsePeriodParam reads a pay period path parameter, responding 400 if it is invalid
+func parsePeriodParam(c *gin.Context, name string) (payroll.Period, bool) {
+	p, err := payroll.ParsePeriod(c.Param(name))
+	if err != nil {
+		respondError(c, badRequest(CodeInvalidParam, err.Error()))
+		return payroll.Period{}, false
+	}
+	return p, true
+}
+
+// etag formats a record version as a strong entity tag
+func etag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// respondWithETag writes a record with its ETag, or 304 if the client's If-None-Match already matches
+func respondWithETag(c *gin.Context, version int, body any) {
+	tag := etag(version)
+	c.Header("ETag", tag)
+	if match := c.GetHeader("If-None-Match"); match == tag || match == "*" {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// applyIfMatch overrides the expected version with the If-Match header when present.
+// "*" matches any version. It responds 400 and returns false for a malformed header.
+func applyIfMatch(c *gin.Context, version *int) bool {
+	match := strings.TrimSpace(c.GetHeader("If-Match"))
+	if match == "" {
+		return true
+	}
+	if match == "*" {
+		*version = 0
+		return true
+	}
+	v, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(match, "W/"), `"`))
+	if err != nil || v < 1 {
+		respondError(c, badRequest(CodeInvalidHeader, "Invalid If-Match header"))
+		return false
+	}
+	*version = v
+	return true
+}
+
+// requireIfMatch is applyIfMatch for an update, which must say which version it replaces: without
+// If-Match or a version in the body it would overwrite whatever another client saved in between, so
+// it responds 428 and returns false. If-Match: * still replaces any version.
+func requireIfMatch(c *gin.Context, version *int) bool {
+	if *version == 0 && strings.TrimSpace(c.GetHeader("If-Match")) == "" {
+		respondError(c, &requestError{status: http.StatusPreconditionRequired, code: CodePrecondition,
+			detail: "Send the record's ETag in If-Match or its version in the body"})
+		return false
+	}
+	return applyIfMatch(c, version)
+}
+
+// bindMergePatch applies the request body as a JSON merge patch (RFC 7396) to current,
+// responding 400 and returning false if the body is not a valid patch
+func bindMergePatch[T any](c *gin.Context, current T) (T, bool) {
+	var merged T
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		respondError(c, badRequest(CodeMalformedBody, err.Error()))
+		return merged, false
+	}
+
+	var patchDoc any
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		respondError(c, badRequest(CodeMalformedBody, "Invalid merge patch: "+err.Error()))
+		return merged, false
+	}
+	if _, ok := patchDoc.(map[string]any); !ok {
+		respondError(c, badRequest(CodeMalformedBody, "Merge patch must be a JSON object"))
+		return merged, false
+	}
+
+	orig, err := json.Marshal(current)
+	if err != nil {
+		respondError(c, err)
+		return merged, false
+	}
+	var target any
+	if err := json.Unmarshal(orig, &target); err != nil {
+		respondError(c, err)
+		return merged, false
+	}
+
+	out, err := json.Marshal(mergePatch(target, patchDoc))
+	if err == nil {
+		err = json.Unmarshal(out, &merged)
+	}
+	if err != nil {
+		respondError(c, badRequest(CodeMalformedBody, "Invalid merge patch: "+err.Error()))
+		return merged, false
+	}
+	return merged, true
+}
+
+// mergePatch implements the RFC 7396 merge algorithm on decoded JSON values
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"payrollproject/internal/auth"
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stable error codes returned in the "code" member of problem responses
+const (
+	CodeNotFound        = "not_found"
+	CodeVersionConflict = "version_conflict"
+	CodePrecondition    = "precondition_required"
+	CodeDuplicate       = "duplicate"
+	CodeLocked          = "record_locked"
+	CodeForeignKey      = "foreign_key_violation"
+	CodeValidation      = "validation_failed"
+	CodeAnomalies       = "payroll_anomalies"
+	CodeInvalidQuery    = "invalid_query"
+	CodeInvalidParam    = "invalid_parameter"
+	CodeMalformedBody   = "malformed_body"
+	CodeInvalidHeader   = "invalid_header"
+	CodeIdempotencyKey  = "idempotency_key_reused"
+	CodeInProgress      = "request_in_progress"
+	CodeTimeout         = "timeout"
+	CodeInvalidLogin    = "invalid_credentials"
+	CodeInvalidToken    = "invalid_token"
+	CodeForbidden       = "forbidden"
+	CodeInternal        = "internal_error"
+)
+
+// Problem is an RFC 7807 problem details response
+type Problem struct {
+	Type     string               `json:"type"`
+	Title    string               `json:"title"`
+	Status   int                  `json:"status"`
+	Code     string               `json:"code"`
+	Detail   string               `json:"detail,omitempty"`
+	Instance string               `json:"instance,omitempty"`
+	Errors   []payroll.FieldError `json:"errors,omitempty"`
+}
+
+// requestError is a client error detected in the handler layer before reaching the payroll system
+type requestError struct {
+	status int
+	code   string
+	detail string
+}
+
+func (e *requestError) Error() string { return e.detail }
+
+// badRequest builds a 400 requestError with a stable code
+func badRequest(code, detail string) error {
+	return &requestError{status: http.StatusBadRequest, code: code, detail: detail}
+}
+
+// bindingError classifies an error from ShouldBindJSON or ShouldBindQuery: type mismatches become
+// field-level validation errors, anything else a malformed request
+func bindingError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return &payroll.ValidationError{Fields: []payroll.FieldError{{Field: typeErr.Field, Message: "must be a " + typeErr.Type.String()}}}
+	}
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return badRequest(CodeInvalidQuery, "invalid number "+strconv.Quote(numErr.Num))
+	}
+	return badRequest(CodeMalformedBody, err.Error())
+}
+
+// respondError records err for ErrorMiddleware, which writes the problem response, and stops the handler chain
+func respondError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// ErrorMiddleware renders the last error recorded by a handler as application/problem+json
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		writeProblem(c)
+	}
+}
+
+// writeProblem renders the last recorded error unless a response has already been written
+func writeProblem(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	p := problemFor(c.Errors.Last().Err)
+	p.Instance = c.Request.URL.Path
+	c.Header("Content-Type", "application/problem+json")
+	c.JSON(p.Status, p)
+}
+
+// problemFor maps domain errors to a status and stable code. Unrecognised errors are logged
+// and reported as a generic 500 so driver messages never reach the client.
+func problemFor(err error) Problem {
+	var (
+		reqErr     *requestError
+		notFound   *payroll.NotFoundError
+		conflict   *payroll.ConflictError
+		fk         *payroll.ForeignKeyError
+		validation *payroll.ValidationError
+		anomalies  *payroll.AnomalyError
+	)
+	switch {
+	case errors.As(err, &reqErr):
+		return newProblem(reqErr.status, reqErr.code, reqErr.detail)
+	case errors.As(err, &notFound):
+		return newProblem(http.StatusNotFound, CodeNotFound, notFound.Error())
+	case errors.As(err, &conflict):
+		if conflict.Duplicate {
+			return newProblem(http.StatusConflict, CodeDuplicate, conflict.Error())
+		}
+		if conflict.Reason != "" {
+			return newProblem(http.StatusConflict, CodeLocked, conflict.Error())
+		}
+		return newProblem(http.StatusConflict, CodeVersionConflict, conflict.Error())
+	case errors.As(err, &fk):
+		p := newProblem(http.StatusUnprocessableEntity, CodeForeignKey, fk.Error())
+		if fk.Field != "" {
+			p.Errors = []payroll.FieldError{{Field: fk.Field, Message: "references a record that does not exist"}}
+		}
+		return p
+	case errors.As(err, &anomalies):
+		p := newProblem(http.StatusUnprocessableEntity, CodeAnomalies, anomalies.Error())
+		for _, a := range anomalies.Anomalies {
+			p.Errors = append(p.Errors, payroll.FieldError{Field: a.Field, Message: a.Message})
+		}
+		return p
+	case errors.As(err, &validation):
+		p := newProblem(http.StatusBadRequest, CodeValidation, "One or more fields are invalid")
+		p.Errors = validation.Fields
+		return p
+	case errors.Is(err, payroll.ErrNotFound):
+		return newProblem(http.StatusNotFound, CodeNotFound, err.Error())
+	case errors.Is(err, payroll.ErrConflict):
+		return newProblem(http.StatusConflict, CodeVersionConflict, err.Error())
+	case errors.Is(err, payroll.ErrInvalidQuery):
+		return newProblem(http.StatusBadRequest, CodeInvalidQuery, err.Error())
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		return newProblem(http.StatusUnauthorized, CodeInvalidLogin, err.Error())
+	case errors.Is(err, auth.ErrInvalidToken):
+		return newProblem(http.StatusUnauthorized, CodeInvalidToken, err.Error())
+	case errors.Is(err, auth.ErrForbidden):
+		return newProblem(http.StatusForbidden, CodeForbidden, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return newProblem(http.StatusServiceUnavailable, CodeTimeout, "The request took too long to complete")
+	}
+	log.Printf("internal error: %v", err)
+	return newProblem(http.StatusInternalServerError, CodeInternal, "An unexpected error occurred")
+}
+
+// newProblem fills in the type URI and title for a status and code
+func newProblem(status int, code, detail string) Problem {
+	return Problem{
+		Type:   "urn:payroll:problem:" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
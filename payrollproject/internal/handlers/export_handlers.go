@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"payrollproject/internal/documents"
+	"payrollproject/internal/payroll"
+	"payrollproject/internal/sheets"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFormat reads the file format a list is asked for, from the format query parameter or else
+// the Accept header. An empty format means the usual JSON array.
+func exportFormat(c *gin.Context) (string, bool) {
+	c.Writer.Header().Add("Vary", "Accept") // beside the Vary: Origin of CORS responses
+	switch f := strings.ToLower(c.Query("format")); f {
+	case "":
+	case "json":
+		return "", true
+	case sheets.CSV, sheets.XLSX:
+		return f, true
+	default:
+		respondError(c, badRequest(CodeInvalidQuery, "format must be json, csv or xlsx"))
+		return "", false
+	}
+	accept := c.GetHeader("Accept")
+	switch {
+	case strings.Contains(accept, "text/csv"):
+		return sheets.CSV, true
+	case strings.Contains(accept, sheets.ContentType(sheets.XLSX)):
+		return sheets.XLSX, true
+	}
+	return "", true
+}
+
+// buddhistEra reports whether an export asks for dates in the Buddhist era (be=true)
+func buddhistEra(c *gin.Context) (bool, bool) {
+	v := c.Query("be")
+	if v == "" {
+		return false, true
+	}
+	be, err := strconv.ParseBool(v)
+	if err != nil {
+		respondError(c, badRequest(CodeInvalidQuery, "be must be true or false"))
+		return false, false
+	}
+	return be, true
+}
+
+// exportDates formats the dates of an exported row
+type exportDates bool
+
+func (be exportDates) period(p payroll.Period) string {
+	if be {
+		return documents.ThaiPeriod(p)
+	}
+	return p.String()
+}
+
+func (be exportDates) day(date string) string {
+	if be {
+		return documents.ThaiDate(date)
+	}
+	return date
+}
+
+// startDownload sets the headers of a file download; the file name gets today's date
+func startDownload(c *gin.Context, format, name string) {
+	c.Header("Content-Type", sheets.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`, name, time.Now().Format("20060102"), format))
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+}
+
+// streamList writes every page of a list query as a single-sheet file: the first page has been
+// read by the caller, next reads the page after a cursor. Nothing is written if the first page
+// failed, so the usual problem response can still be sent.
+func streamList[T any](c *gin.Context, format, name string, cols []sheets.Column, first payroll.Page[T],
+	next func(cursor string) (payroll.Page[T], error), row func(T) []any) {
+	startDownload(c, format, name)
+	w, err := sheets.NewWriter(c.Writer, format)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	err = w.Sheet(name, cols)
+	for page := first; err == nil; {
+		for _, item := range page.Items {
+			if err = w.WriteRow(row(item)...); err != nil {
+				break
+			}
+		}
+		if err != nil || page.NextCursor == "" {
+			break
+		}
+		page, err = next(page.NextCursor)
+	}
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		// Part of the file may already be on the wire; the error is logged and the download cut short
+		respondError(c, err)
+	}
+}
+
+var employeeExportColumns = []sheets.Column{
+	{Header: "emp_id", Kind: sheets.Integer},
+	{Header: "emp_name", Width: 30},
+	{Header: "phone_number", Width: 14},
+	{Header: "dept_id", Kind: sheets.Integer},
+	{Header: "dept_name"},
+	{Header: "position_name"},
+	{Header: "base_salary", Kind: sheets.Money},
+	{Header: "pay_frequency", Width: 14},
+	{Header: "pay_type"},
+	{Header: "wage_rate", Kind: sheets.Money},
+	{Header: "province", Width: 18},
+	{Header: "site_id", Width: 12},
+	{Header: "bank_account"},
+	{Header: "account_num", Width: 16},
+	{Header: "national_id", Width: 18},
+	{Header: "end_date", Width: 12},
+}
+
+// exportEmployees streams the employee list with the same columns an import reads, so a file can be
+// exported, edited and imported again. base_salary and wage_rate are empty and the account and national ID
+// numbers are masked wherever the JSON list would hide them.
+func (h *PayrollHandler) exportEmployees(c *gin.Context, format string, q payroll.EmployeeQuery) {
+	be, ok := buddhistEra(c)
+	if !ok {
+		return
+	}
+	dates := exportDates(be)
+	q.Limit = payroll.MaxPageSize
+	first, err := h.ps.ListEmployees(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	next := func(cursor string) (payroll.Page[payroll.Employee], error) {
+		q.Cursor = cursor
+		return h.ps.ListEmployees(c.Request.Context(), q)
+	}
+	streamList(c, format, "employees", employeeExportColumns, first, next, func(emp payroll.Employee) []any {
+		v := viewEmployee(c, emp)
+		return []any{v.EmployeeID, v.EmpName, v.PhoneNumber, v.DeptID, v.DeptName, v.PositionName, v.BaseSalary,
+			string(v.PayFrequency), v.PayType, v.WageRate, v.Province, v.SiteID, v.BankAccount, v.AccountNum, v.NationalID, dates.day(v.EndDate)}
+	})
+}
+
+var payrollExportColumns = []sheets.Column{
+	{Header: "payroll_id", Kind: sheets.Integer},
+	{Header: "emp_id", Kind: sheets.Integer},
+	{Header: "pay_month", Width: 14},
+	{Header: "pay_date", Width: 12},
+	{Header: "run_type", Width: 10},
+	{Header: "base_salary", Kind: sheets.Money},
+	{Header: "total_additions", Kind: sheets.Money},
+	{Header: "total_deductions", Kind: sheets.Money},
+	{Header: "tax_amount", Kind: sheets.Money},
+	{Header: "net_salary", Kind: sheets.Money},
+	{Header: "status", Width: 10},
+}
+
+// exportPayrolls streams the payroll list with the fields of its JSON form
+func (h *PayrollHandler) exportPayrolls(c *gin.Context, format string, q payroll.PayrollQuery) {
+	be, ok := buddhistEra(c)
+	if !ok {
+		return
+	}
+	dates := exportDates(be)
+	q.Limit = payroll.MaxPageSize
+	first, err := h.ps.ListPayrolls(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	next := func(cursor string) (payroll.Page[payroll.Payroll], error) {
+		q.Cursor = cursor
+		return h.ps.ListPayrolls(c.Request.Context(), q)
+	}
+	streamList(c, format, "payrolls", payrollExportColumns, first, next, func(p payroll.Payroll) []any {
+		return []any{p.PayrollID, p.EmpID, dates.period(p.PayMonth), dates.day(p.PayDate.String()), p.RunType, p.BaseSalary,
+			p.TotalAdditions, p.TotalDeductions, p.TaxAmount, p.NetSalary, p.Status}
+	})
+}
+
+var payrollWorkbookColumns = []sheets.Column{
+	{Header: "Employee ID", Kind: sheets.Integer, Width: 12},
+	{Header: "Name", Width: 30},
+	{Header: "Position"},
+	{Header: "Pay month", Width: 16},
+	{Header: "Pay date", Width: 12},
+	{Header: "Base salary", Kind: sheets.Money},
+	{Header: "Additions", Kind: sheets.Money},
+	{Header: "Deductions", Kind: sheets.Money},
+	{Header: "Tax", Kind: sheets.Money},
+	{Header: "Net pay", Kind: sheets.Money},
+	{Header: "Status", Width: 10},
+}
+
+// PayrollWorkbookHandler downloads the payroll records matching the list filters as an Excel
+// workbook for finance: one sheet per department, each ending in a totals row. be=true writes pay
+// periods and dates in the Buddhist era.
+func (h *PayrollHandler) PayrollWorkbookHandler(c *gin.Context) {
+	var q payroll.PayrollQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	if !scopeFilter(c, &q.DeptID, &q.EmpID) {
+		return
+	}
+	be, ok := buddhistEra(c)
+	if !ok {
+		return
+	}
+	dates := exportDates(be)
+	ctx := c.Request.Context()
+	depts, err := h.ps.GetAllDepartments(ctx)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	startDownload(c, sheets.XLSX, "payroll")
+	w, err := sheets.NewWriter(c.Writer, sheets.XLSX)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	written := false
+	for _, d := range depts {
+		if q.DeptID != 0 && d.DeptID != q.DeptID {
+			continue
+		}
+		dq := q
+		dq.DeptID, dq.Limit, dq.Cursor = d.DeptID, payroll.MaxPageSize, ""
+		if dq.Sort == "" {
+			dq.Sort = "emp_id"
+		}
+		if err = h.writeDepartmentSheet(c, w, d, dq, dates); err != nil {
+			break
+		}
+		written = true
+	}
+	if err == nil && !written {
+		err = w.Sheet("Payroll", payrollWorkbookColumns)
+	}
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		// The workbook is only written on Close, so the problem response still reaches the client
+		c.Header("Content-Disposition", "")
+		c.Header("Content-Type", "")
+		respondError(c, err)
+	}
+}
+
+// writeDepartmentSheet writes a department's payroll records and their totals; a department with
+// no matching records gets no sheet
+func (h *PayrollHandler) writeDepartmentSheet(c *gin.Context, w sheets.Writer, d payroll.Department, q payroll.PayrollQuery, dates exportDates) error {
+	ctx := c.Request.Context()
+	page, err := h.ps.ListPayrolls(ctx, q)
+	if err != nil || len(page.Items) == 0 {
+		return err
+	}
+	emps, err := h.ps.ListEmployees(ctx, payroll.EmployeeQuery{ListOptions: payroll.ListOptions{Limit: payroll.MaxPageSize}, DeptID: d.DeptID})
+	if err != nil {
+		return err
+	}
+	names := map[int]payroll.Employee{}
+	for {
+		for _, emp := range emps.Items {
+			names[emp.EmployeeID] = emp
+		}
+		if emps.NextCursor == "" {
+			break
+		}
+		if emps, err = h.ps.ListEmployees(ctx, payroll.EmployeeQuery{ListOptions: payroll.ListOptions{Limit: payroll.MaxPageSize, Cursor: emps.NextCursor}, DeptID: d.DeptID}); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Sheet(d.DeptName, payrollWorkbookColumns); err != nil {
+		return err
+	}
+	var base, additions, deductions, tax, net float64
+	for {
+		for _, p := range page.Items {
+			emp := names[p.EmpID]
+			if err := w.WriteRow(p.EmpID, emp.EmpName, emp.PositionName, dates.period(p.PayMonth), dates.day(p.PayDate.String()),
+				p.BaseSalary, p.TotalAdditions, p.TotalDeductions, p.TaxAmount, p.NetSalary, p.Status); err != nil {
+				return err
+			}
+			base += p.BaseSalary
+			additions += p.TotalAdditions
+			deductions += p.TotalDeductions
+			tax += p.TaxAmount
+			net += p.NetSalary
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+		if page, err = h.ps.ListPayrolls(ctx, q); err != nil {
+			return err
+		}
+	}
+	return w.WriteTotals(nil, "Total", nil, nil, nil, base, additions, deductions, tax, net, nil)
+}
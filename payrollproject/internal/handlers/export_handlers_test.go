@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"payrollproject/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListExportKeepsVaryOrigin(t *testing.T) {
+	api := newTestAPI(t)
+	// Stands in for the CORS middleware, which runs first
+	varyOrigin := func(c *gin.Context) { c.Header("Vary", "Origin") }
+	api.v1.GET("/employees", varyOrigin, api.can(auth.PermEmployeeRead), api.h.GetAllEmployeesHandler)
+
+	for _, accept := range []string{"application/json", "text/csv"} {
+		w := api.do(http.MethodGet, "/api/v1/employees", nil, "Accept", accept)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Accept %s: status = %d: %s", accept, w.Code, w.Body)
+		}
+		if vary := strings.Join(w.Header().Values("Vary"), ", "); vary != "Origin, Accept" {
+			t.Errorf("Accept %s: Vary = %q, want Origin, Accept", accept, vary)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payrollproject/internal/auth"
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testAPI serves routes over an in-memory database to a signed-in administrator
+type testAPI struct {
+	t      *testing.T
+	ps     *payroll.PayrollSystem
+	h      *PayrollHandler
+	svc    *auth.Service
+	router *gin.Engine
+	v1     *gin.RouterGroup
+	can    func(auth.Permission) gin.HandlerFunc
+	token  string
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	ctx := context.Background()
+	ps := payroll.NewPayrollSystem(payroll.NewMemoryPayrollDB())
+	svc := auth.NewService(ps, auth.Config{Secret: []byte("test secret"), AccessTTL: time.Minute, RefreshTTL: time.Hour})
+	if err := svc.EnsureDefaultRoles(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.EnsureUser(ctx, "admin", "correct horse", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := svc.Login(ctx, "admin", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.AddDepartment(ctx, payroll.Department{DeptID: 1, DeptName: "Ops"}); err != nil {
+		t.Fatal(err)
+	}
+	err = ps.AddEmployee(ctx, payroll.Employee{EmployeeID: 1, EmpName: "A", PhoneNumber: "0812345678", DeptID: 1, BaseSalary: 50000,
+		BankAccount: "Government Savings Bank", AccountNum: "123456789012"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorMiddleware())
+	authH := NewAuthHandler(svc, ps)
+	return &testAPI{t: t, ps: ps, h: NewPayrollHandler(ps), svc: svc, router: r,
+		v1: r.Group("/api/v1", authH.RequireAuth()), can: authH.Require, token: tokens.AccessToken}
+}
+
+// signIn makes later requests as a new user linked to an employee and holding roles
+func (a *testAPI) signIn(username string, empID int, roles ...string) {
+	a.t.Helper()
+	ctx := context.Background()
+	if _, err := a.svc.CreateUser(ctx, payroll.User{Username: username, EmpID: &empID, Roles: roles}, "correct horse"); err != nil {
+		a.t.Fatal(err)
+	}
+	tokens, err := a.svc.Login(ctx, username, "correct horse")
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	a.token = tokens.AccessToken
+}
+
+// do sends a request with body encoded as JSON, unless it is nil, and returns the response
+func (a *testAPI) do(method, path string, body any, header ...string) *httptest.ResponseRecorder {
+	a.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			a.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	return w
+}
+
+// decode reads a JSON response into v, failing the test unless it has the wanted status
+func (a *testAPI) decode(w *httptest.ResponseRecorder, status int, v any) {
+	a.t.Helper()
+	if w.Code != status {
+		a.t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body)
+	}
+	if v != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			a.t.Fatalf("%v: %s", err, w.Body)
+		}
+	}
+}
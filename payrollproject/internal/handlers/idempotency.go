@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limits on a request sent with an Idempotency-Key
+const (
+	maxIdempotencyKey = 255
+	maxIdempotentBody = 16 << 20
+)
+
+// replayedHeaders are the response headers stored with an idempotent response besides its body
+var replayedHeaders = []string{"Content-Type", "Content-Disposition", "Location", "ETag"}
+
+// Idempotency lets a client retry a POST safely: a request sent with an Idempotency-Key header is
+// handled once per caller and key, and a retry within payroll.IdempotencyKeyTTL gets the stored
+// response back, marked with Idempotent-Replayed: true. Reusing a key for a different request is
+// rejected, as is a retry while the first request is still running. A response with a 5xx status, or
+// one that could not be stored, is not kept, and neither is a request whose handler panicked, so the
+// retry runs the request again. It must run after authentication.
+func Idempotency(ps *payroll.PayrollSystem) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			respondError(c, badRequest(CodeInvalidHeader, fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKey)))
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBody+1))
+		if err != nil {
+			respondError(c, badRequest(CodeMalformedBody, "the request body could not be read"))
+			return
+		}
+		if len(body) > maxIdempotentBody {
+			respondError(c, badRequest(CodeMalformedBody, fmt.Sprintf("a request with an Idempotency-Key can carry at most %d MiB", maxIdempotentBody>>20)))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.New()
+		fmt.Fprintf(hash, "%s %s\n", c.Request.Method, c.Request.URL.RequestURI())
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		ctx := c.Request.Context()
+		userID := Principal(c).UserID
+		stored, reserved, err := ps.ReserveIdempotencyKey(ctx, userID, key, requestHash)
+		if errors.Is(err, payroll.ErrConflict) {
+			err = &requestError{status: http.StatusConflict, code: CodeInProgress, detail: "A request with this Idempotency-Key is still being handled"}
+		}
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		if !reserved {
+			replayIdempotent(c, stored, requestHash)
+			return
+		}
+
+		// Unless a response is stored, the reservation is released, even when the handler panics,
+		// so that a retry runs the request again rather than waiting out the key
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := ps.ReleaseIdempotencyKey(context.WithoutCancel(ctx), userID, key); err != nil {
+				log.Printf("failed to release idempotency key: %v", err)
+			}
+		}()
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		// Render a recorded error now so that it is stored with the response
+		writeProblem(c)
+		if w.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		r := payroll.IdempotencyRecord{UserID: userID, Key: key, Status: w.Status(), Headers: map[string]string{}, Body: w.body.Bytes()}
+		for _, h := range replayedHeaders {
+			if v := w.Header().Get(h); v != "" {
+				r.Headers[h] = v
+			}
+		}
+		// The response is stored even when the request's own deadline has passed
+		if err := ps.CompleteIdempotencyKey(context.WithoutCancel(ctx), r); err != nil {
+			log.Printf("failed to store idempotent response: %v", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// replayIdempotent answers a retried request with the stored response of the first
+func replayIdempotent(c *gin.Context, stored payroll.IdempotencyRecord, requestHash string) {
+	switch {
+	case stored.RequestHash != requestHash:
+		respondError(c, &requestError{status: http.StatusUnprocessableEntity, code: CodeIdempotencyKey,
+			detail: "This Idempotency-Key was already used for a different request"})
+	case stored.Status == 0:
+		respondError(c, &requestError{status: http.StatusConflict, code: CodeInProgress,
+			detail: "A request with this Idempotency-Key is still being handled"})
+	default:
+		for h, v := range stored.Headers {
+			c.Header(h, v)
+		}
+		c.Header("Idempotent-Replayed", "true")
+		c.Status(stored.Status)
+		if _, err := c.Writer.Write(stored.Body); err != nil {
+			_ = c.Error(err)
+		}
+		c.Abort()
+	}
+}
+
+// recordingWriter keeps a copy of the response body as it is written
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIdempotencyReleasesKeyOfAPanickedRequest(t *testing.T) {
+	api := newTestAPI(t)
+	calls := 0
+	g := api.v1.Group("", gin.RecoveryWithWriter(io.Discard), Idempotency(api.ps))
+	g.POST("/flaky", func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("lost the connection")
+		}
+		c.JSON(http.StatusCreated, gin.H{"calls": calls})
+	})
+
+	if w := api.do(http.MethodPost, "/api/v1/flaky", gin.H{}, "Idempotency-Key", "k1"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("panicked request status = %d", w.Code)
+	}
+	// The retry runs the request again instead of waiting for a request that is no longer running
+	var got struct{ Calls int }
+	api.decode(api.do(http.MethodPost, "/api/v1/flaky", gin.H{}, "Idempotency-Key", "k1"), http.StatusCreated, &got)
+	w := api.do(http.MethodPost, "/api/v1/flaky", gin.H{}, "Idempotency-Key", "k1")
+	api.decode(w, http.StatusCreated, &got)
+	if got.Calls != 2 || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replayed calls = %d, header = %q", got.Calls, w.Header().Get("Idempotent-Replayed"))
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"payrollproject/internal/payroll"
+	"payrollproject/internal/sheets"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limits on an uploaded import file
+const (
+	maxImportBytes = 10 << 20
+	maxImportRows  = 10000
+)
+
+// ImportEmployeesHandler creates or updates employees from an uploaded CSV or XLSX file. The
+// multipart form carries the file in "file", an optional JSON object mapping employee fields to the
+// file's column headers in "mapping", and "dry_run=true" to validate without writing. Valid rows are
+// committed together; rows that fail validation are listed in the report and skipped.
+func (h *PayrollHandler) ImportEmployeesHandler(c *gin.Context) {
+	if !requireAllRows(c) {
+		return
+	}
+	fh, ok := uploadedFile(c)
+	if !ok {
+		return
+	}
+	format, err := sheets.FormatOf(fh.Filename, fh.Header.Get("Content-Type"))
+	if err != nil {
+		respondError(c, &payroll.ValidationError{Fields: []payroll.FieldError{{Field: "file", Message: err.Error()}}})
+		return
+	}
+
+	var mapping map[string]string
+	if m := c.PostForm("mapping"); m != "" {
+		if err := json.Unmarshal([]byte(m), &mapping); err != nil {
+			respondError(c, &payroll.ValidationError{Fields: []payroll.FieldError{{Field: "mapping", Message: "must be a JSON object of field names to column headers"}}})
+			return
+		}
+	}
+	dryRun, ok := dryRunParam(c)
+	if !ok {
+		return
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer f.Close()
+	table, err := sheets.Read(f, format)
+	if err != nil {
+		respondError(c, &payroll.ValidationError{Fields: []payroll.FieldError{{Field: "file", Message: err.Error()}}})
+		return
+	}
+	if len(table) > maxImportRows+1 {
+		respondError(c, &payroll.ValidationError{Fields: []payroll.FieldError{{Field: "file", Message: fmt.Sprintf("at most %d rows can be imported at once", maxImportRows)}}})
+		return
+	}
+
+	report, err := h.ps.ImportEmployees(c.Request.Context(), table, mapping, dryRun)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// uploadedFile returns the "file" field of a multipart form, writing a 400 response if it is
+// missing or larger than an import may be
+func uploadedFile(c *gin.Context) (*multipart.FileHeader, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes+1<<20)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(c, badRequest(CodeMalformedBody, fmt.Sprintf("the upload is larger than %d MiB", maxImportBytes>>20)))
+			return nil, false
+		}
+		respondError(c, badRequest(CodeMalformedBody, "a multipart form with a \"file\" field is required"))
+		return nil, false
+	}
+	if fh.Size > maxImportBytes {
+		respondError(c, badRequest(CodeMalformedBody, fmt.Sprintf("the file is larger than %d MiB", maxImportBytes>>20)))
+		return nil, false
+	}
+	return fh, true
+}
+
+// dryRunParam reads the dry_run form field, writing a 400 response if it is not a boolean
+func dryRunParam(c *gin.Context) (bool, bool) {
+	v := c.PostForm("dry_run")
+	if v == "" {
+		return false, true
+	}
+	dryRun, err := strconv.ParseBool(v)
+	if err != nil {
+		respondError(c, &payroll.ValidationError{Fields: []payroll.FieldError{{Field: "dry_run", Message: "must be true or false"}}})
+		return false, false
+	}
+	return dryRun, true
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+
+	"payrollproject/internal/payroll"
+	"payrollproject/internal/sheets"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetChartOfAccountsHandler returns the ledger accounts pay run journals are booked to
+func (h *PayrollHandler) GetChartOfAccountsHandler(c *gin.Context) {
+	chart, err := h.ps.GetChartOfAccounts(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, chart)
+}
+
+// SaveChartOfAccountsHandler replaces the ledger account mapping
+func (h *PayrollHandler) SaveChartOfAccountsHandler(c *gin.Context) {
+	var chart payroll.ChartOfAccounts
+	if err := c.ShouldBindJSON(&chart); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	saved, err := h.ps.SaveChartOfAccounts(c.Request.Context(), chart)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
+
+var journalColumns = []sheets.Column{
+	{Header: "date", Width: 12},
+	{Header: "reference", Width: 14},
+	{Header: "account", Width: 12},
+	{Header: "cost_centre", Width: 12},
+	{Header: "description", Width: 40},
+	{Header: "debit", Kind: sheets.Money},
+	{Header: "credit", Kind: sheets.Money},
+}
+
+// PayRunJournalHandler returns the balanced journal entry for a pay period's run, as JSON or, with
+// ?format=csv|xlsx or a matching Accept header, as one line per row for the accounting system's import
+func (h *PayrollHandler) PayRunJournalHandler(c *gin.Context) {
+	if !requireAllRows(c) {
+		return
+	}
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+	period, ok := parsePeriodParam(c, "pay_month")
+	if !ok {
+		return
+	}
+	entry, err := h.ps.PayRunJournal(c.Request.Context(), period)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if format == "" {
+		c.JSON(http.StatusOK, entry)
+		return
+	}
+
+	startDownload(c, format, "journal-"+entry.PayMonth.String())
+	w, err := sheets.NewWriter(c.Writer, format)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	err = w.Sheet(entry.Reference, journalColumns)
+	for _, l := range entry.Lines {
+		if err != nil {
+			break
+		}
+		var debit, credit any
+		if l.Debit != 0 {
+			debit = l.Debit
+		}
+		if l.Credit != 0 {
+			credit = l.Credit
+		}
+		err = w.WriteRow(entry.Date.String(), entry.Reference, l.Account, l.CostCentre, l.Description, debit, credit)
+	}
+	if err == nil && format == sheets.XLSX {
+		// A CSV file is read back by the accounting system, which expects journal lines only
+		err = w.WriteTotals(nil, nil, nil, nil, "Total", entry.TotalDebit, entry.TotalCredit)
+	}
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		respondError(c, err)
+	}
+}
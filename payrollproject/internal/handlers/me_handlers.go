@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"payrollproject/internal/auth"
+	"payrollproject/internal/documents"
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SelfServiceHandler serves /me: an employee's own record, payroll history, payslips, tax
+// certificates, allowance declarations, profile change requests and leave. Every handler acts on
+// the employee linked to the caller's account and ignores any emp_id in the request.
+type SelfServiceHandler struct {
+	ps      *payroll.PayrollSystem
+	company documents.Company
+}
+
+// NewSelfServiceHandler creates a new SelfServiceHandler instance
+func NewSelfServiceHandler(ps *payroll.PayrollSystem, company documents.Company) *SelfServiceHandler {
+	return &SelfServiceHandler{ps: ps, company: company}
+}
+
+// RequireEmployee rejects callers whose account is not linked to an employee record
+func RequireEmployee() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if Principal(c).EmpID == 0 {
+			respondError(c, auth.ErrNoEmployee)
+			return
+		}
+		c.Next()
+	}
+}
+
+// myEmpID is the caller's employee ID; it is only valid behind RequireEmployee
+func myEmpID(c *gin.Context) int { return Principal(c).EmpID }
+
+// GetProfileHandler returns the caller's employee record
+func (h *SelfServiceHandler) GetProfileHandler(c *gin.Context) {
+	emp, err := h.ps.GetEmployee(c.Request.Context(), myEmpID(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, viewEmployee(c, emp))
+}
+
+// RequestProfileChangeHandler files a change to the caller's phone number or bank details for HR
+// to approve (PATCH /me); the record itself is unchanged until then, so the response is 202
+func (h *SelfServiceHandler) RequestProfileChangeHandler(c *gin.Context) {
+	var req payroll.ProfileChange
+	if !bindSelfServiceChange(c, &req) {
+		return
+	}
+	req.EmpID = myEmpID(c)
+	change, err := h.ps.RequestProfileChange(c.Request.Context(), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("Location", "/api/v1/me/profile-changes/"+strconv.Itoa(change.ChangeID))
+	c.JSON(http.StatusAccepted, viewProfileChange(c, change))
+}
+
+// bindSelfServiceChange decodes a profile change, rejecting fields an employee may not change themselves
+func bindSelfServiceChange(c *gin.Context, req *payroll.ProfileChange) bool {
+	var body struct {
+		PhoneNumber *string `json:"phone_number"`
+		BankAccount *string `json:"bank_account"`
+		AccountNum  *string `json:"account_num"`
+	}
+	dec := json.NewDecoder(c.Request.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			field, _ = strconv.Unquote(field)
+			respondError(c, &payroll.ValidationError{Fields: []payroll.FieldError{{Field: field, Message: "cannot be changed through self-service"}}})
+			return false
+		}
+		respondError(c, bindingError(err))
+		return false
+	}
+	req.PhoneNumber, req.BankAccount, req.AccountNum = body.PhoneNumber, body.BankAccount, body.AccountNum
+	return true
+}
+
+// ListProfileChangesHandler lists the caller's profile change requests, optionally by ?status=
+func (h *SelfServiceHandler) ListProfileChangesHandler(c *gin.Context) {
+	changes, err := h.ps.ListProfileChanges(c.Request.Context(), payroll.RequestQuery{EmpID: myEmpID(c), Status: c.Query("status")})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, viewProfileChanges(c, changes))
+}
+
+// GetProfileChangeHandler fetches one of the caller's profile change requests
+func (h *SelfServiceHandler) GetProfileChangeHandler(c *gin.Context) {
+	changeID, ok := parseIDParam(c, "change_id", "Invalid change ID")
+	if !ok {
+		return
+	}
+	change, err := h.ps.GetProfileChange(c.Request.Context(), changeID)
+	if err == nil && change.EmpID != myEmpID(c) {
+		err = &payroll.NotFoundError{Entity: "profile change", ID: changeID}
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, viewProfileChange(c, change))
+}
+
+// ListPayrollsHandler lists the caller's approved payroll records, filtered by pay month range and
+// paginated like GET /payrolls. Drafts are still being reviewed, so staff never see them.
+func (h *SelfServiceHandler) ListPayrollsHandler(c *gin.Context) {
+	var q payroll.PayrollQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	q.EmpID, q.DeptID, q.Status = myEmpID(c), 0, payroll.PayrollApproved
+	page, err := h.ps.ListPayrolls(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	setPageHeaders(c, page.Total, page.NextCursor)
+	c.JSON(http.StatusOK, page.Items)
+}
+
+// myPayroll fetches one of the caller's approved payroll records, responding 404 for drafts and for
+// anyone else's
+func (h *SelfServiceHandler) myPayroll(c *gin.Context) (payroll.Payroll, bool) {
+	payrollID, ok := parseIDParam(c, "payroll_id", "Invalid payroll ID")
+	if !ok {
+		return payroll.Payroll{}, false
+	}
+	p, err := h.ps.GetPayroll(c.Request.Context(), payrollID)
+	if err == nil && (p.EmpID != myEmpID(c) || p.Status != payroll.PayrollApproved) {
+		err = &payroll.NotFoundError{Entity: "payroll", ID: payrollID}
+	}
+	if err != nil {
+		respondError(c, err)
+		return payroll.Payroll{}, false
+	}
+	return p, true
+}
+
+// GetPayrollHandler fetches one of the caller's payroll records
+func (h *SelfServiceHandler) GetPayrollHandler(c *gin.Context) {
+	p, ok := h.myPayroll(c)
+	if !ok {
+		return
+	}
+	respondWithETag(c, p.Version, p)
+}
+
+// PayslipHandler downloads the payslip for one of the caller's payroll records as a printable HTML page
+func (h *SelfServiceHandler) PayslipHandler(c *gin.Context) {
+	p, ok := h.myPayroll(c)
+	if !ok {
+		return
+	}
+	emp, err := h.ps.GetEmployee(c.Request.Context(), p.EmpID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := documents.Payslip(&buf, h.company, emp, p); err != nil {
+		respondError(c, err)
+		return
+	}
+	sendDocument(c, fmt.Sprintf("payslip-%s.html", p.PayMonth), buf.Bytes())
+}
+
+// TaxCertificateHandler downloads the caller's withholding tax certificate (50 Tawi) for a tax year
+func (h *SelfServiceHandler) TaxCertificateHandler(c *gin.Context) {
+	taxYear, ok := parseIDParam(c, "tax_year", "Invalid tax year")
+	if !ok {
+		return
+	}
+	cert, err := h.ps.TaxCertificate(c.Request.Context(), myEmpID(c), taxYear)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := documents.TaxCertificate(&buf, h.company, cert, time.Now()); err != nil {
+		respondError(c, err)
+		return
+	}
+	sendDocument(c, fmt.Sprintf("50tawi-%d.html", taxYear), buf.Bytes())
+}
+
+// sendDocument writes a rendered HTML document as a download
+func sendDocument(c *gin.Context, filename string, body []byte) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", body)
+}
+
+// SaveAllowanceDeclarationHandler submits or updates the caller's allowance declaration for a tax year
+func (h *SelfServiceHandler) SaveAllowanceDeclarationHandler(c *gin.Context) {
+	taxYear, ok := parseIDParam(c, "tax_year", "Invalid tax year")
+	if !ok {
+		return
+	}
+	var decl payroll.AllowanceDeclaration
+	if err := c.ShouldBindJSON(&decl); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	decl.EmpID, decl.TaxYear = myEmpID(c), taxYear
+	if err := h.ps.SaveAllowanceDeclaration(c.Request.Context(), decl); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, decl)
+}
+
+// GetAllowanceDeclarationHandler fetches the caller's allowance declaration for a tax year
+func (h *SelfServiceHandler) GetAllowanceDeclarationHandler(c *gin.Context) {
+	taxYear, ok := parseIDParam(c, "tax_year", "Invalid tax year")
+	if !ok {
+		return
+	}
+	decl, err := h.ps.GetAllowanceDeclaration(c.Request.Context(), myEmpID(c), taxYear)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if decl == nil {
+		respondError(c, &payroll.NotFoundError{Entity: "allowance declaration for tax year", ID: taxYear})
+		return
+	}
+	c.JSON(http.StatusOK, decl)
+}
+
+// GetWithholdingHandler computes the caller's tax withholding for a tax year
+func (h *SelfServiceHandler) GetWithholdingHandler(c *gin.Context) {
+	taxYear, ok := parseIDParam(c, "tax_year", "Invalid tax year")
+	if !ok {
+		return
+	}
+	w, err := h.ps.ComputeWithholding(c.Request.Context(), myEmpID(c), taxYear)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+type leaveRequestBody struct {
+	LeaveType string `json:"leave_type"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Reason    string `json:"reason"`
+}
+
+// RequestLeaveHandler files a leave request for the caller
+func (h *SelfServiceHandler) RequestLeaveHandler(c *gin.Context) {
+	var body leaveRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	leave, err := h.ps.RequestLeave(c.Request.Context(), payroll.LeaveRequest{
+		EmpID:     myEmpID(c),
+		LeaveType: body.LeaveType,
+		StartDate: body.StartDate,
+		EndDate:   body.EndDate,
+		Reason:    body.Reason,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("Location", "/api/v1/me/leave-requests/"+strconv.Itoa(leave.LeaveID))
+	c.JSON(http.StatusCreated, leave)
+}
+
+// ListLeaveRequestsHandler lists the caller's leave requests, optionally by ?status=
+func (h *SelfServiceHandler) ListLeaveRequestsHandler(c *gin.Context) {
+	leaves, err := h.ps.ListLeaveRequests(c.Request.Context(), payroll.RequestQuery{EmpID: myEmpID(c), Status: c.Query("status")})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, leaves)
+}
+
+// myLeaveRequest fetches one of the caller's leave requests, responding 404 for anyone else's
+func (h *SelfServiceHandler) myLeaveRequest(c *gin.Context) (payroll.LeaveRequest, bool) {
+	leaveID, ok := parseIDParam(c, "leave_id", "Invalid leave ID")
+	if !ok {
+		return payroll.LeaveRequest{}, false
+	}
+	leave, err := h.ps.GetLeaveRequest(c.Request.Context(), leaveID)
+	if err == nil && leave.EmpID != myEmpID(c) {
+		err = &payroll.NotFoundError{Entity: "leave request", ID: leaveID}
+	}
+	if err != nil {
+		respondError(c, err)
+		return payroll.LeaveRequest{}, false
+	}
+	return leave, true
+}
+
+// GetLeaveRequestHandler fetches one of the caller's leave requests
+func (h *SelfServiceHandler) GetLeaveRequestHandler(c *gin.Context) {
+	if leave, ok := h.myLeaveRequest(c); ok {
+		c.JSON(http.StatusOK, leave)
+	}
+}
+
+// CancelLeaveRequestHandler withdraws one of the caller's pending leave requests
+func (h *SelfServiceHandler) CancelLeaveRequestHandler(c *gin.Context) {
+	leave, ok := h.myLeaveRequest(c)
+	if !ok {
+		return
+	}
+	userID := Principal(c).UserID
+	cancelled, err := h.ps.ReviewLeaveRequest(c.Request.Context(), leave.LeaveID, payroll.Review{Status: payroll.RequestCancelled, ReviewedBy: &userID})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cancelled)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"payrollproject/internal/documents"
+	"payrollproject/internal/payroll"
+)
+
+func TestMyPayrollsAreApprovedOnly(t *testing.T) {
+	ctx := context.Background()
+	api := newTestAPI(t)
+	me := NewSelfServiceHandler(api.ps, documents.Company{Name: "Acme"})
+	api.v1.GET("/me/payrolls", me.ListPayrollsHandler)
+	api.v1.GET("/me/payrolls/:payroll_id/payslip", me.PayslipHandler)
+
+	var ids []int
+	for _, month := range []string{"2026-01", "2026-02"} {
+		added, err := api.ps.AddPayroll(ctx, payroll.Payroll{EmpID: 1, PayMonth: payroll.MustParsePeriod(month), BaseSalary: 50000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, added.PayrollID)
+	}
+	if _, err := api.ps.ApprovePayroll(ctx, ids[0], 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	api.signIn("staff", 1, "employee")
+
+	var mine []payroll.Payroll
+	api.decode(api.do(http.MethodGet, "/api/v1/me/payrolls", nil), http.StatusOK, &mine)
+	if len(mine) != 1 || mine[0].PayrollID != ids[0] {
+		t.Fatalf("my payrolls = %+v, want only the approved one", mine)
+	}
+	if w := api.do(http.MethodGet, "/api/v1/me/payrolls/"+strconv.Itoa(ids[0])+"/payslip", nil); w.Code != http.StatusOK {
+		t.Fatalf("payslip of the approved record status = %d: %s", w.Code, w.Body)
+	}
+	var problem Problem
+	api.decode(api.do(http.MethodGet, "/api/v1/me/payrolls/"+strconv.Itoa(ids[1])+"/payslip", nil), http.StatusNotFound, &problem)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListMinimumWagesHandler returns the provincial minimum wages, filtered by ?province= and, with
+// ?on=YYYY-MM-DD, narrowed to the rate each province had in force that day
+func (h *PayrollHandler) ListMinimumWagesHandler(c *gin.Context) {
+	var q payroll.MinimumWageQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	wages, err := h.ps.ListMinimumWages(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, wages)
+}
+
+// SaveMinimumWagesHandler stores a minimum wage announcement: a JSON array of provincial rates and
+// the date they take effect
+func (h *PayrollHandler) SaveMinimumWagesHandler(c *gin.Context) {
+	var wages []payroll.MinimumWage
+	if err := c.ShouldBindJSON(&wages); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	saved, err := h.ps.SaveMinimumWages(c.Request.Context(), wages)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteMinimumWageHandler deletes the minimum wage of a province from a date, such as one entered
+// in error
+func (h *PayrollHandler) DeleteMinimumWageHandler(c *gin.Context) {
+	effective, err := payroll.ParseDate(c.Param("effective_date"))
+	if err != nil {
+		respondError(c, badRequest(CodeInvalidParam, err.Error()))
+		return
+	}
+	if err := h.ps.DeleteMinimumWage(c.Request.Context(), c.Param("province"), effective); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
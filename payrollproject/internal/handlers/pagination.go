@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setPageHeaders describes a list page: the body stays a plain JSON array, the total match count goes in
+// X-Total-Count and the next page in X-Next-Cursor and a Link rel="next" built from the request URL
+func setPageHeaders(c *gin.Context, total int, nextCursor string) {
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	if nextCursor == "" {
+		return
+	}
+	c.Header("X-Next-Cursor", nextCursor)
+	next := *c.Request.URL
+	q := next.Query()
+	q.Set("cursor", nextCursor)
+	next.RawQuery = q.Encode()
+	c.Header("Link", "<"+next.RequestURI()+`>; rel="next"`)
+}
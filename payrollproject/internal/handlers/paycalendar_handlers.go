@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListPayCalendarsHandler returns the pay calendar of every pay frequency
+func (h *PayrollHandler) ListPayCalendarsHandler(c *gin.Context) {
+	calendars, err := h.ps.ListPayCalendars(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, calendars)
+}
+
+// SavePayCalendarHandler sets when staff of a pay frequency are paid
+func (h *PayrollHandler) SavePayCalendarHandler(c *gin.Context) {
+	frequency, ok := parseFrequencyParam(c)
+	if !ok {
+		return
+	}
+	var calendar payroll.PayCalendar
+	if err := c.ShouldBindJSON(&calendar); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	calendar.Frequency = frequency
+	saved, err := h.ps.SavePayCalendar(c.Request.Context(), calendar)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
+
+// PayScheduleHandler lists the pay periods of a frequency paid in a year, with their pay dates moved
+// off weekends and holidays
+func (h *PayrollHandler) PayScheduleHandler(c *gin.Context) {
+	frequency, ok := parseFrequencyParam(c)
+	if !ok {
+		return
+	}
+	year, ok := parseIDParam(c, "year", "Invalid year")
+	if !ok {
+		return
+	}
+	if year < 2000 || year > 2200 {
+		respondError(c, badRequest(CodeInvalidParam, "year must be a Gregorian year between 2000 and 2200"))
+		return
+	}
+	schedule, err := h.ps.PaySchedule(c.Request.Context(), frequency, year)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// parseFrequencyParam reads the frequency path parameter, writing a 400 response if it is not a pay frequency
+func parseFrequencyParam(c *gin.Context) (payroll.PeriodKind, bool) {
+	frequency := payroll.PeriodKind(c.Param("frequency"))
+	if !slices.Contains(payroll.PeriodKinds, frequency) {
+		respondError(c, badRequest(CodeInvalidParam, "frequency must be monthly, semi_monthly, weekly or biweekly"))
+		return "", false
+	}
+	return frequency, true
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 
+	"payrollproject/internal/auth"
 	"payrollproject/internal/payroll"
 
 	"github.com/gin-gonic/gin"
@@ -20,13 +22,16 @@ func NewPayrollHandler(ps *payroll.PayrollSystem) *PayrollHandler {
 
 // AddDepartmentHandler adds a new department
 func (h *PayrollHandler) AddDepartmentHandler(c *gin.Context) {
+	if !requireAllRows(c) {
+		return
+	}
 	var dept payroll.Department
 	if err := c.ShouldBindJSON(&dept); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, bindingError(err))
 		return
 	}
 	if err := h.ps.AddDepartment(c.Request.Context(), dept); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, dept)
@@ -36,56 +41,391 @@ func (h *PayrollHandler) AddDepartmentHandler(c *gin.Context) {
 func (h *PayrollHandler) GetAllDepartmentsHandler(c *gin.Context) {
 	departments, err := h.ps.GetAllDepartments(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
+		return
+	}
+	visible := []payroll.Department{}
+	for _, dept := range departments {
+		if rows(c).AllowsDepartment(dept.DeptID) {
+			visible = append(visible, dept)
+		}
+	}
+	c.JSON(http.StatusOK, visible)
+}
+
+// GetDepartmentHandler fetches a single department
+func (h *PayrollHandler) GetDepartmentHandler(c *gin.Context) {
+	deptID, ok := parseIDParam(c, "dept_id", "Invalid department ID")
+	if !ok || !departmentInScope(c, deptID) {
+		return
+	}
+	dept, err := h.ps.GetDepartment(c.Request.Context(), deptID)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, departments)
+	respondWithETag(c, dept.Version, dept)
+}
+
+// UpdateDepartmentHandler replaces a department (PUT)
+func (h *PayrollHandler) UpdateDepartmentHandler(c *gin.Context) {
+	deptID, ok := parseIDParam(c, "dept_id", "Invalid department ID")
+	if !ok || !departmentInScope(c, deptID) {
+		return
+	}
+	var dept payroll.Department
+	if err := c.ShouldBindJSON(&dept); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	dept.DeptID = deptID
+	h.saveDepartment(c, dept)
+}
+
+// PatchDepartmentHandler applies a JSON merge patch to a department (PATCH)
+func (h *PayrollHandler) PatchDepartmentHandler(c *gin.Context) {
+	deptID, ok := parseIDParam(c, "dept_id", "Invalid department ID")
+	if !ok || !departmentInScope(c, deptID) {
+		return
+	}
+	current, err := h.ps.GetDepartment(c.Request.Context(), deptID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	dept, ok := bindMergePatch(c, current)
+	if !ok {
+		return
+	}
+	dept.DeptID = deptID
+	dept.Version = current.Version
+	h.saveDepartment(c, dept)
+}
+
+// saveDepartment performs the optimistic update shared by PUT and PATCH
+func (h *PayrollHandler) saveDepartment(c *gin.Context, dept payroll.Department) {
+	if !requireIfMatch(c, &dept.Version) {
+		return
+	}
+	updated, err := h.ps.UpdateDepartment(c.Request.Context(), dept)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("ETag", etag(updated.Version))
+	c.JSON(http.StatusOK, updated)
 }
 
 // AddEmployeeHandler adds a new employee
 func (h *PayrollHandler) AddEmployeeHandler(c *gin.Context) {
 	var emp payroll.Employee
 	if err := c.ShouldBindJSON(&emp); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, bindingError(err))
+		return
+	}
+	if !rows(c).AllowsEmployee(emp.EmployeeID, emp.DeptID) {
+		respondError(c, auth.ErrForbidden)
 		return
 	}
 	if err := h.ps.AddEmployee(c.Request.Context(), emp); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, viewEmployee(c, emp))
+}
+
+// GetEmployeeHandler fetches a single employee
+func (h *PayrollHandler) GetEmployeeHandler(c *gin.Context) {
+	empID, ok := parseIDParam(c, "emp_id", "Invalid employee ID")
+	if !ok || !h.employeeInScope(c, empID) {
+		return
+	}
+	emp, err := h.ps.GetEmployee(c.Request.Context(), empID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondWithETag(c, emp.Version, viewEmployee(c, emp))
+}
+
+// UpdateEmployeeHandler replaces an employee (PUT)
+func (h *PayrollHandler) UpdateEmployeeHandler(c *gin.Context) {
+	empID, ok := parseIDParam(c, "emp_id", "Invalid employee ID")
+	if !ok || !h.employeeInScope(c, empID) {
+		return
+	}
+	var emp payroll.Employee
+	if err := c.ShouldBindJSON(&emp); err != nil {
+		respondError(c, bindingError(err))
 		return
 	}
-	c.JSON(http.StatusCreated, emp)
+	emp.EmployeeID = empID
+	// A record read with masked fields may be written back as is
+	if strings.Contains(emp.AccountNum+emp.NationalID, "x") {
+		current, err := h.ps.GetEmployee(c.Request.Context(), empID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		emp = emp.KeepMasked(current)
+	}
+	h.saveEmployee(c, emp)
 }
 
-// GetAllEmployeesHandler fetches all employees
+// PatchEmployeeHandler applies a JSON merge patch to an employee (PATCH)
+func (h *PayrollHandler) PatchEmployeeHandler(c *gin.Context) {
+	empID, ok := parseIDParam(c, "emp_id", "Invalid employee ID")
+	if !ok || !h.employeeInScope(c, empID) {
+		return
+	}
+	current, err := h.ps.GetEmployee(c.Request.Context(), empID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	emp, ok := bindMergePatch(c, current)
+	if !ok {
+		return
+	}
+	emp.EmployeeID = empID
+	emp.Version = current.Version
+	h.saveEmployee(c, emp.KeepMasked(current))
+}
+
+// saveEmployee performs the optimistic update shared by PUT and PATCH
+func (h *PayrollHandler) saveEmployee(c *gin.Context, emp payroll.Employee) {
+	if !requireIfMatch(c, &emp.Version) {
+		return
+	}
+	// Moving an employee out of the caller's scope is as forbidden as creating one there
+	if !rows(c).AllowsEmployee(emp.EmployeeID, emp.DeptID) {
+		respondError(c, auth.ErrForbidden)
+		return
+	}
+	updated, err := h.ps.UpdateEmployee(c.Request.Context(), emp)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("ETag", etag(updated.Version))
+	c.JSON(http.StatusOK, viewEmployee(c, updated))
+}
+
+// GetAllEmployeesHandler lists employees, filtered by dept_id, position and salary range,
+// sorted by ?sort= and, given a ?limit=, paginated by ?cursor=. ?format=csv|xlsx, or an Accept header
+// asking for either, downloads every matching employee as a file instead. Filtering or sorting by
+// salary is forbidden unless the caller may read the salaries of every employee listed.
 func (h *PayrollHandler) GetAllEmployeesHandler(c *gin.Context) {
-	employees, err := h.ps.GetAllEmployees(c.Request.Context())
+	var q payroll.EmployeeQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	if !scopeFilter(c, &q.DeptID, &q.EmpID) || !salaryQueryAllowed(c, q) {
+		return
+	}
+	if format, ok := exportFormat(c); !ok {
+		return
+	} else if format != "" {
+		h.exportEmployees(c, format, q)
+		return
+	}
+	page, err := h.ps.ListEmployees(c.Request.Context(), q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, employees)
+	setPageHeaders(c, page.Total, page.NextCursor)
+	c.JSON(http.StatusOK, viewEmployees(c, page.Items))
 }
 
 // AddPayrollHandler adds a new payroll record
 func (h *PayrollHandler) AddPayrollHandler(c *gin.Context) {
 	var payrollRecord payroll.Payroll
 	if err := c.ShouldBindJSON(&payrollRecord); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, bindingError(err))
 		return
 	}
-	if err := h.ps.AddPayroll(c.Request.Context(), payrollRecord); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !h.employeeInScope(c, payrollRecord.EmpID) {
 		return
 	}
-	c.JSON(http.StatusCreated, payrollRecord)
+	added, err := h.ps.AddPayroll(c.Request.Context(), payrollRecord)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("ETag", etag(added.Version))
+	c.JSON(http.StatusCreated, added)
+}
+
+// CalculatePayrollHandler works out an employee's pay for a period from their pay type and the days
+// or hours they worked. Nothing is stored; the payroll record in the response is posted to add it.
+func (h *PayrollHandler) CalculatePayrollHandler(c *gin.Context) {
+	var in payroll.PayInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	if !h.employeeInScope(c, in.EmpID) {
+		return
+	}
+	calc, err := h.ps.CalculatePayroll(c.Request.Context(), in)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, calc)
+}
+
+// AddPayrollBatchHandler adds all payroll records of a pay run in one transaction
+func (h *PayrollHandler) AddPayrollBatchHandler(c *gin.Context) {
+	var payrolls []payroll.Payroll
+	if err := c.ShouldBindJSON(&payrolls); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	for _, p := range payrolls {
+		if !h.employeeInScope(c, p.EmpID) {
+			return
+		}
+	}
+	if err := h.ps.AddPayrolls(c.Request.Context(), payrolls); err != nil {
+		respondError(c, err)
+		return
+	}
+	for i := range payrolls {
+		payrolls[i].Status = payroll.PayrollDraft
+	}
+	c.JSON(http.StatusCreated, payrolls)
 }
 
-// GetAllPayrollHandler retrieves all payroll records
+// GetAllPayrollHandler lists payroll records, filtered by emp_id, dept_id, pay month range, salary range and status,
+// sorted by ?sort= and, given a ?limit=, paginated by ?cursor=. ?format=csv|xlsx, or an Accept header
+// asking for either, downloads every matching record as a file instead.
 func (h *PayrollHandler) GetAllPayrollHandler(c *gin.Context) {
-	payrolls, err := h.ps.GetAllPayrolls(c.Request.Context())
+	var q payroll.PayrollQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	if !scopeFilter(c, &q.DeptID, &q.EmpID) {
+		return
+	}
+	if format, ok := exportFormat(c); !ok {
+		return
+	} else if format != "" {
+		h.exportPayrolls(c, format, q)
+		return
+	}
+	page, err := h.ps.ListPayrolls(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	setPageHeaders(c, page.Total, page.NextCursor)
+	c.JSON(http.StatusOK, page.Items)
+}
+
+// GetPayrollHandler fetches a single payroll record
+func (h *PayrollHandler) GetPayrollHandler(c *gin.Context) {
+	payrollID, ok := parseIDParam(c, "payroll_id", "Invalid payroll ID")
+	if !ok {
+		return
+	}
+	payrollRecord, ok := h.payrollInScope(c, payrollID)
+	if !ok {
+		return
+	}
+	respondWithETag(c, payrollRecord.Version, payrollRecord)
+}
+
+// UpdatePayrollHandler replaces a payroll record (PUT)
+func (h *PayrollHandler) UpdatePayrollHandler(c *gin.Context) {
+	payrollID, ok := parseIDParam(c, "payroll_id", "Invalid payroll ID")
+	if !ok {
+		return
+	}
+	if _, ok := h.payrollInScope(c, payrollID); !ok {
+		return
+	}
+	var payrollRecord payroll.Payroll
+	if err := c.ShouldBindJSON(&payrollRecord); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	payrollRecord.PayrollID = payrollID
+	h.savePayroll(c, payrollRecord)
+}
+
+// PatchPayrollHandler applies a JSON merge patch to a payroll record (PATCH)
+func (h *PayrollHandler) PatchPayrollHandler(c *gin.Context) {
+	payrollID, ok := parseIDParam(c, "payroll_id", "Invalid payroll ID")
+	if !ok {
+		return
+	}
+	current, ok := h.payrollInScope(c, payrollID)
+	if !ok {
+		return
+	}
+	payrollRecord, ok := bindMergePatch(c, current)
+	if !ok {
+		return
+	}
+	payrollRecord.PayrollID = payrollID
+	payrollRecord.Version = current.Version
+	h.savePayroll(c, payrollRecord)
+}
+
+// savePayroll performs the optimistic update shared by PUT and PATCH
+func (h *PayrollHandler) savePayroll(c *gin.Context, payrollRecord payroll.Payroll) {
+	if !requireIfMatch(c, &payrollRecord.Version) || !h.employeeInScope(c, payrollRecord.EmpID) {
+		return
+	}
+	updated, err := h.ps.UpdatePayroll(c.Request.Context(), payrollRecord)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("ETag", etag(updated.Version))
+	c.JSON(http.StatusOK, updated)
+}
+
+// CheckPayRunHandler runs the pre-approval checks over a pay period's run and reports what they
+// found; records with an error cannot be approved until corrected
+func (h *PayrollHandler) CheckPayRunHandler(c *gin.Context) {
+	if !requireAllRows(c) {
+		return
+	}
+	period, ok := parsePeriodParam(c, "pay_month")
+	if !ok {
+		return
+	}
+	report, err := h.ps.CheckPayRun(c.Request.Context(), period)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ApprovePayrollHandler approves a draft payroll record; If-Match may carry the ETag the approver reviewed
+func (h *PayrollHandler) ApprovePayrollHandler(c *gin.Context) {
+	payrollID, ok := parseIDParam(c, "payroll_id", "Invalid payroll ID")
+	if !ok {
+		return
+	}
+	if _, ok := h.payrollInScope(c, payrollID); !ok {
+		return
+	}
+	var version int
+	if !applyIfMatch(c, &version) {
+		return
+	}
+	approved, err := h.ps.ApprovePayroll(c.Request.Context(), payrollID, version, Principal(c).UserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, payrolls)
+	c.Header("ETag", etag(approved.Version))
+	c.JSON(http.StatusOK, approved)
 }
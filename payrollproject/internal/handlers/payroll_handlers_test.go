@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"payrollproject/internal/auth"
+	"payrollproject/internal/payroll"
+)
+
+func TestApprovedPayrollIsFinal(t *testing.T) {
+	api := newTestAPI(t)
+	api.v1.GET("/payrolls/:payroll_id", api.can(auth.PermPayrollRead), api.h.GetPayrollHandler)
+	api.v1.PUT("/payrolls/:payroll_id", api.can(auth.PermPayrollWrite), api.h.UpdatePayrollHandler)
+	api.v1.PATCH("/payrolls/:payroll_id", api.can(auth.PermPayrollWrite), api.h.PatchPayrollHandler)
+	api.v1.POST("/payrolls/:payroll_id/approve", api.can(auth.PermPayrollApprove), api.h.ApprovePayrollHandler)
+
+	added, err := api.ps.AddPayroll(context.Background(), payroll.Payroll{EmpID: 1, PayMonth: payroll.MustParsePeriod("2026-01"),
+		PayDate: payroll.NewDate(2026, 1, 25), BaseSalary: 50000, TaxAmount: 1716.67, TotalDeductions: 750, NetSalary: 47533.33})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/v1/payrolls/" + strconv.Itoa(added.PayrollID)
+
+	// A draft can still be corrected
+	var draft payroll.Payroll
+	api.decode(api.do(http.MethodPatch, path, map[string]any{"total_additions": 1000, "net_salary": 48533.33}), http.StatusOK, &draft)
+
+	var approved payroll.Payroll
+	api.decode(api.do(http.MethodPost, path+"/approve", nil), http.StatusOK, &approved)
+
+	for _, tc := range []struct {
+		method string
+		body   any
+	}{
+		{http.MethodPatch, map[string]any{"net_salary": 1}},
+		{http.MethodPut, approved},
+	} {
+		var problem Problem
+		api.decode(api.do(tc.method, path, tc.body), http.StatusConflict, &problem)
+		if problem.Code != CodeLocked {
+			t.Errorf("%s of an approved payroll code = %q, want %q", tc.method, problem.Code, CodeLocked)
+		}
+	}
+	var stored payroll.Payroll
+	api.decode(api.do(http.MethodGet, path, nil), http.StatusOK, &stored)
+	if stored.Status != payroll.PayrollApproved || stored.NetSalary != draft.NetSalary || stored.Version != approved.Version {
+		t.Fatalf("approved payroll after rejected writes = %+v", stored)
+	}
+}
+
+func TestListPayrollsPages(t *testing.T) {
+	api := newTestAPI(t)
+	api.v1.GET("/payrolls", api.can(auth.PermPayrollRead), api.h.GetAllPayrollHandler)
+	for _, month := range []string{"2026-01", "2026-02", "2026-03"} {
+		if _, err := api.ps.AddPayroll(context.Background(), payroll.Payroll{EmpID: 1, PayMonth: payroll.MustParsePeriod(month), BaseSalary: 50000}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without a limit the whole list comes back
+	var all []payroll.Payroll
+	w := api.do(http.MethodGet, "/api/v1/payrolls", nil)
+	api.decode(w, http.StatusOK, &all)
+	if len(all) != 3 || w.Header().Get("X-Total-Count") != "3" || w.Header().Get("X-Next-Cursor") != "" {
+		t.Fatalf("unpaged list: %d items, headers %v", len(all), w.Header())
+	}
+
+	var paged []payroll.Payroll
+	path := "/api/v1/payrolls?sort=-pay_month&limit=2"
+	for path != "" {
+		var page []payroll.Payroll
+		w := api.do(http.MethodGet, path, nil)
+		api.decode(w, http.StatusOK, &page)
+		paged = append(paged, page...)
+		path = ""
+		if cursor := w.Header().Get("X-Next-Cursor"); cursor != "" {
+			path = "/api/v1/payrolls?sort=-pay_month&limit=2&cursor=" + cursor
+		}
+	}
+	if len(paged) != 3 || paged[0].PayMonth.String() != "2026-03" || paged[2].PayMonth.String() != "2026-01" {
+		t.Fatalf("pages = %+v", paged)
+	}
+
+	// A cursor only continues the sort it was issued for
+	w = api.do(http.MethodGet, "/api/v1/payrolls?sort=-pay_month&limit=1", nil)
+	var problem Problem
+	api.decode(api.do(http.MethodGet, "/api/v1/payrolls?sort=net_salary&limit=1&cursor="+w.Header().Get("X-Next-Cursor"), nil),
+		http.StatusBadRequest, &problem)
+	if problem.Code != CodeInvalidQuery {
+		t.Fatalf("code = %q, want %q", problem.Code, CodeInvalidQuery)
+	}
+}
+
+func TestSalaryFiltersNeedSalaryRead(t *testing.T) {
+	api := newTestAPI(t)
+	api.v1.GET("/employees", api.can(auth.PermEmployeeRead), api.h.GetAllEmployeesHandler)
+	if err := api.ps.AddEmployee(context.Background(), payroll.Employee{EmployeeID: 2, EmpName: "B", PhoneNumber: "0812345679",
+		DeptID: 1, BaseSalary: 30000}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A department manager sees their staff but not what they earn, so may not search by it
+	api.signIn("manager", 1, "department_manager")
+	var staff []map[string]any
+	api.decode(api.do(http.MethodGet, "/api/v1/employees", nil), http.StatusOK, &staff)
+	if len(staff) != 2 || staff[0]["base_salary"] != nil {
+		t.Fatalf("manager's list = %v", staff)
+	}
+	for _, query := range []string{"min_salary=40000", "max_salary=40000", "sort=base_salary", "sort=-base_salary"} {
+		var problem Problem
+		api.decode(api.do(http.MethodGet, "/api/v1/employees?"+query, nil), http.StatusForbidden, &problem)
+		if problem.Code != CodeForbidden {
+			t.Errorf("?%s code = %q, want %q", query, problem.Code, CodeForbidden)
+		}
+	}
+
+	// An employee reads their own salary, so sorting their own one-row list gives nothing away
+	api.signIn("staff", 2, "employee")
+	api.decode(api.do(http.MethodGet, "/api/v1/employees?sort=base_salary&min_salary=1", nil), http.StatusOK, &staff)
+	if len(staff) != 1 || staff[0]["base_salary"] != 30000.0 {
+		t.Fatalf("employee's list = %v", staff)
+	}
+}
+
+func TestUpdateNeedsAPrecondition(t *testing.T) {
+	api := newTestAPI(t)
+	api.v1.GET("/departments/:dept_id", api.can(auth.PermDepartmentRead), api.h.GetDepartmentHandler)
+	api.v1.PUT("/departments/:dept_id", api.can(auth.PermDepartmentWrite), api.h.UpdateDepartmentHandler)
+
+	// Without a version the update would overwrite whatever was saved since the client read it
+	var problem Problem
+	api.decode(api.do(http.MethodPut, "/api/v1/departments/1", map[string]any{"dept_name": "Operations"}),
+		http.StatusPreconditionRequired, &problem)
+	if problem.Code != CodePrecondition {
+		t.Errorf("code = %q, want %q", problem.Code, CodePrecondition)
+	}
+	var dept payroll.Department
+	api.decode(api.do(http.MethodGet, "/api/v1/departments/1", nil), http.StatusOK, &dept)
+	if dept.DeptName != "Ops" {
+		t.Fatalf("department after a rejected update = %+v", dept)
+	}
+
+	dept.DeptName = "Operations"
+	api.decode(api.do(http.MethodPut, "/api/v1/departments/1", dept), http.StatusOK, &dept)
+	api.decode(api.do(http.MethodPut, "/api/v1/departments/1", map[string]any{"dept_name": "Ops"}, "If-Match", "*"), http.StatusOK, &dept)
+	if dept.DeptName != "Ops" {
+		t.Fatalf("department after an unconditional update = %+v", dept)
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportEmployeeHandler downloads everything held about an employee as a JSON package, for a PDPA
+// data subject access request made through HR
+func (h *PayrollHandler) ExportEmployeeHandler(c *gin.Context) {
+	empID, ok := parseIDParam(c, "emp_id", "Invalid employee ID")
+	if !ok || !h.employeeInScope(c, empID) {
+		return
+	}
+	sendExport(c, h.ps, empID)
+}
+
+// ExportHandler downloads everything held about the caller as a JSON package
+func (h *SelfServiceHandler) ExportHandler(c *gin.Context) {
+	sendExport(c, h.ps, myEmpID(c))
+}
+
+func sendExport(c *gin.Context, ps *payroll.PayrollSystem, empID int) {
+	x, err := ps.ExportSubject(c.Request.Context(), empID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	body, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="pdpa-export-%d.json"`, empID))
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// RetentionHandler runs the retention policy that anonymises former employees
+type RetentionHandler struct {
+	ps     *payroll.PayrollSystem
+	policy payroll.RetentionPolicy
+}
+
+// NewRetentionHandler creates a RetentionHandler keeping former employees' personal data for years
+func NewRetentionHandler(ps *payroll.PayrollSystem, years int) *RetentionHandler {
+	return &RetentionHandler{ps: ps, policy: payroll.RetentionPolicy{Years: years}}
+}
+
+// ReportHandler is a dry run: it lists the former employees a retention run would anonymise today
+func (h *RetentionHandler) ReportHandler(c *gin.Context) {
+	h.run(c, true)
+}
+
+// ApplyHandler anonymises every former employee whose retention period has passed
+func (h *RetentionHandler) ApplyHandler(c *gin.Context) {
+	h.run(c, false)
+}
+
+func (h *RetentionHandler) run(c *gin.Context, dryRun bool) {
+	if !requireAllRows(c) {
+		return
+	}
+	report, err := h.ps.ApplyRetention(c.Request.Context(), h.policy, dryRun)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
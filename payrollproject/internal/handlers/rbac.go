@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"payrollproject/internal/auth"
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys set by RequireAuth and Require
+const (
+	principalKey  = "auth.principal"
+	permissionKey = "auth.permission"
+)
+
+// Require rejects callers whose roles do not grant perm. Handlers behind it apply the permission's
+// row scope through rows, so a manager only reaches their own department and an employee only themselves.
+func (h *AuthHandler) Require(perm auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Principal(c).Can(perm) {
+			respondError(c, auth.ErrForbidden)
+			return
+		}
+		c.Set(permissionKey, perm)
+		c.Next()
+	}
+}
+
+// Principal returns the authenticated caller; it is only valid behind RequireAuth
+func Principal(c *gin.Context) *auth.Principal {
+	p, _ := c.MustGet(principalKey).(*auth.Principal)
+	return p
+}
+
+// rows returns the rows the route's permission covers for the caller
+func rows(c *gin.Context) auth.RowFilter {
+	return Principal(c).Rows(c.MustGet(permissionKey).(auth.Permission))
+}
+
+// hideRow answers 404 for a row outside the caller's scope, so its existence is not revealed
+func hideRow(c *gin.Context, entity string, id int) {
+	respondError(c, &payroll.NotFoundError{Entity: entity, ID: id})
+}
+
+// allowsEmployee reports whether the route's row scope covers an employee, looking up their
+// department when the scope is a department
+func (h *PayrollHandler) allowsEmployee(c *gin.Context, empID int) (bool, error) {
+	f := rows(c)
+	if f.All || f.EmpID != 0 || f.DeptID == 0 {
+		return f.AllowsEmployee(empID, 0), nil
+	}
+	emp, err := h.ps.GetEmployee(c.Request.Context(), empID)
+	if errors.Is(err, payroll.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return f.AllowsEmployee(emp.EmployeeID, emp.DeptID), nil
+}
+
+// employeeInScope checks that an employee falls in the route's row scope, responding 404 if not
+func (h *PayrollHandler) employeeInScope(c *gin.Context, empID int) bool {
+	ok, err := h.allowsEmployee(c, empID)
+	if err != nil {
+		respondError(c, err)
+		return false
+	}
+	if !ok {
+		hideRow(c, "employee", empID)
+	}
+	return ok
+}
+
+// payrollInScope fetches a payroll record and checks that its employee falls in the route's row scope
+func (h *PayrollHandler) payrollInScope(c *gin.Context, payrollID int) (payroll.Payroll, bool) {
+	p, err := h.ps.GetPayroll(c.Request.Context(), payrollID)
+	if err != nil {
+		respondError(c, err)
+		return payroll.Payroll{}, false
+	}
+	ok, err := h.allowsEmployee(c, p.EmpID)
+	if err != nil {
+		respondError(c, err)
+		return payroll.Payroll{}, false
+	}
+	if !ok {
+		hideRow(c, "payroll", payrollID)
+		return payroll.Payroll{}, false
+	}
+	return p, true
+}
+
+// departmentInScope checks that a department falls in the route's row scope, responding 404 if not
+func departmentInScope(c *gin.Context, deptID int) bool {
+	if !rows(c).AllowsDepartment(deptID) {
+		hideRow(c, "department", deptID)
+		return false
+	}
+	return true
+}
+
+// requireAllRows rejects creating records unless the route's permission covers every row
+func requireAllRows(c *gin.Context) bool {
+	if !rows(c).All {
+		respondError(c, auth.ErrForbidden)
+		return false
+	}
+	return true
+}
+
+// employeeView is an employee as returned to a caller; base_salary and wage_rate are left out unless
+// the caller holds salary:read over that employee, and account_num and national_id are masked
+// unless they hold pii:reveal
+type employeeView struct {
+	payroll.Employee
+	BaseSalary *float64 `json:"base_salary,omitempty"`
+	WageRate   *float64 `json:"wage_rate,omitempty"`
+}
+
+// viewEmployee hides what the caller may not see of an employee
+func viewEmployee(c *gin.Context, emp payroll.Employee) employeeView {
+	if !canReveal(c, emp.EmployeeID, emp.DeptID) {
+		emp = emp.Masked()
+	}
+	v := employeeView{Employee: emp}
+	if Principal(c).Rows(auth.PermSalaryRead).AllowsEmployee(emp.EmployeeID, emp.DeptID) {
+		v.BaseSalary = &v.Employee.BaseSalary
+		v.WageRate = &v.Employee.WageRate
+	}
+	return v
+}
+
+// canReveal reports whether the caller may see an employee's account number and national ID
+func canReveal(c *gin.Context, empID, deptID int) bool {
+	return Principal(c).Rows(auth.PermPIIReveal).AllowsEmployee(empID, deptID)
+}
+
+// viewProfileChange masks the account number in a profile change the caller may not see unmasked.
+// Changes carry no department, so only all-rows and self scopes reveal it.
+func viewProfileChange(c *gin.Context, change payroll.ProfileChange) payroll.ProfileChange {
+	if !canReveal(c, change.EmpID, 0) {
+		return change.Masked()
+	}
+	return change
+}
+
+// viewProfileChanges applies viewProfileChange to a list
+func viewProfileChanges(c *gin.Context, changes []payroll.ProfileChange) []payroll.ProfileChange {
+	views := make([]payroll.ProfileChange, len(changes))
+	for i, change := range changes {
+		views[i] = viewProfileChange(c, change)
+	}
+	return views
+}
+
+// viewEmployees applies viewEmployee to a list
+func viewEmployees(c *gin.Context, emps []payroll.Employee) []employeeView {
+	views := make([]employeeView, len(emps))
+	for i, emp := range emps {
+		views[i] = viewEmployee(c, emp)
+	}
+	return views
+}
+
+// scopeFilter narrows a list query's dept_id and emp_id filters to the caller's rows. A filter the
+// caller set outside their scope is forbidden rather than silently replaced.
+func scopeFilter(c *gin.Context, deptID, empID *int) bool {
+	f := rows(c)
+	if f.All {
+		return true
+	}
+	if f.DeptID == 0 || (*deptID != 0 && *deptID != f.DeptID) || (f.EmpID != 0 && *empID != 0 && *empID != f.EmpID) {
+		respondError(c, auth.ErrForbidden)
+		return false
+	}
+	*deptID = f.DeptID
+	if f.EmpID != 0 {
+		*empID = f.EmpID
+	}
+	return true
+}
+
+// salaryQueryAllowed rejects filtering or sorting employees by salary unless salary:read covers every
+// row being listed, since a narrowed range or the order would give away the salaries viewEmployee hides
+func salaryQueryAllowed(c *gin.Context, q payroll.EmployeeQuery) bool {
+	if q.MinSalary == nil && q.MaxSalary == nil && strings.TrimPrefix(q.Sort, "-") != "base_salary" {
+		return true
+	}
+	f := Principal(c).Rows(auth.PermSalaryRead)
+	if f.All || (f.EmpID != 0 && q.EmpID == f.EmpID) || (f.EmpID == 0 && f.DeptID != 0 && q.DeptID == f.DeptID) {
+		return true
+	}
+	respondError(c, auth.ErrForbidden)
+	return false
+}
+
+// meResponse describes the caller and what they may do
+type meResponse struct {
+	UserID      int      `json:"user_id"`
+	Username    string   `json:"username"`
+	EmpID       int      `json:"emp_id,omitempty"`
+	DeptID      int      `json:"dept_id,omitempty"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// WhoAmIHandler returns the caller's roles and permissions so clients can hide what they cannot use
+func (h *AuthHandler) WhoAmIHandler(c *gin.Context) {
+	p := Principal(c)
+	c.JSON(http.StatusOK, meResponse{
+		UserID:      p.UserID,
+		Username:    p.Username,
+		EmpID:       p.EmpID,
+		DeptID:      p.DeptID,
+		Roles:       p.Roles,
+		Permissions: p.Permissions(),
+	})
+}
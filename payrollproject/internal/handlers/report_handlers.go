@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"payrollproject/internal/reporting"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReportHandler serves the department cost and headcount reports. Every report takes from and to
+// pay months (YYYY-MM, defaulting to the last twelve months) and an optional dept_id.
+type ReportHandler struct {
+	r *reporting.Reporter
+}
+
+// NewReportHandler creates a ReportHandler
+func NewReportHandler(r *reporting.Reporter) *ReportHandler {
+	return &ReportHandler{r: r}
+}
+
+// LabourCostHandler returns each department's gross pay, employer Social Security and provident
+// fund contributions per month
+func (h *ReportHandler) LabourCostHandler(c *gin.Context) {
+	report(c, h.r.LabourCost)
+}
+
+// HeadcountHandler returns each department's paid headcount per month and its change
+func (h *ReportHandler) HeadcountHandler(c *gin.Context) {
+	report(c, h.r.Headcount)
+}
+
+// SalaryByPositionHandler returns the average, lowest and highest base salary of each position
+func (h *ReportHandler) SalaryByPositionHandler(c *gin.Context) {
+	report(c, h.r.SalaryByPosition)
+}
+
+// VarianceHandler returns the month-over-month change in each department's labour cost
+func (h *ReportHandler) VarianceHandler(c *gin.Context) {
+	report(c, h.r.Variance)
+}
+
+// report binds the report query and writes the rows; reports cover every department, so they are
+// only open to callers whose grant does
+func report[T any](c *gin.Context, build func(context.Context, reporting.Query) ([]T, error)) {
+	if !requireAllRows(c) {
+		return
+	}
+	var q reporting.Query
+	if err := c.ShouldBindQuery(&q); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	rows, err := build(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+
+	"payrollproject/internal/auth"
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reviewRequestBody is the optional body of an approve or reject call
+type reviewRequestBody struct {
+	Note string `json:"note"`
+}
+
+// bindReview builds the caller's decision on a request. Reviewers may not decide their own requests.
+func bindReview(c *gin.Context, status string, empID int) (payroll.Review, bool) {
+	p := Principal(c)
+	if p.EmpID != 0 && p.EmpID == empID {
+		respondError(c, auth.ErrForbidden)
+		return payroll.Review{}, false
+	}
+	var body reviewRequestBody
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			respondError(c, bindingError(err))
+			return payroll.Review{}, false
+		}
+	}
+	return payroll.Review{Status: status, ReviewedBy: &p.UserID, ReviewNote: body.Note}, true
+}
+
+// ListProfileChangesHandler lists the profile change requests employees filed, filtered by emp_id,
+// dept_id and status
+func (h *PayrollHandler) ListProfileChangesHandler(c *gin.Context) {
+	var q payroll.RequestQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	if !scopeFilter(c, &q.DeptID, &q.EmpID) {
+		return
+	}
+	changes, err := h.ps.ListProfileChanges(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, viewProfileChanges(c, changes))
+}
+
+// ApproveProfileChangeHandler approves a pending profile change and applies it to the employee record
+func (h *PayrollHandler) ApproveProfileChangeHandler(c *gin.Context) {
+	h.reviewProfileChange(c, payroll.RequestApproved)
+}
+
+// RejectProfileChangeHandler rejects a pending profile change
+func (h *PayrollHandler) RejectProfileChangeHandler(c *gin.Context) {
+	h.reviewProfileChange(c, payroll.RequestRejected)
+}
+
+func (h *PayrollHandler) reviewProfileChange(c *gin.Context, status string) {
+	changeID, ok := parseIDParam(c, "change_id", "Invalid change ID")
+	if !ok {
+		return
+	}
+	change, err := h.ps.GetProfileChange(c.Request.Context(), changeID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if ok, err := h.allowsEmployee(c, change.EmpID); err != nil || !ok {
+		if err == nil {
+			err = &payroll.NotFoundError{Entity: "profile change", ID: changeID}
+		}
+		respondError(c, err)
+		return
+	}
+	review, ok := bindReview(c, status, change.EmpID)
+	if !ok {
+		return
+	}
+	reviewed, err := h.ps.ReviewProfileChange(c.Request.Context(), changeID, review)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, viewProfileChange(c, reviewed))
+}
+
+// ListLeaveRequestsHandler lists leave requests, filtered by emp_id, dept_id and status
+func (h *PayrollHandler) ListLeaveRequestsHandler(c *gin.Context) {
+	var q payroll.RequestQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	if !scopeFilter(c, &q.DeptID, &q.EmpID) {
+		return
+	}
+	leaves, err := h.ps.ListLeaveRequests(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, leaves)
+}
+
+// ApproveLeaveRequestHandler approves a pending leave request
+func (h *PayrollHandler) ApproveLeaveRequestHandler(c *gin.Context) {
+	h.reviewLeaveRequest(c, payroll.RequestApproved)
+}
+
+// RejectLeaveRequestHandler rejects a pending leave request
+func (h *PayrollHandler) RejectLeaveRequestHandler(c *gin.Context) {
+	h.reviewLeaveRequest(c, payroll.RequestRejected)
+}
+
+func (h *PayrollHandler) reviewLeaveRequest(c *gin.Context, status string) {
+	leaveID, ok := parseIDParam(c, "leave_id", "Invalid leave ID")
+	if !ok {
+		return
+	}
+	leave, err := h.ps.GetLeaveRequest(c.Request.Context(), leaveID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if ok, err := h.allowsEmployee(c, leave.EmpID); err != nil || !ok {
+		if err == nil {
+			err = &payroll.NotFoundError{Entity: "leave request", ID: leaveID}
+		}
+		respondError(c, err)
+		return
+	}
+	review, ok := bindReview(c, status, leave.EmpID)
+	if !ok {
+		return
+	}
+	reviewed, err := h.ps.ReviewLeaveRequest(c.Request.Context(), leaveID, review)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reviewed)
+}
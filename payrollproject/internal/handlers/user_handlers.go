@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+
+	"payrollproject/internal/auth"
+	"payrollproject/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+type createUserRequest struct {
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	EmpID    *int     `json:"emp_id"`
+	Roles    []string `json:"roles"`
+}
+
+type updateUserRequest struct {
+	EmpID *int     `json:"emp_id"`
+	Roles []string `json:"roles"`
+}
+
+// ListUsersHandler lists every user with their roles
+func (h *AuthHandler) ListUsersHandler(c *gin.Context) {
+	users, err := h.ps.ListUsers(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if users == nil {
+		users = []payroll.User{}
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// AddUserHandler creates a user, optionally linked to an employee, with a set of roles
+func (h *AuthHandler) AddUserHandler(c *gin.Context) {
+	var req createUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	user, err := h.auth.CreateUser(c.Request.Context(), payroll.User{Username: req.Username, EmpID: req.EmpID, Roles: req.Roles}, req.Password)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, user)
+}
+
+// UpdateUserHandler replaces a user's employee link and roles
+func (h *AuthHandler) UpdateUserHandler(c *gin.Context) {
+	userID, ok := parseIDParam(c, "user_id", "Invalid user ID")
+	if !ok {
+		return
+	}
+	var req updateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	current, err := h.ps.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	current.EmpID, current.Roles = req.EmpID, req.Roles
+	user, err := h.ps.UpdateUser(c.Request.Context(), current)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// ListRolesHandler lists every role with its scope and permissions
+func (h *AuthHandler) ListRolesHandler(c *gin.Context) {
+	roles, err := h.ps.ListRoles(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if roles == nil {
+		roles = []payroll.Role{}
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetRoleHandler fetches a role
+func (h *AuthHandler) GetRoleHandler(c *gin.Context) {
+	role, err := h.ps.GetRole(c.Request.Context(), c.Param("role_name"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+// SaveRoleHandler creates or replaces a role (PUT)
+func (h *AuthHandler) SaveRoleHandler(c *gin.Context) {
+	var role payroll.Role
+	if err := c.ShouldBindJSON(&role); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	role.RoleName = c.Param("role_name")
+	saved, err := h.auth.SaveRole(c.Request.Context(), role)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteRoleHandler deletes a role
+func (h *AuthHandler) DeleteRoleHandler(c *gin.Context) {
+	if err := h.ps.DeleteRole(c.Request.Context(), c.Param("role_name")); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListPermissionsHandler lists the permissions roles can grant
+func (h *AuthHandler) ListPermissionsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, auth.Permissions)
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"payrollproject/internal/ical"
+	"payrollproject/internal/payroll"
+	"payrollproject/internal/sheets"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListHolidaysHandler returns the holidays of ?year=, or from ?from= to ?to=, optionally only those
+// a ?site_id= observes or of one ?kind=
+func (h *PayrollHandler) ListHolidaysHandler(c *gin.Context) {
+	var q payroll.HolidayQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	holidays, err := h.ps.ListHolidays(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, holidays)
+}
+
+// AddHolidayHandler stores a holiday, replacing the one its site already has that day
+func (h *PayrollHandler) AddHolidayHandler(c *gin.Context) {
+	var holiday payroll.Holiday
+	if err := c.ShouldBindJSON(&holiday); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	saved, err := h.ps.AddHoliday(c.Request.Context(), holiday)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, saved)
+}
+
+// DeleteHolidayHandler deletes a holiday
+func (h *PayrollHandler) DeleteHolidayHandler(c *gin.Context) {
+	id, ok := parseIDParam(c, "holiday_id", "Invalid holiday ID")
+	if !ok {
+		return
+	}
+	if err := h.ps.DeleteHoliday(c.Request.Context(), id); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ImportHolidaysHandler loads a year's holidays from an uploaded iCalendar (.ics), CSV or XLSX
+// file, replacing those of the same kind and site in the years the file covers. The multipart form
+// carries the file in "file", the "kind" of holiday (public unless given), a "site_id" for company
+// holidays of one site, and "dry_run=true" to report without writing. A CSV or XLSX file has a
+// "date" and a "name" column.
+func (h *PayrollHandler) ImportHolidaysHandler(c *gin.Context) {
+	fh, ok := uploadedFile(c)
+	if !ok {
+		return
+	}
+	dryRun, ok := dryRunParam(c)
+	if !ok {
+		return
+	}
+	kind := c.DefaultPostForm("kind", payroll.HolidayPublic)
+
+	f, err := fh.Open()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer f.Close()
+	var holidays []payroll.Holiday
+	if strings.EqualFold(filepath.Ext(fh.Filename), ".ics") || strings.HasPrefix(fh.Header.Get("Content-Type"), "text/calendar") {
+		events, err := ical.Read(f)
+		if err != nil {
+			respondError(c, &payroll.ValidationError{Fields: []payroll.FieldError{{Field: "file", Message: err.Error()}}})
+			return
+		}
+		holidays = payroll.HolidaysFromEvents(events)
+	} else {
+		format, err := sheets.FormatOf(fh.Filename, fh.Header.Get("Content-Type"))
+		if err != nil {
+			respondError(c, &payroll.ValidationError{Fields: []payroll.FieldError{{Field: "file",
+				Message: "unsupported file format: upload an .ics, .csv or .xlsx file"}}})
+			return
+		}
+		table, err := sheets.Read(f, format)
+		if err != nil {
+			respondError(c, &payroll.ValidationError{Fields: []payroll.FieldError{{Field: "file", Message: err.Error()}}})
+			return
+		}
+		if holidays, err = payroll.HolidaysFromTable(table); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	report, err := h.ps.ImportHolidays(c.Request.Context(), kind, c.PostForm("site_id"), holidays, dryRun)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ListWorkSitesHandler returns the work sites and their working weeks
+func (h *PayrollHandler) ListWorkSitesHandler(c *gin.Context) {
+	sites, err := h.ps.ListWorkSites(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sites)
+}
+
+// SaveWorkSiteHandler creates or replaces a work site
+func (h *PayrollHandler) SaveWorkSiteHandler(c *gin.Context) {
+	var site payroll.WorkSite
+	if err := c.ShouldBindJSON(&site); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	site.SiteID = c.Param("site_id")
+	saved, err := h.ps.SaveWorkSite(c.Request.Context(), site)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteWorkSiteHandler deletes a work site that no employee works at, with its own holidays
+func (h *PayrollHandler) DeleteWorkSiteHandler(c *gin.Context) {
+	if err := h.ps.DeleteWorkSite(c.Request.Context(), c.Param("site_id")); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// workingDaysQuery selects the days to count: a pay ?period= or a range ?from= to ?to=
+type workingDaysQuery struct {
+	SiteID string         `form:"site_id"`
+	Period payroll.Period `form:"period"`
+	From   payroll.Date   `form:"from"`
+	To     payroll.Date   `form:"to"`
+}
+
+// WorkingDaysHandler counts and lists the working days of a site, or of the default Monday to
+// Friday week without ?site_id=, in a pay period or range of days
+func (h *PayrollHandler) WorkingDaysHandler(c *gin.Context) {
+	var q workingDaysQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	if !q.Period.IsZero() {
+		if !q.From.IsZero() || !q.To.IsZero() {
+			respondError(c, badRequest(CodeInvalidParam, "give a period or from and to, not both"))
+			return
+		}
+		q.From, q.To = q.Period.Start(), q.Period.End()
+	}
+	wd, err := h.ps.WorkingDaysBetween(c.Request.Context(), q.SiteID, q.From, q.To)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, wd)
+}
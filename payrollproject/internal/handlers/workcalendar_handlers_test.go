@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"payrollproject/internal/auth"
+	"payrollproject/internal/payroll"
+)
+
+func TestWorkingDaysSkipPublicHolidays(t *testing.T) {
+	api := newTestAPI(t)
+	api.v1.GET("/working-days", api.can(auth.PermPayrollRead), api.h.WorkingDaysHandler)
+	if err := api.ps.EnsurePublicHolidays(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var wd payroll.WorkingDays
+	api.decode(api.do(http.MethodGet, "/api/v1/working-days?period=2026-01", nil), http.StatusOK, &wd)
+	newYear := payroll.NewDate(2026, 1, 1)
+	for _, d := range wd.Dates {
+		if d == newYear {
+			t.Fatalf("New Year's Day is counted as a working day: %+v", wd)
+		}
+	}
+	if wd.WorkingDays != 21 {
+		t.Errorf("working days of January 2026 = %d, want 21", wd.WorkingDays)
+	}
+}
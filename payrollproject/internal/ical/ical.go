@@ -0,0 +1,145 @@
+// Package ical reads the events of an iCalendar (.ics) file, as published by holiday calendars and
+// exported by calendar applications. Only what a list of dated events needs is read: the start and
+// end day and the summary of each VEVENT. Recurrence rules are not expanded.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Event is one VEVENT of a calendar. Start and End are days at midnight UTC; End is exclusive, as
+// in iCalendar, so a one-day event ends the day after it starts.
+type Event struct {
+	Start   time.Time
+	End     time.Time
+	Summary string
+}
+
+// Days returns the days the event covers
+func (e Event) Days() []time.Time {
+	var days []time.Time
+	for d := e.Start; d.Before(e.End); d = d.AddDate(0, 0, 1) {
+		days = append(days, d)
+	}
+	return days
+}
+
+// ErrNotCalendar is returned for a file that does not begin with BEGIN:VCALENDAR
+var ErrNotCalendar = errors.New("not an iCalendar file: it must begin with BEGIN:VCALENDAR")
+
+// Read returns the events of a calendar in file order. Cancelled events are left out.
+func Read(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, ErrNotCalendar
+	}
+
+	var events []Event
+	var ev *Event
+	cancelled := false
+	for n, line := range lines {
+		name, params, value, ok := parseLine(line)
+		if !ok {
+			return nil, fmt.Errorf("line %d: %q is not a calendar property", n+1, line)
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			ev, cancelled = &Event{}, false
+		case name == "END" && strings.EqualFold(value, "VEVENT") && ev != nil:
+			if ev.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", n+1, ev.Summary)
+			}
+			if !ev.End.After(ev.Start) {
+				ev.End = ev.Start.AddDate(0, 0, 1)
+			}
+			if !cancelled {
+				events = append(events, *ev)
+			}
+			ev = nil
+		case ev == nil:
+			// Properties of the calendar itself, or of components such as VTIMEZONE
+		case name == "DTSTART" || name == "DTEND":
+			day, err := parseDay(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", n+1, name, err)
+			}
+			if name == "DTSTART" {
+				ev.Start = day
+			} else {
+				ev.End = day
+			}
+		case name == "SUMMARY":
+			ev.Summary = unescape(value)
+		case name == "STATUS":
+			cancelled = strings.EqualFold(value, "CANCELLED")
+		}
+	}
+	return events, nil
+}
+
+// unfold reads the content lines of a calendar, joining lines folded onto a leading space or tab
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	var lines []string
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, sc.Err()
+}
+
+// parseLine splits "NAME;PARAM=x:value" into the upper-case name, its parameters and the value
+func parseLine(line string) (name string, params map[string]string, value string, ok bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", nil, "", false
+	}
+	parts := strings.Split(head, ";")
+	params = map[string]string{}
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, value, true
+}
+
+// parseDay reads the day of a DATE value (20260101) or a DATE-TIME one (20260101T090000Z), taken
+// in its own time zone
+func parseDay(value string, params map[string]string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("%q is not a date", value)
+	}
+	if params["VALUE"] != "DATE" && len(value) > 8 {
+		if _, err := time.Parse("20060102T150405", strings.TrimSuffix(value, "Z")); err != nil {
+			return time.Time{}, fmt.Errorf("%q is not a date-time", value)
+		}
+	}
+	day, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date", value)
+	}
+	return day, nil
+}
+
+// unescape undoes the escaping of a TEXT value
+func unescape(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
@@ -0,0 +1,42 @@
+package ical
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRead(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Example//Holidays//TH\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20260101\r\nDTEND;VALUE=DATE:20260102\r\nSUMMARY:วันขึ้นปีใหม่\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20260413\r\nDTEND;VALUE=DATE:20260416\r\nSUMMARY:วันสงกรานต์\\, หยุดต่อเนื่อง\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART:20260504T000000Z\r\nSUMMARY:Coronation\r\n  Day\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20260505\r\nSTATUS:CANCELLED\r\nSUMMARY:Cancelled\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	events, err := Read(strings.NewReader(ics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("events = %+v", events)
+	}
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC) }
+	if e := events[0]; e.Summary != "วันขึ้นปีใหม่" || !e.Start.Equal(day(1, 1)) || len(e.Days()) != 1 {
+		t.Errorf("new year = %+v", e)
+	}
+	if e := events[1]; e.Summary != "วันสงกรานต์, หยุดต่อเนื่อง" || len(e.Days()) != 3 || !e.Days()[2].Equal(day(4, 15)) {
+		t.Errorf("songkran = %+v", e)
+	}
+	// A folded summary is joined, and an event without DTEND lasts its start day
+	if e := events[2]; e.Summary != "Coronation Day" || !e.Start.Equal(day(5, 4)) || !e.End.Equal(day(5, 5)) {
+		t.Errorf("coronation day = %+v", e)
+	}
+
+	if _, err := Read(strings.NewReader("date,name\n2026-01-01,New Year\n")); !errors.Is(err, ErrNotCalendar) {
+		t.Errorf("CSV error = %v, want ErrNotCalendar", err)
+	}
+	if _, err := Read(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:2026\nEND:VEVENT\nEND:VCALENDAR\n")); err == nil {
+		t.Error("a short DTSTART was accepted")
+	}
+}
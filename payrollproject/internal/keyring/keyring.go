@@ -0,0 +1,229 @@
+// Package keyring encrypts sensitive fields with envelope encryption: every value is sealed with
+// its own random data key, and the data key is sealed with a master key. The stored form names
+// the master key, so master keys can be rotated while values sealed under older keys stay
+// readable for as long as those keys remain on the ring.
+package keyring
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// prefix marks an encrypted value; anything else is treated as legacy plaintext
+const prefix = "enc:v1:"
+
+// KeySize is the length of a master key in bytes (AES-256)
+const KeySize = 32
+
+var (
+	// ErrNoKeys is returned by Load when neither a key file nor keys are configured
+	ErrNoKeys = errors.New("no encryption keys configured")
+	// ErrUnknownKey is returned when a value was sealed under a master key that is not on the ring
+	ErrUnknownKey = errors.New("value was encrypted with an unknown key")
+	// ErrCorrupt is returned when a stored value is malformed or fails authentication
+	ErrCorrupt = errors.New("encrypted value is corrupt")
+)
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,32}$`)
+
+// Keyring holds the master keys by ID; new values are sealed under the primary key
+type Keyring struct {
+	keys    map[string]cipher.AEAD
+	primary string
+}
+
+// New builds a keyring from raw master keys. primary must be one of them.
+func New(keys map[string][]byte, primary string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	kr := &Keyring{keys: make(map[string]cipher.AEAD, len(keys)), primary: primary}
+	for id, key := range keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid key ID %q: use up to 32 letters, digits, '.', '_' or '-'", id)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key %q is %d bytes, want %d", id, len(key), KeySize)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		kr.keys[id] = aead
+	}
+	if _, ok := kr.keys[primary]; !ok {
+		return nil, fmt.Errorf("primary key %q is not among the configured keys", primary)
+	}
+	return kr, nil
+}
+
+// Load reads master keys from a key file and from spec, a list of "id:base64key" entries separated
+// by commas or whitespace (typically an environment variable). The key file holds one entry per
+// line; blank lines and lines starting with '#' are ignored. primary defaults to the last key
+// listed, so rotating means appending a new key.
+func Load(file, spec, primary string) (*Keyring, error) {
+	var entries []string
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		defer f.Close()
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			if line := strings.TrimSpace(s.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				entries = append(entries, line)
+			}
+		}
+		if err := s.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+	}
+	entries = append(entries, strings.Fields(strings.ReplaceAll(spec, ",", " "))...)
+	if len(entries) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	keys := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, errors.New("invalid key entry: want id:base64key")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q is not valid base64: %w", id, err)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, fmt.Errorf("key %q is configured twice", id)
+		}
+		keys[id] = key
+	}
+	if primary == "" {
+		primary, _, _ = strings.Cut(entries[len(entries)-1], ":")
+	}
+	return New(keys, primary)
+}
+
+// GenerateKey returns a new random master key, base64 encoded for a key file or environment variable
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// PrimaryKeyID is the ID of the key new values are sealed under
+func (kr *Keyring) PrimaryKeyID() string { return kr.primary }
+
+// Encrypt seals plaintext under a fresh data key wrapped by the primary key. The empty string is
+// stored as is, so "not recorded" stays distinguishable without decrypting.
+func (kr *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	wrapped, err := seal(kr.keys[kr.primary], dek, []byte(kr.primary))
+	if err != nil {
+		return "", err
+	}
+	header := prefix + kr.primary + ":" + encode(wrapped)
+	data, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(data, []byte(plaintext), []byte(header))
+	if err != nil {
+		return "", err
+	}
+	return header + ":" + encode(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt. Values without the encrypted prefix were stored before
+// encryption was enabled and are returned unchanged.
+func (kr *Keyring) Decrypt(stored string) (string, error) {
+	if !strings.HasPrefix(stored, prefix) {
+		return stored, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(stored, prefix), ":")
+	if len(parts) != 3 {
+		return "", ErrCorrupt
+	}
+	id := parts[0]
+	master, ok := kr.keys[id]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	wrapped, err1 := decode(parts[1])
+	sealed, err2 := decode(parts[2])
+	if err1 != nil || err2 != nil {
+		return "", ErrCorrupt
+	}
+	dek, err := open(master, wrapped, []byte(id))
+	if err != nil {
+		return "", err
+	}
+	data, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+	header := prefix + id + ":" + parts[1]
+	plaintext, err := open(data, sealed, []byte(header))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// KeyID returns the ID of the master key a stored value was sealed under, or "" for plaintext
+func KeyID(stored string) string {
+	if !strings.HasPrefix(stored, prefix) {
+		return ""
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(stored, prefix), ":")
+	return id
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns the nonce followed by the ciphertext
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func open(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrCorrupt
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additional)
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	return plaintext, nil
+}
+
+func encode(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func decode(s string) ([]byte, error) { return base64.RawURLEncoding.DecodeString(s) }
@@ -0,0 +1,96 @@
+package keyring
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mustGenerate(t *testing.T, id string) string {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id + ":" + key
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	kr, err := Load("", mustGenerate(t, "k1"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := kr.Encrypt("1234567890")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := kr.Encrypt("1234567890")
+	if a == b || strings.Contains(a, "1234567890") || KeyID(a) != "k1" {
+		t.Fatalf("ciphertexts %q and %q should differ, hide the value and name k1", a, b)
+	}
+	if got, err := kr.Decrypt(a); err != nil || got != "1234567890" {
+		t.Fatalf("Decrypt = %q, %v", got, err)
+	}
+	if got, _ := kr.Encrypt(""); got != "" {
+		t.Fatalf("Encrypt(\"\") = %q, want empty", got)
+	}
+	if got, err := kr.Decrypt("Kasikorn Bank"); err != nil || got != "Kasikorn Bank" {
+		t.Fatalf("plaintext passthrough = %q, %v", got, err)
+	}
+	tampered := a[:len(a)-2] + "AA"
+	if _, err := kr.Decrypt(tampered); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("tampered value error = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestRotationKeepsOldValuesReadable(t *testing.T) {
+	k1, k2 := mustGenerate(t, "k1"), mustGenerate(t, "k2")
+	old, err := Load("", k1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := old.Encrypt("1234567890123")
+
+	file := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(file, []byte("# master keys\n"+k1+"\n\n"+k2+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	kr, err := Load(file, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr.PrimaryKeyID() != "k2" {
+		t.Fatalf("primary = %q, want the last key listed", kr.PrimaryKeyID())
+	}
+	if got, err := kr.Decrypt(stored); err != nil || got != "1234567890123" {
+		t.Fatalf("Decrypt under old key = %q, %v", got, err)
+	}
+	if fresh, _ := kr.Encrypt("x"); KeyID(fresh) != "k2" {
+		t.Fatalf("new value sealed under %q, want k2", KeyID(fresh))
+	}
+
+	retired, _ := Load("", k2, "")
+	if _, err := retired.Decrypt(stored); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("retired key error = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestLoadRejectsBadConfiguration(t *testing.T) {
+	if _, err := Load("", "", ""); !errors.Is(err, ErrNoKeys) {
+		t.Fatalf("empty config error = %v, want ErrNoKeys", err)
+	}
+	for _, spec := range []string{"nokey", "k1:not-base64!", "k1:c2hvcnQ=", "bad id:" + strings.Repeat("A", 44)} {
+		if _, err := Load("", spec, ""); err == nil {
+			t.Errorf("Load(%q) succeeded", spec)
+		}
+	}
+	k1 := mustGenerate(t, "k1")
+	if _, err := Load("", k1+","+k1, ""); err == nil {
+		t.Error("duplicate key ID accepted")
+	}
+	if _, err := Load("", k1, "k9"); err == nil {
+		t.Error("unknown primary key accepted")
+	}
+}
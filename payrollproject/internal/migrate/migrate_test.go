@@ -0,0 +1,157 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "modernc.org/sqlite"
+)
+
+func newSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "payroll.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// tables lists the tables of a SQLite database besides the migration bookkeeping
+func tables(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table'
+        AND name NOT IN ('schema_migrations', 'sqlite_sequence') ORDER BY name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestUpAndDownSQLite(t *testing.T) {
+	ctx := context.Background()
+	db := newSQLite(t)
+	m, err := New(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(m.migrations) || len(tables(t, db)) == 0 {
+		t.Fatalf("applied %d of %d migrations, tables %v", n, len(m.migrations), tables(t, db))
+	}
+	if n, err := m.Up(ctx); err != nil || n != 0 {
+		t.Fatalf("second Up = %d, %v; want nothing to apply", n, err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			t.Fatalf("migration %d_%s is not applied", s.Version, s.Name)
+		}
+	}
+
+	// Rolling back the last migration leaves the others applied, and it can be applied again
+	if n, err := m.Down(ctx, 1); err != nil || n != 1 {
+		t.Fatalf("Down(1) = %d, %v", n, err)
+	}
+	if statuses, _ = m.Status(ctx); statuses[len(statuses)-1].AppliedAt != nil || statuses[0].AppliedAt == nil {
+		t.Fatalf("statuses after Down(1) = %+v", statuses)
+	}
+	if n, err := m.Up(ctx); err != nil || n != 1 {
+		t.Fatalf("Up after Down(1) = %d, %v", n, err)
+	}
+
+	// Every down script undoes its up script, back to an empty database
+	if n, err := m.Down(ctx, len(m.migrations)); err != nil || n != len(m.migrations) {
+		t.Fatalf("Down(all) = %d, %v", n, err)
+	}
+	if left := tables(t, db); len(left) != 0 {
+		t.Fatalf("tables left after rolling everything back: %v", left)
+	}
+	if n, err := m.Up(ctx); err != nil || n != len(m.migrations) {
+		t.Fatalf("Up after Down(all) = %d, %v", n, err)
+	}
+}
+
+func TestVerifyRejectsChangedAndUnknownMigrations(t *testing.T) {
+	ctx := context.Background()
+	db := newSQLite(t)
+	m, err := New(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// An applied script edited afterwards no longer matches the checksum recorded for it
+	if _, err := db.Exec("UPDATE schema_migrations SET checksum = ? WHERE version = 1", strings.Repeat("0", 64)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err == nil || !strings.Contains(err.Error(), "checksum mismatch for migration 1_") {
+		t.Fatalf("Up with a changed migration error = %v, want a checksum mismatch", err)
+	}
+	if _, err := m.Down(ctx, 1); err == nil {
+		t.Fatal("Down ran with a changed migration")
+	}
+	if _, err := db.Exec("UPDATE schema_migrations SET checksum = ? WHERE version = 1", m.migrations[0].Checksum); err != nil {
+		t.Fatal(err)
+	}
+
+	// A database migrated by a newer binary is left alone
+	if _, err := db.Exec("INSERT INTO schema_migrations (version, name, checksum) VALUES (9999, 'future', ?)", strings.Repeat("0", 64)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err == nil || !strings.Contains(err.Error(), "does not know") {
+		t.Fatalf("Up with an unknown migration error = %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+		"0001_first.up.sql":    {Data: []byte("CREATE TABLE a (id INT);")},
+		"0001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+		"README.md":            {Data: []byte("not a migration")},
+		"0003_Bad-Name.up.sql": {Data: []byte("ignored")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Name != "first" || migrations[0].Down == "" || migrations[1].Down != "" ||
+		len(migrations[0].Checksum) != 64 || migrations[0].Checksum == migrations[1].Checksum {
+		t.Fatalf("migrations = %+v", migrations)
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"conflicting names": {
+			"0001_first.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_other.down.sql": {Data: []byte("SELECT 1;")},
+		},
+		"no up script": {"0001_first.down.sql": {Data: []byte("SELECT 1;")}},
+	} {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: Load succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS trigger_decrement_num_emp ON employees;
+DROP TRIGGER IF EXISTS trigger_increment_num_emp ON employees;
+DROP FUNCTION IF EXISTS decrement_num_emp();
+DROP FUNCTION IF EXISTS increment_num_emp();
+DROP TABLE IF EXISTS taxcalculation;
+DROP TABLE IF EXISTS deduction;
+DROP TABLE IF EXISTS addition;
+DROP TABLE IF EXISTS payroll;
+DROP TABLE IF EXISTS employees;
+DROP TABLE IF EXISTS departments;
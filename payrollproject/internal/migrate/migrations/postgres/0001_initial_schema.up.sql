@@ -1,16 +1,18 @@
+-- Baseline schema, written to be a no-op on databases created by the old init.sql
+
 -- Create Departments Table
-CREATE TABLE Departments (
+CREATE TABLE IF NOT EXISTS departments (
     dept_id INT PRIMARY KEY,
     dept_name VARCHAR(255) NOT NULL,
     num_emp INT DEFAULT 0
 );
 
 -- Create Employees table
-CREATE TABLE employees (
+CREATE TABLE IF NOT EXISTS employees (
     emp_id INT PRIMARY KEY,
     emp_name VARCHAR(100),
     phone_number VARCHAR(20),
-    dept_id INT REFERENCES Departments(dept_id) ON DELETE CASCADE,
+    dept_id INT REFERENCES departments(dept_id) ON DELETE CASCADE,
     position_name VARCHAR(100),
     base_salary DECIMAL(10, 2),
     bank_account VARCHAR(100),
@@ -18,7 +20,7 @@ CREATE TABLE employees (
 );
 
 -- Create Payroll table
-CREATE TABLE payroll (
+CREATE TABLE IF NOT EXISTS payroll (
     payroll_id SERIAL PRIMARY KEY,
     emp_id INT REFERENCES employees(emp_id) ON DELETE CASCADE,
     pay_month VARCHAR(20),
@@ -30,8 +32,7 @@ CREATE TABLE payroll (
     net_salary DECIMAL(10, 2)
 );
 
-
-CREATE TABLE addition (
+CREATE TABLE IF NOT EXISTS addition (
     addition_id SERIAL PRIMARY KEY,
     emp_id INT REFERENCES employees(emp_id) ON DELETE CASCADE,
     overtime DECIMAL(10, 2),
@@ -39,7 +40,7 @@ CREATE TABLE addition (
     total_additions DECIMAL(10, 2)
 );
 
-CREATE TABLE deduction (
+CREATE TABLE IF NOT EXISTS deduction (
     deduction_id SERIAL PRIMARY KEY,
     emp_id INT REFERENCES employees(emp_id) ON DELETE CASCADE,
     absent_late DECIMAL(10, 2),
@@ -60,44 +61,47 @@ CREATE TABLE IF NOT EXISTS taxcalculation (
     tax_amount DECIMAL(10, 2)
 );
 
--- Insert Data into Departments
-INSERT INTO
-    Departments (dept_id, dept_name, num_emp)
-VALUES
-    (1001, 'Marketing', 0),
-    (1002, 'Sales', 0),
-    (1003, 'Human Resources', 0);
-
-CREATE OR REPLACE FUNCTION increment_num_emp() RETURNS TRIGGER AS $$ 
+CREATE OR REPLACE FUNCTION increment_num_emp() RETURNS TRIGGER AS $$
 BEGIN
     -- เพิ่มจำนวนพนักงานในแผนกเมื่อมีการเพิ่มพนักงาน
-    UPDATE Departments
+    UPDATE departments
     SET num_emp = num_emp + 1
     WHERE dept_id = NEW.dept_id;
 
     RETURN NEW;
-END; 
+END;
 $$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS trigger_increment_num_emp ON employees;
 CREATE TRIGGER trigger_increment_num_emp
 AFTER INSERT ON employees
 FOR EACH ROW EXECUTE FUNCTION increment_num_emp();
 
-CREATE OR REPLACE FUNCTION decrement_num_emp() RETURNS TRIGGER AS $$ 
+CREATE OR REPLACE FUNCTION decrement_num_emp() RETURNS TRIGGER AS $$
 BEGIN
     -- ลดจำนวนพนักงานในแผนกเมื่อพนักงานถูกลบ
-    UPDATE Departments
+    UPDATE departments
     SET num_emp = num_emp - 1
     WHERE dept_id = OLD.dept_id;
 
     RETURN OLD;
-END; 
+END;
 $$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS trigger_decrement_num_emp ON employees;
 CREATE TRIGGER trigger_decrement_num_emp
 AFTER DELETE ON employees
 FOR EACH ROW EXECUTE FUNCTION decrement_num_emp();
 
+-- Insert Data into Departments
+INSERT INTO
+    departments (dept_id, dept_name, num_emp)
+VALUES
+    (1001, 'Marketing', 0),
+    (1002, 'Sales', 0),
+    (1003, 'Human Resources', 0)
+ON CONFLICT (dept_id) DO NOTHING;
+
 -- Insert employee data
 INSERT INTO
     employees (
@@ -111,33 +115,7 @@ INSERT INTO
         account_num
     )
 VALUES
-    (
-        1,
-        'นายสมชาย',
-        '0812345678',
-        1001,
-        'Marketing Manager',
-        30000,
-        'Kasikorn Bank',
-        '1234567890'
-    ),
-    (
-        2,
-        'นางสาวสาวิตรี',
-        '0823456789',
-        1002,
-        'Sales',
-        22000,
-        'Bangkok Bank',
-        '0987654321'
-    ),
-    (
-        3,
-        'นายบ่าว',
-        '0834567890',
-        1003,
-        'Human Resources',
-        25000,
-        'Krungsri Bank',
-        '5678901234'
-    );
\ No newline at end of file
+    (1, 'นายสมชาย', '0812345678', 1001, 'Marketing Manager', 30000, 'Kasikorn Bank', '1234567890'),
+    (2, 'นางสาวสาวิตรี', '0823456789', 1002, 'Sales', 22000, 'Bangkok Bank', '0987654321'),
+    (3, 'นายบ่าว', '0834567890', 1003, 'Human Resources', 25000, 'Krungsri Bank', '5678901234')
+ON CONFLICT (emp_id) DO NOTHING;
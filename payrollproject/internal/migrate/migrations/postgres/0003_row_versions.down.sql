@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS trigger_move_num_emp ON employees;
+DROP FUNCTION IF EXISTS move_num_emp();
+ALTER TABLE payroll DROP COLUMN IF EXISTS version;
+ALTER TABLE employees DROP COLUMN IF EXISTS version;
+ALTER TABLE departments DROP COLUMN IF EXISTS version;
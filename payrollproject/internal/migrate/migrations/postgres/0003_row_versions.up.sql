@@ -0,0 +1,20 @@
+-- Optimistic concurrency: every update increments version, exposed to clients as the ETag
+ALTER TABLE departments ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE payroll ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+-- ย้ายจำนวนพนักงานเมื่อพนักงานเปลี่ยนแผนก
+CREATE OR REPLACE FUNCTION move_num_emp() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE departments SET num_emp = num_emp - 1 WHERE dept_id = OLD.dept_id;
+    UPDATE departments SET num_emp = num_emp + 1 WHERE dept_id = NEW.dept_id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_move_num_emp ON employees;
+CREATE TRIGGER trigger_move_num_emp
+AFTER UPDATE OF dept_id ON employees
+FOR EACH ROW
+WHEN (OLD.dept_id IS DISTINCT FROM NEW.dept_id)
+EXECUTE FUNCTION move_num_emp();
@@ -0,0 +1,20 @@
+-- API users; passwords are stored as bcrypt hashes
+CREATE TABLE IF NOT EXISTS users (
+    user_id SERIAL PRIMARY KEY,
+    username VARCHAR(100) NOT NULL UNIQUE,
+    password_hash VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per login; the refresh token is rotated on every refresh and only its sha256 is kept.
+-- Revoking the session invalidates its refresh token and every access token issued for it.
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    session_id VARCHAR(64) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    refresh_hash CHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS auth_sessions_user_id ON auth_sessions (user_id);
@@ -0,0 +1,7 @@
+ALTER TABLE payroll DROP COLUMN IF EXISTS approved_at;
+ALTER TABLE payroll DROP COLUMN IF EXISTS approved_by;
+ALTER TABLE payroll DROP COLUMN IF EXISTS status;
+ALTER TABLE users DROP COLUMN IF EXISTS emp_id;
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
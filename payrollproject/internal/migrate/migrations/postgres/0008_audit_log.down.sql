@@ -0,0 +1,4 @@
+DELETE FROM role_permissions WHERE permission = 'audit:read';
+DROP TRIGGER IF EXISTS trigger_audit_log_append_only ON audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,34 @@
+-- Append-only audit log; each row's hash covers its content and the previous row's hash.
+-- Snapshots are stored as TEXT rather than JSONB so the hashed bytes round-trip unchanged.
+CREATE TABLE IF NOT EXISTS audit_log (
+    audit_id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    actor_id INT,
+    actor VARCHAR(100) NOT NULL DEFAULT '',
+    request_id VARCHAR(100) NOT NULL DEFAULT '',
+    entity VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(100) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    before_data TEXT,
+    after_data TEXT,
+    prev_hash VARCHAR(64) NOT NULL UNIQUE,
+    hash VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity ON audit_log (entity, entity_id);
+
+-- ห้ามแก้ไขหรือลบประวัติการเปลี่ยนแปลง
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_audit_log_append_only ON audit_log;
+CREATE TRIGGER trigger_audit_log_append_only
+BEFORE UPDATE OR DELETE ON audit_log
+FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+INSERT INTO role_permissions (role_name, permission)
+SELECT role_name, 'audit:read' FROM roles WHERE role_name = 'admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+-- The columns stay TEXT: encrypted values would not fit the original widths
+DELETE FROM role_permissions WHERE permission = 'pii:reveal';
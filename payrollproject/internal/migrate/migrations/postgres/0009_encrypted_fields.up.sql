@@ -0,0 +1,12 @@
+-- Bank details and national IDs may now hold envelope-encrypted values, which outgrow the
+-- original widths
+ALTER TABLE employees ALTER COLUMN bank_account TYPE TEXT;
+ALTER TABLE employees ALTER COLUMN account_num TYPE TEXT;
+ALTER TABLE employees ALTER COLUMN national_id TYPE TEXT;
+ALTER TABLE profile_change_requests ALTER COLUMN bank_account TYPE TEXT;
+ALTER TABLE profile_change_requests ALTER COLUMN account_num TYPE TEXT;
+
+-- Let the roles that handle bank transfers, and employees for their own record, see them unmasked
+INSERT INTO role_permissions (role_name, permission)
+SELECT role_name, 'pii:reveal' FROM roles WHERE role_name IN ('admin', 'hr_clerk', 'payroll_officer', 'employee')
+ON CONFLICT DO NOTHING;
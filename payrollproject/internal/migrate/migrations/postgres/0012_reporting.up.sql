@@ -0,0 +1,3 @@
+INSERT INTO role_permissions (role_name, permission)
+SELECT role_name, 'report:read' FROM roles WHERE role_name IN ('admin', 'payroll_officer', 'finance_approver')
+ON CONFLICT DO NOTHING;
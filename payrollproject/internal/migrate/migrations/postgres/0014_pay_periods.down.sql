@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS payroll_emp_month_run;
+DROP INDEX IF EXISTS payroll_period_start;
+ALTER TABLE payroll ALTER COLUMN pay_date TYPE VARCHAR(20) USING TO_CHAR(pay_date, 'YYYY-MM-DD');
+ALTER TABLE payroll ALTER COLUMN pay_month DROP NOT NULL;
+ALTER TABLE payroll DROP COLUMN IF EXISTS period_end;
+ALTER TABLE payroll DROP COLUMN IF EXISTS period_start;
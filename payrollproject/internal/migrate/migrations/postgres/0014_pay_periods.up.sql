@@ -0,0 +1,26 @@
+-- pay_month holds the text form of a pay period: "2026-01" for a month, "2026-01-H1" and
+-- "2026-01-H2" for its halves and "2026-W05" for an ISO week. The first and last day of the period
+-- are kept beside it so records can be filtered and sorted by date. Before this script runs, the
+-- migrate command has rewritten pay months and pay dates stored in other formats (Thai month names,
+-- Buddhist-era years, day/month/year dates), so every existing record is a month.
+ALTER TABLE payroll ADD COLUMN period_start DATE;
+ALTER TABLE payroll ADD COLUMN period_end DATE;
+UPDATE payroll SET period_start = TO_DATE(pay_month || '-01', 'YYYY-MM-DD');
+UPDATE payroll SET period_end = (period_start + INTERVAL '1 month' - INTERVAL '1 day')::DATE;
+ALTER TABLE payroll ALTER COLUMN pay_month SET NOT NULL;
+ALTER TABLE payroll ALTER COLUMN period_start SET NOT NULL;
+ALTER TABLE payroll ALTER COLUMN period_end SET NOT NULL;
+
+ALTER TABLE payroll ALTER COLUMN pay_date TYPE DATE USING NULLIF(pay_date, '')::DATE;
+
+CREATE INDEX IF NOT EXISTS payroll_period_start ON payroll (period_start);
+
+-- Records that pay an employee twice in a month are kept as off-cycle runs. Pay months were rewritten
+-- before this script, so "ม.ค. 69" and "2026-01" count as the same month. An employee paid three or
+-- more times in one month stops the migration and has to be resolved by hand.
+UPDATE payroll SET run_type = 'off_cycle'
+WHERE run_type = 'regular'
+  AND payroll_id > (SELECT MIN(p.payroll_id) FROM payroll p
+                    WHERE p.emp_id = payroll.emp_id AND p.pay_month = payroll.pay_month AND p.run_type = 'regular');
+
+CREATE UNIQUE INDEX IF NOT EXISTS payroll_emp_month_run ON payroll (emp_id, pay_month, run_type);
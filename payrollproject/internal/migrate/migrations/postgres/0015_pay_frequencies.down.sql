@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS pay_calendars;
+ALTER TABLE employees DROP COLUMN IF EXISTS wage_rate;
+ALTER TABLE employees DROP COLUMN IF EXISTS pay_type;
+ALTER TABLE employees DROP COLUMN IF EXISTS pay_frequency;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS minimum_wages;
+ALTER TABLE employees DROP COLUMN IF EXISTS province;
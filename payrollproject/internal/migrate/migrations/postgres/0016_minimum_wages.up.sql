@@ -0,0 +1,12 @@
+-- The province an employee works in, by its Thai name, whose minimum wage applies to them; empty
+-- for existing employees until it is set
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS province VARCHAR(100) NOT NULL DEFAULT '';
+
+-- The minimum daily wage of each province from the day it takes effect, as announced by the Wage
+-- Committee; a rate stays in force until the province's next one
+CREATE TABLE IF NOT EXISTS minimum_wages (
+    province VARCHAR(100) NOT NULL,
+    effective_date DATE NOT NULL,
+    daily_rate DECIMAL(10, 2) NOT NULL CHECK (daily_rate > 0),
+    PRIMARY KEY (province, effective_date)
+);
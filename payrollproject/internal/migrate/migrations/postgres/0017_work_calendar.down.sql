@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS holidays;
+ALTER TABLE employees DROP COLUMN IF EXISTS site_id;
+DROP TABLE IF EXISTS work_sites;
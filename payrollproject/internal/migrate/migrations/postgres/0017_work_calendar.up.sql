@@ -0,0 +1,20 @@
+-- Work sites and their working week, stored as weekday names such as 'mon,tue,wed,thu,fri'.
+-- Employees without a site work Monday to Friday.
+CREATE TABLE IF NOT EXISTS work_sites (
+    site_id VARCHAR(50) PRIMARY KEY,
+    site_name VARCHAR(100) NOT NULL,
+    work_days VARCHAR(50) NOT NULL DEFAULT 'mon,tue,wed,thu,fri'
+);
+
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS site_id VARCHAR(50) NOT NULL DEFAULT '';
+
+-- Public holidays, observed at every site, and company holidays, at every site (an empty site_id)
+-- or at one; a site has one holiday a day
+CREATE TABLE IF NOT EXISTS holidays (
+    holiday_id SERIAL PRIMARY KEY,
+    holiday_date DATE NOT NULL,
+    holiday_name VARCHAR(200) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('public', 'company')),
+    site_id VARCHAR(50) NOT NULL DEFAULT '',
+    UNIQUE (holiday_date, site_id)
+);
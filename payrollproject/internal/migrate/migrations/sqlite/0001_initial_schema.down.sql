@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS trigger_decrement_num_emp;
+DROP TRIGGER IF EXISTS trigger_increment_num_emp;
+DROP TABLE IF EXISTS taxcalculation;
+DROP TABLE IF EXISTS deduction;
+DROP TABLE IF EXISTS addition;
+DROP TABLE IF EXISTS payroll;
+DROP TABLE IF EXISTS employees;
+DROP TABLE IF EXISTS departments;
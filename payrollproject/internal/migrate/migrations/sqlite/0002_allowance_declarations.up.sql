@@ -0,0 +1,21 @@
+-- Create tax allowance declaration (ล.ย.01) table
+CREATE TABLE IF NOT EXISTS allowance_declarations (
+    emp_id INT REFERENCES employees(emp_id) ON DELETE CASCADE,
+    tax_year INT NOT NULL,
+    has_spouse BOOLEAN NOT NULL DEFAULT FALSE,
+    num_children INT NOT NULL DEFAULT 0,
+    num_children_born_from_2018 INT NOT NULL DEFAULT 0,
+    num_parents INT NOT NULL DEFAULT 0,
+    num_disabled_dependants INT NOT NULL DEFAULT 0,
+    life_insurance DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    health_insurance DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    parent_health_insurance DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    home_loan_interest DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    ssf DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    rmf DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    provident_fund DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    donations DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    education_donations DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (emp_id, tax_year)
+);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trigger_move_num_emp;
+ALTER TABLE payroll DROP COLUMN version;
+ALTER TABLE employees DROP COLUMN version;
+ALTER TABLE departments DROP COLUMN version;
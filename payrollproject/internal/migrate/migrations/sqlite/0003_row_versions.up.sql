@@ -0,0 +1,14 @@
+-- Optimistic concurrency: every update increments version, exposed to clients as the ETag
+ALTER TABLE departments ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE employees ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE payroll ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- ย้ายจำนวนพนักงานเมื่อพนักงานเปลี่ยนแผนก
+CREATE TRIGGER IF NOT EXISTS trigger_move_num_emp
+AFTER UPDATE OF dept_id ON employees
+FOR EACH ROW
+WHEN OLD.dept_id IS NOT NEW.dept_id
+BEGIN
+    UPDATE departments SET num_emp = num_emp - 1 WHERE dept_id = OLD.dept_id;
+    UPDATE departments SET num_emp = num_emp + 1 WHERE dept_id = NEW.dept_id;
+END;
@@ -0,0 +1,2 @@
+-- เลขประจำตัวประชาชน 13 หลัก; empty for employees recorded before it was collected
+ALTER TABLE employees ADD COLUMN national_id VARCHAR(13) NOT NULL DEFAULT '';
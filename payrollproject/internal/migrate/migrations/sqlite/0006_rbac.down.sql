@@ -0,0 +1,7 @@
+ALTER TABLE payroll DROP COLUMN approved_at;
+ALTER TABLE payroll DROP COLUMN approved_by;
+ALTER TABLE payroll DROP COLUMN status;
+ALTER TABLE users DROP COLUMN emp_id;
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
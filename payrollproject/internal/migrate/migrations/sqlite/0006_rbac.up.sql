@@ -0,0 +1,27 @@
+-- Roles grant permissions such as employee:read over a scope of rows:
+-- all, the user's own department, or only the user's own employee record
+CREATE TABLE IF NOT EXISTS roles (
+    role_name VARCHAR(50) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    scope VARCHAR(16) NOT NULL DEFAULT 'all' CHECK (scope IN ('all', 'department', 'self'))
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_name VARCHAR(50) NOT NULL REFERENCES roles(role_name) ON DELETE CASCADE,
+    permission VARCHAR(50) NOT NULL,
+    PRIMARY KEY (role_name, permission)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    role_name VARCHAR(50) NOT NULL REFERENCES roles(role_name) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, role_name)
+);
+
+-- The employee a user is, which department- and self-scoped roles are resolved against
+ALTER TABLE users ADD COLUMN emp_id INT REFERENCES employees(emp_id) ON DELETE SET NULL;
+
+-- Payroll approval by a user holding payroll:approve
+ALTER TABLE payroll ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'draft';
+ALTER TABLE payroll ADD COLUMN approved_by INT REFERENCES users(user_id) ON DELETE SET NULL;
+ALTER TABLE payroll ADD COLUMN approved_at TIMESTAMP;
@@ -0,0 +1,3 @@
+DELETE FROM role_permissions WHERE permission = 'leave:approve';
+DROP TABLE IF EXISTS leave_requests;
+DROP TABLE IF EXISTS profile_change_requests;
@@ -0,0 +1,35 @@
+-- Phone and bank changes employees submit through /me; HR applies them by approving
+CREATE TABLE IF NOT EXISTS profile_change_requests (
+    change_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    emp_id INT NOT NULL REFERENCES employees(emp_id) ON DELETE CASCADE,
+    phone_number VARCHAR(20),
+    bank_account VARCHAR(100),
+    account_num VARCHAR(20),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    requested_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reviewed_by INT REFERENCES users(user_id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    review_note VARCHAR(500) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS leave_requests (
+    leave_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    emp_id INT NOT NULL REFERENCES employees(emp_id) ON DELETE CASCADE,
+    leave_type VARCHAR(20) NOT NULL,
+    start_date VARCHAR(10) NOT NULL,
+    end_date VARCHAR(10) NOT NULL,
+    reason VARCHAR(500) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    requested_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reviewed_by INT REFERENCES users(user_id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    review_note VARCHAR(500) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS profile_change_requests_emp_id ON profile_change_requests (emp_id);
+CREATE INDEX IF NOT EXISTS leave_requests_emp_id ON leave_requests (emp_id);
+
+-- Let the roles that review leave do so
+INSERT INTO role_permissions (role_name, permission)
+SELECT role_name, 'leave:approve' FROM roles WHERE role_name IN ('admin', 'hr_clerk', 'department_manager')
+ON CONFLICT DO NOTHING;
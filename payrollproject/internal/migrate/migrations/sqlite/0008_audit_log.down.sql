@@ -0,0 +1,4 @@
+DELETE FROM role_permissions WHERE permission = 'audit:read';
+DROP TRIGGER IF EXISTS trigger_audit_log_no_update;
+DROP TRIGGER IF EXISTS trigger_audit_log_no_delete;
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,35 @@
+-- Append-only audit log; each row's hash covers its content and the previous row's hash.
+-- Snapshots are stored as TEXT rather than JSONB so the hashed bytes round-trip unchanged.
+CREATE TABLE IF NOT EXISTS audit_log (
+    audit_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    occurred_at TIMESTAMP NOT NULL,
+    actor_id INT,
+    actor VARCHAR(100) NOT NULL DEFAULT '',
+    request_id VARCHAR(100) NOT NULL DEFAULT '',
+    entity VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(100) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    before_data TEXT,
+    after_data TEXT,
+    prev_hash VARCHAR(64) NOT NULL UNIQUE,
+    hash VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity ON audit_log (entity, entity_id);
+
+-- ห้ามแก้ไขหรือลบประวัติการเปลี่ยนแปลง
+CREATE TRIGGER IF NOT EXISTS trigger_audit_log_no_update
+BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS trigger_audit_log_no_delete
+BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+INSERT INTO role_permissions (role_name, permission)
+SELECT role_name, 'audit:read' FROM roles WHERE role_name = 'admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+DELETE FROM role_permissions WHERE permission = 'privacy:manage';
+ALTER TABLE employees DROP COLUMN anonymised_at;
+ALTER TABLE employees DROP COLUMN end_date;
@@ -0,0 +1,8 @@
+-- วันสุดท้ายของการทำงาน; empty while employed. Retention anonymises former employees once their
+-- retention period has passed and records when it did.
+ALTER TABLE employees ADD COLUMN end_date VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE employees ADD COLUMN anonymised_at TIMESTAMP;
+
+INSERT INTO role_permissions (role_name, permission)
+SELECT role_name, 'privacy:manage' FROM roles WHERE role_name = 'admin'
+ON CONFLICT DO NOTHING;
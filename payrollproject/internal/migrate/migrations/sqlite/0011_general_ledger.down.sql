@@ -0,0 +1,2 @@
+DELETE FROM role_permissions WHERE permission = 'ledger:manage';
+DROP TABLE IF EXISTS gl_accounts;
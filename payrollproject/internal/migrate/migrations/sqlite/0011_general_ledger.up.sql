@@ -0,0 +1,13 @@
+-- Chart of accounts for pay run journals: one row per account purpose; salary_expense rows with a
+-- dept_id give that department its own expense account or cost centre
+CREATE TABLE IF NOT EXISTS gl_accounts (
+    purpose VARCHAR(40) NOT NULL,
+    dept_id INT NOT NULL DEFAULT 0,
+    account_code VARCHAR(50) NOT NULL,
+    cost_centre VARCHAR(50) NOT NULL DEFAULT '',
+    PRIMARY KEY (purpose, dept_id)
+);
+
+INSERT INTO role_permissions (role_name, permission)
+SELECT role_name, 'ledger:manage' FROM roles WHERE role_name IN ('admin', 'finance_approver')
+ON CONFLICT DO NOTHING;
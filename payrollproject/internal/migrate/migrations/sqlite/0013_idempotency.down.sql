@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS idempotency_keys;
+ALTER TABLE payroll DROP COLUMN run_type;
@@ -0,0 +1,17 @@
+-- Every payroll record belongs to a pay run; an employee is paid at most once per run type in a month.
+-- Records that already pay an employee twice in a month are told apart, and the rule enforced, by
+-- migration 14, once every pay month is written the same way.
+ALTER TABLE payroll ADD COLUMN run_type VARCHAR(20) NOT NULL DEFAULT 'regular';
+
+-- Responses to POST requests sent with an Idempotency-Key, replayed when a client retries the request.
+-- status is 0 while the first request is still being handled.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status INT NOT NULL DEFAULT 0,
+    headers TEXT NOT NULL DEFAULT '{}',
+    body BLOB,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, idempotency_key)
+);
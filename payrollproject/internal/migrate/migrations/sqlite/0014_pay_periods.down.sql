@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS payroll_emp_month_run;
+DROP INDEX IF EXISTS payroll_period_start;
+ALTER TABLE payroll DROP COLUMN period_end;
+ALTER TABLE payroll DROP COLUMN period_start;
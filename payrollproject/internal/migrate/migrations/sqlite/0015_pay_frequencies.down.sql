@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS pay_calendars;
+ALTER TABLE employees DROP COLUMN wage_rate;
+ALTER TABLE employees DROP COLUMN pay_type;
+ALTER TABLE employees DROP COLUMN pay_frequency;
@@ -0,0 +1,19 @@
+-- How often an employee is paid and how their pay is worked out: salaried staff from base_salary,
+-- a monthly amount spread over the periods of the year, hourly and daily staff from wage_rate and
+-- the hours or days they worked. Existing employees are salaried and paid monthly.
+ALTER TABLE employees ADD COLUMN pay_frequency VARCHAR(20) NOT NULL DEFAULT 'monthly'
+    CHECK (pay_frequency IN ('monthly', 'semi_monthly', 'weekly', 'biweekly'));
+ALTER TABLE employees ADD COLUMN pay_type VARCHAR(20) NOT NULL DEFAULT 'salaried'
+    CHECK (pay_type IN ('salaried', 'hourly', 'daily'));
+ALTER TABLE employees ADD COLUMN wage_rate DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+-- The pay calendar of each frequency: staff are paid pay_days_after_end days after the last day of
+-- a period, or on the Friday before when that is a weekend
+CREATE TABLE IF NOT EXISTS pay_calendars (
+    frequency VARCHAR(20) PRIMARY KEY,
+    pay_days_after_end INT NOT NULL DEFAULT 0
+);
+
+INSERT INTO pay_calendars (frequency, pay_days_after_end)
+VALUES ('monthly', 0), ('semi_monthly', 0), ('weekly', 5), ('biweekly', 5)
+ON CONFLICT DO NOTHING;
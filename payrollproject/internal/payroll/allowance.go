@@ -0,0 +1,261 @@
+package payroll
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"payrollproject/internal/tax"
+)
+
+// AllowanceDeclaration is an employee's ล.ย.01 allowance declaration for one tax year
+type AllowanceDeclaration struct {
+	EmpID                   int     `json:"emp_id"`
+	TaxYear                 int     `json:"tax_year"`
+	HasSpouse               bool    `json:"has_spouse"` // spouse without income
+	NumChildren             int     `json:"num_children"`
+	NumChildrenBornFrom2018 int     `json:"num_children_born_from_2018"`
+	NumParents              int     `json:"num_parents"`
+	NumDisabledDependants   int     `json:"num_disabled_dependants"`
+	LifeInsurance           float64 `json:"life_insurance"`
+	HealthInsurance         float64 `json:"health_insurance"`
+	ParentHealthInsurance   float64 `json:"parent_health_insurance"`
+	HomeLoanInterest        float64 `json:"home_loan_interest"`
+	SSF                     float64 `json:"ssf"`
+	RMF                     float64 `json:"rmf"`
+	ProvidentFund           float64 `json:"provident_fund"`
+	Donations               float64 `json:"donations"`
+	EducationDonations      float64 `json:"education_donations"`
+}
+
+// Withholding is the result of applying the tax engine to an employee for a tax year
+type Withholding struct {
+	EmpID        int        `json:"emp_id"`
+	TaxYear      int        `json:"tax_year"`
+	PayFrequency PeriodKind `json:"pay_frequency"` // period_withholding is withheld from the pay of each period
+	Declared     bool       `json:"declared"`      // false when no declaration exists and only the personal allowance applied
+	tax.Result
+}
+
+// Tax years are Gregorian years in this range. Buddhist-era years, 543 years later, are refused
+// rather than read as Gregorian years five centuries ahead.
+const (
+	MinTaxYear = 2000
+	MaxTaxYear = 2100
+)
+
+// checkTaxYear adds an error on tax_year to v unless year is a Gregorian tax year
+func checkTaxYear(v *ValidationError, year int) {
+	switch {
+	case year >= MinTaxYear+543 && year <= MaxTaxYear+543:
+		v.Add("tax_year", "must be a Gregorian year: %d is the Buddhist-era year of %d", year, year-543)
+	case year < MinTaxYear || year > MaxTaxYear:
+		v.Add("tax_year", "must be a Gregorian year between %d and %d, got %d", MinTaxYear, MaxTaxYear, year)
+	}
+}
+
+// Validate checks the declaration for values the Revenue Department would reject
+func (d AllowanceDeclaration) Validate() error {
+	v := &ValidationError{}
+	checkTaxYear(v, d.TaxYear)
+	for _, c := range []struct {
+		field string
+		n     int
+	}{
+		{"num_children", d.NumChildren},
+		{"num_children_born_from_2018", d.NumChildrenBornFrom2018},
+		{"num_parents", d.NumParents},
+		{"num_disabled_dependants", d.NumDisabledDependants},
+	} {
+		if c.n < 0 {
+			v.Add(c.field, "must not be negative")
+		}
+	}
+	if d.NumChildrenBornFrom2018 > d.NumChildren {
+		v.Add("num_children_born_from_2018", "cannot exceed num_children")
+	}
+	if d.NumParents > tax.MaxParents {
+		v.Add("num_parents", "cannot exceed %d", tax.MaxParents)
+	}
+	for _, a := range []struct {
+		field  string
+		amount float64
+	}{
+		{"life_insurance", d.LifeInsurance},
+		{"health_insurance", d.HealthInsurance},
+		{"parent_health_insurance", d.ParentHealthInsurance},
+		{"home_loan_interest", d.HomeLoanInterest},
+		{"ssf", d.SSF},
+		{"rmf", d.RMF},
+		{"provident_fund", d.ProvidentFund},
+		{"donations", d.Donations},
+		{"education_donations", d.EducationDonations},
+	} {
+		if a.amount < 0 {
+			v.Add(a.field, "must not be negative")
+		}
+	}
+	return v.Err()
+}
+
+// Allowances converts the declaration into tax engine input
+func (d AllowanceDeclaration) Allowances() tax.Allowances {
+	return tax.Allowances{
+		HasSpouse:               d.HasSpouse,
+		NumChildren:             d.NumChildren,
+		NumChildrenBornFrom2018: d.NumChildrenBornFrom2018,
+		NumParents:              d.NumParents,
+		NumDisabledDependants:   d.NumDisabledDependants,
+		LifeInsurance:           d.LifeInsurance,
+		HealthInsurance:         d.HealthInsurance,
+		ParentHealthInsurance:   d.ParentHealthInsurance,
+		HomeLoanInterest:        d.HomeLoanInterest,
+		SSF:                     d.SSF,
+		RMF:                     d.RMF,
+		ProvidentFund:           d.ProvidentFund,
+		Donations:               d.Donations,
+		EducationDonations:      d.EducationDonations,
+	}
+}
+
+// SaveAllowanceDeclaration inserts or replaces an employee's declaration for a tax year
+func (pdb *sqlPayrollDB) SaveAllowanceDeclaration(ctx context.Context, d AllowanceDeclaration) error {
+	_, err := pdb.db.ExecContext(ctx, `
+        INSERT INTO allowance_declarations (
+            emp_id, tax_year, has_spouse, num_children, num_children_born_from_2018, num_parents,
+            num_disabled_dependants, life_insurance, health_insurance, parent_health_insurance,
+            home_loan_interest, ssf, rmf, provident_fund, donations, education_donations
+        ) VALUES (
+            $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+        )
+        ON CONFLICT (emp_id, tax_year) DO UPDATE SET
+            has_spouse = EXCLUDED.has_spouse,
+            num_children = EXCLUDED.num_children,
+            num_children_born_from_2018 = EXCLUDED.num_children_born_from_2018,
+            num_parents = EXCLUDED.num_parents,
+            num_disabled_dependants = EXCLUDED.num_disabled_dependants,
+            life_insurance = EXCLUDED.life_insurance,
+            health_insurance = EXCLUDED.health_insurance,
+            parent_health_insurance = EXCLUDED.parent_health_insurance,
+            home_loan_interest = EXCLUDED.home_loan_interest,
+            ssf = EXCLUDED.ssf,
+            rmf = EXCLUDED.rmf,
+            provident_fund = EXCLUDED.provident_fund,
+            donations = EXCLUDED.donations,
+            education_donations = EXCLUDED.education_donations,
+            updated_at = CURRENT_TIMESTAMP`,
+		d.EmpID, d.TaxYear, d.HasSpouse, d.NumChildren, d.NumChildrenBornFrom2018, d.NumParents,
+		d.NumDisabledDependants, d.LifeInsurance, d.HealthInsurance, d.ParentHealthInsurance,
+		d.HomeLoanInterest, d.SSF, d.RMF, d.ProvidentFund, d.Donations, d.EducationDonations)
+	if err != nil {
+		return fmt.Errorf("failed to save allowance declaration: %w", constraintError(err, "allowance declaration", d.TaxYear,
+			&ForeignKeyError{Field: "emp_id", Entity: "employee", ID: d.EmpID}))
+	}
+	return nil
+}
+
+// GetAllowanceDeclaration retrieves an employee's declaration for a tax year, or nil if none was submitted
+func (pdb *sqlPayrollDB) GetAllowanceDeclaration(ctx context.Context, empID, taxYear int) (*AllowanceDeclaration, error) {
+	d := AllowanceDeclaration{EmpID: empID, TaxYear: taxYear}
+	err := pdb.db.QueryRowContext(ctx, `
+        SELECT has_spouse, num_children, num_children_born_from_2018, num_parents, num_disabled_dependants,
+               life_insurance, health_insurance, parent_health_insurance, home_loan_interest,
+               ssf, rmf, provident_fund, donations, education_donations
+        FROM allowance_declarations
+        WHERE emp_id = $1 AND tax_year = $2`, empID, taxYear).Scan(
+		&d.HasSpouse, &d.NumChildren, &d.NumChildrenBornFrom2018, &d.NumParents, &d.NumDisabledDependants,
+		&d.LifeInsurance, &d.HealthInsurance, &d.ParentHealthInsurance, &d.HomeLoanInterest,
+		&d.SSF, &d.RMF, &d.ProvidentFund, &d.Donations, &d.EducationDonations)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query allowance declaration: %w", err)
+	}
+	return &d, nil
+}
+
+// ListAllowanceDeclarations retrieves every declaration an employee has submitted, oldest tax year first
+func (pdb *sqlPayrollDB) ListAllowanceDeclarations(ctx context.Context, empID int) ([]AllowanceDeclaration, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+        SELECT tax_year, has_spouse, num_children, num_children_born_from_2018, num_parents, num_disabled_dependants,
+               life_insurance, health_insurance, parent_health_insurance, home_loan_interest,
+               ssf, rmf, provident_fund, donations, education_donations
+        FROM allowance_declarations
+        WHERE emp_id = $1
+        ORDER BY tax_year`, empID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query allowance declarations: %w", err)
+	}
+	defer rows.Close()
+
+	declarations := []AllowanceDeclaration{}
+	for rows.Next() {
+		d := AllowanceDeclaration{EmpID: empID}
+		if err := rows.Scan(&d.TaxYear, &d.HasSpouse, &d.NumChildren, &d.NumChildrenBornFrom2018, &d.NumParents, &d.NumDisabledDependants,
+			&d.LifeInsurance, &d.HealthInsurance, &d.ParentHealthInsurance, &d.HomeLoanInterest,
+			&d.SSF, &d.RMF, &d.ProvidentFund, &d.Donations, &d.EducationDonations); err != nil {
+			return nil, fmt.Errorf("failed to scan allowance declaration: %w", err)
+		}
+		declarations = append(declarations, d)
+	}
+	return declarations, rows.Err()
+}
+
+// SaveAllowanceDeclaration validates and stores an employee's allowance declaration
+func (ps *PayrollSystem) SaveAllowanceDeclaration(ctx context.Context, d AllowanceDeclaration) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	return ps.withTx(ctx, func(tps *PayrollSystem) error {
+		if _, err := tps.db.GetEmployee(ctx, d.EmpID); err != nil {
+			return err
+		}
+		before, err := tps.db.GetAllowanceDeclaration(ctx, d.EmpID, d.TaxYear)
+		if err != nil {
+			return err
+		}
+		if err := tps.db.SaveAllowanceDeclaration(ctx, d); err != nil {
+			return err
+		}
+		action := AuditUpdate
+		if before == nil {
+			action = AuditCreate
+		}
+		return tps.audit(ctx, "allowance_declaration", fmt.Sprintf("%d/%d", d.EmpID, d.TaxYear), action, before, d)
+	})
+}
+
+// GetAllowanceDeclaration retrieves an employee's allowance declaration for a tax year
+func (ps *PayrollSystem) GetAllowanceDeclaration(ctx context.Context, empID, taxYear int) (*AllowanceDeclaration, error) {
+	return ps.db.GetAllowanceDeclaration(ctx, empID, taxYear)
+}
+
+// ComputeWithholding runs the tax engine for an employee's usual pay and declared allowances: the base
+// salary of salaried staff, and for hourly and daily staff their wage rate over a standard month
+func (ps *PayrollSystem) ComputeWithholding(ctx context.Context, empID, taxYear int) (Withholding, error) {
+	v := &ValidationError{}
+	checkTaxYear(v, taxYear)
+	if err := v.Err(); err != nil {
+		return Withholding{}, err
+	}
+	emp, err := ps.db.GetEmployee(ctx, empID)
+	if err != nil {
+		return Withholding{}, err
+	}
+	decl, err := ps.db.GetAllowanceDeclaration(ctx, empID, taxYear)
+	if err != nil {
+		return Withholding{}, err
+	}
+
+	emp = emp.withPayDefaults()
+	w := Withholding{EmpID: empID, TaxYear: taxYear, PayFrequency: emp.PayFrequency}
+	var allowances tax.Allowances
+	if decl != nil {
+		w.Declared = true
+		allowances = decl.Allowances()
+	}
+	w.Result = tax.ComputePeriod(usualPeriodPay(emp), emp.PayFrequency.PerYear(), allowances)
+	return w, nil
+}
@@ -0,0 +1,229 @@
+package payroll
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"payrollproject/internal/tax"
+)
+
+// Severity says whether an anomaly blocks approval
+type Severity string
+
+const (
+	// SeverityWarning is shown to the approver but does not block approval
+	SeverityWarning Severity = "warning"
+	// SeverityError blocks approval of the payroll record until it is corrected
+	SeverityError Severity = "error"
+)
+
+// Checks run over a pay run before it is approved
+const (
+	CheckNetPayChange       = "net_pay_change"
+	CheckNegativeNetPay     = "negative_net_pay"
+	CheckMissingBankAccount = "missing_bank_account"
+	CheckTaxMismatch        = "tax_mismatch"
+	CheckMissingPayroll     = "missing_payroll"
+	CheckDuplicatePayroll   = "duplicate_payroll"
+	CheckBelowMinimumWage   = "below_minimum_wage"
+)
+
+// AnomalyRules sets the thresholds of the pre-approval checks
+type AnomalyRules struct {
+	NetPayChangePercent      float64 // net pay moving more than this against the previous period is a warning
+	NetPayChangeErrorPercent float64 // and more than this an error, such as a commission keyed in ten times over
+	TaxTolerance             float64 // baht the withheld tax may differ from the recomputed withholding
+}
+
+// DefaultAnomalyRules are the thresholds a PayrollSystem starts with
+var DefaultAnomalyRules = AnomalyRules{NetPayChangePercent: 30, NetPayChangeErrorPercent: 200, TaxTolerance: 1}
+
+// SetAnomalyRules replaces the thresholds of the pre-approval checks
+func (ps *PayrollSystem) SetAnomalyRules(r AnomalyRules) {
+	ps.anomalyRules = r
+}
+
+// Anomaly is one finding of the pre-approval checks about an employee's pay
+type Anomaly struct {
+	Check     string   `json:"check"`
+	Severity  Severity `json:"severity"`
+	EmpID     int      `json:"emp_id"`
+	PayrollID int      `json:"payroll_id,omitempty"` // empty for an employee with no payroll record
+	Field     string   `json:"field"`                // the payroll or employee field to correct
+	Message   string   `json:"message"`
+}
+
+// AnomalyReport lists what the pre-approval checks found in a pay period's run
+type AnomalyReport struct {
+	PayMonth   Period    `json:"pay_month"`
+	Payrolls   int       `json:"payrolls"`
+	Errors     int       `json:"errors"`
+	Warnings   int       `json:"warnings"`
+	Approvable bool      `json:"approvable"` // no record has an error
+	Anomalies  []Anomaly `json:"anomalies"`
+}
+
+func (r *AnomalyReport) add(a Anomaly) {
+	r.Anomalies = append(r.Anomalies, a)
+	if a.Severity == SeverityError {
+		r.Errors++
+	} else {
+		r.Warnings++
+	}
+}
+
+// AnomalyError reports the errors that keep a payroll record from being approved; it matches ErrValidation
+type AnomalyError struct {
+	PayrollID int
+	Anomalies []Anomaly
+}
+
+func (e *AnomalyError) Error() string {
+	msgs := make([]string, len(e.Anomalies))
+	for i, a := range e.Anomalies {
+		msgs[i] = a.Message
+	}
+	return fmt.Sprintf("payroll %d cannot be approved: %s", e.PayrollID, strings.Join(msgs, "; "))
+}
+
+// Is makes errors.Is(err, ErrValidation) true
+func (e *AnomalyError) Is(target error) bool { return target == ErrValidation }
+
+// CheckPayRun runs the pre-approval checks over every payroll record of a pay period: net pay that
+// moved sharply against the previous period or is negative, a missing bank account, withheld tax
+// that differs from the recomputed withholding, a pay rate below the provincial minimum wage,
+// employees without a record and employees paid an off-cycle run besides the regular one. Pay below
+// the minimum wage is an error when the minimum wage policy blocks it and a warning otherwise. Only employees paid at the frequency of the period are
+// expected to have a record. Net pay and tax are checked on regular runs only; the withholding is
+// recomputed from the base salary annualised over the periods of the year, with the additions taxed
+// as one-off income on top.
+func (ps *PayrollSystem) CheckPayRun(ctx context.Context, payMonth Period) (AnomalyReport, error) {
+	if payMonth.IsZero() {
+		return AnomalyReport{}, &ValidationError{Fields: []FieldError{{Field: "pay_month", Message: "is required"}}}
+	}
+	payrolls, err := ps.allPayrolls(ctx, PayrollQuery{ListOptions: ListOptions{Sort: "payroll_id"}, PayMonth: payMonth})
+	if err != nil {
+		return AnomalyReport{}, err
+	}
+	previous := payMonth.Prev()
+	before, err := ps.allPayrolls(ctx, PayrollQuery{ListOptions: ListOptions{Sort: "payroll_id"}, PayMonth: previous})
+	if err != nil {
+		return AnomalyReport{}, err
+	}
+	emps, err := ps.db.GetAllEmployees(ctx)
+	if err != nil {
+		return AnomalyReport{}, err
+	}
+
+	report := AnomalyReport{PayMonth: payMonth, Payrolls: len(payrolls), Anomalies: []Anomaly{}}
+	rules := ps.anomalyRules
+	byEmp := map[int][]Payroll{}
+	for _, p := range payrolls {
+		byEmp[p.EmpID] = append(byEmp[p.EmpID], p)
+	}
+	lastNet := map[int]float64{}
+	for _, p := range before {
+		if p.RunType == RunRegular {
+			lastNet[p.EmpID] = p.NetSalary
+		}
+	}
+
+	for _, emp := range emps {
+		records := byEmp[emp.EmployeeID]
+		if len(records) == 0 {
+			// Staff who left before the period began, or whose data retention erased, are not expected
+			if emp.withPayDefaults().PayFrequency == payMonth.Kind() && emp.AnonymisedAt == nil &&
+				(emp.EndDate == "" || emp.EndDate >= payMonth.Start().String()) {
+				report.add(Anomaly{Check: CheckMissingPayroll, Severity: SeverityWarning, EmpID: emp.EmployeeID, Field: "emp_id",
+					Message: fmt.Sprintf("employee %d has no payroll record for %s", emp.EmployeeID, payMonth)})
+			}
+			continue
+		}
+		// The unique index allows one record per run type; a regular and an off-cycle run in the same
+		// period are often the same pay entered twice
+		salaryRuns := 0
+		for _, p := range records {
+			if p.RunType != RunBonus {
+				salaryRuns++
+			}
+		}
+		for _, p := range records {
+			if salaryRuns > 1 && p.RunType != RunBonus {
+				report.add(Anomaly{Check: CheckDuplicatePayroll, Severity: SeverityWarning, EmpID: p.EmpID, PayrollID: p.PayrollID, Field: "run_type",
+					Message: fmt.Sprintf("employee %d is paid %d salary runs for %s", p.EmpID, salaryRuns, payMonth)})
+			}
+			if p.NetSalary < 0 {
+				report.add(Anomaly{Check: CheckNegativeNetPay, Severity: SeverityError, EmpID: p.EmpID, PayrollID: p.PayrollID, Field: "net_salary",
+					Message: fmt.Sprintf("net pay of employee %d is negative: %.2f", p.EmpID, p.NetSalary)})
+			}
+			if strings.TrimSpace(emp.BankAccount) == "" || strings.TrimSpace(emp.AccountNum) == "" {
+				report.add(Anomaly{Check: CheckMissingBankAccount, Severity: SeverityError, EmpID: p.EmpID, PayrollID: p.PayrollID, Field: "account_num",
+					Message: fmt.Sprintf("employee %d has no bank account to pay into", p.EmpID)})
+			}
+			if p.RunType != RunRegular {
+				continue
+			}
+			minimum, err := ps.CheckMinimumWage(ctx, emp, payMonth.End())
+			if err != nil {
+				return AnomalyReport{}, err
+			}
+			if minimum != nil && !minimum.Compliant {
+				severity := SeverityWarning
+				if ps.minimumWagePolicy == MinimumWageBlock {
+					severity = SeverityError
+				}
+				report.add(Anomaly{Check: CheckBelowMinimumWage, Severity: severity, EmpID: p.EmpID, PayrollID: p.PayrollID,
+					Field: payRateField(emp.withPayDefaults()), Message: minimum.Message(p.EmpID)})
+			}
+			if last, ok := lastNet[p.EmpID]; ok && last > 0 {
+				change := (p.NetSalary - last) / last * 100
+				severity := SeverityWarning
+				if math.Abs(change) > rules.NetPayChangeErrorPercent {
+					severity = SeverityError
+				}
+				if math.Abs(change) > rules.NetPayChangePercent {
+					report.add(Anomaly{Check: CheckNetPayChange, Severity: severity, EmpID: p.EmpID, PayrollID: p.PayrollID, Field: "net_salary",
+						Message: fmt.Sprintf("net pay of employee %d changed by %+.1f%% from %.2f in %s to %.2f", p.EmpID, change, last, previous, p.NetSalary)})
+				}
+			}
+			decl, err := ps.db.GetAllowanceDeclaration(ctx, p.EmpID, taxYear(p.PayMonth, p.PayDate))
+			if err != nil {
+				return AnomalyReport{}, err
+			}
+			var allowances tax.Allowances
+			if decl != nil {
+				allowances = decl.Allowances()
+			}
+			periods := payMonth.Kind().PerYear()
+			want := round2(tax.ComputePeriod(p.BaseSalary, periods, allowances).PeriodWithholding +
+				tax.OneOffWithholding(p.BaseSalary, periods, allowances, p.TotalAdditions))
+			if math.Abs(p.TaxAmount-want) > rules.TaxTolerance {
+				report.add(Anomaly{Check: CheckTaxMismatch, Severity: SeverityWarning, EmpID: p.EmpID, PayrollID: p.PayrollID, Field: "tax_amount",
+					Message: fmt.Sprintf("tax of employee %d is %.2f but the withholding on a base salary of %.2f and additions of %.2f is %.2f",
+						p.EmpID, p.TaxAmount, p.BaseSalary, p.TotalAdditions, want)})
+			}
+		}
+	}
+
+	sort.SliceStable(report.Anomalies, func(i, j int) bool { return report.Anomalies[i].EmpID < report.Anomalies[j].EmpID })
+	report.Approvable = report.Errors == 0
+	return report, nil
+}
+
+// blockingAnomalies returns the errors the checks found in the pay run of p that concern p itself
+func (ps *PayrollSystem) blockingAnomalies(ctx context.Context, p Payroll) ([]Anomaly, error) {
+	report, err := ps.CheckPayRun(ctx, p.PayMonth)
+	if err != nil {
+		return nil, err
+	}
+	var blocking []Anomaly
+	for _, a := range report.Anomalies {
+		if a.Severity == SeverityError && a.PayrollID == p.PayrollID {
+			blocking = append(blocking, a)
+		}
+	}
+	return blocking, nil
+}
@@ -0,0 +1,164 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"payrollproject/internal/tax"
+)
+
+func TestCheckPayRunFlagsAnomaliesAndBlocksApproval(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	for _, emp := range []Employee{
+		{EmployeeID: 2, EmpName: "B", PhoneNumber: "0898765432", DeptID: 1, BaseSalary: 50000},
+		{EmployeeID: 3, EmpName: "C", PhoneNumber: "0823456789", DeptID: 1, BaseSalary: 15000,
+			BankAccount: "Krungthai Bank", AccountNum: "1234567890"},
+		{EmployeeID: 4, EmpName: "D", PhoneNumber: "0834567890", DeptID: 1, BaseSalary: 15000, EndDate: "2025-12-31"},
+	} {
+		if err := ps.AddEmployee(ctx, emp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	withheld := tax.Compute(30000, tax.Allowances{}).MonthlyWithholding
+	records := []Payroll{
+		{EmpID: 1, PayMonth: MustParsePeriod("2025-12"), BaseSalary: 30000, TaxAmount: withheld, NetSalary: 28000},
+		// Employee 1's commission was keyed in ten times over, and withheld on as if it were not there
+		{EmpID: 1, PayMonth: MustParsePeriod("2026-01"), BaseSalary: 30000, TotalAdditions: 250000, TaxAmount: withheld, NetSalary: 278000},
+		// Employee 2 has no bank account and too little tax withheld
+		{EmpID: 2, PayMonth: MustParsePeriod("2026-01"), BaseSalary: 50000, NetSalary: 49250},
+		// Employee 3 was paid twice, once in an off-cycle run with deductions above pay, and a bonus
+		{EmpID: 3, PayMonth: MustParsePeriod("2026-01"), BaseSalary: 15000, NetSalary: 14250},
+		{EmpID: 3, PayMonth: MustParsePeriod("2026-01"), RunType: RunOffCycle, BaseSalary: 15000, TotalDeductions: 20000, NetSalary: -5000},
+		{EmpID: 3, PayMonth: MustParsePeriod("2026-01"), RunType: RunBonus, TotalAdditions: 15000, NetSalary: 15000},
+	}
+	var ids []int
+	for _, p := range records {
+		added, err := ps.AddPayroll(ctx, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, added.PayrollID)
+	}
+
+	if _, err := ps.CheckPayRun(ctx, Period{}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("missing pay month error = %v, want ErrValidation", err)
+	}
+	report, err := ps.CheckPayRun(ctx, MustParsePeriod("2026-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type finding struct {
+		check     string
+		severity  Severity
+		payrollID int
+	}
+	want := map[finding]bool{
+		{CheckNetPayChange, SeverityError, ids[1]}:       true,
+		{CheckTaxMismatch, SeverityWarning, ids[1]}:      true,
+		{CheckMissingBankAccount, SeverityError, ids[2]}: true,
+		{CheckTaxMismatch, SeverityWarning, ids[2]}:      true,
+		{CheckDuplicatePayroll, SeverityWarning, ids[3]}: true,
+		{CheckDuplicatePayroll, SeverityWarning, ids[4]}: true,
+		{CheckNegativeNetPay, SeverityError, ids[4]}:     true,
+	}
+	for _, a := range report.Anomalies {
+		f := finding{a.Check, a.Severity, a.PayrollID}
+		if !want[f] {
+			t.Errorf("unexpected anomaly %+v", a)
+		}
+		delete(want, f)
+	}
+	for f := range want {
+		t.Errorf("missing anomaly %+v", f)
+	}
+	if report.Payrolls != 5 || report.Errors != 3 || report.Warnings != 4 || report.Approvable {
+		t.Errorf("report = %+v", report)
+	}
+
+	// Only employee 1 was paid in December; employee 4 left at its end, so January expects no record
+	report, err = ps.CheckPayRun(ctx, MustParsePeriod("2025-12"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing := map[int]bool{}
+	for _, a := range report.Anomalies {
+		if a.Check == CheckMissingPayroll {
+			missing[a.EmpID] = true
+		}
+	}
+	if len(missing) != 3 || !missing[2] || !missing[3] || !missing[4] || !report.Approvable {
+		t.Errorf("December report = %+v, want employees 2 to 4 missing and no errors", report)
+	}
+
+	var anomalies *AnomalyError
+	if _, err := ps.ApprovePayroll(ctx, ids[1], 0, 1); !errors.As(err, &anomalies) || len(anomalies.Anomalies) != 1 {
+		t.Fatalf("approving a tenfold net pay: error = %v, want an AnomalyError", err)
+	}
+	if p, _ := ps.GetPayroll(ctx, ids[1]); p.Status != PayrollDraft {
+		t.Errorf("blocked record status = %q, want draft", p.Status)
+	}
+	p, err := ps.GetPayroll(ctx, ids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.TotalAdditions, p.NetSalary = 25000, 53000
+	if _, err := ps.UpdatePayroll(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.ApprovePayroll(ctx, ids[1], 0, 1); err != nil {
+		t.Fatalf("approving a corrected record: %v", err)
+	}
+
+	// The checks ran at approval, so the approved record cannot take the tenfold commission back
+	if p, err = ps.GetPayroll(ctx, ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	p.TotalAdditions, p.NetSalary = 250000, 278000
+	if _, err := ps.UpdatePayroll(ctx, p); !errors.Is(err, ErrConflict) {
+		t.Fatalf("reintroducing the tenfold net pay after approval: error = %v, want ErrConflict", err)
+	}
+	report, err = ps.CheckPayRun(ctx, MustParsePeriod("2026-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range report.Anomalies {
+		if a.PayrollID == ids[1] && a.Severity == SeverityError {
+			t.Errorf("approved record has blocking anomaly %+v", a)
+		}
+	}
+}
+
+func TestCheckPayRunTaxesAPeriodInTheYearItIsPaid(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	if err := ps.AddEmployee(ctx, Employee{EmployeeID: 2, EmpName: "B", PhoneNumber: "0898765432", DeptID: 1, BaseSalary: 60000,
+		PayFrequency: Weekly, BankAccount: "Government Savings Bank", AccountNum: "210987654321"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.SaveAllowanceDeclaration(ctx, AllowanceDeclaration{EmpID: 2, TaxYear: 2026, HasSpouse: true}); err != nil {
+		t.Fatal(err)
+	}
+	// The first ISO week of 2026 starts on 29 December 2025 and is paid in January
+	week := MustParsePeriod("2026-W01")
+	calc, err := ps.CalculatePayroll(ctx, PayInput{EmpID: 2, PayMonth: week, PayDate: NewDate(2026, 1, 9)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := tax.ComputePeriod(calc.Earnings, 52, tax.Allowances{HasSpouse: true}).PeriodWithholding; calc.Payroll.TaxAmount != want {
+		t.Fatalf("tax = %v, want %v under the 2026 declaration", calc.Payroll.TaxAmount, want)
+	}
+	if _, err := ps.AddPayroll(ctx, calc.Payroll); err != nil {
+		t.Fatal(err)
+	}
+	report, err := ps.CheckPayRun(ctx, week)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range report.Anomalies {
+		if a.Check == CheckTaxMismatch {
+			t.Errorf("unexpected anomaly %+v", a)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package payroll
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditCreate    = "create"
+	AuditUpdate    = "update"
+	AuditDelete    = "delete"
+	AuditApprove   = "approve"
+	AuditReject    = "reject"
+	AuditCancel    = "cancel"
+	AuditExport    = "export"    // a data subject export of an employee's records
+	AuditAnonymise = "anonymise" // retention erased an employee's personal fields
+)
+
+// AuditContext identifies who is making changes through a context; PayrollSystem copies it into
+// every audit entry it writes. A zero UserID is recorded as the system itself.
+type AuditContext struct {
+	UserID    int
+	Username  string
+	RequestID string
+}
+
+type auditContextKey struct{}
+
+// WithAuditContext returns a copy of ctx carrying the caller and request ID for the audit log
+func WithAuditContext(ctx context.Context, a AuditContext) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, a)
+}
+
+// AuditContextFrom returns the AuditContext carried by ctx, or the zero value
+func AuditContextFrom(ctx context.Context) AuditContext {
+	a, _ := ctx.Value(auditContextKey{}).(AuditContext)
+	return a
+}
+
+// AuditEntry is one change recorded in the append-only audit log. Before is empty for a create and
+// After for a delete. Each entry's Hash covers its content and the previous entry's hash, so
+// editing or removing a stored entry breaks the chain from that point on.
+type AuditEntry struct {
+	AuditID    int                    `json:"audit_id"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	ActorID    *int                   `json:"actor_id,omitempty"`
+	Actor      string                 `json:"actor"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	Entity     string                 `json:"entity"`
+	EntityID   string                 `json:"entity_id"`
+	Action     string                 `json:"action"`
+	Before     json.RawMessage        `json:"before,omitempty"`
+	After      json.RawMessage        `json:"after,omitempty"`
+	Changes    map[string]FieldChange `json:"changes,omitempty"`
+	PrevHash   string                 `json:"prev_hash"`
+	Hash       string                 `json:"hash"`
+}
+
+// FieldChange is one top-level field that differs between an entry's before and after snapshots
+type FieldChange struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// computeHash returns the hash sealing e onto the entry before it
+func (e AuditEntry) computeHash() string {
+	content, _ := json.Marshal(struct {
+		OccurredAt string          `json:"occurred_at"`
+		ActorID    *int            `json:"actor_id"`
+		Actor      string          `json:"actor"`
+		RequestID  string          `json:"request_id"`
+		Entity     string          `json:"entity"`
+		EntityID   string          `json:"entity_id"`
+		Action     string          `json:"action"`
+		Before     json.RawMessage `json:"before"`
+		After      json.RawMessage `json:"after"`
+	}{e.OccurredAt.UTC().Format(time.RFC3339Nano), e.ActorID, e.Actor, e.RequestID, e.Entity, e.EntityID, e.Action,
+		nullJSON(e.Before), nullJSON(e.After)})
+	sum := sha256.Sum256(append([]byte(e.PrevHash), content...))
+	return hex.EncodeToString(sum[:])
+}
+
+// seal links e to the previous hash and stamps it; stores call it while holding the log's write lock
+func (e *AuditEntry) seal(prevHash string) {
+	e.PrevHash = prevHash
+	// Microseconds survive a round trip through every backend's timestamp type
+	e.OccurredAt = time.Now().UTC().Truncate(time.Microsecond)
+	e.Hash = e.computeHash()
+}
+
+func nullJSON(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return json.RawMessage("null")
+	}
+	return raw
+}
+
+// diff fills in Changes from the snapshots
+func (e *AuditEntry) diff() {
+	var before, after map[string]json.RawMessage
+	_ = json.Unmarshal(e.Before, &before)
+	_ = json.Unmarshal(e.After, &after)
+	changes := map[string]FieldChange{}
+	for k, v := range before {
+		if w, ok := after[k]; !ok || !bytes.Equal(v, w) {
+			changes[k] = FieldChange{Before: v, After: after[k]}
+		}
+	}
+	for k, w := range after {
+		if _, ok := before[k]; !ok {
+			changes[k] = FieldChange{After: w}
+		}
+	}
+	if len(changes) > 0 {
+		e.Changes = changes
+	}
+}
+
+// AuditQuery filters the audit log
+type AuditQuery struct {
+	ListOptions
+	Entity    string `form:"entity"`
+	EntityID  string `form:"id"`
+	ActorID   int    `form:"actor_id"`
+	RequestID string `form:"request_id"`
+}
+
+var auditSorts = map[string]sortField[AuditEntry]{
+	"audit_id": {"a.audit_id", func(e AuditEntry) any { return e.AuditID }},
+}
+
+// auditFilters adds the AuditQuery filters to b
+func auditFilters(b *queryBuilder, q AuditQuery) {
+	if q.Entity != "" {
+		b.where("a.entity = ?", q.Entity)
+	}
+	if q.EntityID != "" {
+		b.where("a.entity_id = ?", q.EntityID)
+	}
+	if q.ActorID != 0 {
+		b.where("a.actor_id = ?", q.ActorID)
+	}
+	if q.RequestID != "" {
+		b.where("a.request_id = ?", q.RequestID)
+	}
+}
+
+// auditLockKey is the Postgres advisory lock serialising appends to the hash chain
+const auditLockKey = 0x6175646974 // "audit"
+
+// AppendAudit seals an entry onto the end of the chain and stores it. It must run inside WithTx so
+// the chain lock is held until the change being recorded commits.
+func (pdb *sqlPayrollDB) AppendAudit(ctx context.Context, e AuditEntry) error {
+	if pdb.dialect == dialectPostgres {
+		if _, err := pdb.db.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", auditLockKey); err != nil {
+			return fmt.Errorf("failed to lock audit log: %w", err)
+		}
+	}
+	var prev string
+	err := pdb.db.QueryRowContext(ctx, "SELECT hash FROM audit_log ORDER BY audit_id DESC LIMIT 1").Scan(&prev)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to read audit chain: %w", err)
+	}
+	e.seal(prev)
+	_, err = pdb.db.ExecContext(ctx, `
+        INSERT INTO audit_log (occurred_at, actor_id, actor, request_id, entity, entity_id, action, before_data, after_data, prev_hash, hash)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		e.OccurredAt, nullableID(e.ActorID), e.Actor, e.RequestID, e.Entity, e.EntityID, e.Action,
+		nullableJSON(e.Before), nullableJSON(e.After), e.PrevHash, e.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", constraintError(err, "audit entry", e.PrevHash, nil))
+	}
+	return nil
+}
+
+const auditColumns = "a.audit_id, a.occurred_at, a.actor_id, a.actor, a.request_id, a.entity, a.entity_id, a.action, a.before_data, a.after_data, a.prev_hash, a.hash"
+
+func scanAuditEntry(row interface{ Scan(...any) error }) (AuditEntry, error) {
+	var e AuditEntry
+	var before, after sql.NullString
+	err := row.Scan(&e.AuditID, &e.OccurredAt, nullIntScanner{&e.ActorID}, &e.Actor, &e.RequestID, &e.Entity, &e.EntityID,
+		&e.Action, &before, &after, &e.PrevHash, &e.Hash)
+	e.OccurredAt = e.OccurredAt.UTC()
+	if before.Valid {
+		e.Before = json.RawMessage(before.String)
+	}
+	if after.Valid {
+		e.After = json.RawMessage(after.String)
+	}
+	e.diff()
+	return e, err
+}
+
+// ListAudit returns one page of audit entries matching q, oldest first unless sorted by -audit_id
+func (pdb *sqlPayrollDB) ListAudit(ctx context.Context, q AuditQuery) (Page[AuditEntry], error) {
+	b := &queryBuilder{}
+	auditFilters(b, q)
+	return listPage(ctx, pdb, "audit log", auditColumns, "FROM audit_log a", b,
+		q.ListOptions, auditSorts, "audit_id", scanAuditEntry)
+}
+
+func nullableJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+// personalFields are the snapshot fields of each audited entity that identify or contact a person.
+// Retention has to be able to erase them but the log is append-only, so they never enter it: a set
+// field is stored as redactedValue, or as changedValue where it differs from the before snapshot.
+var personalFields = map[string][]string{
+	"employee":       {"emp_name", "phone_number", "bank_account", "account_num", "national_id"},
+	"profile_change": {"phone_number", "bank_account", "account_num"},
+	"leave_request":  {"reason"},
+}
+
+// Markers standing in for personal fields in audit snapshots
+const (
+	redactedValue = "[redacted]"
+	changedValue  = "[redacted: changed]"
+)
+
+// snapshot encodes a record for the audit log; nil and typed nil pointers encode as empty
+func snapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	return raw, nil
+}
+
+// redact replaces the personal fields of an entity's before and after snapshots with markers
+func redact(entity string, before, after json.RawMessage) (json.RawMessage, json.RawMessage, error) {
+	fields := personalFields[entity]
+	if len(fields) == 0 {
+		return before, after, nil
+	}
+	var b, a map[string]json.RawMessage
+	if err := decodeSnapshot(before, &b); err != nil {
+		return nil, nil, err
+	}
+	if err := decodeSnapshot(after, &a); err != nil {
+		return nil, nil, err
+	}
+	for _, f := range fields {
+		old, hadOld := b[f]
+		if hadOld {
+			b[f] = redactedJSON(old, redactedValue)
+		}
+		if v, ok := a[f]; ok {
+			marker := redactedValue
+			if hadOld && !bytes.Equal(old, v) {
+				marker = changedValue
+			}
+			a[f] = redactedJSON(v, marker)
+		}
+	}
+	var err error
+	if before, err = encodeSnapshot(b); err != nil {
+		return nil, nil, err
+	}
+	if after, err = encodeSnapshot(a); err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+// redactedJSON returns marker in place of a field's value, or the value itself when it holds
+// nothing personal
+func redactedJSON(v json.RawMessage, marker string) json.RawMessage {
+	switch string(v) {
+	case "null", `""`:
+		return v
+	}
+	raw, _ := json.Marshal(marker)
+	return raw
+}
+
+func decodeSnapshot(raw json.RawMessage, fields *map[string]json.RawMessage) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, fields); err != nil {
+		return fmt.Errorf("failed to decode audit snapshot: %w", err)
+	}
+	return nil
+}
+
+func encodeSnapshot(fields map[string]json.RawMessage) (json.RawMessage, error) {
+	if fields == nil {
+		return nil, nil
+	}
+	return snapshot(fields)
+}
+
+// audit records a change to an entity made by the caller in ctx. PayrollSystem calls it inside the
+// transaction making the change, so the entry is stored if and only if the change is.
+func (ps *PayrollSystem) audit(ctx context.Context, entity string, id any, action string, before, after any) error {
+	a := AuditContextFrom(ctx)
+	e := AuditEntry{Actor: "system", RequestID: a.RequestID, Entity: entity, EntityID: fmt.Sprint(id), Action: action}
+	if a.UserID != 0 {
+		e.ActorID, e.Actor = &a.UserID, a.Username
+	}
+	var err error
+	if e.Before, err = snapshot(before); err != nil {
+		return err
+	}
+	if e.After, err = snapshot(after); err != nil {
+		return err
+	}
+	if e.Before, e.After, err = redact(entity, e.Before, e.After); err != nil {
+		return err
+	}
+	return ps.db.AppendAudit(ctx, e)
+}
+
+// reviewAction is the audit action for a request moving to status
+func reviewAction(status string) string {
+	switch status {
+	case RequestApproved:
+		return AuditApprove
+	case RequestRejected:
+		return AuditReject
+	}
+	return AuditCancel
+}
+
+// ListAudit returns one page of the audit log, of DefaultAuditPageSize entries unless q sets a limit
+func (ps *PayrollSystem) ListAudit(ctx context.Context, q AuditQuery) (Page[AuditEntry], error) {
+	if q.Limit == 0 {
+		q.Limit = DefaultAuditPageSize
+	}
+	return ps.db.ListAudit(ctx, q)
+}
+
+// AuditVerification is the result of checking the audit log's hash chain
+type AuditVerification struct {
+	Entries  int    `json:"entries"`
+	Valid    bool   `json:"valid"`
+	BrokenAt int    `json:"broken_at,omitempty"` // audit_id of the first entry that fails to verify
+	Reason   string `json:"reason,omitempty"`
+}
+
+// VerifyAudit walks the audit log from the start, recomputing each hash and checking it links to
+// the entry before it
+func (ps *PayrollSystem) VerifyAudit(ctx context.Context) (AuditVerification, error) {
+	var v AuditVerification
+	prev := ""
+	q := AuditQuery{ListOptions: ListOptions{Limit: MaxPageSize}}
+	for {
+		page, err := ps.db.ListAudit(ctx, q)
+		if err != nil {
+			return AuditVerification{}, err
+		}
+		for _, e := range page.Items {
+			v.Entries++
+			switch {
+			case e.PrevHash != prev:
+				v.BrokenAt, v.Reason = e.AuditID, "previous hash does not match the entry before it"
+			case e.computeHash() != e.Hash:
+				v.BrokenAt, v.Reason = e.AuditID, "content does not match its hash"
+			}
+			if v.BrokenAt != 0 {
+				return v, nil
+			}
+			prev = e.Hash
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	v.Valid = true
+	return v, nil
+}
@@ -0,0 +1,83 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestUpdateEmployeeIsAuditedAsTheCaller(t *testing.T) {
+	ps := newSelfServiceSystem(t)
+	ctx := WithAuditContext(context.Background(), AuditContext{UserID: 4, Username: "hr", RequestID: "req-42"})
+
+	emp, err := ps.GetEmployee(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	emp.BaseSalary = 32000
+	if _, err := ps.UpdateEmployee(ctx, emp); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := ps.ListAudit(ctx, AuditQuery{Entity: "employee", EntityID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 || page.Items[0].Action != AuditCreate || page.Items[0].Actor != "system" {
+		t.Fatalf("employee 1 audit = %+v, want create then update", page.Items)
+	}
+	e := page.Items[1]
+	if e.Action != AuditUpdate || e.ActorID == nil || *e.ActorID != 4 || e.Actor != "hr" || e.RequestID != "req-42" {
+		t.Fatalf("unexpected update entry: %+v", e)
+	}
+	if c, ok := e.Changes["base_salary"]; !ok || string(c.Before) != "30000" || string(c.After) != "32000" {
+		t.Fatalf("changes = %+v, want base_salary 30000 -> 32000", e.Changes)
+	}
+}
+
+func TestFailedChangeWritesNoAuditEntry(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	before, _ := ps.ListAudit(ctx, AuditQuery{})
+
+	if err := ps.AddEmployee(ctx, Employee{EmployeeID: 2, EmpName: "B", PhoneNumber: "0812345678", DeptID: 99, BaseSalary: 20000,
+		BankAccount: "Government Savings Bank", AccountNum: "123456789012"}); err == nil {
+		t.Fatal("AddEmployee into a missing department succeeded")
+	}
+	if err := ps.DeleteEmployee(ctx, 99); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("DeleteEmployee(99) error = %v, want ErrNotFound", err)
+	}
+	if after, _ := ps.ListAudit(ctx, AuditQuery{}); after.Total != before.Total {
+		t.Fatalf("audit entries = %d after failed changes, want %d", after.Total, before.Total)
+	}
+}
+
+func TestVerifyAuditDetectsTampering(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryPayrollDB()
+	ps := NewPayrollSystem(db)
+	for _, d := range []Department{{DeptID: 1, DeptName: "Ops"}, {DeptID: 2, DeptName: "Finance"}} {
+		if err := ps.AddDepartment(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ps.DeleteDepartment(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	v, err := ps.VerifyAudit(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Valid || v.Entries != 3 {
+		t.Fatalf("verification = %+v, want 3 valid entries", v)
+	}
+
+	db.state.audit[1].After = []byte(`{"dept_id":2,"dept_name":"Sales"}`)
+	if v, _ := ps.VerifyAudit(ctx); v.Valid || v.BrokenAt != 2 {
+		t.Fatalf("verification after editing entry 2 = %+v, want broken at 2", v)
+	}
+	db.state.audit = append(db.state.audit[:1], db.state.audit[2:]...)
+	if v, _ := ps.VerifyAudit(ctx); v.Valid || v.BrokenAt != 3 {
+		t.Fatalf("verification after removing entry 2 = %+v, want broken at 3", v)
+	}
+}
@@ -0,0 +1,220 @@
+package payroll
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"payrollproject/internal/tax"
+)
+
+// Standard working time, used to estimate the usual pay of hourly and daily staff when no hours or
+// days have been recorded, such as for their withholding forecast
+const (
+	StandardDaysPerMonth = 26
+	StandardHoursPerDay  = 8
+)
+
+// PayInput is what one employee's pay for a period is calculated from
+type PayInput struct {
+	EmpID       int     `json:"emp_id" validate:"gt=0"`
+	PayMonth    Period  `json:"pay_month" validate:"required"` // a period of the employee's pay frequency
+	PayDate     Date    `json:"pay_date"`                      // defaults to the pay calendar's pay date
+	DaysWorked  float64 `json:"days_worked" validate:"gte=0"`  // for daily pay
+	HoursWorked float64 `json:"hours_worked" validate:"gte=0"` // for hourly pay
+	Additions   float64 `json:"total_additions" validate:"gte=0"`
+	Deductions  float64 `json:"total_deductions" validate:"gte=0"` // besides social security, which is added
+}
+
+// PayCalculation is a calculated payroll record and how it was worked out. The record is not
+// stored; it is posted to the payroll records once checked.
+type PayCalculation struct {
+	Payroll        Payroll    `json:"payroll"`
+	PayFrequency   PeriodKind `json:"pay_frequency"`
+	PayType        string     `json:"pay_type"`
+	PeriodsPerYear int        `json:"periods_per_year"`
+	Earnings       float64    `json:"earnings"`                    // the base_salary of the record
+	SocialSecurity float64    `json:"social_security"`             // the employee's contribution, included in total_deductions
+	AnnualIncome   float64    `json:"annual_income"`               // the earnings annualised over the periods of the year
+	AnnualTax      float64    `json:"annual_tax"`                  // on the annualised earnings, spread over the periods of the year
+	AdditionsTax   float64    `json:"additions_tax"`               // on the additions; tax_amount is this plus the period's share of annual_tax
+	WorkingDays    int        `json:"working_days"`                // of the period at the employee's work site
+	DaysPaid       int        `json:"working_days_paid,omitempty"` // for a salary, fewer than working_days when the employee left during the period
+
+	// MinimumWage compares the pay rate with the minimum wage in force at the end of the period;
+	// it is empty when the employee has no work province or the province no minimum wage
+	MinimumWage *MinimumWageCheck `json:"minimum_wage,omitempty"`
+}
+
+// Validate checks a pay input before it is calculated
+func (in PayInput) Validate() error { return validateStruct(in) }
+
+// periodEarnings works out an employee's earnings for a period from their pay type
+func periodEarnings(emp Employee, in PayInput) (float64, error) {
+	v := &ValidationError{}
+	days := in.PayMonth.End().Sub(in.PayMonth.Start().Time).Hours()/24 + 1
+	var earnings float64
+	switch emp.PayType {
+	case PayHourly:
+		if in.DaysWorked != 0 {
+			v.Add("days_worked", "employee %d is paid by the hour; give hours_worked", emp.EmployeeID)
+		}
+		if in.HoursWorked > days*24 {
+			v.Add("hours_worked", "must be at most %g, the hours in %s", days*24, in.PayMonth)
+		}
+		earnings = emp.WageRate * in.HoursWorked
+	case PayDaily:
+		if in.HoursWorked != 0 {
+			v.Add("hours_worked", "employee %d is paid by the day; give days_worked", emp.EmployeeID)
+		}
+		if in.DaysWorked > days {
+			v.Add("days_worked", "must be at most %g, the days in %s", days, in.PayMonth)
+		}
+		earnings = emp.WageRate * in.DaysWorked
+	default:
+		if in.DaysWorked != 0 || in.HoursWorked != 0 {
+			v.Add("days_worked", "employee %d is salaried; days and hours worked do not change their pay", emp.EmployeeID)
+		}
+		earnings = emp.BaseSalary * (12 / float64(emp.PayFrequency.PerYear()))
+	}
+	return round2(earnings), v.Err()
+}
+
+// prorateSalary pays a salaried employee who left during a period for the working days up to their
+// last day, returning the prorated earnings and the working days paid
+func prorateSalary(emp Employee, wd WorkingDays, earnings float64) (float64, int) {
+	if emp.EndDate == "" || emp.EndDate >= wd.End.String() || wd.WorkingDays == 0 {
+		return earnings, wd.WorkingDays
+	}
+	paid := 0
+	for _, d := range wd.Dates {
+		if d.String() <= emp.EndDate {
+			paid++
+		}
+	}
+	return round2(earnings * float64(paid) / float64(wd.WorkingDays)), paid
+}
+
+// usualPeriodPay estimates what an employee earns in a pay period when they work their usual time
+func usualPeriodPay(emp Employee) float64 {
+	monthly := emp.BaseSalary
+	switch emp.PayType {
+	case PayHourly:
+		monthly = emp.WageRate * StandardDaysPerMonth * StandardHoursPerDay
+	case PayDaily:
+		monthly = emp.WageRate * StandardDaysPerMonth
+	}
+	return monthly * (12 / float64(emp.PayFrequency.PerYear()))
+}
+
+// CalculatePayroll works out an employee's pay for a period: earnings from their base salary, or
+// from their wage rate and the hours or days worked, less social security and the withholding tax
+// of the earnings annualised over the periods of the year at the employee's pay frequency. Additions
+// are taxed as one-off income on top of the annualised earnings. A salary
+// is prorated over the working days of the employee's site for a leaver, and the pay date moved off
+// holidays. A pay rate below the minimum wage of the employee's province is refused when
+// underpayment is blocked, and otherwise flagged in the calculation.
+func (ps *PayrollSystem) CalculatePayroll(ctx context.Context, in PayInput) (PayCalculation, error) {
+	if err := in.Validate(); err != nil {
+		return PayCalculation{}, err
+	}
+	emp, err := ps.db.GetEmployee(ctx, in.EmpID)
+	if err != nil {
+		return PayCalculation{}, err
+	}
+	emp = emp.withPayDefaults()
+	if in.PayMonth.Kind() != emp.PayFrequency {
+		return PayCalculation{}, &ValidationError{Fields: []FieldError{{Field: "pay_month",
+			Message: fmt.Sprintf("employee %d is paid %s; give a period such as %s", emp.EmployeeID,
+				frequencyName(emp.PayFrequency), PeriodOf(emp.PayFrequency, in.PayMonth.Start().Time))}}}
+	}
+	if emp.EndDate != "" && emp.EndDate < in.PayMonth.Start().String() {
+		return PayCalculation{}, &ValidationError{Fields: []FieldError{{Field: "pay_month",
+			Message: fmt.Sprintf("employee %d left on %s, before %s", emp.EmployeeID, emp.EndDate, in.PayMonth)}}}
+	}
+	earnings, err := periodEarnings(emp, in)
+	if err != nil {
+		return PayCalculation{}, err
+	}
+	wd, err := ps.WorkingDaysBetween(ctx, emp.SiteID, in.PayMonth.Start(), in.PayMonth.End())
+	if err != nil {
+		return PayCalculation{}, err
+	}
+	daysPaid := 0
+	if emp.PayType == PaySalaried {
+		earnings, daysPaid = prorateSalary(emp, wd, earnings)
+	}
+	minimum, err := ps.CheckMinimumWage(ctx, emp, in.PayMonth.End())
+	if err != nil {
+		return PayCalculation{}, err
+	}
+	if minimum != nil && !minimum.Compliant && ps.minimumWagePolicy == MinimumWageBlock {
+		return PayCalculation{}, &ValidationError{Fields: []FieldError{{Field: payRateField(emp), Message: minimum.Message(emp.EmployeeID)}}}
+	}
+
+	payDate := in.PayDate
+	if payDate.IsZero() {
+		calendar, err := ps.GetPayCalendar(ctx, emp.PayFrequency)
+		if err != nil {
+			return PayCalculation{}, err
+		}
+		holidays, err := ps.companyHolidays(ctx, in.PayMonth.End().Year())
+		if err != nil {
+			return PayCalculation{}, err
+		}
+		payDate = calendar.PayDate(in.PayMonth, holidays)
+	}
+
+	decl, err := ps.db.GetAllowanceDeclaration(ctx, emp.EmployeeID, taxYear(in.PayMonth, payDate))
+	if err != nil {
+		return PayCalculation{}, err
+	}
+	var allowances tax.Allowances
+	if decl != nil {
+		allowances = decl.Allowances()
+	}
+	periods := emp.PayFrequency.PerYear()
+	res := tax.ComputePeriod(earnings, periods, allowances)
+	additionsTax := tax.OneOffWithholding(earnings, periods, allowances, in.Additions)
+	sso := tax.SocialSecurityPeriodContribution(earnings, periods)
+	p := Payroll{
+		EmpID:           emp.EmployeeID,
+		PayMonth:        in.PayMonth,
+		PayDate:         payDate,
+		RunType:         RunRegular,
+		BaseSalary:      earnings,
+		TaxAmount:       round2(res.PeriodWithholding + additionsTax),
+		TotalAdditions:  round2(in.Additions),
+		TotalDeductions: round2(in.Deductions + sso),
+	}
+	p.NetSalary = round2(p.BaseSalary + p.TotalAdditions - p.TotalDeductions - p.TaxAmount)
+	return PayCalculation{
+		Payroll:        p,
+		PayFrequency:   emp.PayFrequency,
+		PayType:        emp.PayType,
+		PeriodsPerYear: periods,
+		Earnings:       earnings,
+		SocialSecurity: sso,
+		AnnualIncome:   res.AnnualIncome,
+		AnnualTax:      res.AnnualTax,
+		AdditionsTax:   additionsTax,
+		WorkingDays:    wd.WorkingDays,
+		DaysPaid:       daysPaid,
+		MinimumWage:    minimum,
+	}, nil
+}
+
+// frequencyName describes a pay frequency in words
+func frequencyName(k PeriodKind) string {
+	switch k {
+	case SemiMonthly:
+		return "twice a month"
+	case Weekly:
+		return "weekly"
+	case Biweekly:
+		return "every two weeks"
+	}
+	return "monthly"
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
@@ -0,0 +1,106 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"payrollproject/internal/tax"
+)
+
+func TestCalculatePayrollByPayType(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	for _, emp := range []Employee{
+		{EmployeeID: 2, EmpName: "B", PhoneNumber: "0898765432", DeptID: 1, PayFrequency: Biweekly, PayType: PayDaily, WageRate: 400},
+		{EmployeeID: 3, EmpName: "C", PhoneNumber: "0823456789", DeptID: 1, PayFrequency: SemiMonthly, BaseSalary: 60000},
+	} {
+		if err := ps.AddEmployee(ctx, emp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	monthly, err := ps.CalculatePayroll(ctx, PayInput{EmpID: 1, PayMonth: MustParsePeriod("2026-01"), Additions: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The 1,000 of additions is taxed as one-off income, adding 50 to the year's tax
+	withheld := round2(tax.Compute(30000, tax.Allowances{}).MonthlyWithholding + 50)
+	if monthly.AdditionsTax != 50 {
+		t.Fatalf("tax on additions = %v, want 50", monthly.AdditionsTax)
+	}
+	if p := monthly.Payroll; p.BaseSalary != 30000 || p.TaxAmount != withheld || p.TotalDeductions != 750 ||
+		p.NetSalary != round2(31000-750-withheld) || p.PayDate != NewDate(2026, 1, 30) {
+		t.Fatalf("monthly salary = %+v", p)
+	}
+
+	daily, err := ps.CalculatePayroll(ctx, PayInput{EmpID: 2, PayMonth: MustParsePeriod("2026-F01"), DaysWorked: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A fortnight of daily wages is taxed as 26 such fortnights a year, which is below the allowances
+	if p := daily.Payroll; p.BaseSalary != 4800 || p.TaxAmount != 0 || p.TotalDeductions != 240 || p.NetSalary != 4560 ||
+		p.PayDate != NewDate(2026, 1, 30) || daily.AnnualIncome != 4800*26 || daily.PeriodsPerYear != 26 {
+		t.Fatalf("daily wage = %+v, calculation %+v", p, daily)
+	}
+
+	half, err := ps.CalculatePayroll(ctx, PayInput{EmpID: 3, PayMonth: MustParsePeriod("2026-02-H1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := half.Payroll; p.BaseSalary != 30000 || p.TaxAmount != round2(tax.Compute(60000, tax.Allowances{}).AnnualTax/24) || p.TotalDeductions != 375 {
+		t.Fatalf("semi-monthly salary = %+v", p)
+	}
+	if err := half.Payroll.Validate(); err != nil {
+		t.Fatalf("calculated record does not validate: %v", err)
+	}
+
+	for name, in := range map[string]PayInput{
+		"period of another frequency": {EmpID: 2, PayMonth: MustParsePeriod("2026-01"), DaysWorked: 20},
+		"hours for daily pay":         {EmpID: 2, PayMonth: MustParsePeriod("2026-F01"), HoursWorked: 80},
+		"more days than the period":   {EmpID: 2, PayMonth: MustParsePeriod("2026-F01"), DaysWorked: 15},
+		"days for a salary":           {EmpID: 1, PayMonth: MustParsePeriod("2026-01"), DaysWorked: 20},
+		"no period":                   {EmpID: 1},
+	} {
+		if _, err := ps.CalculatePayroll(ctx, in); !errors.Is(err, ErrValidation) {
+			t.Errorf("%s: error = %v, want ErrValidation", name, err)
+		}
+	}
+
+	if err := (Employee{EmployeeID: 4, EmpName: "D", PhoneNumber: "0834567890", DeptID: 1, PayType: PayHourly}).Validate(); !errors.Is(err, ErrValidation) {
+		t.Fatalf("hourly employee without a wage rate error = %v, want ErrValidation", err)
+	}
+}
+
+func TestPayCalendarSchedule(t *testing.T) {
+	ctx := context.Background()
+	ps := NewPayrollSystem(NewMemoryPayrollDB())
+	weekly, err := ps.GetPayCalendar(ctx, Weekly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule := weekly.Schedule(2026, nil)
+	// The week of 22-28 December 2025 is paid on Friday 2 January 2026
+	if first := schedule[0]; first.Period != MustParsePeriod("2025-W52") || first.PayDate != NewDate(2026, 1, 2) {
+		t.Fatalf("first weekly pay of 2026 = %+v", first)
+	}
+	for _, s := range schedule {
+		if s.PayDate.Year() != 2026 || s.PayDate.Weekday() != time.Friday {
+			t.Fatalf("weekly pay date %s of %s", s.PayDate, s.Period)
+		}
+	}
+
+	saved, err := ps.SavePayCalendar(ctx, PayCalendar{Frequency: Monthly, PayDaysAfterEnd: -6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	monthly := saved.Schedule(2026, nil)
+	// 25 January 2026 is a Sunday
+	if len(monthly) != 12 || monthly[0].PayDate != NewDate(2026, 1, 23) || monthly[2].PayDate != NewDate(2026, 3, 25) {
+		t.Fatalf("monthly schedule = %+v", monthly)
+	}
+	if _, err := ps.SavePayCalendar(ctx, PayCalendar{Frequency: "daily"}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("unknown frequency error = %v, want ErrValidation", err)
+	}
+}
@@ -0,0 +1,58 @@
+package payroll
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"payrollproject/internal/tax"
+)
+
+// TaxCertificate is the withholding tax certificate (หนังสือรับรองการหักภาษี ณ ที่จ่าย, 50 Tawi)
+// an employer issues for one employee's tax year, summed from that year's approved payroll records
+type TaxCertificate struct {
+	Employee       Employee  `json:"employee"`
+	TaxYear        int       `json:"tax_year"`
+	Payrolls       []Payroll `json:"payrolls"`
+	Income         float64   `json:"income"`          // base salary plus additions, section 40(1)
+	TaxWithheld    float64   `json:"tax_withheld"`    // sum of tax_amount
+	SocialSecurity float64   `json:"social_security"` // employee contributions to the Social Security Fund
+}
+
+// TaxCertificate builds an employee's 50 Tawi certificate for a calendar tax year; a year with no
+// approved payroll records is ErrNotFound. Social security is only deducted from regular runs, and
+// never more than the year's legal maximum.
+func (ps *PayrollSystem) TaxCertificate(ctx context.Context, empID, taxYear int) (TaxCertificate, error) {
+	v := &ValidationError{}
+	checkTaxYear(v, taxYear)
+	if err := v.Err(); err != nil {
+		return TaxCertificate{}, err
+	}
+	emp, err := ps.db.GetEmployee(ctx, empID)
+	if err != nil {
+		return TaxCertificate{}, err
+	}
+	cert := TaxCertificate{Employee: emp, TaxYear: taxYear}
+	cert.Payrolls, err = ps.allPayrolls(ctx, PayrollQuery{
+		ListOptions:  ListOptions{Sort: "pay_month"},
+		EmpID:        empID,
+		PayMonthFrom: MonthPeriod(taxYear, time.January),
+		PayMonthTo:   MonthPeriod(taxYear, time.December),
+		Status:       PayrollApproved,
+	})
+	if err != nil {
+		return TaxCertificate{}, err
+	}
+	if len(cert.Payrolls) == 0 {
+		return TaxCertificate{}, &NotFoundError{Entity: "tax certificate", ID: taxYear}
+	}
+	for _, p := range cert.Payrolls {
+		cert.Income += p.BaseSalary + p.TotalAdditions
+		cert.TaxWithheld += p.TaxAmount
+		if p.RunType == RunRegular {
+			cert.SocialSecurity += tax.SocialSecurityPeriodContribution(p.BaseSalary, p.PayMonth.Kind().PerYear())
+		}
+	}
+	cert.SocialSecurity = math.Min(cert.SocialSecurity, 12*tax.SocialSecurityMonthlyCap)
+	return cert, nil
+}
@@ -0,0 +1,1077 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// runConformance exercises the semantics every PayrollDatabase implementation must share.
+// newDB must return an empty database.
+func runConformance(t *testing.T, newDB func(t *testing.T) PayrollDatabase) {
+	ctx := context.Background()
+
+	seed := func(t *testing.T, db PayrollDatabase) {
+		t.Helper()
+		for _, dept := range []Department{{DeptID: 10, DeptName: "Ops"}, {DeptID: 20, DeptName: "Finance"}} {
+			if err := db.AddDepartment(ctx, dept); err != nil {
+				t.Fatalf("AddDepartment(%d): %v", dept.DeptID, err)
+			}
+		}
+		for _, emp := range []Employee{
+			{EmployeeID: 2, EmpName: "B", DeptID: 10, BaseSalary: 20000},
+			{EmployeeID: 1, EmpName: "A", DeptID: 10, BaseSalary: 30000},
+			{EmployeeID: 3, EmpName: "C", DeptID: 20, BaseSalary: 40000},
+		} {
+			if err := db.AddEmployee(ctx, emp); err != nil {
+				t.Fatalf("AddEmployee(%d): %v", emp.EmployeeID, err)
+			}
+		}
+	}
+
+	numEmp := func(t *testing.T, db PayrollDatabase, deptID int) int {
+		t.Helper()
+		depts, err := db.GetAllDepartments(ctx)
+		if err != nil {
+			t.Fatalf("GetAllDepartments: %v", err)
+		}
+		for _, d := range depts {
+			if d.DeptID == deptID {
+				return d.NumEmp
+			}
+		}
+		t.Fatalf("department %d not found", deptID)
+		return 0
+	}
+
+	t.Run("departments are ordered and num_emp starts at zero", func(t *testing.T) {
+		db := newDB(t)
+		if err := db.AddDepartment(ctx, Department{DeptID: 2, DeptName: "Two", NumEmp: 99}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.AddDepartment(ctx, Department{DeptID: 1, DeptName: "One"}); err != nil {
+			t.Fatal(err)
+		}
+		depts, err := db.GetAllDepartments(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(depts) != 2 || depts[0].DeptID != 1 || depts[1].DeptID != 2 {
+			t.Fatalf("unexpected departments: %+v", depts)
+		}
+		if depts[1].NumEmp != 0 {
+			t.Fatalf("num_emp = %d, want 0", depts[1].NumEmp)
+		}
+	})
+
+	t.Run("duplicate department is rejected", func(t *testing.T) {
+		db := newDB(t)
+		if err := db.AddDepartment(ctx, Department{DeptID: 1, DeptName: "One"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.AddDepartment(ctx, Department{DeptID: 1, DeptName: "Again"}); !errors.Is(err, ErrConflict) {
+			t.Fatalf("duplicate dept_id error = %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("employees join department and count towards num_emp", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+
+		emps, err := db.GetAllEmployees(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(emps) != 3 || emps[0].EmployeeID != 1 || emps[2].EmployeeID != 3 {
+			t.Fatalf("unexpected employees: %+v", emps)
+		}
+		emp, err := db.GetEmployee(ctx, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if emp.DeptName != "Finance" || emp.BaseSalary != 40000 {
+			t.Fatalf("unexpected employee: %+v", emp)
+		}
+		if n := numEmp(t, db, 10); n != 2 {
+			t.Fatalf("num_emp = %d, want 2", n)
+		}
+	})
+
+	t.Run("employee constraints", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+
+		var conflict *ConflictError
+		if err := db.AddEmployee(ctx, Employee{EmployeeID: 1, EmpName: "Dup", DeptID: 10}); !errors.As(err, &conflict) || !conflict.Duplicate {
+			t.Fatalf("duplicate emp_id error = %v, want duplicate ConflictError", err)
+		}
+		var fk *ForeignKeyError
+		if err := db.AddEmployee(ctx, Employee{EmployeeID: 9, EmpName: "Orphan", DeptID: 999}); !errors.As(err, &fk) || fk.Field != "dept_id" || fk.ID != 999 {
+			t.Fatalf("unknown dept_id error = %v, want ForeignKeyError on dept_id", err)
+		}
+		if _, err := db.GetEmployee(ctx, 999); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("GetEmployee(999) error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("payroll ids are serial and emp_id must exist", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+
+		for _, empID := range []int{2, 1} {
+			if _, err := db.AddPayroll(ctx, Payroll{EmpID: empID, PayMonth: MustParsePeriod("2026-01"), BaseSalary: 100, NetSalary: 90}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := db.AddPayroll(ctx, Payroll{EmpID: 999, PayMonth: MustParsePeriod("2026-01")}); !errors.Is(err, ErrForeignKey) {
+			t.Fatalf("unknown emp_id error = %v, want ErrForeignKey", err)
+		}
+		payrolls, err := db.GetAllPayrolls(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(payrolls) != 2 || payrolls[0].EmpID != 2 || payrolls[1].PayrollID <= payrolls[0].PayrollID {
+			t.Fatalf("unexpected payrolls: %+v", payrolls)
+		}
+	})
+
+	t.Run("allowance declarations upsert", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+
+		if d, err := db.GetAllowanceDeclaration(ctx, 1, 2026); err != nil || d != nil {
+			t.Fatalf("GetAllowanceDeclaration before save = %+v, %v", d, err)
+		}
+		for _, children := range []int{1, 2} {
+			if err := db.SaveAllowanceDeclaration(ctx, AllowanceDeclaration{EmpID: 1, TaxYear: 2026, NumChildren: children, LifeInsurance: 5000}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		d, err := db.GetAllowanceDeclaration(ctx, 1, 2026)
+		if err != nil || d == nil {
+			t.Fatalf("GetAllowanceDeclaration = %+v, %v", d, err)
+		}
+		if d.NumChildren != 2 || d.LifeInsurance != 5000 {
+			t.Fatalf("unexpected declaration: %+v", d)
+		}
+		if err := db.SaveAllowanceDeclaration(ctx, AllowanceDeclaration{EmpID: 999, TaxYear: 2026}); err == nil {
+			t.Fatal("expected error for unknown emp_id")
+		}
+	})
+
+	t.Run("deleting an employee cascades and decrements num_emp", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		if _, err := db.AddPayroll(ctx, Payroll{EmpID: 1, PayMonth: MustParsePeriod("2026-01")}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SaveAllowanceDeclaration(ctx, AllowanceDeclaration{EmpID: 1, TaxYear: 2026}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := db.DeleteEmployee(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+		if n := numEmp(t, db, 10); n != 1 {
+			t.Fatalf("num_emp = %d, want 1", n)
+		}
+		if payrolls, _ := db.GetAllPayrolls(ctx); len(payrolls) != 0 {
+			t.Fatalf("payrolls not cascaded: %+v", payrolls)
+		}
+		if d, _ := db.GetAllowanceDeclaration(ctx, 1, 2026); d != nil {
+			t.Fatalf("declaration not cascaded: %+v", d)
+		}
+		if err := db.DeleteEmployee(ctx, 1); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("second DeleteEmployee error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("deleting a department cascades to employees and payroll", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		if _, err := db.AddPayroll(ctx, Payroll{EmpID: 2, PayMonth: MustParsePeriod("2026-01")}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.AddPayroll(ctx, Payroll{EmpID: 3, PayMonth: MustParsePeriod("2026-01")}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := db.DeleteDepartment(ctx, 10); err != nil {
+			t.Fatal(err)
+		}
+		emps, _ := db.GetAllEmployees(ctx)
+		if len(emps) != 1 || emps[0].EmployeeID != 3 {
+			t.Fatalf("employees not cascaded: %+v", emps)
+		}
+		payrolls, _ := db.GetAllPayrolls(ctx)
+		if len(payrolls) != 1 || payrolls[0].EmpID != 3 {
+			t.Fatalf("payrolls not cascaded: %+v", payrolls)
+		}
+		if err := db.DeleteDepartment(ctx, 10); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("second DeleteDepartment error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("WithTx commits on success and rolls back on error", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+
+		err := db.WithTx(ctx, func(tdb PayrollDatabase) error {
+			return tdb.AddEmployee(ctx, Employee{EmployeeID: 4, EmpName: "D", DeptID: 20})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.GetEmployee(ctx, 4); err != nil {
+			t.Fatalf("committed employee missing: %v", err)
+		}
+
+		boom := errors.New("boom")
+		err = db.WithTx(ctx, func(tdb PayrollDatabase) error {
+			if err := tdb.AddEmployee(ctx, Employee{EmployeeID: 5, EmpName: "E", DeptID: 20}); err != nil {
+				return err
+			}
+			return tdb.WithTx(ctx, func(inner PayrollDatabase) error {
+				if _, err := inner.GetEmployee(ctx, 5); err != nil {
+					t.Errorf("nested tx does not see outer write: %v", err)
+				}
+				return boom
+			})
+		})
+		if !errors.Is(err, boom) {
+			t.Fatalf("WithTx error = %v, want boom", err)
+		}
+		if _, err := db.GetEmployee(ctx, 5); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("rolled back employee visible: %v", err)
+		}
+		if n := numEmp(t, db, 20); n != 2 {
+			t.Fatalf("num_emp = %d after rollback, want 2", n)
+		}
+	})
+
+	t.Run("updates enforce versions and distinguish missing rows", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+
+		dept, err := db.GetDepartment(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dept.Version != 1 {
+			t.Fatalf("new department version = %d, want 1", dept.Version)
+		}
+		dept.DeptName = "Operations"
+		if err := db.UpdateDepartment(ctx, dept); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.UpdateDepartment(ctx, dept); !errors.Is(err, ErrConflict) {
+			t.Fatalf("stale update error = %v, want ErrConflict", err)
+		}
+		if err := db.UpdateDepartment(ctx, Department{DeptID: 999, DeptName: "X"}); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("missing update error = %v, want ErrNotFound", err)
+		}
+		dept, _ = db.GetDepartment(ctx, 10)
+		if dept.DeptName != "Operations" || dept.Version != 2 || dept.NumEmp != 2 {
+			t.Fatalf("unexpected department after update: %+v", dept)
+		}
+	})
+
+	t.Run("moving an employee between departments moves num_emp", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+
+		emp, err := db.GetEmployee(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		emp.DeptID = 20
+		emp.EmpName = "A2"
+		if err := db.UpdateEmployee(ctx, emp); err != nil {
+			t.Fatal(err)
+		}
+		if n := numEmp(t, db, 10); n != 1 {
+			t.Fatalf("old department num_emp = %d, want 1", n)
+		}
+		if n := numEmp(t, db, 20); n != 2 {
+			t.Fatalf("new department num_emp = %d, want 2", n)
+		}
+		emp, _ = db.GetEmployee(ctx, 1)
+		if emp.EmpName != "A2" || emp.DeptName != "Finance" || emp.Version != 2 {
+			t.Fatalf("unexpected employee after update: %+v", emp)
+		}
+
+		emp.DeptID = 999
+		emp.Version = 0
+		if err := db.UpdateEmployee(ctx, emp); err == nil {
+			t.Fatal("expected error moving to unknown dept_id")
+		}
+	})
+
+	t.Run("payroll get, update and delete", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		if _, err := db.AddPayroll(ctx, Payroll{EmpID: 1, PayMonth: MustParsePeriod("2026-01"), NetSalary: 100}); err != nil {
+			t.Fatal(err)
+		}
+		payrolls, _ := db.GetAllPayrolls(ctx)
+		p, err := db.GetPayroll(ctx, payrolls[0].PayrollID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p.NetSalary = 200
+		if err := db.UpdatePayroll(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.UpdatePayroll(ctx, p); !errors.Is(err, ErrConflict) {
+			t.Fatalf("stale update error = %v, want ErrConflict", err)
+		}
+		if p, _ = db.GetPayroll(ctx, p.PayrollID); p.NetSalary != 200 || p.Version != 2 {
+			t.Fatalf("unexpected payroll after update: %+v", p)
+		}
+
+		if err := db.DeletePayroll(ctx, p.PayrollID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.GetPayroll(ctx, p.PayrollID); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("GetPayroll after delete error = %v, want ErrNotFound", err)
+		}
+		if err := db.DeletePayroll(ctx, p.PayrollID); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("second DeletePayroll error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("list employees filters, sorts and pages with a cursor", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		if err := db.AddEmployee(ctx, Employee{EmployeeID: 4, EmpName: "D", DeptID: 10, BaseSalary: 30000}); err != nil {
+			t.Fatal(err)
+		}
+
+		page, err := db.ListEmployees(ctx, EmployeeQuery{DeptID: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 3 || len(page.Items) != 3 || page.NextCursor != "" {
+			t.Fatalf("dept filter: %+v", page)
+		}
+
+		lo := 25000.0
+		var ids []int
+		q := EmployeeQuery{ListOptions: ListOptions{Sort: "-base_salary", Limit: 2}, MinSalary: &lo}
+		for {
+			page, err := db.ListEmployees(ctx, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Total != 3 {
+				t.Fatalf("total = %d, want 3", page.Total)
+			}
+			for _, e := range page.Items {
+				ids = append(ids, e.EmployeeID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+		// salary descending, ties broken by emp_id in the same direction
+		if len(ids) != 3 || ids[0] != 3 || ids[1] != 4 || ids[2] != 1 {
+			t.Fatalf("paged ids = %v, want [3 4 1]", ids)
+		}
+
+		// Without a limit every row comes back in one page
+		for id := 5; id <= 64; id++ {
+			if err := db.AddEmployee(ctx, Employee{EmployeeID: id, EmpName: "E", DeptID: 20}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if page, err = db.ListEmployees(ctx, EmployeeQuery{}); err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 64 || len(page.Items) != 64 || page.NextCursor != "" {
+			t.Fatalf("unlimited list: total %d, %d items, cursor %q", page.Total, len(page.Items), page.NextCursor)
+		}
+
+		for _, bad := range []EmployeeQuery{
+			{ListOptions: ListOptions{Sort: "phone_number"}},
+			{ListOptions: ListOptions{Cursor: "not a cursor"}},
+			{ListOptions: ListOptions{Sort: "emp_name", Cursor: q.Cursor}}, // issued for -base_salary
+			{ListOptions: ListOptions{Limit: -1}},
+			{MinSalary: &lo, MaxSalary: new(float64)},
+		} {
+			if _, err := db.ListEmployees(ctx, bad); !errors.Is(err, ErrInvalidQuery) {
+				t.Fatalf("ListEmployees(%+v) error = %v, want ErrInvalidQuery", bad, err)
+			}
+		}
+	})
+
+	t.Run("list payrolls filters by month range and department", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		for _, p := range []Payroll{
+			{EmpID: 1, PayMonth: MustParsePeriod("2026-01"), BaseSalary: 30000},
+			{EmpID: 1, PayMonth: MustParsePeriod("2026-02"), BaseSalary: 30000},
+			{EmpID: 3, PayMonth: MustParsePeriod("2026-02"), BaseSalary: 40000},
+			{EmpID: 2, PayMonth: MustParsePeriod("2026-03"), BaseSalary: 20000},
+		} {
+			if _, err := db.AddPayroll(ctx, p); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		page, err := db.ListPayrolls(ctx, PayrollQuery{PayMonthFrom: MustParsePeriod("2026-02"), PayMonthTo: MustParsePeriod("2026-03"), DeptID: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 2 || len(page.Items) != 2 || page.Items[0].PayMonth != MustParsePeriod("2026-02") || page.Items[1].EmpID != 2 {
+			t.Fatalf("unexpected page: %+v", page)
+		}
+
+		page, err = db.ListPayrolls(ctx, PayrollQuery{ListOptions: ListOptions{Sort: "-pay_month", Limit: 1}, EmpID: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 2 || len(page.Items) != 1 || page.Items[0].PayMonth != MustParsePeriod("2026-02") || page.NextCursor == "" {
+			t.Fatalf("unexpected first page: %+v", page)
+		}
+		page, err = db.ListPayrolls(ctx, PayrollQuery{ListOptions: ListOptions{Sort: "-pay_month", Limit: 1, Cursor: page.NextCursor}, EmpID: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Items) != 1 || page.Items[0].PayMonth != MustParsePeriod("2026-01") || page.NextCursor != "" {
+			t.Fatalf("unexpected second page: %+v", page)
+		}
+
+		// A cursor holds a pay month, which cannot be compared with another sort's values
+		page, err = db.ListPayrolls(ctx, PayrollQuery{ListOptions: ListOptions{Sort: "-pay_month", Limit: 1}, EmpID: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sort := range []string{"net_salary", "pay_month", ""} {
+			q := PayrollQuery{ListOptions: ListOptions{Sort: sort, Limit: 1, Cursor: page.NextCursor}, EmpID: 1}
+			if _, err := db.ListPayrolls(ctx, q); !errors.Is(err, ErrInvalidQuery) {
+				t.Fatalf("cursor for -pay_month under sort %q error = %v, want ErrInvalidQuery", sort, err)
+			}
+		}
+
+		if _, err := db.ListPayrolls(ctx, PayrollQuery{PayMonthFrom: MustParsePeriod("2026-03"), PayMonthTo: MustParsePeriod("2026-01")}); !errors.Is(err, ErrInvalidQuery) {
+			t.Fatalf("reversed month range error = %v, want ErrInvalidQuery", err)
+		}
+	})
+
+	t.Run("users and sessions", func(t *testing.T) {
+		db := newDB(t)
+		if err := db.AddUser(ctx, User{Username: "hr", PasswordHash: "hash"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.AddUser(ctx, User{Username: "hr", PasswordHash: "other"}); !errors.Is(err, ErrConflict) {
+			t.Fatalf("duplicate username error = %v, want ErrConflict", err)
+		}
+		u, err := db.GetUserByUsername(ctx, "hr")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.UserID == 0 || u.PasswordHash != "hash" || u.CreatedAt.IsZero() {
+			t.Fatalf("unexpected user: %+v", u)
+		}
+		if _, err := db.GetUserByUsername(ctx, "nobody"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("unknown user error = %v, want ErrNotFound", err)
+		}
+
+		expires := time.Now().Add(time.Hour).Truncate(time.Second)
+		if err := db.AddSession(ctx, Session{SessionID: "s1", UserID: u.UserID, RefreshHash: "a", ExpiresAt: expires}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.RotateSession(ctx, "s1", "stale", "b", expires); !errors.Is(err, ErrConflict) {
+			t.Fatalf("stale rotate error = %v, want ErrConflict", err)
+		}
+		if err := db.RotateSession(ctx, "s1", "a", "b", expires); err != nil {
+			t.Fatal(err)
+		}
+		s, err := db.GetSession(ctx, "s1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.RefreshHash != "b" || !s.ExpiresAt.Equal(expires) || !s.Active(time.Now()) {
+			t.Fatalf("unexpected session: %+v", s)
+		}
+
+		if err := db.RevokeSession(ctx, "s1"); err != nil {
+			t.Fatal(err)
+		}
+		if s, _ = db.GetSession(ctx, "s1"); s.RevokedAt == nil || s.Active(time.Now()) {
+			t.Fatalf("session still active after revoke: %+v", s)
+		}
+		if err := db.RotateSession(ctx, "s1", "b", "c", expires); !errors.Is(err, ErrConflict) {
+			t.Fatalf("rotate after revoke error = %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("roles and user roles", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		clerk := Role{RoleName: "clerk", Description: "Clerk", Scope: ScopeAll, Permissions: []string{"employee:read", "department:read"}}
+		if err := db.SaveRole(ctx, clerk); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SaveRole(ctx, Role{RoleName: "self", Scope: ScopeSelf, Permissions: []string{"payroll:read"}}); err != nil {
+			t.Fatal(err)
+		}
+		clerk.Permissions = []string{"employee:read"}
+		if err := db.SaveRole(ctx, clerk); err != nil {
+			t.Fatal(err)
+		}
+		r, err := db.GetRole(ctx, "clerk")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Permissions) != 1 || r.Permissions[0] != "employee:read" || r.Scope != ScopeAll {
+			t.Fatalf("unexpected role after replace: %+v", r)
+		}
+		if roles, err := db.ListRoles(ctx); err != nil || len(roles) != 2 || roles[0].RoleName != "clerk" {
+			t.Fatalf("ListRoles() = %+v, %v", roles, err)
+		}
+
+		empID := 3
+		if err := db.AddUser(ctx, User{Username: "c", PasswordHash: "h", EmpID: &empID, Roles: []string{"self", "clerk"}}); err != nil {
+			t.Fatal(err)
+		}
+		u, err := db.GetUserByUsername(ctx, "c")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.EmpID == nil || *u.EmpID != 3 || len(u.Roles) != 2 || u.Roles[0] != "clerk" {
+			t.Fatalf("unexpected user: %+v", u)
+		}
+
+		u.Roles = []string{"self"}
+		missing := 999
+		u.EmpID = &missing
+		if err := db.UpdateUser(ctx, u); !errors.Is(err, ErrForeignKey) {
+			t.Fatalf("unknown emp_id error = %v, want ErrForeignKey", err)
+		}
+		u.EmpID = &empID
+		if err := db.UpdateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.DeleteRole(ctx, "self"); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.DeleteRole(ctx, "self"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("second delete error = %v, want ErrNotFound", err)
+		}
+		if u, _ = db.GetUser(ctx, u.UserID); len(u.Roles) != 0 {
+			t.Fatalf("deleted role still assigned: %+v", u.Roles)
+		}
+
+		// Deleting the employee unlinks the user rather than deleting it
+		if err := db.DeleteEmployee(ctx, 3); err != nil {
+			t.Fatal(err)
+		}
+		if u, err = db.GetUser(ctx, u.UserID); err != nil || u.EmpID != nil {
+			t.Fatalf("user after employee delete = %+v, %v", u, err)
+		}
+	})
+
+	t.Run("approve payroll", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		if err := db.AddUser(ctx, User{Username: "approver", PasswordHash: "h"}); err != nil {
+			t.Fatal(err)
+		}
+		approver, _ := db.GetUserByUsername(ctx, "approver")
+		if _, err := db.AddPayroll(ctx, Payroll{EmpID: 1, PayMonth: MustParsePeriod("2026-01"), Status: PayrollApproved}); err != nil {
+			t.Fatal(err)
+		}
+		payrolls, _ := db.GetAllPayrolls(ctx)
+		p := payrolls[0]
+		if p.Status != PayrollDraft || p.ApprovedBy != nil {
+			t.Fatalf("new payroll should be a draft: %+v", p)
+		}
+
+		if err := db.ApprovePayroll(ctx, p.PayrollID, p.Version+1, approver.UserID); !errors.Is(err, ErrConflict) {
+			t.Fatalf("stale version error = %v, want ErrConflict", err)
+		}
+		if err := db.ApprovePayroll(ctx, p.PayrollID, p.Version, approver.UserID); err != nil {
+			t.Fatal(err)
+		}
+		p, _ = db.GetPayroll(ctx, p.PayrollID)
+		if p.Status != PayrollApproved || p.ApprovedBy == nil || *p.ApprovedBy != approver.UserID || p.ApprovedAt == nil {
+			t.Fatalf("unexpected approved payroll: %+v", p)
+		}
+		if err := db.ApprovePayroll(ctx, p.PayrollID, 0, approver.UserID); !errors.Is(err, ErrConflict) {
+			t.Fatalf("second approval error = %v, want ErrConflict", err)
+		}
+
+		// An approved record is final: it can be neither edited nor deleted
+		edited := p
+		edited.NetSalary = 1
+		if err := db.UpdatePayroll(ctx, edited); !errors.Is(err, ErrConflict) {
+			t.Fatalf("update of an approved payroll error = %v, want ErrConflict", err)
+		}
+		edited.Version = 0
+		if err := db.UpdatePayroll(ctx, edited); !errors.Is(err, ErrConflict) {
+			t.Fatalf("unversioned update of an approved payroll error = %v, want ErrConflict", err)
+		}
+		if err := db.DeletePayroll(ctx, p.PayrollID); !errors.Is(err, ErrConflict) {
+			t.Fatalf("delete of an approved payroll error = %v, want ErrConflict", err)
+		}
+		if stored, err := db.GetPayroll(ctx, p.PayrollID); err != nil || stored.NetSalary != p.NetSalary ||
+			stored.Status != PayrollApproved || stored.Version != p.Version {
+			t.Fatalf("approved payroll after rejected writes = %+v, %v", stored, err)
+		}
+		if err := db.ApprovePayroll(ctx, 999, 0, approver.UserID); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("unknown payroll error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("profile change and leave requests", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		if err := db.AddUser(ctx, User{Username: "hr", PasswordHash: "h"}); err != nil {
+			t.Fatal(err)
+		}
+		hr, _ := db.GetUserByUsername(ctx, "hr")
+
+		phone := "0812345678"
+		changeID, err := db.AddProfileChange(ctx, ProfileChange{EmpID: 1, PhoneNumber: &phone})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.AddProfileChange(ctx, ProfileChange{EmpID: 999, PhoneNumber: &phone}); !errors.Is(err, ErrForeignKey) {
+			t.Fatalf("unknown employee error = %v, want ErrForeignKey", err)
+		}
+		c, err := db.GetProfileChange(ctx, changeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Status != RequestPending || c.PhoneNumber == nil || *c.PhoneNumber != phone || c.BankAccount != nil || c.RequestedAt.IsZero() {
+			t.Fatalf("unexpected profile change: %+v", c)
+		}
+		if err := db.ReviewProfileChange(ctx, changeID, Review{Status: RequestApproved, ReviewedBy: &hr.UserID}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.ReviewProfileChange(ctx, changeID, Review{Status: RequestRejected}); !errors.Is(err, ErrConflict) {
+			t.Fatalf("second review error = %v, want ErrConflict", err)
+		}
+		if err := db.ReviewProfileChange(ctx, 999, Review{Status: RequestRejected}); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("unknown change error = %v, want ErrNotFound", err)
+		}
+		c, _ = db.GetProfileChange(ctx, changeID)
+		if c.Status != RequestApproved || c.ReviewedBy == nil || *c.ReviewedBy != hr.UserID || c.ReviewedAt == nil {
+			t.Fatalf("unexpected reviewed change: %+v", c)
+		}
+
+		for _, l := range []LeaveRequest{
+			{EmpID: 3, LeaveType: "sick", StartDate: "2026-03-02", EndDate: "2026-03-02"},
+			{EmpID: 1, LeaveType: "vacation", StartDate: "2026-04-13", EndDate: "2026-04-15", Reason: "Songkran"},
+			{EmpID: 1, LeaveType: "personal", StartDate: "2026-02-01", EndDate: "2026-02-01"},
+		} {
+			if _, err := db.AddLeaveRequest(ctx, l); err != nil {
+				t.Fatal(err)
+			}
+		}
+		leaves, err := db.ListLeaveRequests(ctx, RequestQuery{DeptID: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(leaves) != 2 || leaves[0].StartDate != "2026-02-01" || leaves[1].Reason != "Songkran" {
+			t.Fatalf("unexpected department leave: %+v", leaves)
+		}
+		if err := db.ReviewLeaveRequest(ctx, leaves[0].LeaveID, Review{Status: RequestCancelled}); err != nil {
+			t.Fatal(err)
+		}
+		if pending, _ := db.ListLeaveRequests(ctx, RequestQuery{EmpID: 1, Status: RequestPending}); len(pending) != 1 {
+			t.Fatalf("pending leave = %+v, want 1", pending)
+		}
+
+		// Deleting the employee removes their requests
+		if err := db.DeleteEmployee(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.GetProfileChange(ctx, changeID); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("change after delete error = %v, want ErrNotFound", err)
+		}
+		if leaves, _ := db.ListLeaveRequests(ctx, RequestQuery{}); len(leaves) != 1 {
+			t.Fatalf("leave after delete = %+v, want 1", leaves)
+		}
+	})
+
+	t.Run("audit log chains entries and filters by entity", func(t *testing.T) {
+		db := newDB(t)
+		uid := 7
+		for _, e := range []AuditEntry{
+			{Actor: "system", Entity: "department", EntityID: "10", Action: AuditCreate, After: []byte(`{"dept_name":"Ops"}`)},
+			{ActorID: &uid, Actor: "hr", RequestID: "req-1", Entity: "employee", EntityID: "1", Action: AuditUpdate,
+				Before: []byte(`{"emp_name":"A","base_salary":30000}`), After: []byte(`{"emp_name":"A","base_salary":32000}`)},
+			{ActorID: &uid, Actor: "hr", RequestID: "req-2", Entity: "employee", EntityID: "2", Action: AuditDelete,
+				Before: []byte(`{"emp_name":"B"}`)},
+		} {
+			if err := db.WithTx(ctx, func(tx PayrollDatabase) error { return tx.AppendAudit(ctx, e) }); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		all, err := db.ListAudit(ctx, AuditQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all.Items) != 3 || all.Total != 3 {
+			t.Fatalf("audit log = %+v, want 3 entries", all)
+		}
+		prev := ""
+		for i, e := range all.Items {
+			if e.PrevHash != prev || e.Hash == "" || e.Hash != e.computeHash() || e.OccurredAt.IsZero() {
+				t.Fatalf("entry %d does not chain: %+v", i, e)
+			}
+			prev = e.Hash
+		}
+
+		page, err := db.ListAudit(ctx, AuditQuery{Entity: "employee", EntityID: "1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Items) != 1 {
+			t.Fatalf("employee 1 entries = %+v, want 1", page.Items)
+		}
+		e := page.Items[0]
+		if e.ActorID == nil || *e.ActorID != uid || e.RequestID != "req-1" || len(e.Changes) != 1 ||
+			string(e.Changes["base_salary"].Before) != "30000" || string(e.Changes["base_salary"].After) != "32000" {
+			t.Fatalf("unexpected employee entry: %+v", e)
+		}
+		if page, _ := db.ListAudit(ctx, AuditQuery{RequestID: "req-2"}); len(page.Items) != 1 || page.Items[0].After != nil {
+			t.Fatalf("req-2 entries = %+v, want one delete", page.Items)
+		}
+	})
+
+	t.Run("anonymise employee keeps pay records", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		emp, _ := db.GetEmployee(ctx, 1)
+		emp.PhoneNumber, emp.AccountNum, emp.NationalID, emp.EndDate = "0812345678", "1234567890", "1101700207030", "2015-06-30"
+		if err := db.UpdateEmployee(ctx, emp); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.AddPayroll(ctx, Payroll{EmpID: 1, PayMonth: MustParsePeriod("2015-06"), BaseSalary: 30000, NetSalary: 30000}); err != nil {
+			t.Fatal(err)
+		}
+		for _, year := range []int{2015, 2014} {
+			if err := db.SaveAllowanceDeclaration(ctx, AllowanceDeclaration{EmpID: 1, TaxYear: year, NumChildren: 1}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		phone := "0898765432"
+		changeID, err := db.AddProfileChange(ctx, ProfileChange{EmpID: 1, PhoneNumber: &phone})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.AddLeaveRequest(ctx, LeaveRequest{EmpID: 1, LeaveType: "sick", StartDate: "2015-03-02", EndDate: "2015-03-02", Reason: "flu"}); err != nil {
+			t.Fatal(err)
+		}
+
+		at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		if err := db.AnonymiseEmployee(ctx, 1, at); err != nil {
+			t.Fatal(err)
+		}
+		got, err := db.GetEmployee(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.EmpName == "A" || got.PhoneNumber != "" || got.AccountNum != "" || got.NationalID != "" ||
+			got.AnonymisedAt == nil || !got.AnonymisedAt.Equal(at) || got.EndDate != "2015-06-30" || got.BaseSalary != 30000 {
+			t.Fatalf("unexpected anonymised employee: %+v", got)
+		}
+		if err := db.AnonymiseEmployee(ctx, 1, at); !errors.Is(err, ErrConflict) {
+			t.Fatalf("second anonymise error = %v, want ErrConflict", err)
+		}
+		if err := db.AnonymiseEmployee(ctx, 999, at); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("unknown employee error = %v, want ErrNotFound", err)
+		}
+		if c, _ := db.GetProfileChange(ctx, changeID); c.PhoneNumber != nil {
+			t.Fatalf("profile change keeps phone %q", *c.PhoneNumber)
+		}
+		if leaves, _ := db.ListLeaveRequests(ctx, RequestQuery{EmpID: 1}); len(leaves) != 1 || leaves[0].Reason != "" {
+			t.Fatalf("leave after anonymise = %+v", leaves)
+		}
+		if page, _ := db.ListPayrolls(ctx, PayrollQuery{EmpID: 1}); len(page.Items) != 1 || page.Items[0].NetSalary != 30000 {
+			t.Fatalf("payroll after anonymise = %+v", page.Items)
+		}
+		decls, err := db.ListAllowanceDeclarations(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(decls) != 2 || decls[0].TaxYear != 2014 || decls[1].NumChildren != 1 {
+			t.Fatalf("allowance declarations = %+v", decls)
+		}
+
+		// Later updates leave anonymised_at alone
+		got.PositionName = "Former"
+		if err := db.UpdateEmployee(ctx, got); err != nil {
+			t.Fatal(err)
+		}
+		if again, _ := db.GetEmployee(ctx, 1); again.AnonymisedAt == nil {
+			t.Fatal("update cleared anonymised_at")
+		}
+	})
+
+	t.Run("chart of accounts is replaced as a whole", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		empty, err := db.GetChartOfAccounts(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if empty.SalaryExpense.Code != "" || empty.DepartmentSalaryExpense == nil || len(empty.DepartmentSalaryExpense) != 0 {
+			t.Fatalf("unset chart = %+v", empty)
+		}
+		chart := ChartOfAccounts{
+			SalaryExpense: GLAccount{Code: "5100"},
+			DepartmentSalaryExpense: []DepartmentAccount{
+				{DeptID: 20, GLAccount: GLAccount{Code: "5100", CostCentre: "FIN"}},
+				{DeptID: 10, GLAccount: GLAccount{Code: "5110", CostCentre: "OPS"}},
+			},
+			TaxPayable: GLAccount{Code: "2130"}, SocialSecurityPayable: GLAccount{Code: "2140"},
+			ProvidentFundPayable: GLAccount{Code: "2150"}, OtherDeductionsPayable: GLAccount{Code: "2190"},
+			NetPayClearing: GLAccount{Code: "2110"},
+		}
+		if err := db.SaveChartOfAccounts(ctx, chart); err != nil {
+			t.Fatal(err)
+		}
+		got, err := db.GetChartOfAccounts(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.NetPayClearing.Code != "2110" || len(got.DepartmentSalaryExpense) != 2 ||
+			got.DepartmentSalaryExpense[0] != chart.DepartmentSalaryExpense[1] {
+			t.Fatalf("saved chart = %+v", got)
+		}
+		chart.DepartmentSalaryExpense = chart.DepartmentSalaryExpense[:1]
+		if err := db.SaveChartOfAccounts(ctx, chart); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ = db.GetChartOfAccounts(ctx); len(got.DepartmentSalaryExpense) != 1 || got.DepartmentSalaryExpense[0].DeptID != 20 {
+			t.Fatalf("replaced chart = %+v", got.DepartmentSalaryExpense)
+		}
+	})
+
+	t.Run("pay calendars and employee pay types are stored", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		if err := db.SavePayCalendar(ctx, PayCalendar{Frequency: Weekly, PayDaysAfterEnd: 3}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SavePayCalendar(ctx, PayCalendar{Frequency: Weekly, PayDaysAfterEnd: 4}); err != nil {
+			t.Fatal(err)
+		}
+		calendars, err := db.ListPayCalendars(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, c := range calendars {
+			found = found || c == PayCalendar{Frequency: Weekly, PayDaysAfterEnd: 4}
+		}
+		if !found {
+			t.Fatalf("pay calendars = %+v", calendars)
+		}
+
+		emp, err := db.GetEmployee(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if emp.PayFrequency != Monthly || emp.PayType != PaySalaried {
+			t.Fatalf("default pay = %s %s", emp.PayFrequency, emp.PayType)
+		}
+		emp.PayFrequency, emp.PayType, emp.WageRate = Biweekly, PayDaily, 412.5
+		if err := db.UpdateEmployee(ctx, emp); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := db.GetEmployee(ctx, 1); got.PayFrequency != Biweekly || got.PayType != PayDaily || got.WageRate != 412.5 {
+			t.Fatalf("updated pay = %s %s %v", got.PayFrequency, got.PayType, got.WageRate)
+		}
+	})
+
+	t.Run("minimum wages and work provinces are stored", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		for _, w := range []MinimumWage{
+			{Province: "ภูเก็ต", EffectiveDate: NewDate(2025, 1, 1), DailyRate: 400},
+			{Province: "เชียงใหม่", EffectiveDate: NewDate(2025, 1, 1), DailyRate: 350},
+			{Province: "เชียงใหม่", EffectiveDate: NewDate(2024, 1, 1), DailyRate: 345},
+			{Province: "เชียงใหม่", EffectiveDate: NewDate(2025, 1, 1), DailyRate: 352},
+		} {
+			if err := db.SaveMinimumWage(ctx, w); err != nil {
+				t.Fatal(err)
+			}
+		}
+		wages, err := db.ListMinimumWages(ctx, "เชียงใหม่")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(wages) != 2 || wages[0].EffectiveDate != NewDate(2024, 1, 1) || wages[1].DailyRate != 352 {
+			t.Fatalf("minimum wages of เชียงใหม่ = %+v", wages)
+		}
+		if all, _ := db.ListMinimumWages(ctx, ""); len(all) != 3 {
+			t.Fatalf("all minimum wages = %+v", all)
+		}
+		if err := db.DeleteMinimumWage(ctx, "ภูเก็ต", NewDate(2025, 1, 1)); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.DeleteMinimumWage(ctx, "ภูเก็ต", NewDate(2025, 1, 1)); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("deleting a missing minimum wage error = %v, want ErrNotFound", err)
+		}
+
+		emp, err := db.GetEmployee(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		emp.Province = "เชียงใหม่"
+		if err := db.UpdateEmployee(ctx, emp); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := db.GetEmployee(ctx, 1); got.Province != "เชียงใหม่" {
+			t.Fatalf("province = %q", got.Province)
+		}
+	})
+
+	t.Run("holidays and work sites are stored", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		site := WorkSite{SiteID: "bkk-wh", SiteName: "Bangkok warehouse", WorkDays: WorkWeek{time.Monday, time.Tuesday, time.Saturday}}
+		if err := db.SaveWorkSite(ctx, site); err != nil {
+			t.Fatal(err)
+		}
+		site.SiteName = "Bangkok DC"
+		if err := db.SaveWorkSite(ctx, site); err != nil {
+			t.Fatal(err)
+		}
+		got, err := db.GetWorkSite(ctx, "bkk-wh")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.SiteName != "Bangkok DC" || got.WorkDays.String() != "mon,tue,sat" {
+			t.Fatalf("work site = %+v", got)
+		}
+		if sites, _ := db.ListWorkSites(ctx); len(sites) != 1 {
+			t.Fatalf("work sites = %+v", sites)
+		}
+
+		newYear := Holiday{Date: NewDate(2026, time.January, 1), Name: "New Year", Kind: HolidayPublic}
+		id, err := db.SaveHoliday(ctx, newYear)
+		if err != nil {
+			t.Fatal(err)
+		}
+		newYear.Name = "วันขึ้นปีใหม่"
+		if again, err := db.SaveHoliday(ctx, newYear); err != nil || again != id {
+			t.Fatalf("saving the same day again = %d, %v; want %d", again, err, id)
+		}
+		stocktake := Holiday{Date: NewDate(2026, time.January, 1), Name: "Stocktake", Kind: HolidayCompany, SiteID: "bkk-wh"}
+		if _, err := db.SaveHoliday(ctx, stocktake); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.SaveHoliday(ctx, Holiday{Date: NewDate(2027, time.January, 1), Name: "New Year", Kind: HolidayPublic}); err != nil {
+			t.Fatal(err)
+		}
+		holidays, err := db.ListHolidays(ctx, NewDate(2026, time.January, 1), NewDate(2026, time.December, 31))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(holidays) != 2 || holidays[0].Name != "วันขึ้นปีใหม่" || holidays[1].SiteID != "bkk-wh" {
+			t.Fatalf("holidays of 2026 = %+v", holidays)
+		}
+		if h, err := db.GetHoliday(ctx, id); err != nil || h.Date != newYear.Date {
+			t.Fatalf("holiday %d = %+v, %v", id, h, err)
+		}
+		if err := db.DeleteHoliday(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.GetHoliday(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("deleted holiday error = %v, want ErrNotFound", err)
+		}
+
+		emp, err := db.GetEmployee(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		emp.SiteID = "bkk-wh"
+		if err := db.UpdateEmployee(ctx, emp); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := db.GetEmployee(ctx, 1); got.SiteID != "bkk-wh" {
+			t.Fatalf("site = %q", got.SiteID)
+		}
+		if err := db.DeleteWorkSite(ctx, "nowhere"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("deleting a missing work site error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("payroll is unique per employee, month and run type", func(t *testing.T) {
+		db := newDB(t)
+		seed(t, db)
+		id, err := db.AddPayroll(ctx, Payroll{EmpID: 1, PayMonth: MustParsePeriod("2026-01"), NetSalary: 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p, _ := db.GetPayroll(ctx, id); p.RunType != RunRegular {
+			t.Fatalf("run type = %q, want %q", p.RunType, RunRegular)
+		}
+		if _, err := db.AddPayroll(ctx, Payroll{EmpID: 1, PayMonth: MustParsePeriod("2026-01"), RunType: RunRegular, NetSalary: 100}); !errors.Is(err, ErrConflict) {
+			t.Fatalf("second regular run error = %v, want ErrConflict", err)
+		}
+		bonus, err := db.AddPayroll(ctx, Payroll{EmpID: 1, PayMonth: MustParsePeriod("2026-01"), RunType: RunBonus, NetSalary: 50})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.AddPayroll(ctx, Payroll{EmpID: 2, PayMonth: MustParsePeriod("2026-01"), NetSalary: 100}); err != nil {
+			t.Fatal(err)
+		}
+		p, _ := db.GetPayroll(ctx, bonus)
+		p.RunType = ""
+		if err := db.UpdatePayroll(ctx, p); !errors.Is(err, ErrConflict) {
+			t.Fatalf("moving the bonus onto the regular run error = %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("idempotency keys are reserved once and expire", func(t *testing.T) {
+		db := newDB(t)
+		if err := db.AddUser(ctx, User{Username: "hr", PasswordHash: "hash"}); err != nil {
+			t.Fatal(err)
+		}
+		u, _ := db.GetUserByUsername(ctx, "hr")
+		now := time.Now().Truncate(time.Second)
+		r := IdempotencyRecord{UserID: u.UserID, Key: "k1", RequestHash: "h1", CreatedAt: now}
+		if _, reserved, err := db.ReserveIdempotencyKey(ctx, r); err != nil || !reserved {
+			t.Fatalf("first reservation = %v, %v", reserved, err)
+		}
+		stored, reserved, err := db.ReserveIdempotencyKey(ctx, r)
+		if err != nil || reserved || stored.Status != 0 || stored.RequestHash != "h1" {
+			t.Fatalf("pending reservation = %+v, %v, %v", stored, reserved, err)
+		}
+
+		r.Status, r.Headers, r.Body = 201, map[string]string{"Content-Type": "application/json"}, []byte(`{"id":1}`)
+		if err := db.CompleteIdempotencyKey(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+		stored, reserved, err = db.ReserveIdempotencyKey(ctx, IdempotencyRecord{UserID: u.UserID, Key: "k1", RequestHash: "h2", CreatedAt: now})
+		if err != nil || reserved || stored.Status != 201 || string(stored.Body) != `{"id":1}` ||
+			stored.Headers["Content-Type"] != "application/json" || stored.RequestHash != "h1" {
+			t.Fatalf("completed record = %+v, %v, %v", stored, reserved, err)
+		}
+
+		later := IdempotencyRecord{UserID: u.UserID, Key: "k1", RequestHash: "h2", CreatedAt: now.Add(IdempotencyKeyTTL + time.Minute)}
+		if _, reserved, err := db.ReserveIdempotencyKey(ctx, later); err != nil || !reserved {
+			t.Fatalf("reservation after expiry = %v, %v", reserved, err)
+		}
+		if err := db.ReleaseIdempotencyKey(ctx, u.UserID, "k1"); err != nil {
+			t.Fatal(err)
+		}
+		if _, reserved, err := db.ReserveIdempotencyKey(ctx, later); err != nil || !reserved {
+			t.Fatalf("reservation after release = %v, %v", reserved, err)
+		}
+		if err := db.CompleteIdempotencyKey(ctx, IdempotencyRecord{UserID: u.UserID, Key: "unknown", Status: 200}); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("completing an unknown key error = %v, want ErrNotFound", err)
+		}
+	})
+}
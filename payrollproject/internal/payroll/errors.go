@@ -0,0 +1,180 @@
+package payroll
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var (
+	// ErrNotFound is returned when a requested record does not exist
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a write clashes with stored data: a stale version or a duplicate key
+	ErrConflict = errors.New("record was modified by another request")
+	// ErrValidation is returned when input fails validation
+	ErrValidation = errors.New("validation failed")
+	// ErrForeignKey is returned when a record references one that does not exist
+	ErrForeignKey = errors.New("referenced record does not exist")
+)
+
+// NotFoundError reports a missing record; it matches ErrNotFound
+type NotFoundError struct {
+	Entity string
+	ID     any
+}
+
+func (e *NotFoundError) Error() string { return fmt.Sprintf("%s %v not found", e.Entity, e.ID) }
+
+// Is makes errors.Is(err, ErrNotFound) true
+func (e *NotFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// ConflictError reports a stale version, a key that already exists when Duplicate is set, or a record
+// whose state forbids the write when Reason is set; it matches ErrConflict
+type ConflictError struct {
+	Entity    string
+	ID        any
+	Duplicate bool
+	Reason    string // e.g. "is approved", completing "payroll 7 is approved"
+}
+
+func (e *ConflictError) Error() string {
+	if e.Duplicate {
+		return fmt.Sprintf("%s %v already exists", e.Entity, e.ID)
+	}
+	if e.Reason != "" {
+		return fmt.Sprintf("%s %v %s", e.Entity, e.ID, e.Reason)
+	}
+	return fmt.Sprintf("%s %v was modified by another request", e.Entity, e.ID)
+}
+
+// Is makes errors.Is(err, ErrConflict) true
+func (e *ConflictError) Is(target error) bool { return target == ErrConflict }
+
+// FieldError describes why one input field is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists the invalid fields of an input; it matches ErrValidation
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Is makes errors.Is(err, ErrValidation) true
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+
+// Add records an invalid field
+func (e *ValidationError) Add(field, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns e if any field was added and nil otherwise
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ForeignKeyError reports that Field references Entity ID, which does not exist; it matches ErrForeignKey
+type ForeignKeyError struct {
+	Field  string
+	Entity string
+	ID     int
+}
+
+func (e *ForeignKeyError) Error() string {
+	return fmt.Sprintf("%s: %s %d does not exist", e.Field, e.Entity, e.ID)
+}
+
+// Is makes errors.Is(err, ErrForeignKey) true
+func (e *ForeignKeyError) Is(target error) bool { return target == ErrForeignKey }
+
+// constraintKind classifies a constraint violation reported by the database driver
+type constraintKind int
+
+const (
+	constraintNone constraintKind = iota
+	constraintUnique
+	constraintForeignKey
+	constraintNotNull
+	constraintCheck
+	constraintInvalidValue
+)
+
+// sqliteColumn extracts the column from messages like "NOT NULL constraint failed: employees.emp_name"
+var sqliteColumn = regexp.MustCompile(`constraint failed: \w+\.(\w+)`)
+
+// classifyConstraint identifies constraint violations from lib/pq and modernc.org/sqlite, returning
+// the offending column when the driver reports it
+func classifyConstraint(err error) (constraintKind, string) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505": // unique_violation
+			return constraintUnique, pqErr.Column
+		case "23503": // foreign_key_violation
+			return constraintForeignKey, pqErr.Column
+		case "23502": // not_null_violation
+			return constraintNotNull, pqErr.Column
+		case "23514": // check_violation
+			return constraintCheck, pqErr.Column
+		case "22001", "22003", "22P02": // value too long, numeric out of range, invalid text representation
+			return constraintInvalidValue, pqErr.Column
+		}
+		return constraintNone, ""
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		column := ""
+		if m := sqliteColumn.FindStringSubmatch(sqliteErr.Error()); m != nil {
+			column = m[1]
+		}
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY, sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+			return constraintUnique, column
+		case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+			return constraintForeignKey, column
+		case sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+			return constraintNotNull, column
+		case sqlite3.SQLITE_CONSTRAINT_CHECK:
+			return constraintCheck, column
+		}
+	}
+	return constraintNone, ""
+}
+
+// constraintError translates a driver constraint violation from writing entity id into a domain error;
+// ref describes the foreign key the statement can violate and may be nil. Other errors are returned unchanged.
+func constraintError(err error, entity string, id any, ref *ForeignKeyError) error {
+	kind, column := classifyConstraint(err)
+	switch kind {
+	case constraintUnique:
+		return &ConflictError{Entity: entity, ID: id, Duplicate: true}
+	case constraintForeignKey:
+		if ref != nil {
+			return ref
+		}
+		return &ForeignKeyError{Field: column, Entity: "referenced record"}
+	case constraintNotNull:
+		return &ValidationError{Fields: []FieldError{{Field: column, Message: "is required"}}}
+	case constraintCheck, constraintInvalidValue:
+		return &ValidationError{Fields: []FieldError{{Field: column, Message: "has an invalid value"}}}
+	}
+	return err
+}
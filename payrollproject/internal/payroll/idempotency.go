@@ -0,0 +1,112 @@
+package payroll
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// IdempotencyKeyTTL is how long the response to a request sent with an Idempotency-Key is replayed
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyRecord is a POST request a user sent with an Idempotency-Key and, once it has been
+// handled, the response it got
+type IdempotencyRecord struct {
+	UserID      int
+	Key         string
+	RequestHash string            // hex sha256 of the method, path and body
+	Status      int               // 0 while the request is being handled
+	Headers     map[string]string // the response headers replayed with the body
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// ReserveIdempotencyKey stores r for its user and key unless a record younger than
+// IdempotencyKeyTTL already exists, in which case that record is returned with reserved false.
+// Expired records are purged.
+func (pdb *sqlPayrollDB) ReserveIdempotencyKey(ctx context.Context, r IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	if _, err := pdb.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE created_at < $1",
+		r.CreatedAt.Add(-IdempotencyKeyTTL).UTC()); err != nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	res, err := pdb.db.ExecContext(ctx, `
+        INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, created_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT DO NOTHING`, r.UserID, r.Key, r.RequestHash, r.CreatedAt.UTC())
+	if err != nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("failed to reserve idempotency key: %w",
+			constraintError(err, "idempotency key", r.Key, &ForeignKeyError{Field: "user_id", Entity: "user", ID: r.UserID}))
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return IdempotencyRecord{}, false, err
+	} else if n == 1 {
+		r.Status, r.Headers, r.Body = 0, nil, nil
+		return r, true, nil
+	}
+
+	stored := IdempotencyRecord{UserID: r.UserID, Key: r.Key}
+	var headers string
+	err = pdb.db.QueryRowContext(ctx, `
+        SELECT request_hash, status, headers, body, created_at
+        FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2`, r.UserID, r.Key).
+		Scan(&stored.RequestHash, &stored.Status, &headers, &stored.Body, &stored.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released by the request holding it in the meantime
+		return IdempotencyRecord{}, false, &ConflictError{Entity: "idempotency key", ID: r.Key}
+	}
+	if err != nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("failed to query idempotency key: %w", err)
+	}
+	if err := json.Unmarshal([]byte(headers), &stored.Headers); err != nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("failed to decode idempotency key headers: %w", err)
+	}
+	return stored, false, nil
+}
+
+// CompleteIdempotencyKey stores the response to a reserved request
+func (pdb *sqlPayrollDB) CompleteIdempotencyKey(ctx context.Context, r IdempotencyRecord) error {
+	headers, err := json.Marshal(r.Headers)
+	if err != nil {
+		return err
+	}
+	res, err := pdb.db.ExecContext(ctx, `
+        UPDATE idempotency_keys SET status = $3, headers = $4, body = $5
+        WHERE user_id = $1 AND idempotency_key = $2`, r.UserID, r.Key, r.Status, string(headers), r.Body)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return &NotFoundError{Entity: "idempotency key", ID: r.Key}
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets a reservation so the request can be retried
+func (pdb *sqlPayrollDB) ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error {
+	if _, err := pdb.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2", userID, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// ReserveIdempotencyKey claims key for a user's request with the given hash. When the key was
+// already used within IdempotencyKeyTTL the earlier record is returned with reserved false: its
+// Status is 0 while that request is still being handled.
+func (ps *PayrollSystem) ReserveIdempotencyKey(ctx context.Context, userID int, key, requestHash string) (IdempotencyRecord, bool, error) {
+	return ps.db.ReserveIdempotencyKey(ctx, IdempotencyRecord{UserID: userID, Key: key, RequestHash: requestHash, CreatedAt: time.Now()})
+}
+
+// CompleteIdempotencyKey stores the response to a request reserved with ReserveIdempotencyKey
+func (ps *PayrollSystem) CompleteIdempotencyKey(ctx context.Context, r IdempotencyRecord) error {
+	return ps.db.CompleteIdempotencyKey(ctx, r)
+}
+
+// ReleaseIdempotencyKey drops a reservation whose request failed, so a retry runs it again
+func (ps *PayrollSystem) ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error {
+	return ps.db.ReleaseIdempotencyKey(ctx, userID, key)
+}
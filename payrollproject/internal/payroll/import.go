@@ -0,0 +1,316 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// importField is an employee field an import can set, by the target name used in a column mapping
+type importField struct {
+	name string
+	set  func(emp *Employee, v string) error // nil for columns read before the others
+}
+
+var importFields = []importField{
+	{"emp_id", nil}, // the upsert key
+	{"emp_name", func(emp *Employee, v string) error { emp.EmpName = v; return nil }},
+	{"phone_number", func(emp *Employee, v string) error { emp.PhoneNumber = v; return nil }},
+	{"dept_id", func(emp *Employee, v string) error { return parseImportInt(v, &emp.DeptID) }},
+	{"dept_name", nil}, // resolved to dept_id when the row has no dept_id
+	{"position_name", func(emp *Employee, v string) error { emp.PositionName = v; return nil }},
+	{"base_salary", func(emp *Employee, v string) error { return parseImportAmount(v, &emp.BaseSalary) }},
+	{"pay_frequency", func(emp *Employee, v string) error { emp.PayFrequency = PeriodKind(v); return nil }},
+	{"pay_type", func(emp *Employee, v string) error { emp.PayType = v; return nil }},
+	{"wage_rate", func(emp *Employee, v string) error { return parseImportAmount(v, &emp.WageRate) }},
+	{"province", func(emp *Employee, v string) error { emp.Province = v; return nil }},
+	{"site_id", func(emp *Employee, v string) error { emp.SiteID = v; return nil }},
+	{"bank_account", func(emp *Employee, v string) error { emp.BankAccount = v; return nil }},
+	{"account_num", func(emp *Employee, v string) error { emp.AccountNum = v; return nil }},
+	{"national_id", func(emp *Employee, v string) error { emp.NationalID = v; return nil }},
+	{"end_date", func(emp *Employee, v string) error { emp.EndDate = v; return nil }},
+}
+
+// keptIfBlank are the fields an existing employee keeps when the file leaves them blank
+var keptIfBlank = map[string]bool{"base_salary": true, "wage_rate": true}
+
+func isImportField(name string) bool {
+	for _, f := range importFields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseImportAmount reads an amount of baht, which spreadsheets often write with thousands separators
+func parseImportAmount(v string, dst *float64) error {
+	f, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64)
+	if err != nil {
+		return errors.New("must be a number")
+	}
+	*dst = f
+	return nil
+}
+
+func parseImportInt(v string, dst *int) error {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return errors.New("must be a whole number")
+	}
+	*dst = n
+	return nil
+}
+
+// ImportRowError lists why one row of an import was rejected. Row is the line in the file, counting
+// the header as row 1.
+type ImportRowError struct {
+	Row    int          `json:"row"`
+	EmpID  int          `json:"emp_id,omitempty"`
+	Fields []FieldError `json:"fields"`
+}
+
+// ImportReport is the outcome of an employee import, or what it would be on a dry run
+type ImportReport struct {
+	DryRun  bool             `json:"dry_run"`
+	Rows    int              `json:"rows"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+// importRow is a validated row ready to be written
+type importRow struct {
+	row    int
+	emp    Employee
+	exists bool
+}
+
+// ImportEmployees creates or updates employees from a table whose first row is a header. mapping
+// gives the source column header for each target field; without a mapping, headers that match a
+// field name (ignoring case) are used. Rows are keyed on emp_id: an existing employee keeps any
+// field the file does not map, and a blank base_salary or wage_rate. An account number or national
+// ID written back masked, as an export shows them to a caller without pii:reveal, keeps the stored
+// value. A department may be given by dept_name instead of dept_id.
+//
+// Every row is validated as AddEmployee and UpdateEmployee would, the minimum wage and work site
+// included. A dry run only reports; otherwise the valid rows are written in one transaction, so
+// either all of them are stored or none is.
+func (ps *PayrollSystem) ImportEmployees(ctx context.Context, table [][]string, mapping map[string]string, dryRun bool) (ImportReport, error) {
+	report := ImportReport{DryRun: dryRun, Errors: []ImportRowError{}}
+	if len(table) == 0 {
+		return report, &ValidationError{Fields: []FieldError{{Field: "file", Message: "the file is empty"}}}
+	}
+	columns, err := importColumns(table[0], mapping)
+	if err != nil {
+		return report, err
+	}
+
+	emps, err := ps.db.GetAllEmployees(ctx)
+	if err != nil {
+		return report, err
+	}
+	existing := make(map[int]Employee, len(emps))
+	for _, emp := range emps {
+		existing[emp.EmployeeID] = emp
+	}
+	depts, err := ps.db.GetAllDepartments(ctx)
+	if err != nil {
+		return report, err
+	}
+	deptIDs := make(map[int]bool, len(depts))
+	deptsByName := make(map[string][]int, len(depts))
+	for _, d := range depts {
+		deptIDs[d.DeptID] = true
+		name := strings.ToLower(strings.TrimSpace(d.DeptName))
+		deptsByName[name] = append(deptsByName[name], d.DeptID)
+	}
+
+	var valid []importRow
+	seen := map[int]int{}
+	for i, cells := range table[1:] {
+		rowNum := i + 2
+		if blankRow(cells) {
+			continue
+		}
+		report.Rows++
+		cell := func(field string) (string, bool) {
+			col, ok := columns[field]
+			if !ok {
+				return "", false
+			}
+			if col >= len(cells) {
+				return "", true
+			}
+			return strings.TrimSpace(cells[col]), true
+		}
+
+		rowErr := &ValidationError{}
+		var empID int
+		if v, _ := cell("emp_id"); v == "" {
+			rowErr.Add("emp_id", "is required")
+		} else if err := parseImportInt(v, &empID); err != nil {
+			rowErr.Add("emp_id", "%s", err)
+		}
+		if first, dup := seen[empID]; dup && empID != 0 {
+			rowErr.Add("emp_id", "appears again after row %d", first)
+		}
+		if rowErr.Err() != nil {
+			report.Errors = append(report.Errors, ImportRowError{Row: rowNum, EmpID: empID, Fields: rowErr.Fields})
+			continue
+		}
+		seen[empID] = rowNum
+
+		emp, exists := existing[empID]
+		if !exists {
+			emp = Employee{EmployeeID: empID}
+		}
+		if emp.AnonymisedAt != nil {
+			rowErr.Add("emp_id", "the employee has been anonymised and can no longer be changed")
+		}
+		for _, f := range importFields {
+			if f.set == nil {
+				continue
+			}
+			v, ok := cell(f.name)
+			// An export leaves the pay of employees the caller may not see blank, so a blank
+			// amount keeps what is stored
+			if !ok || (exists && v == "" && keptIfBlank[f.name]) {
+				continue
+			}
+			if err := f.set(&emp, v); err != nil {
+				rowErr.Add(f.name, "%s", err)
+			}
+		}
+		if exists {
+			emp = emp.KeepMasked(existing[empID])
+		}
+		if v, ok := cell("dept_name"); ok && v != "" {
+			if id, given := cell("dept_id"); !given || id == "" {
+				switch ids := deptsByName[strings.ToLower(v)]; len(ids) {
+				case 0:
+					rowErr.Add("dept_name", "no department is named %q", v)
+				case 1:
+					emp.DeptID = ids[0]
+				default:
+					rowErr.Add("dept_name", "%d departments are named %q; give dept_id instead", len(ids), v)
+				}
+			}
+		}
+		if err := emp.Validate(); err != nil {
+			var ve *ValidationError
+			if !errors.As(err, &ve) {
+				return report, err
+			}
+			rowErr.Fields = append(rowErr.Fields, ve.Fields...)
+		}
+		if emp.DeptID > 0 && !deptIDs[emp.DeptID] {
+			rowErr.Add("dept_id", "department %d does not exist", emp.DeptID)
+		}
+		var before *Employee
+		if exists {
+			stored := existing[empID]
+			before = &stored
+		}
+		for _, err := range []error{ps.requireMinimumWage(ctx, before, emp), ps.requireWorkSite(ctx, emp.SiteID)} {
+			var ve *ValidationError
+			if err != nil && !errors.As(err, &ve) {
+				return report, err
+			}
+			if ve != nil {
+				rowErr.Fields = append(rowErr.Fields, ve.Fields...)
+			}
+		}
+		if rowErr.Err() != nil {
+			report.Errors = append(report.Errors, ImportRowError{Row: rowNum, EmpID: empID, Fields: rowErr.Fields})
+			continue
+		}
+		valid = append(valid, importRow{row: rowNum, emp: emp, exists: exists})
+	}
+
+	for _, r := range valid {
+		if r.exists {
+			report.Updated++
+		} else {
+			report.Created++
+		}
+	}
+	if dryRun || len(valid) == 0 {
+		return report, nil
+	}
+	err = ps.withTx(ctx, func(tps *PayrollSystem) error {
+		for _, r := range valid {
+			var err error
+			if r.exists {
+				_, err = tps.UpdateEmployee(ctx, r.emp)
+			} else {
+				err = tps.AddEmployee(ctx, r.emp)
+			}
+			if err != nil {
+				return fmt.Errorf("row %d: %w", r.row, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		report.Created, report.Updated = 0, 0
+		return report, err
+	}
+	return report, nil
+}
+
+// importColumns maps each target field to its column index in the header
+func importColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if _, dup := index[h]; !dup && h != "" {
+			index[h] = i
+		}
+	}
+
+	columns := map[string]int{}
+	verr := &ValidationError{}
+	if len(mapping) == 0 {
+		for _, f := range importFields {
+			if i, ok := index[f.name]; ok {
+				columns[f.name] = i
+			}
+		}
+	} else {
+		fields := make([]string, 0, len(mapping))
+		for field := range mapping {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			source := mapping[field]
+			if !isImportField(field) {
+				verr.Add("mapping", "%q is not an employee field", field)
+				continue
+			}
+			i, ok := index[strings.ToLower(strings.TrimSpace(source))]
+			if !ok {
+				verr.Add("mapping", "the file has no column %q for %s", source, field)
+				continue
+			}
+			columns[field] = i
+		}
+	}
+	if _, ok := columns["emp_id"]; !ok && len(verr.Fields) == 0 {
+		verr.Add("mapping", "no column is mapped to emp_id")
+	}
+	return columns, verr.Err()
+}
+
+func blankRow(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
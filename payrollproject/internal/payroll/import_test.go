@@ -0,0 +1,126 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestImportEmployeesUpsertsValidRows(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	if err := ps.AddDepartment(ctx, Department{DeptID: 2, DeptName: "Finance"}); err != nil {
+		t.Fatal(err)
+	}
+	table := [][]string{
+		{"Staff No", "Name", "Mobile", "Department", "Salary"},
+		{"1", "A renamed", "", "", "32,000"},
+		{"5", "New", "0898765432", "finance", "25000"},
+		{"6", "Bad", "123", "Finance", "25000"},
+		{"7", "Nowhere", "0898765432", "Legal", "25000"},
+		{"5", "Again", "0898765432", "Finance", "25000"},
+		{},
+	}
+	mapping := map[string]string{"emp_id": "staff no", "emp_name": "Name", "phone_number": "Mobile",
+		"dept_name": "Department", "base_salary": "Salary"}
+
+	report, err := ps.ImportEmployees(ctx, table, mapping, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Row 2 blanks the phone number, so it fails validation like any other update would
+	if report.Rows != 5 || report.Created != 1 || report.Updated != 0 || len(report.Errors) != 4 {
+		t.Fatalf("dry run report = %+v", report)
+	}
+	for i, want := range []struct {
+		row   int
+		field string
+	}{{2, "phone_number"}, {4, "phone_number"}, {5, "dept_name"}, {6, "emp_id"}} {
+		if got := report.Errors[i]; got.Row != want.row || got.Fields[0].Field != want.field {
+			t.Errorf("error %d = %+v, want row %d on %s", i, got, want.row, want.field)
+		}
+	}
+	if _, err := ps.GetEmployee(ctx, 5); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("dry run created an employee: %v", err)
+	}
+
+	// Without the phone column, the existing employee keeps their number
+	delete(mapping, "phone_number")
+	if report, err = ps.ImportEmployees(ctx, table[:2], mapping, false); err != nil || report.Updated != 1 {
+		t.Fatalf("import = %+v, %v", report, err)
+	}
+	emp, err := ps.GetEmployee(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emp.EmpName != "A renamed" || emp.BaseSalary != 32000 || emp.PhoneNumber != "0812345678" || emp.AccountNum != "123456789012" {
+		t.Fatalf("updated employee = %+v", emp)
+	}
+	page, _ := ps.ListAudit(ctx, AuditQuery{Entity: "employee", EntityID: "1"})
+	if last := page.Items[len(page.Items)-1]; last.Action != AuditUpdate {
+		t.Fatalf("import audit entry = %+v", last)
+	}
+}
+
+func TestImportEmployeesCreatesWithDefaultMapping(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	table := [][]string{
+		{"EMP_ID", "emp_name", "phone_number", "dept_id", "base_salary", "unused"},
+		{"8", "B", "0811111111", "1", "18000", "x"},
+		{"9", "C", "0822222222", "1", "19000"},
+	}
+	report, err := ps.ImportEmployees(ctx, table, nil, false)
+	if err != nil || report.Created != 2 || len(report.Errors) != 0 {
+		t.Fatalf("import = %+v, %v", report, err)
+	}
+	if emp, err := ps.GetEmployee(ctx, 9); err != nil || emp.EmpName != "C" {
+		t.Fatalf("imported employee = %+v, %v", emp, err)
+	}
+
+	for _, mapping := range []map[string]string{
+		{"emp_id": "EMP_ID", "salary": "base_salary"},
+		{"emp_id": "missing"},
+		{"emp_name": "emp_name"},
+	} {
+		if _, err := ps.ImportEmployees(ctx, table, mapping, true); !errors.Is(err, ErrValidation) {
+			t.Errorf("mapping %v error = %v, want ErrValidation", mapping, err)
+		}
+	}
+}
+
+func TestImportEmployeesKeepsWhatAnExportHid(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	emp, err := ps.GetEmployee(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	emp.NationalID = "1101700207030"
+	if emp, err = ps.UpdateEmployee(ctx, emp); err != nil {
+		t.Fatal(err)
+	}
+	// The file was exported by a caller who sees neither pay nor the unmasked account number
+	masked := emp.Masked()
+	table := [][]string{
+		{"emp_id", "emp_name", "base_salary", "wage_rate", "account_num", "national_id"},
+		{"1", "A renamed", "", "", masked.AccountNum, masked.NationalID},
+	}
+	if report, err := ps.ImportEmployees(ctx, table, nil, false); err != nil || report.Updated != 1 || len(report.Errors) != 0 {
+		t.Fatalf("import = %+v, %v", report, err)
+	}
+	got, err := ps.GetEmployee(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.EmpName != "A renamed" || got.BaseSalary != emp.BaseSalary || got.WageRate != emp.WageRate ||
+		got.AccountNum != emp.AccountNum || got.NationalID != emp.NationalID {
+		t.Fatalf("imported employee = %+v, want %+v renamed", got, emp)
+	}
+
+	// A new employee still needs a salary
+	table[1] = []string{"9", "C", "", "", "", ""}
+	if report, _ := ps.ImportEmployees(ctx, table, nil, true); len(report.Errors) != 1 {
+		t.Fatalf("new employee without a salary: report = %+v", report)
+	}
+}
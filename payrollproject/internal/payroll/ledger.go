@@ -0,0 +1,382 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"payrollproject/internal/tax"
+)
+
+// GLAccount is a general ledger account in the accounting system, with an optional cost centre
+type GLAccount struct {
+	Code       string `json:"code"`
+	CostCentre string `json:"cost_centre,omitempty"`
+}
+
+// DepartmentAccount books one department's salaries to its own expense account or cost centre
+type DepartmentAccount struct {
+	DeptID int `json:"dept_id"`
+	GLAccount
+}
+
+// ChartOfAccounts maps the amounts of a pay run to the general ledger accounts they are booked to
+type ChartOfAccounts struct {
+	SalaryExpense           GLAccount           `json:"salary_expense"` // for departments not listed below
+	DepartmentSalaryExpense []DepartmentAccount `json:"department_salary_expense"`
+	TaxPayable              GLAccount           `json:"tax_payable"`              // withholding tax due to the Revenue Department
+	SocialSecurityPayable   GLAccount           `json:"social_security_payable"`  // employee contributions due to the Social Security Office
+	ProvidentFundPayable    GLAccount           `json:"provident_fund_payable"`   // employee contributions due to the provident fund
+	OtherDeductionsPayable  GLAccount           `json:"other_deductions_payable"` // the rest of total_deductions
+	NetPayClearing          GLAccount           `json:"net_pay_clearing"`         // cleared when the bank transfer is made
+}
+
+// Ledger account purposes, as stored in gl_accounts
+const (
+	glSalaryExpense          = "salary_expense"
+	glTaxPayable             = "tax_payable"
+	glSocialSecurityPayable  = "social_security_payable"
+	glProvidentFundPayable   = "provident_fund_payable"
+	glOtherDeductionsPayable = "other_deductions_payable"
+	glNetPayClearing         = "net_pay_clearing"
+)
+
+// accounts lists the chart's single accounts by purpose
+func (c *ChartOfAccounts) accounts() []struct {
+	purpose string
+	account *GLAccount
+} {
+	return []struct {
+		purpose string
+		account *GLAccount
+	}{
+		{glSalaryExpense, &c.SalaryExpense},
+		{glTaxPayable, &c.TaxPayable},
+		{glSocialSecurityPayable, &c.SocialSecurityPayable},
+		{glProvidentFundPayable, &c.ProvidentFundPayable},
+		{glOtherDeductionsPayable, &c.OtherDeductionsPayable},
+		{glNetPayClearing, &c.NetPayClearing},
+	}
+}
+
+// Validate checks that every account is set and no department is mapped twice
+func (c ChartOfAccounts) Validate() error {
+	v := &ValidationError{}
+	check := func(field string, a GLAccount) {
+		if strings.TrimSpace(a.Code) == "" {
+			v.Add(field+".code", "is required")
+		} else if len(a.Code) > 50 {
+			v.Add(field+".code", "must be at most 50 characters")
+		}
+		if len(a.CostCentre) > 50 {
+			v.Add(field+".cost_centre", "must be at most 50 characters")
+		}
+	}
+	for _, a := range c.accounts() {
+		check(a.purpose, *a.account)
+	}
+	seen := map[int]bool{}
+	for i, d := range c.DepartmentSalaryExpense {
+		field := fmt.Sprintf("department_salary_expense[%d]", i)
+		if d.DeptID <= 0 {
+			v.Add(field+".dept_id", "must be greater than 0")
+		} else if seen[d.DeptID] {
+			v.Add(field+".dept_id", "department %d is mapped more than once", d.DeptID)
+		}
+		seen[d.DeptID] = true
+		check(field, d.GLAccount)
+	}
+	return v.Err()
+}
+
+// salaryAccount is the expense account a department's salaries are booked to
+func (c ChartOfAccounts) salaryAccount(deptID int) GLAccount {
+	for _, d := range c.DepartmentSalaryExpense {
+		if d.DeptID == deptID {
+			return d.GLAccount
+		}
+	}
+	return c.SalaryExpense
+}
+
+// GetChartOfAccounts reads the ledger account mapping; accounts never set are empty
+func (pdb *sqlPayrollDB) GetChartOfAccounts(ctx context.Context) (ChartOfAccounts, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+        SELECT purpose, dept_id, account_code, cost_centre
+        FROM gl_accounts
+        ORDER BY purpose, dept_id`)
+	if err != nil {
+		return ChartOfAccounts{}, fmt.Errorf("failed to query chart of accounts: %w", err)
+	}
+	defer rows.Close()
+
+	c := ChartOfAccounts{DepartmentSalaryExpense: []DepartmentAccount{}}
+	for rows.Next() {
+		var purpose string
+		var deptID int
+		var a GLAccount
+		if err := rows.Scan(&purpose, &deptID, &a.Code, &a.CostCentre); err != nil {
+			return ChartOfAccounts{}, fmt.Errorf("failed to scan ledger account: %w", err)
+		}
+		c.set(purpose, deptID, a)
+	}
+	return c, rows.Err()
+}
+
+// set places a stored account in the chart
+func (c *ChartOfAccounts) set(purpose string, deptID int, a GLAccount) {
+	if purpose == glSalaryExpense && deptID != 0 {
+		c.DepartmentSalaryExpense = append(c.DepartmentSalaryExpense, DepartmentAccount{DeptID: deptID, GLAccount: a})
+		return
+	}
+	for _, s := range c.accounts() {
+		if s.purpose == purpose {
+			*s.account = a
+		}
+	}
+}
+
+// SaveChartOfAccounts replaces the ledger account mapping
+func (pdb *sqlPayrollDB) SaveChartOfAccounts(ctx context.Context, c ChartOfAccounts) error {
+	if _, err := pdb.db.ExecContext(ctx, "DELETE FROM gl_accounts"); err != nil {
+		return fmt.Errorf("failed to clear chart of accounts: %w", err)
+	}
+	insert := func(purpose string, deptID int, a GLAccount) error {
+		_, err := pdb.db.ExecContext(ctx, `
+            INSERT INTO gl_accounts (purpose, dept_id, account_code, cost_centre)
+            VALUES ($1, $2, $3, $4)`, purpose, deptID, a.Code, a.CostCentre)
+		if err != nil {
+			return fmt.Errorf("failed to save ledger account: %w", err)
+		}
+		return nil
+	}
+	for _, a := range c.accounts() {
+		if err := insert(a.purpose, 0, *a.account); err != nil {
+			return err
+		}
+	}
+	for _, d := range c.DepartmentSalaryExpense {
+		if err := insert(glSalaryExpense, d.DeptID, d.GLAccount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetChartOfAccounts returns the ledger account mapping used for pay run journals
+func (ps *PayrollSystem) GetChartOfAccounts(ctx context.Context) (ChartOfAccounts, error) {
+	return ps.db.GetChartOfAccounts(ctx)
+}
+
+// SaveChartOfAccounts validates and replaces the ledger account mapping
+func (ps *PayrollSystem) SaveChartOfAccounts(ctx context.Context, c ChartOfAccounts) (ChartOfAccounts, error) {
+	if err := c.Validate(); err != nil {
+		return ChartOfAccounts{}, err
+	}
+	var saved ChartOfAccounts
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		for i, d := range c.DepartmentSalaryExpense {
+			if _, err := tps.db.GetDepartment(ctx, d.DeptID); errors.Is(err, ErrNotFound) {
+				return &ValidationError{Fields: []FieldError{{
+					Field: fmt.Sprintf("department_salary_expense[%d].dept_id", i), Message: fmt.Sprintf("department %d does not exist", d.DeptID)}}}
+			} else if err != nil {
+				return err
+			}
+		}
+		before, err := tps.db.GetChartOfAccounts(ctx)
+		if err != nil {
+			return err
+		}
+		if err := tps.db.SaveChartOfAccounts(ctx, c); err != nil {
+			return err
+		}
+		if saved, err = tps.db.GetChartOfAccounts(ctx); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "chart_of_accounts", "gl", AuditUpdate, before, saved)
+	})
+	return saved, err
+}
+
+// JournalLine is one debit or credit of a journal entry; exactly one of Debit and Credit is set
+type JournalLine struct {
+	Account     string  `json:"account"`
+	CostCentre  string  `json:"cost_centre,omitempty"`
+	Description string  `json:"description"`
+	Debit       float64 `json:"debit"`
+	Credit      float64 `json:"credit"`
+}
+
+// JournalEntry books one pay run to the general ledger
+type JournalEntry struct {
+	Reference   string        `json:"reference"`
+	Date        Date          `json:"date"` // the latest pay date, or the last day of the pay period
+	PayMonth    Period        `json:"pay_month"`
+	Description string        `json:"description"`
+	Payrolls    int           `json:"payrolls"` // number of payroll records booked
+	Lines       []JournalLine `json:"lines"`
+	TotalDebit  float64       `json:"total_debit"`
+	TotalCredit float64       `json:"total_credit"`
+}
+
+// satang converts baht to whole satang, so journal sums are exact
+func satang(baht float64) int64 { return int64(math.Round(baht * 100)) }
+
+func baht(satang int64) float64 { return float64(satang) / 100 }
+
+// PayRunJournal builds the journal entry for the pay run of a pay period: salaries and additions are
+// debited to each department's expense account, and withholding tax, social security, provident
+// fund and other deductions and net pay are credited to their payable and clearing accounts.
+// Every record of the run must be approved, and the entry must balance: a record whose net_salary
+// is not base_salary + total_additions - tax_amount - total_deductions is reported.
+//
+// Payroll records keep only their total deductions, so the social security and provident fund
+// credits are estimates: for a regular run, the period's share of the contribution on the base
+// salary and of the declared provident fund, never more than the deductions. Deductions from bonus
+// and off-cycle runs are all credited to other deductions.
+func (ps *PayrollSystem) PayRunJournal(ctx context.Context, payMonth Period) (JournalEntry, error) {
+	if payMonth.IsZero() {
+		return JournalEntry{}, &ValidationError{Fields: []FieldError{{Field: "pay_month", Message: "is required"}}}
+	}
+	chart, err := ps.db.GetChartOfAccounts(ctx)
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	if err := chart.Validate(); err != nil {
+		return JournalEntry{}, fmt.Errorf("the chart of accounts is incomplete: %w", err)
+	}
+	payrolls, err := ps.allPayrolls(ctx, PayrollQuery{ListOptions: ListOptions{Sort: "payroll_id"}, PayMonth: payMonth})
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	if len(payrolls) == 0 {
+		return JournalEntry{}, &NotFoundError{Entity: "pay run", ID: payMonth.String()}
+	}
+	var unapproved []string
+	for _, p := range payrolls {
+		if p.Status != PayrollApproved {
+			unapproved = append(unapproved, fmt.Sprint(p.PayrollID))
+		}
+	}
+	if len(unapproved) > 0 {
+		return JournalEntry{}, &ValidationError{Fields: []FieldError{{Field: "pay_month",
+			Message: "the pay run has payroll records not yet approved: " + strings.Join(unapproved, ", ")}}}
+	}
+
+	emps, err := ps.db.GetAllEmployees(ctx)
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	deptOf := make(map[int]int, len(emps))
+	for _, emp := range emps {
+		deptOf[emp.EmployeeID] = emp.DeptID
+	}
+	depts, err := ps.db.GetAllDepartments(ctx)
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	deptNames := make(map[int]string, len(depts))
+	for _, d := range depts {
+		deptNames[d.DeptID] = d.DeptName
+	}
+
+	type expense struct {
+		account GLAccount
+		depts   []string
+		amount  int64
+	}
+	var expenses []*expense
+	var taxDue, ssoDue, pvdDue, otherDue, netDue int64
+	var unbalanced []string
+	entry := JournalEntry{PayMonth: payMonth, Payrolls: len(payrolls)}
+	for _, p := range payrolls {
+		gross := satang(p.BaseSalary) + satang(p.TotalAdditions)
+		deductions := satang(p.TotalDeductions)
+		var sso, pvd int64
+		if p.RunType == RunRegular {
+			periods := payMonth.Kind().PerYear()
+			sso = min(satang(tax.SocialSecurityPeriodContribution(p.BaseSalary, periods)), deductions)
+			d, err := ps.db.GetAllowanceDeclaration(ctx, p.EmpID, taxYear(p.PayMonth, p.PayDate))
+			if err != nil {
+				return JournalEntry{}, err
+			}
+			if d != nil {
+				pvd = min(satang(d.ProvidentFund/float64(periods)), deductions-sso)
+			}
+		}
+		if gross-satang(p.TaxAmount)-deductions != satang(p.NetSalary) {
+			unbalanced = append(unbalanced, fmt.Sprint(p.PayrollID))
+		}
+
+		deptID := deptOf[p.EmpID]
+		account := chart.salaryAccount(deptID)
+		var e *expense
+		for _, x := range expenses {
+			if x.account == account {
+				e = x
+			}
+		}
+		if e == nil {
+			e = &expense{account: account}
+			expenses = append(expenses, e)
+		}
+		if name := deptNames[deptID]; name != "" && !slices.Contains(e.depts, name) {
+			e.depts = append(e.depts, name)
+		}
+		e.amount += gross
+		taxDue += satang(p.TaxAmount)
+		ssoDue += sso
+		pvdDue += pvd
+		otherDue += deductions - sso - pvd
+		netDue += satang(p.NetSalary)
+		if p.PayDate.After(entry.Date) {
+			entry.Date = p.PayDate
+		}
+	}
+	if entry.Date.IsZero() {
+		entry.Date = payMonth.End()
+	}
+	entry.Reference = "PAY-" + payMonth.String()
+	entry.Description = "Payroll " + payMonth.String()
+
+	var debit, credit int64
+	for _, e := range expenses {
+		sort.Strings(e.depts)
+		desc := "Salaries and additions"
+		if len(e.depts) > 0 {
+			desc += " - " + strings.Join(e.depts, ", ")
+		}
+		entry.Lines = append(entry.Lines, JournalLine{Account: e.account.Code, CostCentre: e.account.CostCentre,
+			Description: desc, Debit: baht(e.amount)})
+		debit += e.amount
+	}
+	for _, c := range []struct {
+		account GLAccount
+		desc    string
+		amount  int64
+	}{
+		{chart.TaxPayable, "Withholding tax payable", taxDue},
+		{chart.SocialSecurityPayable, "Social security contributions payable", ssoDue},
+		{chart.ProvidentFundPayable, "Provident fund contributions payable", pvdDue},
+		{chart.OtherDeductionsPayable, "Other deductions payable", otherDue},
+		{chart.NetPayClearing, "Net pay clearing", netDue},
+	} {
+		if c.amount == 0 {
+			continue
+		}
+		entry.Lines = append(entry.Lines, JournalLine{Account: c.account.Code, CostCentre: c.account.CostCentre,
+			Description: c.desc, Credit: baht(c.amount)})
+		credit += c.amount
+	}
+	entry.TotalDebit, entry.TotalCredit = baht(debit), baht(credit)
+	if debit != credit {
+		return JournalEntry{}, &ValidationError{Fields: []FieldError{{Field: "pay_month", Message: fmt.Sprintf(
+			"the journal does not balance: debits %.2f, credits %.2f; net_salary of payroll records %s is not base_salary + total_additions - tax_amount - total_deductions",
+			entry.TotalDebit, entry.TotalCredit, strings.Join(unbalanced, ", "))}}}
+	}
+	return entry, nil
+}
@@ -0,0 +1,139 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+var testChart = ChartOfAccounts{
+	SalaryExpense:           GLAccount{Code: "5100"},
+	DepartmentSalaryExpense: []DepartmentAccount{{DeptID: 2, GLAccount: GLAccount{Code: "5100", CostCentre: "FIN"}}},
+	TaxPayable:              GLAccount{Code: "2130"},
+	SocialSecurityPayable:   GLAccount{Code: "2140"},
+	ProvidentFundPayable:    GLAccount{Code: "2150"},
+	OtherDeductionsPayable:  GLAccount{Code: "2190"},
+	NetPayClearing:          GLAccount{Code: "2110"},
+}
+
+func TestPayRunJournalBalances(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	if err := ps.AddDepartment(ctx, Department{DeptID: 2, DeptName: "Finance"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.AddEmployee(ctx, Employee{EmployeeID: 2, EmpName: "B", PhoneNumber: "0898765432", DeptID: 2, BaseSalary: 10000,
+		BankAccount: "Government Savings Bank", AccountNum: "210987654321"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.SaveAllowanceDeclaration(ctx, AllowanceDeclaration{EmpID: 1, TaxYear: 2026, ProvidentFund: 18000}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ps.PayRunJournal(ctx, MustParsePeriod("2026-01")); err == nil {
+		t.Fatal("journal built without a chart of accounts")
+	}
+	if _, err := ps.SaveChartOfAccounts(ctx, ChartOfAccounts{}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("empty chart error = %v, want ErrValidation", err)
+	}
+	if _, err := ps.SaveChartOfAccounts(ctx, testChart); err != nil {
+		t.Fatal(err)
+	}
+
+	// Employee 1: 30,000 + 2,000 additions; 750 social security, 1,500 provident fund and 250 other deductions
+	run := []Payroll{
+		{EmpID: 1, PayMonth: MustParsePeriod("2026-01"), PayDate: NewDate(2026, 1, 25), BaseSalary: 30000, TotalAdditions: 2000, TaxAmount: 1000, TotalDeductions: 2500, NetSalary: 28500},
+		{EmpID: 2, PayMonth: MustParsePeriod("2026-01"), PayDate: NewDate(2026, 1, 25), BaseSalary: 10000, TotalDeductions: 500, NetSalary: 9500},
+	}
+	var ids []int
+	for _, p := range run {
+		added, err := ps.AddPayroll(ctx, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, added.PayrollID)
+	}
+	if _, err := ps.PayRunJournal(ctx, MustParsePeriod("2026-01")); !errors.Is(err, ErrValidation) {
+		t.Fatalf("unapproved run error = %v, want ErrValidation", err)
+	}
+	for _, id := range ids {
+		if _, err := ps.ApprovePayroll(ctx, id, 0, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entry, err := ps.PayRunJournal(ctx, MustParsePeriod("2026-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []JournalLine{
+		{Account: "5100", Description: "Salaries and additions - Ops", Debit: 32000},
+		{Account: "5100", CostCentre: "FIN", Description: "Salaries and additions - Finance", Debit: 10000},
+		{Account: "2130", Description: "Withholding tax payable", Credit: 1000},
+		{Account: "2140", Description: "Social security contributions payable", Credit: 1250},
+		{Account: "2150", Description: "Provident fund contributions payable", Credit: 1500},
+		{Account: "2190", Description: "Other deductions payable", Credit: 250},
+		{Account: "2110", Description: "Net pay clearing", Credit: 38000},
+	}
+	if len(entry.Lines) != len(want) {
+		t.Fatalf("lines = %+v", entry.Lines)
+	}
+	for i := range want {
+		if entry.Lines[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, entry.Lines[i], want[i])
+		}
+	}
+	if entry.TotalDebit != 42000 || entry.TotalCredit != 42000 || entry.Date != NewDate(2026, 1, 25) || entry.Reference != "PAY-2026-01" {
+		t.Fatalf("entry = %+v", entry)
+	}
+
+	// A record whose net pay does not add up unbalances the entry; approved records cannot be
+	// edited, so the stored one is changed directly
+	store := ps.db.(*MemoryPayrollDB)
+	p := store.state.payrolls[ids[1]]
+	p.NetSalary = 9000
+	store.state.payrolls[ids[1]] = p
+	if _, err := ps.PayRunJournal(ctx, MustParsePeriod("2026-01")); !errors.Is(err, ErrValidation) {
+		t.Fatalf("unbalanced run error = %v, want ErrValidation", err)
+	}
+	if _, err := ps.PayRunJournal(ctx, MustParsePeriod("2026-02")); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("empty month error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPayRunJournalEstimatesContributionsOfRegularRuns(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	if err := ps.AddDepartment(ctx, Department{DeptID: 2, DeptName: "Finance"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.AddEmployee(ctx, Employee{EmployeeID: 2, EmpName: "B", PhoneNumber: "0898765432", DeptID: 2, BaseSalary: 43333.33,
+		PayFrequency: Biweekly, BankAccount: "Government Savings Bank", AccountNum: "210987654321"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.SaveAllowanceDeclaration(ctx, AllowanceDeclaration{EmpID: 2, TaxYear: 2026, ProvidentFund: 26000}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.SaveChartOfAccounts(ctx, testChart); err != nil {
+		t.Fatal(err)
+	}
+	// A fortnight of 20,000 pays a twenty-sixth of the year's 9,000 social security and 26,000
+	// provident fund, and 100 of other deductions
+	addApproved(t, ps, Payroll{EmpID: 2, PayMonth: MustParsePeriod("2026-F03"), PayDate: NewDate(2026, 2, 6), BaseSalary: 20000,
+		TotalDeductions: 1446.15, NetSalary: 18553.85})
+	// Deductions from a bonus run are not estimated as contributions
+	addApproved(t, ps, Payroll{EmpID: 2, PayMonth: MustParsePeriod("2026-F03"), PayDate: NewDate(2026, 2, 6), RunType: RunBonus,
+		BaseSalary: 20000, TotalDeductions: 500, NetSalary: 19500})
+
+	entry, err := ps.PayRunJournal(ctx, MustParsePeriod("2026-F03"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	credits := map[string]float64{}
+	for _, l := range entry.Lines {
+		credits[l.Account] += l.Credit
+	}
+	if credits["2140"] != 346.15 || credits["2150"] != 1000 || credits["2190"] != 600 || entry.TotalDebit != entry.TotalCredit {
+		t.Fatalf("journal lines = %+v", entry.Lines)
+	}
+}
@@ -0,0 +1,161 @@
+package payroll
+
+import (
+	"context"
+	"fmt"
+)
+
+// validateSalaryRange rejects salary bounds that cannot match anything
+func validateSalaryRange(lo, hi *float64) error {
+	if lo != nil && hi != nil && *lo > *hi {
+		return fmt.Errorf("%w: min_salary is greater than max_salary", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// employeeFilters adds the EmployeeQuery filters to b
+func employeeFilters(b *queryBuilder, q EmployeeQuery) {
+	if q.EmpID != 0 {
+		b.where("e.emp_id = ?", q.EmpID)
+	}
+	if q.DeptID != 0 {
+		b.where("e.dept_id = ?", q.DeptID)
+	}
+	if q.Position != "" {
+		b.where("e.position_name = ?", q.Position)
+	}
+	if q.MinSalary != nil {
+		b.where("e.base_salary >= ?", *q.MinSalary)
+	}
+	if q.MaxSalary != nil {
+		b.where("e.base_salary <= ?", *q.MaxSalary)
+	}
+}
+
+// payrollFilters adds the PayrollQuery filters to b
+func payrollFilters(b *queryBuilder, q PayrollQuery) {
+	if q.EmpID != 0 {
+		b.where("p.emp_id = ?", q.EmpID)
+	}
+	if q.DeptID != 0 {
+		b.where("p.emp_id IN (SELECT emp_id FROM employees WHERE dept_id = ?)", q.DeptID)
+	}
+	if !q.PayMonth.IsZero() {
+		b.where("p.pay_month = ?", q.PayMonth)
+	}
+	if !q.PayMonthFrom.IsZero() {
+		b.where("p.period_start >= ?", q.PayMonthFrom.Start())
+	}
+	if !q.PayMonthTo.IsZero() {
+		b.where("p.period_start <= ?", q.PayMonthTo.End())
+	}
+	if q.MinSalary != nil {
+		b.where("p.base_salary >= ?", *q.MinSalary)
+	}
+	if q.MaxSalary != nil {
+		b.where("p.base_salary <= ?", *q.MaxSalary)
+	}
+	if q.Status != "" {
+		b.where("p.status = ?", q.Status)
+	}
+}
+
+// validatePayrollQuery checks the filter combinations of a PayrollQuery
+func validatePayrollQuery(q PayrollQuery) error {
+	if !q.PayMonthFrom.IsZero() && !q.PayMonthTo.IsZero() && q.PayMonthFrom.Start().After(q.PayMonthTo.End()) {
+		return fmt.Errorf("%w: pay_month_from is after pay_month_to", ErrInvalidQuery)
+	}
+	if q.Status != "" && q.Status != PayrollDraft && q.Status != PayrollApproved {
+		return fmt.Errorf("%w: status must be draft or approved", ErrInvalidQuery)
+	}
+	return validateSalaryRange(q.MinSalary, q.MaxSalary)
+}
+
+// listPage runs the count and page queries for a list endpoint. from is the FROM clause,
+// columns the select list read by scan; the filters in b apply to both queries.
+func listPage[T any](ctx context.Context, pdb *sqlPayrollDB, what, columns, from string, b *queryBuilder,
+	opts ListOptions, fields map[string]sortField[T], idName string, scan func(interface{ Scan(...any) error }) (T, error)) (Page[T], error) {
+	s, err := resolveSort(opts.Sort, fields, idName)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	limit, err := normalizeLimit(opts.Limit)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	cursor, err := decodeCursor(opts.Cursor, s)
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	var page Page[T]
+	if err := pdb.db.QueryRowContext(ctx, "SELECT COUNT(*) "+from+b.clause(), b.args...).Scan(&page.Total); err != nil {
+		return Page[T]{}, fmt.Errorf("failed to count %s: %w", what, err)
+	}
+
+	pb := b.clone()
+	if cursor != nil {
+		after(pb, s, cursor)
+	}
+	query := "SELECT " + columns + " " + from + pb.clause() + orderBy(s)
+	if limit > 0 {
+		query += " LIMIT " + pb.bind(limit+1)
+	}
+
+	rows, err := pdb.db.QueryContext(ctx, query, pb.args...)
+	if err != nil {
+		return Page[T]{}, fmt.Errorf("failed to query %s: %w", what, err)
+	}
+	defer rows.Close()
+
+	page.Items = []T{}
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return Page[T]{}, fmt.Errorf("failed to scan %s: %w", what, err)
+		}
+		page.Items = append(page.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return Page[T]{}, fmt.Errorf("failed to read %s: %w", what, err)
+	}
+
+	if limit > 0 && len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.NextCursor = encodeCursor(s, page.Items[limit-1])
+	}
+	return page, nil
+}
+
+// ListEmployees returns one page of employees matching q
+func (pdb *sqlPayrollDB) ListEmployees(ctx context.Context, q EmployeeQuery) (Page[Employee], error) {
+	if err := validateSalaryRange(q.MinSalary, q.MaxSalary); err != nil {
+		return Page[Employee]{}, err
+	}
+	b := &queryBuilder{}
+	employeeFilters(b, q)
+	return listPage(ctx, pdb, "employees", employeeColumns,
+		"FROM employees e JOIN departments d ON e.dept_id = d.dept_id", b,
+		q.ListOptions, employeeSorts, "emp_id", scanEmployee)
+}
+
+// ListPayrolls returns one page of payroll records matching q
+func (pdb *sqlPayrollDB) ListPayrolls(ctx context.Context, q PayrollQuery) (Page[Payroll], error) {
+	if err := validatePayrollQuery(q); err != nil {
+		return Page[Payroll]{}, err
+	}
+	b := &queryBuilder{}
+	payrollFilters(b, q)
+	return listPage(ctx, pdb, "payroll", payrollColumns, "FROM payroll p", b,
+		q.ListOptions, payrollSorts, "payroll_id", scanPayroll)
+}
+
+// ListEmployees returns one page of employees matching q
+func (ps *PayrollSystem) ListEmployees(ctx context.Context, q EmployeeQuery) (Page[Employee], error) {
+	return ps.db.ListEmployees(ctx, q)
+}
+
+// ListPayrolls returns one page of payroll records matching q
+func (ps *PayrollSystem) ListPayrolls(ctx context.Context, q PayrollQuery) (Page[Payroll], error) {
+	return ps.db.ListPayrolls(ctx, q)
+}
@@ -0,0 +1,1313 @@
+package payroll
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryPayrollDB is an in-memory PayrollDatabase with the same semantics as the Postgres schema:
+// primary and foreign keys are enforced, num_emp is maintained like the employee triggers,
+// and deletes cascade. It is safe for concurrent use.
+type MemoryPayrollDB struct {
+	mu    *sync.RWMutex
+	state *memoryState
+	inTx  bool
+}
+
+// memoryState holds the tables of a MemoryPayrollDB
+type memoryState struct {
+	departments   map[int]Department
+	employees     map[int]Employee
+	payrolls      map[int]Payroll
+	declarations  map[[2]int]AllowanceDeclaration // keyed by emp_id, tax_year
+	nextPayrollID int
+	users         map[int]User
+	nextUserID    int
+	sessions      map[string]Session
+	roles         map[string]Role
+	changes       map[int]ProfileChange
+	nextChangeID  int
+	leaves        map[int]LeaveRequest
+	nextLeaveID   int
+	audit         []AuditEntry // append-only, in audit_id order
+	chart         ChartOfAccounts
+	idempotency   map[idempotencyKey]IdempotencyRecord
+	calendars     map[PeriodKind]PayCalendar
+	minimumWages  map[minimumWageKey]MinimumWage
+	holidays      map[int]Holiday
+	nextHolidayID int
+	sites         map[string]WorkSite
+}
+
+// minimumWageKey is the primary key of a stored minimum wage
+type minimumWageKey struct {
+	province  string
+	effective Date
+}
+
+// idempotencyKey is the primary key of a stored idempotent request
+type idempotencyKey struct {
+	userID int
+	key    string
+}
+
+// NewMemoryPayrollDB creates an empty in-memory payroll database
+func NewMemoryPayrollDB() *MemoryPayrollDB {
+	return &MemoryPayrollDB{
+		mu: &sync.RWMutex{},
+		state: &memoryState{
+			departments:   map[int]Department{},
+			employees:     map[int]Employee{},
+			payrolls:      map[int]Payroll{},
+			declarations:  map[[2]int]AllowanceDeclaration{},
+			nextPayrollID: 1,
+			users:         map[int]User{},
+			nextUserID:    1,
+			sessions:      map[string]Session{},
+			roles:         map[string]Role{},
+			changes:       map[int]ProfileChange{},
+			nextChangeID:  1,
+			leaves:        map[int]LeaveRequest{},
+			nextLeaveID:   1,
+			idempotency:   map[idempotencyKey]IdempotencyRecord{},
+			calendars:     map[PeriodKind]PayCalendar{},
+			minimumWages:  map[minimumWageKey]MinimumWage{},
+			holidays:      map[int]Holiday{},
+			nextHolidayID: 1,
+			sites:         map[string]WorkSite{},
+		},
+	}
+}
+
+// clone returns a deep copy of the state for use by a transaction
+func (s *memoryState) clone() *memoryState {
+	c := &memoryState{
+		departments:   make(map[int]Department, len(s.departments)),
+		employees:     make(map[int]Employee, len(s.employees)),
+		payrolls:      make(map[int]Payroll, len(s.payrolls)),
+		declarations:  make(map[[2]int]AllowanceDeclaration, len(s.declarations)),
+		nextPayrollID: s.nextPayrollID,
+		users:         make(map[int]User, len(s.users)),
+		nextUserID:    s.nextUserID,
+		sessions:      make(map[string]Session, len(s.sessions)),
+		roles:         make(map[string]Role, len(s.roles)),
+		changes:       make(map[int]ProfileChange, len(s.changes)),
+		nextChangeID:  s.nextChangeID,
+		leaves:        make(map[int]LeaveRequest, len(s.leaves)),
+		nextLeaveID:   s.nextLeaveID,
+		audit:         append([]AuditEntry(nil), s.audit...),
+		chart:         s.chart,
+		idempotency:   make(map[idempotencyKey]IdempotencyRecord, len(s.idempotency)),
+		calendars:     make(map[PeriodKind]PayCalendar, len(s.calendars)),
+		minimumWages:  make(map[minimumWageKey]MinimumWage, len(s.minimumWages)),
+		holidays:      make(map[int]Holiday, len(s.holidays)),
+		nextHolidayID: s.nextHolidayID,
+		sites:         make(map[string]WorkSite, len(s.sites)),
+	}
+	for k, v := range s.departments {
+		c.departments[k] = v
+	}
+	for k, v := range s.employees {
+		c.employees[k] = v
+	}
+	for k, v := range s.payrolls {
+		c.payrolls[k] = v
+	}
+	for k, v := range s.declarations {
+		c.declarations[k] = v
+	}
+	for k, v := range s.users {
+		c.users[k] = v
+	}
+	for k, v := range s.sessions {
+		c.sessions[k] = v
+	}
+	for k, v := range s.roles {
+		c.roles[k] = v
+	}
+	for k, v := range s.changes {
+		c.changes[k] = v
+	}
+	for k, v := range s.leaves {
+		c.leaves[k] = v
+	}
+	for k, v := range s.idempotency {
+		c.idempotency[k] = v
+	}
+	for k, v := range s.calendars {
+		c.calendars[k] = v
+	}
+	for k, v := range s.minimumWages {
+		c.minimumWages[k] = v
+	}
+	for k, v := range s.holidays {
+		c.holidays[k] = v
+	}
+	for k, v := range s.sites {
+		c.sites[k] = v
+	}
+	return c
+}
+
+// GetAllEmployees retrieves all employees ordered by emp_id
+func (m *MemoryPayrollDB) GetAllEmployees(ctx context.Context) ([]Employee, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var employees []Employee
+	for _, emp := range m.state.employees {
+		employees = append(employees, m.withDeptName(emp))
+	}
+	sort.Slice(employees, func(i, j int) bool { return employees[i].EmployeeID < employees[j].EmployeeID })
+	return employees, nil
+}
+
+// GetEmployee retrieves a single employee by ID
+func (m *MemoryPayrollDB) GetEmployee(ctx context.Context, empID int) (Employee, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	emp, ok := m.state.employees[empID]
+	if !ok {
+		return Employee{}, &NotFoundError{Entity: "employee", ID: empID}
+	}
+	return m.withDeptName(emp), nil
+}
+
+// withDeptName fills in the department name as the Postgres join does
+func (m *MemoryPayrollDB) withDeptName(emp Employee) Employee {
+	emp.DeptName = m.state.departments[emp.DeptID].DeptName
+	return emp
+}
+
+// GetAllDepartments retrieves all departments ordered by dept_id
+func (m *MemoryPayrollDB) GetAllDepartments(ctx context.Context) ([]Department, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var departments []Department
+	for _, dept := range m.state.departments {
+		departments = append(departments, dept)
+	}
+	sort.Slice(departments, func(i, j int) bool { return departments[i].DeptID < departments[j].DeptID })
+	return departments, nil
+}
+
+// GetDepartment retrieves a single department by ID
+func (m *MemoryPayrollDB) GetDepartment(ctx context.Context, deptID int) (Department, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dept, ok := m.state.departments[deptID]
+	if !ok {
+		return Department{}, &NotFoundError{Entity: "department", ID: deptID}
+	}
+	return dept, nil
+}
+
+// UpdateDepartment renames a department
+func (m *MemoryPayrollDB) UpdateDepartment(ctx context.Context, dept Department) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.state.departments[dept.DeptID]
+	if err := checkVersion(ok, stored.Version, dept.Version, "department", dept.DeptID); err != nil {
+		return err
+	}
+	stored.DeptName = dept.DeptName
+	stored.Version++
+	m.state.departments[dept.DeptID] = stored
+	return nil
+}
+
+// checkVersion applies the optimistic concurrency rules shared by the update methods
+func checkVersion(exists bool, stored, expected int, what string, id int) error {
+	if !exists {
+		return &NotFoundError{Entity: what, ID: id}
+	}
+	if expected != 0 && expected != stored {
+		return &ConflictError{Entity: what, ID: id}
+	}
+	return nil
+}
+
+// AddDepartment adds a new department; num_emp always starts at zero
+func (m *MemoryPayrollDB) AddDepartment(ctx context.Context, dept Department) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.state.departments[dept.DeptID]; ok {
+		return &ConflictError{Entity: "department", ID: dept.DeptID, Duplicate: true}
+	}
+	dept.NumEmp = 0
+	dept.Version = 1
+	m.state.departments[dept.DeptID] = dept
+	return nil
+}
+
+// AddEmployee adds a new employee and increments the department's num_emp
+func (m *MemoryPayrollDB) AddEmployee(ctx context.Context, emp Employee) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.state.employees[emp.EmployeeID]; ok {
+		return &ConflictError{Entity: "employee", ID: emp.EmployeeID, Duplicate: true}
+	}
+	dept, ok := m.state.departments[emp.DeptID]
+	if !ok {
+		return &ForeignKeyError{Field: "dept_id", Entity: "department", ID: emp.DeptID}
+	}
+
+	emp = emp.withPayDefaults()
+	emp.DeptName = ""
+	emp.AnonymisedAt = nil
+	emp.Version = 1
+	m.state.employees[emp.EmployeeID] = emp
+	dept.NumEmp++
+	m.state.departments[dept.DeptID] = dept
+	return nil
+}
+
+// UpdateEmployee replaces an employee's details, moving num_emp between departments if needed
+func (m *MemoryPayrollDB) UpdateEmployee(ctx context.Context, emp Employee) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.state.employees[emp.EmployeeID]
+	if err := checkVersion(ok, stored.Version, emp.Version, "employee", emp.EmployeeID); err != nil {
+		return err
+	}
+	if emp.DeptID != stored.DeptID {
+		newDept, ok := m.state.departments[emp.DeptID]
+		if !ok {
+			return &ForeignKeyError{Field: "dept_id", Entity: "department", ID: emp.DeptID}
+		}
+		newDept.NumEmp++
+		m.state.departments[newDept.DeptID] = newDept
+		if oldDept, ok := m.state.departments[stored.DeptID]; ok {
+			oldDept.NumEmp--
+			m.state.departments[oldDept.DeptID] = oldDept
+		}
+	}
+
+	emp = emp.withPayDefaults()
+	emp.DeptName = ""
+	emp.AnonymisedAt = stored.AnonymisedAt
+	emp.Version = stored.Version + 1
+	m.state.employees[emp.EmployeeID] = emp
+	return nil
+}
+
+// DeleteDepartment deletes a department and cascades to its employees
+func (m *MemoryPayrollDB) DeleteDepartment(ctx context.Context, deptID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.state.departments[deptID]; !ok {
+		return &NotFoundError{Entity: "department", ID: deptID}
+	}
+	for empID, emp := range m.state.employees {
+		if emp.DeptID == deptID {
+			m.deleteEmployee(empID)
+		}
+	}
+	delete(m.state.departments, deptID)
+	return nil
+}
+
+// DeleteEmployee deletes an employee and cascades to their payroll records
+func (m *MemoryPayrollDB) DeleteEmployee(ctx context.Context, empID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.state.employees[empID]; !ok {
+		return &NotFoundError{Entity: "employee", ID: empID}
+	}
+	m.deleteEmployee(empID)
+	return nil
+}
+
+// deleteEmployee removes an employee and dependent rows, decrementing num_emp; the caller holds the lock
+func (m *MemoryPayrollDB) deleteEmployee(empID int) {
+	emp := m.state.employees[empID]
+	delete(m.state.employees, empID)
+
+	if dept, ok := m.state.departments[emp.DeptID]; ok {
+		dept.NumEmp--
+		m.state.departments[dept.DeptID] = dept
+	}
+	for id, p := range m.state.payrolls {
+		if p.EmpID == empID {
+			delete(m.state.payrolls, id)
+		}
+	}
+	for key := range m.state.declarations {
+		if key[0] == empID {
+			delete(m.state.declarations, key)
+		}
+	}
+	for id, u := range m.state.users {
+		if u.EmpID != nil && *u.EmpID == empID {
+			u.EmpID = nil
+			m.state.users[id] = u
+		}
+	}
+	for id, c := range m.state.changes {
+		if c.EmpID == empID {
+			delete(m.state.changes, id)
+		}
+	}
+	for id, l := range m.state.leaves {
+		if l.EmpID == empID {
+			delete(m.state.leaves, id)
+		}
+	}
+}
+
+// AddPayroll adds a new payroll record with the next serial payroll_id
+func (m *MemoryPayrollDB) AddPayroll(ctx context.Context, payroll Payroll) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.state.employees[payroll.EmpID]; !ok {
+		return 0, &ForeignKeyError{Field: "emp_id", Entity: "employee", ID: payroll.EmpID}
+	}
+	payroll = payroll.withRunType()
+	if err := m.checkRunUnique(payroll); err != nil {
+		return 0, err
+	}
+	payroll.PayrollID = m.state.nextPayrollID
+	payroll.Version = 1
+	payroll.Status, payroll.ApprovedBy, payroll.ApprovedAt = PayrollDraft, nil, nil
+	m.state.nextPayrollID++
+	m.state.payrolls[payroll.PayrollID] = payroll
+	return payroll.PayrollID, nil
+}
+
+// checkRunUnique enforces the unique (emp_id, pay_month, run_type) index on records other than p itself
+func (m *MemoryPayrollDB) checkRunUnique(p Payroll) error {
+	for _, other := range m.state.payrolls {
+		if other.PayrollID != p.PayrollID && other.EmpID == p.EmpID && other.PayMonth == p.PayMonth && other.RunType == p.RunType {
+			return &ConflictError{Entity: "payroll", ID: p.runKey(), Duplicate: true}
+		}
+	}
+	return nil
+}
+
+// GetAllPayrolls retrieves all payroll records ordered by payroll_id
+func (m *MemoryPayrollDB) GetAllPayrolls(ctx context.Context) ([]Payroll, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var payrolls []Payroll
+	for _, p := range m.state.payrolls {
+		payrolls = append(payrolls, p)
+	}
+	sort.Slice(payrolls, func(i, j int) bool { return payrolls[i].PayrollID < payrolls[j].PayrollID })
+	return payrolls, nil
+}
+
+// ListEmployees returns one page of employees matching q
+func (m *MemoryPayrollDB) ListEmployees(ctx context.Context, q EmployeeQuery) (Page[Employee], error) {
+	if err := validateSalaryRange(q.MinSalary, q.MaxSalary); err != nil {
+		return Page[Employee]{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var employees []Employee
+	for _, emp := range m.state.employees {
+		if (q.EmpID != 0 && emp.EmployeeID != q.EmpID) ||
+			(q.DeptID != 0 && emp.DeptID != q.DeptID) ||
+			(q.Position != "" && emp.PositionName != q.Position) ||
+			!inRange(emp.BaseSalary, q.MinSalary, q.MaxSalary) {
+			continue
+		}
+		employees = append(employees, m.withDeptName(emp))
+	}
+	return memoryPage(employees, q.ListOptions, employeeSorts, "emp_id")
+}
+
+// ListPayrolls returns one page of payroll records matching q
+func (m *MemoryPayrollDB) ListPayrolls(ctx context.Context, q PayrollQuery) (Page[Payroll], error) {
+	if err := validatePayrollQuery(q); err != nil {
+		return Page[Payroll]{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var payrolls []Payroll
+	for _, p := range m.state.payrolls {
+		if (q.EmpID != 0 && p.EmpID != q.EmpID) ||
+			(q.DeptID != 0 && m.state.employees[p.EmpID].DeptID != q.DeptID) ||
+			(!q.PayMonth.IsZero() && p.PayMonth != q.PayMonth) ||
+			(!q.PayMonthFrom.IsZero() && p.PayMonth.Start().Before(q.PayMonthFrom.Start())) ||
+			(!q.PayMonthTo.IsZero() && p.PayMonth.Start().After(q.PayMonthTo.End())) ||
+			!inRange(p.BaseSalary, q.MinSalary, q.MaxSalary) || (q.Status != "" && p.Status != q.Status) {
+			continue
+		}
+		payrolls = append(payrolls, p)
+	}
+	return memoryPage(payrolls, q.ListOptions, payrollSorts, "payroll_id")
+}
+
+// inRange reports whether v lies within the optional inclusive bounds
+func inRange(v float64, lo, hi *float64) bool {
+	return (lo == nil || v >= *lo) && (hi == nil || v <= *hi)
+}
+
+// memoryPage sorts the filtered items and cuts the page the SQL backends would return
+func memoryPage[T any](items []T, opts ListOptions, fields map[string]sortField[T], idName string) (Page[T], error) {
+	s, err := resolveSort(opts.Sort, fields, idName)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	limit, err := normalizeLimit(opts.Limit)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	cursor, err := decodeCursor(opts.Cursor, s)
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	// compare orders an item against a sort value and ID, honouring the direction
+	compare := func(item T, value any, id int) int {
+		c := compareValues(s.field.key(item), value)
+		if c == 0 {
+			c = compareValues(s.id.key(item), id)
+		}
+		if s.desc {
+			c = -c
+		}
+		return c
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return compare(items[i], s.field.key(items[j]), s.id.key(items[j]).(int)) < 0
+	})
+
+	page := Page[T]{Total: len(items), Items: []T{}}
+	for _, item := range items {
+		if cursor != nil && compare(item, cursor.Value, cursor.ID) <= 0 {
+			continue
+		}
+		if limit > 0 && len(page.Items) == limit {
+			page.NextCursor = encodeCursor(s, page.Items[limit-1])
+			break
+		}
+		page.Items = append(page.Items, item)
+	}
+	return page, nil
+}
+
+// GetPayroll retrieves a single payroll record by ID
+func (m *MemoryPayrollDB) GetPayroll(ctx context.Context, payrollID int) (Payroll, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.state.payrolls[payrollID]
+	if !ok {
+		return Payroll{}, &NotFoundError{Entity: "payroll", ID: payrollID}
+	}
+	return p, nil
+}
+
+// UpdatePayroll replaces a payroll record's amounts and period
+func (m *MemoryPayrollDB) UpdatePayroll(ctx context.Context, payroll Payroll) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.state.payrolls[payroll.PayrollID]
+	if err := checkVersion(ok, stored.Version, payroll.Version, "payroll", payroll.PayrollID); err != nil {
+		return err
+	}
+	if stored.Status == PayrollApproved {
+		return approvedError(payroll.PayrollID)
+	}
+	if _, ok := m.state.employees[payroll.EmpID]; !ok {
+		return &ForeignKeyError{Field: "emp_id", Entity: "employee", ID: payroll.EmpID}
+	}
+	payroll = payroll.withRunType()
+	if err := m.checkRunUnique(payroll); err != nil {
+		return err
+	}
+	payroll.Version = stored.Version + 1
+	payroll.Status, payroll.ApprovedBy, payroll.ApprovedAt = stored.Status, stored.ApprovedBy, stored.ApprovedAt
+	m.state.payrolls[payroll.PayrollID] = payroll
+	return nil
+}
+
+// DeletePayroll deletes a payroll record that is not approved
+func (m *MemoryPayrollDB) DeletePayroll(ctx context.Context, payrollID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.state.payrolls[payrollID]
+	if !ok {
+		return &NotFoundError{Entity: "payroll", ID: payrollID}
+	}
+	if stored.Status == PayrollApproved {
+		return approvedError(payrollID)
+	}
+	delete(m.state.payrolls, payrollID)
+	return nil
+}
+
+// SaveAllowanceDeclaration inserts or replaces an employee's declaration for a tax year
+func (m *MemoryPayrollDB) SaveAllowanceDeclaration(ctx context.Context, d AllowanceDeclaration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.state.employees[d.EmpID]; !ok {
+		return &ForeignKeyError{Field: "emp_id", Entity: "employee", ID: d.EmpID}
+	}
+	m.state.declarations[[2]int{d.EmpID, d.TaxYear}] = d
+	return nil
+}
+
+// GetAllowanceDeclaration retrieves an employee's declaration for a tax year, or nil if none was submitted
+func (m *MemoryPayrollDB) GetAllowanceDeclaration(ctx context.Context, empID, taxYear int) (*AllowanceDeclaration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	d, ok := m.state.declarations[[2]int{empID, taxYear}]
+	if !ok {
+		return nil, nil
+	}
+	return &d, nil
+}
+
+// ListAllowanceDeclarations retrieves every declaration an employee has submitted, oldest tax year first
+func (m *MemoryPayrollDB) ListAllowanceDeclarations(ctx context.Context, empID int) ([]AllowanceDeclaration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	declarations := []AllowanceDeclaration{}
+	for key, d := range m.state.declarations {
+		if key[0] == empID {
+			declarations = append(declarations, d)
+		}
+	}
+	sort.Slice(declarations, func(i, j int) bool { return declarations[i].TaxYear < declarations[j].TaxYear })
+	return declarations, nil
+}
+
+// GetChartOfAccounts reads the ledger account mapping; accounts never set are empty
+func (m *MemoryPayrollDB) GetChartOfAccounts(ctx context.Context) (ChartOfAccounts, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c := m.state.chart
+	c.DepartmentSalaryExpense = append([]DepartmentAccount{}, c.DepartmentSalaryExpense...)
+	sort.Slice(c.DepartmentSalaryExpense, func(i, j int) bool {
+		return c.DepartmentSalaryExpense[i].DeptID < c.DepartmentSalaryExpense[j].DeptID
+	})
+	return c, nil
+}
+
+// SaveChartOfAccounts replaces the ledger account mapping
+func (m *MemoryPayrollDB) SaveChartOfAccounts(ctx context.Context, c ChartOfAccounts) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c.DepartmentSalaryExpense = append([]DepartmentAccount(nil), c.DepartmentSalaryExpense...)
+	m.state.chart = c
+	return nil
+}
+
+// ListPayCalendars reads the stored pay calendars by frequency
+func (m *MemoryPayrollDB) ListPayCalendars(ctx context.Context) ([]PayCalendar, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var calendars []PayCalendar
+	for _, c := range m.state.calendars {
+		calendars = append(calendars, c)
+	}
+	sort.Slice(calendars, func(i, j int) bool { return calendars[i].Frequency < calendars[j].Frequency })
+	return calendars, nil
+}
+
+// SavePayCalendar stores the pay calendar of a frequency, replacing the one it had
+func (m *MemoryPayrollDB) SavePayCalendar(ctx context.Context, c PayCalendar) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.state.calendars[c.Frequency] = c
+	return nil
+}
+
+// ListMinimumWages reads the minimum wages of a province, or of every province when it is empty,
+// by province and effective date
+func (m *MemoryPayrollDB) ListMinimumWages(ctx context.Context, province string) ([]MinimumWage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var wages []MinimumWage
+	for _, w := range m.state.minimumWages {
+		if province == "" || w.Province == province {
+			wages = append(wages, w)
+		}
+	}
+	sortMinimumWages(wages)
+	return wages, nil
+}
+
+// SaveMinimumWage stores the minimum wage of a province from a date, replacing the rate it had
+func (m *MemoryPayrollDB) SaveMinimumWage(ctx context.Context, w MinimumWage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.state.minimumWages[minimumWageKey{w.Province, w.EffectiveDate}] = w
+	return nil
+}
+
+// DeleteMinimumWage deletes the minimum wage of a province from a date
+func (m *MemoryPayrollDB) DeleteMinimumWage(ctx context.Context, province string, effective Date) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := minimumWageKey{province, effective}
+	if _, ok := m.state.minimumWages[key]; !ok {
+		return &NotFoundError{Entity: "minimum wage", ID: province + " " + effective.String()}
+	}
+	delete(m.state.minimumWages, key)
+	return nil
+}
+
+// ListHolidays reads the holidays between two days, inclusive, by date and site
+func (m *MemoryPayrollDB) ListHolidays(ctx context.Context, from, to Date) ([]Holiday, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var holidays []Holiday
+	for _, h := range m.state.holidays {
+		if !h.Date.Before(from) && !h.Date.After(to) {
+			holidays = append(holidays, h)
+		}
+	}
+	sortHolidays(holidays)
+	return holidays, nil
+}
+
+// GetHoliday retrieves a holiday
+func (m *MemoryPayrollDB) GetHoliday(ctx context.Context, holidayID int) (Holiday, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	h, ok := m.state.holidays[holidayID]
+	if !ok {
+		return Holiday{}, &NotFoundError{Entity: "holiday", ID: holidayID}
+	}
+	return h, nil
+}
+
+// SaveHoliday stores a holiday and returns its ID; a site has one holiday a day, so one already on
+// the day is replaced
+func (m *MemoryPayrollDB) SaveHoliday(ctx context.Context, h Holiday) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h.HolidayID = 0
+	for id, e := range m.state.holidays {
+		if e.Date == h.Date && e.SiteID == h.SiteID {
+			h.HolidayID = id
+		}
+	}
+	if h.HolidayID == 0 {
+		h.HolidayID = m.state.nextHolidayID
+		m.state.nextHolidayID++
+	}
+	m.state.holidays[h.HolidayID] = h
+	return h.HolidayID, nil
+}
+
+// DeleteHoliday deletes a holiday
+func (m *MemoryPayrollDB) DeleteHoliday(ctx context.Context, holidayID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.state.holidays[holidayID]; !ok {
+		return &NotFoundError{Entity: "holiday", ID: holidayID}
+	}
+	delete(m.state.holidays, holidayID)
+	return nil
+}
+
+// ListWorkSites reads the work sites by ID
+func (m *MemoryPayrollDB) ListWorkSites(ctx context.Context) ([]WorkSite, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var sites []WorkSite
+	for _, s := range m.state.sites {
+		sites = append(sites, s)
+	}
+	sort.Slice(sites, func(i, j int) bool { return sites[i].SiteID < sites[j].SiteID })
+	return sites, nil
+}
+
+// GetWorkSite retrieves a work site
+func (m *MemoryPayrollDB) GetWorkSite(ctx context.Context, siteID string) (WorkSite, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.state.sites[siteID]
+	if !ok {
+		return WorkSite{}, &NotFoundError{Entity: "work site", ID: siteID}
+	}
+	return s, nil
+}
+
+// SaveWorkSite stores a work site, replacing the one with its ID
+func (m *MemoryPayrollDB) SaveWorkSite(ctx context.Context, s WorkSite) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s.WorkDays = append(WorkWeek(nil), s.WorkDays...)
+	m.state.sites[s.SiteID] = s
+	return nil
+}
+
+// DeleteWorkSite deletes a work site
+func (m *MemoryPayrollDB) DeleteWorkSite(ctx context.Context, siteID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.state.sites[siteID]; !ok {
+		return &NotFoundError{Entity: "work site", ID: siteID}
+	}
+	delete(m.state.sites, siteID)
+	return nil
+}
+
+// AnonymiseEmployee erases an employee's personal fields and those of their requests
+func (m *MemoryPayrollDB) AnonymiseEmployee(ctx context.Context, empID int, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	emp, ok := m.state.employees[empID]
+	if !ok {
+		return &NotFoundError{Entity: "employee", ID: empID}
+	}
+	if emp.AnonymisedAt != nil {
+		return &ConflictError{Entity: "employee", ID: empID}
+	}
+	emp.EmpName = anonymisedName(empID)
+	emp.PhoneNumber, emp.BankAccount, emp.AccountNum, emp.NationalID = "", "", "", ""
+	emp.AnonymisedAt = &at
+	emp.Version++
+	m.state.employees[empID] = emp
+	for id, c := range m.state.changes {
+		if c.EmpID == empID {
+			c.PhoneNumber, c.BankAccount, c.AccountNum = nil, nil, nil
+			m.state.changes[id] = c
+		}
+	}
+	for id, l := range m.state.leaves {
+		if l.EmpID == empID {
+			l.Reason = ""
+			m.state.leaves[id] = l
+		}
+	}
+	return nil
+}
+
+// AddUser creates a user with the next serial user_id; usernames are unique
+func (m *MemoryPayrollDB) AddUser(ctx context.Context, u User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.state.users {
+		if existing.Username == u.Username {
+			return &ConflictError{Entity: "user", ID: u.Username, Duplicate: true}
+		}
+	}
+	if err := m.checkUserRefs(u); err != nil {
+		return err
+	}
+	u.Roles = append([]string{}, u.Roles...)
+	sort.Strings(u.Roles)
+	u.UserID = m.state.nextUserID
+	u.CreatedAt = time.Now().UTC()
+	m.state.nextUserID++
+	m.state.users[u.UserID] = u
+	return nil
+}
+
+// GetUserByUsername retrieves a user for login
+func (m *MemoryPayrollDB) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.state.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return User{}, &NotFoundError{Entity: "user", ID: username}
+}
+
+// GetUser retrieves a user by ID
+func (m *MemoryPayrollDB) GetUser(ctx context.Context, userID int) (User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.state.users[userID]
+	if !ok {
+		return User{}, &NotFoundError{Entity: "user", ID: userID}
+	}
+	return u, nil
+}
+
+// UpdateUser changes a user's employee link and replaces their roles
+func (m *MemoryPayrollDB) UpdateUser(ctx context.Context, u User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.state.users[u.UserID]
+	if !ok {
+		return &NotFoundError{Entity: "user", ID: u.UserID}
+	}
+	if err := m.checkUserRefs(u); err != nil {
+		return err
+	}
+	stored.EmpID = u.EmpID
+	stored.Roles = append([]string{}, u.Roles...)
+	sort.Strings(stored.Roles)
+	m.state.users[u.UserID] = stored
+	return nil
+}
+
+// checkUserRefs enforces the foreign keys from a user to their employee and roles; the caller holds the lock
+func (m *MemoryPayrollDB) checkUserRefs(u User) error {
+	if u.EmpID != nil {
+		if _, ok := m.state.employees[*u.EmpID]; !ok {
+			return &ForeignKeyError{Field: "emp_id", Entity: "employee", ID: *u.EmpID}
+		}
+	}
+	for _, role := range u.Roles {
+		if _, ok := m.state.roles[role]; !ok {
+			return &NotFoundError{Entity: "role", ID: role}
+		}
+	}
+	return nil
+}
+
+// ListUsers retrieves every user ordered by user_id
+func (m *MemoryPayrollDB) ListUsers(ctx context.Context) ([]User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var users []User
+	for _, u := range m.state.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+	return users, nil
+}
+
+// ListRoles retrieves every role ordered by name
+func (m *MemoryPayrollDB) ListRoles(ctx context.Context) ([]Role, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var roles []Role
+	for _, r := range m.state.roles {
+		roles = append(roles, r)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].RoleName < roles[j].RoleName })
+	return roles, nil
+}
+
+// GetRole retrieves a role
+func (m *MemoryPayrollDB) GetRole(ctx context.Context, name string) (Role, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.state.roles[name]
+	if !ok {
+		return Role{}, &NotFoundError{Entity: "role", ID: name}
+	}
+	return r, nil
+}
+
+// SaveRole creates or replaces a role
+func (m *MemoryPayrollDB) SaveRole(ctx context.Context, r Role) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r.Permissions = append([]string{}, r.Permissions...)
+	sort.Strings(r.Permissions)
+	m.state.roles[r.RoleName] = r
+	return nil
+}
+
+// DeleteRole deletes a role and removes it from every user, like the cascading foreign key
+func (m *MemoryPayrollDB) DeleteRole(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.state.roles[name]; !ok {
+		return &NotFoundError{Entity: "role", ID: name}
+	}
+	delete(m.state.roles, name)
+	for id, u := range m.state.users {
+		roles := []string{}
+		for _, role := range u.Roles {
+			if role != name {
+				roles = append(roles, role)
+			}
+		}
+		u.Roles = roles
+		m.state.users[id] = u
+	}
+	return nil
+}
+
+// ApprovePayroll marks a draft payroll record approved, checking its version like an update
+func (m *MemoryPayrollDB) ApprovePayroll(ctx context.Context, payrollID, version, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.state.payrolls[payrollID]
+	if err := checkVersion(ok, stored.Version, version, "payroll", payrollID); err != nil {
+		return err
+	}
+	if stored.Status != PayrollDraft {
+		return &ConflictError{Entity: "payroll", ID: payrollID}
+	}
+	now := time.Now().UTC()
+	stored.Status, stored.ApprovedBy, stored.ApprovedAt = PayrollApproved, &userID, &now
+	stored.Version++
+	m.state.payrolls[payrollID] = stored
+	return nil
+}
+
+// AddSession stores a new login session
+func (m *MemoryPayrollDB) AddSession(ctx context.Context, s Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.state.users[s.UserID]; !ok {
+		return &ForeignKeyError{Field: "user_id", Entity: "user", ID: s.UserID}
+	}
+	if _, ok := m.state.sessions[s.SessionID]; ok {
+		return &ConflictError{Entity: "session", ID: s.SessionID, Duplicate: true}
+	}
+	s.RevokedAt = nil
+	m.state.sessions[s.SessionID] = s
+	return nil
+}
+
+// GetSession retrieves a session by ID
+func (m *MemoryPayrollDB) GetSession(ctx context.Context, sessionID string) (Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.state.sessions[sessionID]
+	if !ok {
+		return Session{}, &NotFoundError{Entity: "session", ID: sessionID}
+	}
+	return s, nil
+}
+
+// RotateSession replaces the refresh token hash if oldHash is still current and the session is not revoked
+func (m *MemoryPayrollDB) RotateSession(ctx context.Context, sessionID, oldHash, newHash string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.state.sessions[sessionID]
+	if !ok || s.RefreshHash != oldHash || s.RevokedAt != nil {
+		return &ConflictError{Entity: "session", ID: sessionID}
+	}
+	s.RefreshHash = newHash
+	s.ExpiresAt = expiresAt
+	m.state.sessions[sessionID] = s
+	return nil
+}
+
+// ReserveIdempotencyKey stores r unless an unexpired record for its user and key exists, which is returned instead
+func (m *MemoryPayrollDB) ReserveIdempotencyKey(ctx context.Context, r IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.state.users[r.UserID]; !ok {
+		return IdempotencyRecord{}, false, &ForeignKeyError{Field: "user_id", Entity: "user", ID: r.UserID}
+	}
+	expiry := r.CreatedAt.Add(-IdempotencyKeyTTL)
+	for k, stored := range m.state.idempotency {
+		if stored.CreatedAt.Before(expiry) {
+			delete(m.state.idempotency, k)
+		}
+	}
+	k := idempotencyKey{r.UserID, r.Key}
+	if stored, ok := m.state.idempotency[k]; ok {
+		return stored, false, nil
+	}
+	r.Status, r.Headers, r.Body = 0, nil, nil
+	m.state.idempotency[k] = r
+	return r, true, nil
+}
+
+// CompleteIdempotencyKey stores the response to a reserved request
+func (m *MemoryPayrollDB) CompleteIdempotencyKey(ctx context.Context, r IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := idempotencyKey{r.UserID, r.Key}
+	stored, ok := m.state.idempotency[k]
+	if !ok {
+		return &NotFoundError{Entity: "idempotency key", ID: r.Key}
+	}
+	stored.Status, stored.Headers, stored.Body = r.Status, r.Headers, append([]byte(nil), r.Body...)
+	m.state.idempotency[k] = stored
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets a reservation so the request can be retried
+func (m *MemoryPayrollDB) ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.state.idempotency, idempotencyKey{userID, key})
+	return nil
+}
+
+// RevokeSession marks a session revoked; revoking twice is not an error
+func (m *MemoryPayrollDB) RevokeSession(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.state.sessions[sessionID]
+	if ok && s.RevokedAt == nil {
+		now := time.Now().UTC()
+		s.RevokedAt = &now
+		m.state.sessions[sessionID] = s
+	}
+	return nil
+}
+
+// WithTx runs fn against a private copy of the data and publishes it only if fn succeeds.
+// Transactions hold the write lock for their duration, so they are fully serialized.
+func (m *MemoryPayrollDB) WithTx(ctx context.Context, fn func(PayrollDatabase) error) error {
+	if m.inTx {
+		return fn(m)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx := &MemoryPayrollDB{mu: &sync.RWMutex{}, state: m.state.clone(), inTx: true}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	m.state = tx.state
+	return nil
+}
+
+// Close is a no-op for the in-memory database
+func (m *MemoryPayrollDB) Close() error {
+	return nil
+}
+
+// SeedSampleData loads the same sample departments and employees as the initial migration
+func SeedSampleData(ctx context.Context, db PayrollDatabase) error {
+	return db.WithTx(ctx, func(tdb PayrollDatabase) error {
+		for _, dept := range []Department{
+			{DeptID: 1001, DeptName: "Marketing"},
+			{DeptID: 1002, DeptName: "Sales"},
+			{DeptID: 1003, DeptName: "Human Resources"},
+		} {
+			if err := tdb.AddDepartment(ctx, dept); err != nil {
+				return err
+			}
+		}
+		for _, emp := range []Employee{
+			{EmployeeID: 1, EmpName: "นายสมชาย", PhoneNumber: "0812345678", DeptID: 1001, PositionName: "Marketing Manager", BaseSalary: 30000, BankAccount: "Kasikorn Bank", AccountNum: "1234567890"},
+			{EmployeeID: 2, EmpName: "นางสาวสาวิตรี", PhoneNumber: "0823456789", DeptID: 1002, PositionName: "Sales", BaseSalary: 22000, BankAccount: "Bangkok Bank", AccountNum: "0987654321"},
+			{EmployeeID: 3, EmpName: "นายบ่าว", PhoneNumber: "0834567890", DeptID: 1003, PositionName: "Human Resources", BaseSalary: 25000, BankAccount: "Krungsri Bank", AccountNum: "5678901234"},
+		} {
+			if err := tdb.AddEmployee(ctx, emp); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// matchesRequest reports whether a request by empID with status falls under q; the caller holds the lock
+func (m *MemoryPayrollDB) matchesRequest(q RequestQuery, empID int, status string) bool {
+	return (q.EmpID == 0 || empID == q.EmpID) &&
+		(q.DeptID == 0 || m.state.employees[empID].DeptID == q.DeptID) &&
+		(q.Status == "" || status == q.Status)
+}
+
+// reviewPending checks that a request exists and is still pending, then fills in the decision
+func reviewPending(current Review, ok bool, r Review, what string, id int) (Review, error) {
+	if !ok {
+		return Review{}, &NotFoundError{Entity: what, ID: id}
+	}
+	if current.Status != RequestPending {
+		return Review{}, &ConflictError{Entity: what, ID: id}
+	}
+	at := reviewTime(r)
+	r.RequestedAt, r.ReviewedAt = current.RequestedAt, &at
+	return r, nil
+}
+
+// AddProfileChange stores a pending profile change request with the next serial change_id
+func (m *MemoryPayrollDB) AddProfileChange(ctx context.Context, c ProfileChange) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.state.employees[c.EmpID]; !ok {
+		return 0, &ForeignKeyError{Field: "emp_id", Entity: "employee", ID: c.EmpID}
+	}
+	c.ChangeID = m.state.nextChangeID
+	c.Review = Review{Status: RequestPending, RequestedAt: time.Now().UTC()}
+	m.state.nextChangeID++
+	m.state.changes[c.ChangeID] = c
+	return c.ChangeID, nil
+}
+
+// GetProfileChange retrieves a profile change request
+func (m *MemoryPayrollDB) GetProfileChange(ctx context.Context, changeID int) (ProfileChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.state.changes[changeID]
+	if !ok {
+		return ProfileChange{}, &NotFoundError{Entity: "profile change", ID: changeID}
+	}
+	return c, nil
+}
+
+// ListProfileChanges retrieves the profile change requests matching q, oldest first
+func (m *MemoryPayrollDB) ListProfileChanges(ctx context.Context, q RequestQuery) ([]ProfileChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	changes := []ProfileChange{}
+	for _, c := range m.state.changes {
+		if m.matchesRequest(q, c.EmpID, c.Status) {
+			changes = append(changes, c)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ChangeID < changes[j].ChangeID })
+	return changes, nil
+}
+
+// ReviewProfileChange records the decision on a pending profile change request
+func (m *MemoryPayrollDB) ReviewProfileChange(ctx context.Context, changeID int, r Review) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.state.changes[changeID]
+	review, err := reviewPending(c.Review, ok, r, "profile change", changeID)
+	if err != nil {
+		return err
+	}
+	c.Review = review
+	m.state.changes[changeID] = c
+	return nil
+}
+
+// AddLeaveRequest stores a pending leave request with the next serial leave_id
+func (m *MemoryPayrollDB) AddLeaveRequest(ctx context.Context, l LeaveRequest) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.state.employees[l.EmpID]; !ok {
+		return 0, &ForeignKeyError{Field: "emp_id", Entity: "employee", ID: l.EmpID}
+	}
+	l.LeaveID = m.state.nextLeaveID
+	l.Review = Review{Status: RequestPending, RequestedAt: time.Now().UTC()}
+	m.state.nextLeaveID++
+	m.state.leaves[l.LeaveID] = l
+	return l.LeaveID, nil
+}
+
+// GetLeaveRequest retrieves a leave request
+func (m *MemoryPayrollDB) GetLeaveRequest(ctx context.Context, leaveID int) (LeaveRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	l, ok := m.state.leaves[leaveID]
+	if !ok {
+		return LeaveRequest{}, &NotFoundError{Entity: "leave request", ID: leaveID}
+	}
+	return l, nil
+}
+
+// ListLeaveRequests retrieves the leave requests matching q, ordered by start date
+func (m *MemoryPayrollDB) ListLeaveRequests(ctx context.Context, q RequestQuery) ([]LeaveRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	leaves := []LeaveRequest{}
+	for _, l := range m.state.leaves {
+		if m.matchesRequest(q, l.EmpID, l.Status) {
+			leaves = append(leaves, l)
+		}
+	}
+	sort.Slice(leaves, func(i, j int) bool {
+		if leaves[i].StartDate != leaves[j].StartDate {
+			return leaves[i].StartDate < leaves[j].StartDate
+		}
+		return leaves[i].LeaveID < leaves[j].LeaveID
+	})
+	return leaves, nil
+}
+
+// ReviewLeaveRequest records the decision on a pending leave request
+func (m *MemoryPayrollDB) ReviewLeaveRequest(ctx context.Context, leaveID int, r Review) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, ok := m.state.leaves[leaveID]
+	review, err := reviewPending(l.Review, ok, r, "leave request", leaveID)
+	if err != nil {
+		return err
+	}
+	l.Review = review
+	m.state.leaves[leaveID] = l
+	return nil
+}
+
+// AppendAudit seals an entry onto the end of the chain and stores it
+func (m *MemoryPayrollDB) AppendAudit(ctx context.Context, e AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev := ""
+	if n := len(m.state.audit); n > 0 {
+		prev = m.state.audit[n-1].Hash
+	}
+	e.seal(prev)
+	e.AuditID = len(m.state.audit) + 1
+	e.Changes = nil
+	m.state.audit = append(m.state.audit, e)
+	return nil
+}
+
+// ListAudit returns one page of audit entries matching q
+func (m *MemoryPayrollDB) ListAudit(ctx context.Context, q AuditQuery) (Page[AuditEntry], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var entries []AuditEntry
+	for _, e := range m.state.audit {
+		if (q.Entity != "" && e.Entity != q.Entity) ||
+			(q.EntityID != "" && e.EntityID != q.EntityID) ||
+			(q.ActorID != 0 && (e.ActorID == nil || *e.ActorID != q.ActorID)) ||
+			(q.RequestID != "" && e.RequestID != q.RequestID) {
+			continue
+		}
+		e.diff()
+		entries = append(entries, e)
+	}
+	return memoryPage(entries, q.ListOptions, auditSorts, "audit_id")
+}
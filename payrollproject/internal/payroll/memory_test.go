@@ -0,0 +1,46 @@
+package payroll
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestMemoryPayrollDBConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T) PayrollDatabase {
+		return NewMemoryPayrollDB()
+	})
+}
+
+func TestMemoryPayrollDBConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemoryPayrollDB()
+	if err := db.AddDepartment(ctx, Department{DeptID: 1, DeptName: "One"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 50; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			err := db.WithTx(ctx, func(tdb PayrollDatabase) error {
+				if err := tdb.AddEmployee(ctx, Employee{EmployeeID: id, DeptID: 1}); err != nil {
+					return err
+				}
+				_, err := tdb.AddPayroll(ctx, Payroll{EmpID: id})
+				return err
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	depts, _ := db.GetAllDepartments(ctx)
+	payrolls, _ := db.GetAllPayrolls(ctx)
+	if depts[0].NumEmp != 50 || len(payrolls) != 50 {
+		t.Fatalf("num_emp = %d, payrolls = %d, want 50 and 50", depts[0].NumEmp, len(payrolls))
+	}
+}
@@ -0,0 +1,299 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ThaiProvinces lists the 77 provinces of Thailand by their Thai names, Bangkok included. Minimum
+// wages are announced per province, so an employee's work location is one of these.
+var ThaiProvinces = []string{
+	"กรุงเทพมหานคร", "กระบี่", "กาญจนบุรี", "กาฬสินธุ์", "กำแพงเพชร", "ขอนแก่น", "จันทบุรี",
+	"ฉะเชิงเทรา", "ชลบุรี", "ชัยนาท", "ชัยภูมิ", "ชุมพร", "เชียงราย", "เชียงใหม่", "ตรัง", "ตราด",
+	"ตาก", "นครนายก", "นครปฐม", "นครพนม", "นครราชสีมา", "นครศรีธรรมราช", "นครสวรรค์", "นนทบุรี",
+	"นราธิวาส", "น่าน", "บึงกาฬ", "บุรีรัมย์", "ปทุมธานี", "ประจวบคีรีขันธ์", "ปราจีนบุรี", "ปัตตานี",
+	"พระนครศรีอยุธยา", "พะเยา", "พังงา", "พัทลุง", "พิจิตร", "พิษณุโลก", "เพชรบุรี", "เพชรบูรณ์",
+	"แพร่", "ภูเก็ต", "มหาสารคาม", "มุกดาหาร", "แม่ฮ่องสอน", "ยโสธร", "ยะลา", "ร้อยเอ็ด", "ระนอง",
+	"ระยอง", "ราชบุรี", "ลพบุรี", "ลำปาง", "ลำพูน", "เลย", "ศรีสะเกษ", "สกลนคร", "สงขลา", "สตูล",
+	"สมุทรปราการ", "สมุทรสงคราม", "สมุทรสาคร", "สระแก้ว", "สระบุรี", "สิงห์บุรี", "สุโขทัย",
+	"สุพรรณบุรี", "สุราษฎร์ธานี", "สุรินทร์", "หนองคาย", "หนองบัวลำภู", "อ่างทอง", "อำนาจเจริญ",
+	"อุดรธานี", "อุตรดิตถ์", "อุทัยธานี", "อุบลราชธานี",
+}
+
+// IsThaiProvince reports whether s is the Thai name of a province
+func IsThaiProvince(s string) bool { return slices.Contains(ThaiProvinces, s) }
+
+// MinimumWage is the lowest daily wage that may be paid in a province from a date, as announced by
+// the Wage Committee. A rate stays in force until the province's next one takes effect.
+type MinimumWage struct {
+	Province      string  `json:"province" validate:"thai_province"`
+	EffectiveDate Date    `json:"effective_date" validate:"required"`
+	DailyRate     float64 `json:"daily_rate" validate:"gt=0,lte=10000"`
+}
+
+// Validate checks a minimum wage before it is stored
+func (w MinimumWage) Validate() error { return validateStruct(w) }
+
+// MinimumWagePolicy says what happens when an employee is paid below the minimum wage
+type MinimumWagePolicy string
+
+const (
+	// MinimumWageBlock refuses employee changes and pay calculations below the minimum wage, and
+	// makes it an error that blocks approval of a pay run
+	MinimumWageBlock MinimumWagePolicy = "block"
+	// MinimumWageWarn allows them, flagging the calculation and warning in the pay run checks
+	MinimumWageWarn MinimumWagePolicy = "warn"
+)
+
+// SetMinimumWagePolicy sets whether pay below the minimum wage is blocked or only flagged
+func (ps *PayrollSystem) SetMinimumWagePolicy(p MinimumWagePolicy) {
+	ps.minimumWagePolicy = p
+}
+
+// MinimumWageCheck compares an employee's pay with the minimum wage of their work province
+type MinimumWageCheck struct {
+	Province      string  `json:"province"`
+	EffectiveDate Date    `json:"effective_date"` // when the minimum compared with took effect
+	MinimumRate   float64 `json:"minimum_daily_rate"`
+	DailyRate     float64 `json:"daily_rate"` // the employee's pay for a day's work
+	Compliant     bool    `json:"compliant"`
+}
+
+// DailyPayRate is what an employee earns for a standard day's work: their daily wage, a standard
+// day of their hourly wage, or a thirtieth of their monthly salary, as the Labour Protection Act
+// converts a monthly wage to a daily one
+func DailyPayRate(emp Employee) float64 {
+	switch emp.PayType {
+	case PayHourly:
+		return round2(emp.WageRate * StandardHoursPerDay)
+	case PayDaily:
+		return emp.WageRate
+	}
+	return round2(emp.BaseSalary / 30)
+}
+
+// ListMinimumWages reads the minimum wages of a province, or of every province when it is empty,
+// by province and effective date
+func (pdb *sqlPayrollDB) ListMinimumWages(ctx context.Context, province string) ([]MinimumWage, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+        SELECT province, effective_date, daily_rate FROM minimum_wages
+        WHERE $1 = '' OR province = $1
+        ORDER BY province, effective_date`, province)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query minimum wages: %w", err)
+	}
+	defer rows.Close()
+
+	var wages []MinimumWage
+	for rows.Next() {
+		var w MinimumWage
+		if err := rows.Scan(&w.Province, &w.EffectiveDate, &w.DailyRate); err != nil {
+			return nil, fmt.Errorf("failed to scan minimum wage: %w", err)
+		}
+		wages = append(wages, w)
+	}
+	return wages, rows.Err()
+}
+
+// SaveMinimumWage stores the minimum wage of a province from a date, replacing the rate it had
+func (pdb *sqlPayrollDB) SaveMinimumWage(ctx context.Context, w MinimumWage) error {
+	_, err := pdb.db.ExecContext(ctx, `
+        INSERT INTO minimum_wages (province, effective_date, daily_rate) VALUES ($1, $2, $3)
+        ON CONFLICT (province, effective_date) DO UPDATE SET daily_rate = excluded.daily_rate`,
+		w.Province, w.EffectiveDate, w.DailyRate)
+	if err != nil {
+		return fmt.Errorf("failed to save minimum wage: %w", err)
+	}
+	return nil
+}
+
+// DeleteMinimumWage deletes the minimum wage of a province from a date
+func (pdb *sqlPayrollDB) DeleteMinimumWage(ctx context.Context, province string, effective Date) error {
+	res, err := pdb.db.ExecContext(ctx, "DELETE FROM minimum_wages WHERE province = $1 AND effective_date = $2", province, effective)
+	if err != nil {
+		return fmt.Errorf("failed to delete minimum wage: %w", err)
+	}
+	return requireAffected(res, "minimum wage", province+" "+effective.String())
+}
+
+// MinimumWageQuery selects minimum wages
+type MinimumWageQuery struct {
+	Province string `form:"province"` // empty for every province
+	On       Date   `form:"on"`       // only the rate in force on this day, one per province
+}
+
+// ListMinimumWages returns the minimum wages a query selects, by province and effective date
+func (ps *PayrollSystem) ListMinimumWages(ctx context.Context, q MinimumWageQuery) ([]MinimumWage, error) {
+	wages, err := ps.db.ListMinimumWages(ctx, q.Province)
+	if err != nil {
+		return nil, err
+	}
+	out := []MinimumWage{}
+	for i, w := range wages {
+		if q.On.IsZero() {
+			out = append(out, w)
+			continue
+		}
+		// The rate in force is the last to have taken effect, and wages are listed by date
+		next := i+1 < len(wages) && wages[i+1].Province == w.Province && !wages[i+1].EffectiveDate.After(q.On)
+		if !w.EffectiveDate.After(q.On) && !next {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+// MinimumWageOn returns the minimum wage in force in a province on a day, or nil when none had
+// taken effect by then
+func (ps *PayrollSystem) MinimumWageOn(ctx context.Context, province string, day Date) (*MinimumWage, error) {
+	wages, err := ps.ListMinimumWages(ctx, MinimumWageQuery{Province: province, On: day})
+	if err != nil || len(wages) == 0 {
+		return nil, err
+	}
+	return &wages[0], nil
+}
+
+// SaveMinimumWages validates and stores a wage announcement, one rate per province, in one
+// transaction; a rate for a province and date already stored is replaced
+func (ps *PayrollSystem) SaveMinimumWages(ctx context.Context, wages []MinimumWage) ([]MinimumWage, error) {
+	v := &ValidationError{}
+	if len(wages) == 0 {
+		v.Add("wages", "give at least one minimum wage")
+	}
+	seen := map[string]int{}
+	for i, w := range wages {
+		if err := w.Validate(); err != nil {
+			var ve *ValidationError
+			if !errors.As(err, &ve) {
+				return nil, err
+			}
+			for _, f := range ve.Fields {
+				v.Add(fmt.Sprintf("wages[%d].%s", i, f.Field), "%s", f.Message)
+			}
+			continue
+		}
+		key := w.Province + " " + w.EffectiveDate.String()
+		if first, dup := seen[key]; dup {
+			v.Add(fmt.Sprintf("wages[%d]", i), "repeats the rate of %s from %s given at wages[%d]", w.Province, w.EffectiveDate, first)
+		}
+		seen[key] = i
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		for _, w := range wages {
+			stored, err := tps.db.ListMinimumWages(ctx, w.Province)
+			if err != nil {
+				return err
+			}
+			var before any
+			action := AuditCreate
+			for _, s := range stored {
+				if s.EffectiveDate == w.EffectiveDate {
+					before, action = s, AuditUpdate
+				}
+			}
+			if err := tps.db.SaveMinimumWage(ctx, w); err != nil {
+				return err
+			}
+			if err := tps.audit(ctx, "minimum_wage", w.Province+" "+w.EffectiveDate.String(), action, before, w); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wages, nil
+}
+
+// DeleteMinimumWage deletes the minimum wage of a province from a date
+func (ps *PayrollSystem) DeleteMinimumWage(ctx context.Context, province string, effective Date) error {
+	return ps.withTx(ctx, func(tps *PayrollSystem) error {
+		stored, err := tps.db.ListMinimumWages(ctx, province)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(stored, func(w MinimumWage) bool { return w.EffectiveDate == effective })
+		if i < 0 {
+			return &NotFoundError{Entity: "minimum wage", ID: province + " " + effective.String()}
+		}
+		if err := tps.db.DeleteMinimumWage(ctx, province, effective); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "minimum_wage", province+" "+effective.String(), AuditDelete, stored[i], nil)
+	})
+}
+
+// CheckMinimumWage compares an employee's pay with the minimum wage in force in their work province
+// on a day. It returns nil when the employee has no work province or the province no minimum wage
+// by then, as there is nothing to compare with.
+func (ps *PayrollSystem) CheckMinimumWage(ctx context.Context, emp Employee, day Date) (*MinimumWageCheck, error) {
+	if emp.Province == "" {
+		return nil, nil
+	}
+	w, err := ps.MinimumWageOn(ctx, emp.Province, day)
+	if err != nil || w == nil {
+		return nil, err
+	}
+	rate := DailyPayRate(emp.withPayDefaults())
+	return &MinimumWageCheck{
+		Province:      w.Province,
+		EffectiveDate: w.EffectiveDate,
+		MinimumRate:   w.DailyRate,
+		DailyRate:     rate,
+		Compliant:     rate >= w.DailyRate,
+	}, nil
+}
+
+// Message describes an underpayment found by the check
+func (c MinimumWageCheck) Message(empID int) string {
+	return fmt.Sprintf("employee %d is paid %.2f baht a day, below the minimum wage of %.2f in %s from %s",
+		empID, c.DailyRate, c.MinimumRate, c.Province, c.EffectiveDate)
+}
+
+// payRateField is the employee field that sets their daily pay rate
+func payRateField(emp Employee) string {
+	if emp.PayType == PayHourly || emp.PayType == PayDaily {
+		return "wage_rate"
+	}
+	return "base_salary"
+}
+
+// requireMinimumWage refuses an employee whose pay is below today's minimum wage of their work
+// province when underpayment is blocked. An update is only checked when it changes the pay type,
+// rate or province, so staff whose wage a new minimum has overtaken can still have their other
+// details corrected.
+func (ps *PayrollSystem) requireMinimumWage(ctx context.Context, before *Employee, emp Employee) error {
+	if ps.minimumWagePolicy != MinimumWageBlock {
+		return nil
+	}
+	if before != nil {
+		b, e := before.withPayDefaults(), emp.withPayDefaults()
+		if b.PayType == e.PayType && b.WageRate == e.WageRate && b.BaseSalary == e.BaseSalary && b.Province == e.Province {
+			return nil
+		}
+	}
+	check, err := ps.CheckMinimumWage(ctx, emp, DateOf(time.Now()))
+	if err != nil || check == nil || check.Compliant {
+		return err
+	}
+	return &ValidationError{Fields: []FieldError{{Field: payRateField(emp), Message: check.Message(emp.EmployeeID)}}}
+}
+
+// sortMinimumWages orders minimum wages by province and effective date, as the database lists them
+func sortMinimumWages(wages []MinimumWage) {
+	sort.Slice(wages, func(i, j int) bool {
+		if c := strings.Compare(wages[i].Province, wages[j].Province); c != 0 {
+			return c < 0
+		}
+		return wages[i].EffectiveDate.Before(wages[j].EffectiveDate)
+	})
+}
@@ -0,0 +1,105 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMinimumWageCompliance(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	if _, err := ps.SaveMinimumWages(ctx, []MinimumWage{
+		{Province: "เชียงใหม่", EffectiveDate: NewDate(2025, 1, 1), DailyRate: 350},
+		{Province: "เชียงใหม่", EffectiveDate: NewDate(2026, 1, 1), DailyRate: 370},
+		{Province: "ภูเก็ต", EffectiveDate: NewDate(2026, 1, 1), DailyRate: 400},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for name, wages := range map[string][]MinimumWage{
+		"unknown province": {{Province: "Chiang Mai", EffectiveDate: NewDate(2026, 1, 1), DailyRate: 370}},
+		"no rate":          {{Province: "เชียงใหม่", EffectiveDate: NewDate(2026, 1, 1)}},
+		"repeated rate": {
+			{Province: "ภูเก็ต", EffectiveDate: NewDate(2027, 1, 1), DailyRate: 410},
+			{Province: "ภูเก็ต", EffectiveDate: NewDate(2027, 1, 1), DailyRate: 420},
+		},
+		"empty announcement": nil,
+	} {
+		if _, err := ps.SaveMinimumWages(ctx, wages); !errors.Is(err, ErrValidation) {
+			t.Errorf("%s: error = %v, want ErrValidation", name, err)
+		}
+	}
+
+	inForce, err := ps.ListMinimumWages(ctx, MinimumWageQuery{On: NewDate(2025, 6, 30)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inForce) != 1 || inForce[0].DailyRate != 350 {
+		t.Fatalf("minimum wages in force on 2025-06-30 = %+v", inForce)
+	}
+
+	emp := Employee{EmployeeID: 2, EmpName: "B", PhoneNumber: "0898765432", DeptID: 1, PayFrequency: Biweekly, PayType: PayDaily,
+		WageRate: 360, Province: "เชียงใหม่", BankAccount: "Government Savings Bank", AccountNum: "123456789013"}
+	if err := ps.AddEmployee(ctx, emp); !errors.Is(err, ErrValidation) {
+		t.Fatalf("daily wage below the minimum error = %v, want ErrValidation", err)
+	}
+	emp.WageRate = 370
+	if err := ps.AddEmployee(ctx, emp); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Employee{EmployeeID: 3, EmpName: "C", PhoneNumber: "0823456789", DeptID: 1, BaseSalary: 30000, Province: "Phuket"}).Validate(); !errors.Is(err, ErrValidation) {
+		t.Fatalf("unknown province error = %v, want ErrValidation", err)
+	}
+
+	// A new minimum overtakes the wage: other details can still be changed, but not the pay
+	if _, err := ps.SaveMinimumWages(ctx, []MinimumWage{{Province: "เชียงใหม่", EffectiveDate: NewDate(2026, 4, 1), DailyRate: 380}}); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := ps.GetEmployee(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored.PositionName = "Packer"
+	if stored, err = ps.UpdateEmployee(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+	stored.WageRate = 375
+	if _, err := ps.UpdateEmployee(ctx, stored); !errors.Is(err, ErrValidation) {
+		t.Fatalf("raising the wage to below the minimum error = %v, want ErrValidation", err)
+	}
+
+	// The rate in force at the end of the period applies
+	before, err := ps.CalculatePayroll(ctx, PayInput{EmpID: 2, PayMonth: MustParsePeriod("2026-F05"), DaysWorked: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := before.MinimumWage; m == nil || !m.Compliant || m.MinimumRate != 370 {
+		t.Fatalf("minimum wage check before the new rate = %+v", m)
+	}
+	if _, err := ps.CalculatePayroll(ctx, PayInput{EmpID: 2, PayMonth: MustParsePeriod("2026-F08"), DaysWorked: 10}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("pay below the minimum error = %v, want ErrValidation", err)
+	}
+
+	ps.SetMinimumWagePolicy(MinimumWageWarn)
+	calc, err := ps.CalculatePayroll(ctx, PayInput{EmpID: 2, PayMonth: MustParsePeriod("2026-F08"), DaysWorked: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := calc.MinimumWage; m == nil || m.Compliant || m.DailyRate != 370 || m.MinimumRate != 380 {
+		t.Fatalf("flagged minimum wage check = %+v", m)
+	}
+	if _, err := ps.AddPayroll(ctx, calc.Payroll); err != nil {
+		t.Fatal(err)
+	}
+	report, err := ps.CheckPayRun(ctx, MustParsePeriod("2026-F08"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Anomalies) != 1 || report.Anomalies[0].Check != CheckBelowMinimumWage || report.Anomalies[0].Severity != SeverityWarning {
+		t.Fatalf("anomalies = %+v", report.Anomalies)
+	}
+	ps.SetMinimumWagePolicy(MinimumWageBlock)
+	if report, _ = ps.CheckPayRun(ctx, MustParsePeriod("2026-F08")); report.Approvable {
+		t.Fatalf("pay run below the minimum wage is approvable: %+v", report.Anomalies)
+	}
+}
@@ -0,0 +1,142 @@
+package payroll
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PayCalendar sets when staff paid at one frequency are paid: PayDaysAfterEnd days after the last
+// day of each period, moved back to the working day before when that falls on a weekend or holiday
+type PayCalendar struct {
+	Frequency       PeriodKind `json:"frequency" validate:"oneof=monthly semi_monthly weekly biweekly"`
+	PayDaysAfterEnd int        `json:"pay_days_after_end" validate:"gte=-10,lte=31"` // negative pays before the period ends
+}
+
+// DefaultPayCalendars pay monthly and semi-monthly staff on the last day of the period, and weekly
+// and biweekly staff on the Friday after it
+var DefaultPayCalendars = []PayCalendar{
+	{Frequency: Monthly, PayDaysAfterEnd: 0},
+	{Frequency: SemiMonthly, PayDaysAfterEnd: 0},
+	{Frequency: Weekly, PayDaysAfterEnd: 5},
+	{Frequency: Biweekly, PayDaysAfterEnd: 5},
+}
+
+// Validate checks a pay calendar before it is stored
+func (c PayCalendar) Validate() error { return validateStruct(c) }
+
+// PayDate is the day the pay of period p is paid, moved back off weekends and holidays, when banks
+// do not pay out
+func (c PayCalendar) PayDate(p Period, holidays HolidaySet) Date {
+	day := DateOf(p.End().AddDate(0, 0, c.PayDaysAfterEnd))
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday || holidays.Has(day) {
+		day = DateOf(day.AddDate(0, 0, -1))
+	}
+	return day
+}
+
+// ScheduledPeriod is one pay period of a pay calendar
+type ScheduledPeriod struct {
+	Period  Period `json:"period"`
+	Start   Date   `json:"start"`
+	End     Date   `json:"end"`
+	PayDate Date   `json:"pay_date"`
+}
+
+// Schedule lists the periods whose pay date falls in a year, which is the year their pay is taxed in
+func (c PayCalendar) Schedule(year int, holidays HolidaySet) []ScheduledPeriod {
+	var out []ScheduledPeriod
+	// Start far enough back to catch a period of the year before paid in January
+	for p := PeriodOf(c.Frequency, time.Date(year-1, time.December, 1, 0, 0, 0, 0, time.UTC)); ; p = p.Next() {
+		pay := c.PayDate(p, holidays)
+		if pay.Year() > year {
+			break
+		}
+		if pay.Year() == year {
+			out = append(out, ScheduledPeriod{Period: p, Start: p.Start(), End: p.End(), PayDate: pay})
+		}
+	}
+	return out
+}
+
+// ListPayCalendars reads the stored pay calendars by frequency
+func (pdb *sqlPayrollDB) ListPayCalendars(ctx context.Context) ([]PayCalendar, error) {
+	rows, err := pdb.db.QueryContext(ctx, "SELECT frequency, pay_days_after_end FROM pay_calendars ORDER BY frequency")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pay calendars: %w", err)
+	}
+	defer rows.Close()
+
+	var calendars []PayCalendar
+	for rows.Next() {
+		var c PayCalendar
+		if err := rows.Scan(&c.Frequency, &c.PayDaysAfterEnd); err != nil {
+			return nil, fmt.Errorf("failed to scan pay calendar: %w", err)
+		}
+		calendars = append(calendars, c)
+	}
+	return calendars, rows.Err()
+}
+
+// SavePayCalendar stores the pay calendar of a frequency, replacing the one it had
+func (pdb *sqlPayrollDB) SavePayCalendar(ctx context.Context, c PayCalendar) error {
+	_, err := pdb.db.ExecContext(ctx, `
+        INSERT INTO pay_calendars (frequency, pay_days_after_end) VALUES ($1, $2)
+        ON CONFLICT (frequency) DO UPDATE SET pay_days_after_end = excluded.pay_days_after_end`,
+		c.Frequency, c.PayDaysAfterEnd)
+	if err != nil {
+		return fmt.Errorf("failed to save pay calendar: %w", err)
+	}
+	return nil
+}
+
+// ListPayCalendars returns the pay calendar of every frequency; frequencies never configured have
+// their default calendar
+func (ps *PayrollSystem) ListPayCalendars(ctx context.Context) ([]PayCalendar, error) {
+	stored, err := ps.db.ListPayCalendars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	calendars := make([]PayCalendar, len(DefaultPayCalendars))
+	for i, def := range DefaultPayCalendars {
+		calendars[i] = def
+		for _, c := range stored {
+			if c.Frequency == def.Frequency {
+				calendars[i] = c
+			}
+		}
+	}
+	return calendars, nil
+}
+
+// GetPayCalendar returns the pay calendar of a frequency
+func (ps *PayrollSystem) GetPayCalendar(ctx context.Context, frequency PeriodKind) (PayCalendar, error) {
+	calendars, err := ps.ListPayCalendars(ctx)
+	if err != nil {
+		return PayCalendar{}, err
+	}
+	for _, c := range calendars {
+		if c.Frequency == frequency {
+			return c, nil
+		}
+	}
+	return PayCalendar{}, &NotFoundError{Entity: "pay calendar", ID: frequency}
+}
+
+// SavePayCalendar validates and stores the pay calendar of a frequency
+func (ps *PayrollSystem) SavePayCalendar(ctx context.Context, c PayCalendar) (PayCalendar, error) {
+	if err := c.Validate(); err != nil {
+		return PayCalendar{}, err
+	}
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		before, err := tps.GetPayCalendar(ctx, c.Frequency)
+		if err != nil {
+			return err
+		}
+		if err := tps.db.SavePayCalendar(ctx, c); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "pay_calendar", string(c.Frequency), AuditUpdate, before, c)
+	})
+	return c, err
+}
@@ -3,6 +3,7 @@ package payroll
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,58 +12,200 @@ import (
 
 // Department represents the department structure
 type Department struct {
-	DeptID   int    `json:"dept_id"`
-	DeptName string `json:"dept_name"`
+	DeptID   int    `json:"dept_id" validate:"gt=0"`
+	DeptName string `json:"dept_name" validate:"required,max=255"`
 	NumEmp   int    `json:"num_emp"` // Optional field for completeness
+	Version  int    `json:"version"` // incremented on every update, used as the ETag
 }
 
 // Employee struct
 type Employee struct {
-	EmployeeID   int     `json:"emp_id"`
-	EmpName      string  `json:"emp_name"`
-	PhoneNumber  string  `json:"phone_number"`
-	DeptID       int     `json:"dept_id"`
-	DeptName     string  `json:"dept_name"`
-	PositionName string  `json:"position_name"`
-	BaseSalary   float64 `json:"base_salary"`
-	BankAccount  string  `json:"bank_account"`
-	AccountNum   string  `json:"account_num"`
+	EmployeeID   int        `json:"emp_id" validate:"gt=0"`
+	EmpName      string     `json:"emp_name" validate:"required,max=100"`
+	PhoneNumber  string     `json:"phone_number" validate:"required,thai_phone"`
+	DeptID       int        `json:"dept_id" validate:"gt=0"`
+	DeptName     string     `json:"dept_name"`
+	PositionName string     `json:"position_name" validate:"max=100"`
+	BaseSalary   float64    `json:"base_salary" validate:"gte=0,lte=10000000"`                                     // monthly salary; required for salaried pay
+	PayFrequency PeriodKind `json:"pay_frequency" validate:"omitempty,oneof=monthly semi_monthly weekly biweekly"` // empty means monthly
+	PayType      string     `json:"pay_type" validate:"omitempty,oneof=salaried hourly daily"`                     // empty means salaried
+	WageRate     float64    `json:"wage_rate" validate:"gte=0,lte=100000"`                                         // baht per hour or per day worked; required for hourly and daily pay
+	Province     string     `json:"province" validate:"omitempty,thai_province"`                                   // work location, whose minimum wage applies
+	SiteID       string     `json:"site_id" validate:"max=50"`                                                     // work site, whose working week and holidays apply; empty for Monday to Friday
+	BankAccount  string     `json:"bank_account" validate:"max=100"`
+	AccountNum   string     `json:"account_num"` // checked against the bank's account number length
+	NationalID   string     `json:"national_id" validate:"omitempty,thai_national_id"`
+	EndDate      string     `json:"end_date,omitempty" validate:"omitempty,datetime=2006-01-02"` // last day of employment; empty while employed
+	AnonymisedAt *time.Time `json:"anonymised_at,omitempty"`                                     // set once retention has erased the personal fields
+	Version      int        `json:"version"`
 }
 
 // Payroll struct
 type Payroll struct {
 	PayrollID       int     `json:"payroll_id"`
-	EmpID           int     `json:"emp_id"`
-	PayMonth        string  `json:"pay_month"`
-	PayDate         string  `json:"pay_date"`
-	BaseSalary      float64 `json:"base_salary"`
-	TaxAmount       float64 `json:"tax_amount"`
-	TotalAdditions  float64 `json:"total_additions"`
-	TotalDeductions float64 `json:"total_deductions"`
+	EmpID           int     `json:"emp_id" validate:"gt=0"`
+	PayMonth        Period  `json:"pay_month" validate:"required"` // the pay period, a month unless the employee is paid semi-monthly or weekly
+	PayDate         Date    `json:"pay_date"`
+	RunType         string  `json:"run_type" validate:"omitempty,oneof=regular bonus off_cycle"` // empty means regular; one record per employee, period and run type
+	BaseSalary      float64 `json:"base_salary" validate:"gte=0,lte=10000000"`
+	TaxAmount       float64 `json:"tax_amount" validate:"gte=0"`
+	TotalAdditions  float64 `json:"total_additions" validate:"gte=0"`
+	TotalDeductions float64 `json:"total_deductions" validate:"gte=0"`
 	NetSalary       float64 `json:"net_salary"`
+	Version         int     `json:"version"`
+
+	// Approval is recorded by ApprovePayroll; an approved record can no longer be updated or deleted
+	Status     string     `json:"status"`
+	ApprovedBy *int       `json:"approved_by,omitempty"`
+	ApprovedAt *time.Time `json:"approved_at,omitempty"`
+}
+
+// Pay run types; an employee is paid at most once per run type in a pay period
+const (
+	RunRegular  = "regular"
+	RunBonus    = "bonus"
+	RunOffCycle = "off_cycle" // a correction or late payment outside the regular run
+)
+
+// Pay types: how an employee's earnings for a pay period are worked out
+const (
+	PaySalaried = "salaried" // a monthly base salary, spread over the pay periods of the year
+	PayHourly   = "hourly"   // wage_rate for every hour worked
+	PayDaily    = "daily"    // wage_rate for every day worked
+)
+
+// withPayDefaults returns emp with an empty pay frequency defaulted to monthly and an empty pay
+// type to salaried
+func (e Employee) withPayDefaults() Employee {
+	if e.PayFrequency == "" {
+		e.PayFrequency = Monthly
+	}
+	if e.PayType == "" {
+		e.PayType = PaySalaried
+	}
+	return e
+}
+
+// runKey identifies the pay run a record belongs to, for duplicate errors
+func (p Payroll) runKey() string {
+	return fmt.Sprintf("%d/%s/%s", p.EmpID, p.PayMonth, p.RunType)
+}
+
+// taxYear is the year pay for a period is taxed in, the year it is paid. Pay without a pay date
+// counts in the year its period ends in, which for a period spanning new year is the later one.
+func taxYear(period Period, payDate Date) int {
+	if payDate.IsZero() {
+		return period.End().Year()
+	}
+	return payDate.Year()
+}
+
+// withRunType returns p with an empty run type defaulted to regular
+func (p Payroll) withRunType() Payroll {
+	if p.RunType == "" {
+		p.RunType = RunRegular
+	}
+	return p
 }
 
-// PayrollDatabase defines the interface for interacting with the payroll database
+// PayrollDatabase defines the interface for interacting with the payroll database.
+// Update methods treat the record's Version as the expected stored version (0 skips the check),
+// return ErrConflict when it differs and increment the version on success. UpdatePayroll and
+// DeletePayroll also return ErrConflict for an approved payroll record.
 type PayrollDatabase interface {
 	GetAllEmployees(ctx context.Context) ([]Employee, error)
+	GetEmployee(ctx context.Context, empID int) (Employee, error)
 	GetAllDepartments(ctx context.Context) ([]Department, error)
+	GetDepartment(ctx context.Context, deptID int) (Department, error)
 	AddDepartment(ctx context.Context, dept Department) error
+	UpdateDepartment(ctx context.Context, dept Department) error
 	AddEmployee(ctx context.Context, emp Employee) error
-	AddPayroll(ctx context.Context, payroll Payroll) error
+	UpdateEmployee(ctx context.Context, emp Employee) error
+	DeleteDepartment(ctx context.Context, deptID int) error
+	DeleteEmployee(ctx context.Context, empID int) error
+	AddPayroll(ctx context.Context, payroll Payroll) (int, error)
 	GetAllPayrolls(ctx context.Context) ([]Payroll, error)
+	ListEmployees(ctx context.Context, q EmployeeQuery) (Page[Employee], error)
+	ListPayrolls(ctx context.Context, q PayrollQuery) (Page[Payroll], error)
+	GetPayroll(ctx context.Context, payrollID int) (Payroll, error)
+	UpdatePayroll(ctx context.Context, payroll Payroll) error
+	DeletePayroll(ctx context.Context, payrollID int) error
+	SaveAllowanceDeclaration(ctx context.Context, d AllowanceDeclaration) error
+	GetAllowanceDeclaration(ctx context.Context, empID, taxYear int) (*AllowanceDeclaration, error)
+	ListAllowanceDeclarations(ctx context.Context, empID int) ([]AllowanceDeclaration, error)
+	AddUser(ctx context.Context, u User) error
+	GetUserByUsername(ctx context.Context, username string) (User, error)
+	GetUser(ctx context.Context, userID int) (User, error)
+	AddSession(ctx context.Context, s Session) error
+	GetSession(ctx context.Context, sessionID string) (Session, error)
+	RotateSession(ctx context.Context, sessionID, oldHash, newHash string, expiresAt time.Time) error
+	RevokeSession(ctx context.Context, sessionID string) error
+	ReserveIdempotencyKey(ctx context.Context, r IdempotencyRecord) (IdempotencyRecord, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, r IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error
+	ListUsers(ctx context.Context) ([]User, error)
+	UpdateUser(ctx context.Context, u User) error
+	ListRoles(ctx context.Context) ([]Role, error)
+	GetRole(ctx context.Context, name string) (Role, error)
+	SaveRole(ctx context.Context, r Role) error
+	DeleteRole(ctx context.Context, name string) error
+	ApprovePayroll(ctx context.Context, payrollID, version, userID int) error
+	AddProfileChange(ctx context.Context, c ProfileChange) (int, error)
+	GetProfileChange(ctx context.Context, changeID int) (ProfileChange, error)
+	ListProfileChanges(ctx context.Context, q RequestQuery) ([]ProfileChange, error)
+	ReviewProfileChange(ctx context.Context, changeID int, r Review) error
+	AddLeaveRequest(ctx context.Context, l LeaveRequest) (int, error)
+	GetLeaveRequest(ctx context.Context, leaveID int) (LeaveRequest, error)
+	ListLeaveRequests(ctx context.Context, q RequestQuery) ([]LeaveRequest, error)
+	ReviewLeaveRequest(ctx context.Context, leaveID int, r Review) error
+	AnonymiseEmployee(ctx context.Context, empID int, at time.Time) error
+	GetChartOfAccounts(ctx context.Context) (ChartOfAccounts, error)
+	SaveChartOfAccounts(ctx context.Context, c ChartOfAccounts) error
+	ListPayCalendars(ctx context.Context) ([]PayCalendar, error)
+	SavePayCalendar(ctx context.Context, c PayCalendar) error
+	ListMinimumWages(ctx context.Context, province string) ([]MinimumWage, error)
+	SaveMinimumWage(ctx context.Context, w MinimumWage) error
+	DeleteMinimumWage(ctx context.Context, province string, effective Date) error
+	ListHolidays(ctx context.Context, from, to Date) ([]Holiday, error)
+	GetHoliday(ctx context.Context, holidayID int) (Holiday, error)
+	SaveHoliday(ctx context.Context, h Holiday) (int, error)
+	DeleteHoliday(ctx context.Context, holidayID int) error
+	ListWorkSites(ctx context.Context) ([]WorkSite, error)
+	GetWorkSite(ctx context.Context, siteID string) (WorkSite, error)
+	SaveWorkSite(ctx context.Context, s WorkSite) error
+	DeleteWorkSite(ctx context.Context, siteID string) error
+	AppendAudit(ctx context.Context, e AuditEntry) error
+	ListAudit(ctx context.Context, q AuditQuery) (Page[AuditEntry], error)
+	WithTx(ctx context.Context, fn func(PayrollDatabase) error) error
 	Close() error
 }
 
+// sqlPayrollDB implements PayrollDatabase over database/sql; the Postgres and SQLite backends share it
+type sqlPayrollDB struct {
+	db      dbtx    // the pool, or the open transaction for a tx-scoped instance
+	pool    *sql.DB // connection pool used to begin transactions
+	tx      *sql.Tx // non-nil inside WithTx
+	dialect dialect
+}
+
+// dialect identifies the SQL backend where queries or error codes differ
+type dialect int
+
+const (
+	dialectPostgres dialect = iota
+	dialectSQLite
+)
+
 // PostgresPayrollDB is the struct for interacting with the PostgreSQL database
 type PostgresPayrollDB struct {
-	db *sql.DB
+	*sqlPayrollDB
 }
 
 // NewPostgresPayrollDB initializes a new PostgresPayrollDB
 func NewPostgresPayrollDB(connStr string) (*PostgresPayrollDB, error) {
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %v", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	db.SetMaxOpenConns(25)
@@ -70,68 +213,124 @@ func NewPostgresPayrollDB(connStr string) (*PostgresPayrollDB, error) {
 	db.SetConnMaxLifetime(5 * time.Minute)
 
 	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %v", err)
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &PostgresPayrollDB{db: db}, nil
+	return &PostgresPayrollDB{&sqlPayrollDB{db: db, pool: db, dialect: dialectPostgres}}, nil
+}
+
+// employeeColumns is the column list matching scanEmployee; it expects employees e joined to departments d
+const employeeColumns = `e.emp_id, e.emp_name, e.phone_number, e.dept_id, d.dept_name, e.position_name, e.base_salary, e.pay_frequency, e.pay_type, e.wage_rate, e.province, e.site_id, e.bank_account, e.account_num, e.national_id, e.end_date, e.anonymised_at, e.version`
+
+// scanEmployee reads a row selected with employeeColumns
+func scanEmployee(row interface{ Scan(...any) error }) (Employee, error) {
+	var emp Employee
+	err := row.Scan(&emp.EmployeeID, &emp.EmpName, &emp.PhoneNumber, &emp.DeptID, &emp.DeptName, &emp.PositionName, &emp.BaseSalary, &emp.PayFrequency, &emp.PayType, &emp.WageRate, &emp.Province, &emp.SiteID,
+		&emp.BankAccount, &emp.AccountNum, &emp.NationalID, &emp.EndDate, nullTimeScanner{&emp.AnonymisedAt}, &emp.Version)
+	return emp, err
 }
 
 // GetAllEmployees retrieves all employees from the payroll database
-func (pdb *PostgresPayrollDB) GetAllEmployees(ctx context.Context) ([]Employee, error) {
+func (pdb *sqlPayrollDB) GetAllEmployees(ctx context.Context) ([]Employee, error) {
 	rows, err := pdb.db.QueryContext(ctx, `
-        SELECT e.emp_id, e.emp_name, e.phone_number, e.dept_id, d.dept_name, e.position_name, e.base_salary, e.bank_account, e.account_num 
+        SELECT `+employeeColumns+`
         FROM employees e 
         JOIN departments d ON e.dept_id = d.dept_id 
         ORDER BY e.emp_id ASC`)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query employees: %v", err)
+		return nil, fmt.Errorf("failed to query employees: %w", err)
 	}
 	defer rows.Close()
 
 	var employees []Employee
 	for rows.Next() {
-		var emp Employee
-		if err := rows.Scan(&emp.EmployeeID, &emp.EmpName, &emp.PhoneNumber, &emp.DeptID, &emp.DeptName, &emp.PositionName, &emp.BaseSalary, &emp.BankAccount, &emp.AccountNum); err != nil {
-			return nil, fmt.Errorf("failed to scan employee data: %v", err)
+		emp, err := scanEmployee(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan employee data: %w", err)
 		}
 		employees = append(employees, emp)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over employees: %v", err)
+		return nil, fmt.Errorf("error iterating over employees: %w", err)
 	}
 	return employees, nil
 }
 
+// GetEmployee retrieves a single employee by ID
+func (pdb *sqlPayrollDB) GetEmployee(ctx context.Context, empID int) (Employee, error) {
+	emp, err := scanEmployee(pdb.db.QueryRowContext(ctx, `
+        SELECT `+employeeColumns+`
+        FROM employees e 
+        JOIN departments d ON e.dept_id = d.dept_id 
+        WHERE e.emp_id = $1`, empID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Employee{}, &NotFoundError{Entity: "employee", ID: empID}
+	}
+	if err != nil {
+		return Employee{}, fmt.Errorf("failed to query employee: %w", err)
+	}
+	return emp, nil
+}
+
 // GetAllDepartments retrieves all departments from the database
-func (pdb *PostgresPayrollDB) GetAllDepartments(ctx context.Context) ([]Department, error) {
-	rows, err := pdb.db.QueryContext(ctx, "SELECT dept_id, dept_name, num_emp FROM departments ORDER BY dept_id ASC")
+func (pdb *sqlPayrollDB) GetAllDepartments(ctx context.Context) ([]Department, error) {
+	rows, err := pdb.db.QueryContext(ctx, "SELECT dept_id, dept_name, num_emp, version FROM departments ORDER BY dept_id ASC")
 	if err != nil {
-		return nil, fmt.Errorf("failed to query departments: %v", err)
+		return nil, fmt.Errorf("failed to query departments: %w", err)
 	}
 	defer rows.Close()
 
 	var departments []Department
 	for rows.Next() {
 		var dept Department
-		if err := rows.Scan(&dept.DeptID, &dept.DeptName, &dept.NumEmp); err != nil {
-			return nil, fmt.Errorf("failed to scan department data: %v", err)
+		if err := rows.Scan(&dept.DeptID, &dept.DeptName, &dept.NumEmp, &dept.Version); err != nil {
+			return nil, fmt.Errorf("failed to scan department data: %w", err)
 		}
 		departments = append(departments, dept)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over departments: %v", err)
+		return nil, fmt.Errorf("error iterating over departments: %w", err)
 	}
 	return departments, nil
 }
 
+// GetDepartment retrieves a single department by ID
+func (pdb *sqlPayrollDB) GetDepartment(ctx context.Context, deptID int) (Department, error) {
+	var dept Department
+	err := pdb.db.QueryRowContext(ctx, "SELECT dept_id, dept_name, num_emp, version FROM departments WHERE dept_id = $1", deptID).
+		Scan(&dept.DeptID, &dept.DeptName, &dept.NumEmp, &dept.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Department{}, &NotFoundError{Entity: "department", ID: deptID}
+	}
+	if err != nil {
+		return Department{}, fmt.Errorf("failed to query department: %w", err)
+	}
+	return dept, nil
+}
+
 // AddDepartment adds a new department to the payroll system
-func (pdb *PostgresPayrollDB) AddDepartment(ctx context.Context, dept Department) error {
+func (pdb *sqlPayrollDB) AddDepartment(ctx context.Context, dept Department) error {
 	_, err := pdb.db.ExecContext(ctx, "INSERT INTO departments (dept_id, dept_name) VALUES ($1, $2)", dept.DeptID, dept.DeptName)
-	return err
+	if err != nil {
+		return fmt.Errorf("failed to add department: %w", constraintError(err, "department", dept.DeptID, nil))
+	}
+	return nil
+}
+
+// UpdateDepartment renames a department
+func (pdb *sqlPayrollDB) UpdateDepartment(ctx context.Context, dept Department) error {
+	res, err := pdb.db.ExecContext(ctx, `
+        UPDATE departments SET dept_name = $2, version = version + 1
+        WHERE dept_id = $1 AND ($3 = 0 OR version = $3)`, dept.DeptID, dept.DeptName, dept.Version)
+	if err != nil {
+		return fmt.Errorf("failed to update department: %w", constraintError(err, "department", dept.DeptID, nil))
+	}
+	return pdb.requireUpdated(ctx, res, "departments", "dept_id", dept.DeptID, "department")
 }
 
 // AddEmployee adds a new employee to the payroll system
-func (pdb *PostgresPayrollDB) AddEmployee(ctx context.Context, emp Employee) error {
+func (pdb *sqlPayrollDB) AddEmployee(ctx context.Context, emp Employee) error {
+	emp = emp.withPayDefaults()
 	_, err := pdb.db.ExecContext(ctx, `
         INSERT INTO employees (
             emp_id, 
@@ -141,9 +340,16 @@ func (pdb *PostgresPayrollDB) AddEmployee(ctx context.Context, emp Employee) err
             position_name, 
             base_salary, 
             bank_account, 
-            account_num
+            account_num,
+            national_id,
+            end_date,
+            pay_frequency,
+            pay_type,
+            wage_rate,
+            province,
+            site_id
         ) VALUES (
-            $1, $2, $3, $4, $5, $6, $7, $8
+            $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
         )`,
 		emp.EmployeeID,
 		emp.EmpName,
@@ -152,17 +358,122 @@ func (pdb *PostgresPayrollDB) AddEmployee(ctx context.Context, emp Employee) err
 		emp.PositionName,
 		emp.BaseSalary,
 		emp.BankAccount,
-		emp.AccountNum)
+		emp.AccountNum,
+		emp.NationalID,
+		emp.EndDate,
+		emp.PayFrequency,
+		emp.PayType,
+		emp.WageRate,
+		emp.Province,
+		emp.SiteID)
 
 	if err != nil {
-		return fmt.Errorf("failed to add employee: %v", err)
+		return fmt.Errorf("failed to add employee: %w", constraintError(err, "employee", emp.EmployeeID,
+			&ForeignKeyError{Field: "dept_id", Entity: "department", ID: emp.DeptID}))
 	}
 	return nil
 }
 
-// AddPayroll adds a new payroll record to the database
-func (pdb *PostgresPayrollDB) AddPayroll(ctx context.Context, payroll Payroll) error {
-	_, err := pdb.db.ExecContext(ctx, `
+// UpdateEmployee replaces an employee's details; moving department adjusts num_emp via trigger
+func (pdb *sqlPayrollDB) UpdateEmployee(ctx context.Context, emp Employee) error {
+	emp = emp.withPayDefaults()
+	res, err := pdb.db.ExecContext(ctx, `
+        UPDATE employees SET
+            emp_name = $2,
+            phone_number = $3,
+            dept_id = $4,
+            position_name = $5,
+            base_salary = $6,
+            bank_account = $7,
+            account_num = $8,
+            national_id = $9,
+            end_date = $10,
+            pay_frequency = $12,
+            pay_type = $13,
+            wage_rate = $14,
+            province = $15,
+            site_id = $16,
+            version = version + 1
+        WHERE emp_id = $1 AND ($11 = 0 OR version = $11)`,
+		emp.EmployeeID,
+		emp.EmpName,
+		emp.PhoneNumber,
+		emp.DeptID,
+		emp.PositionName,
+		emp.BaseSalary,
+		emp.BankAccount,
+		emp.AccountNum,
+		emp.NationalID,
+		emp.EndDate,
+		emp.Version,
+		emp.PayFrequency,
+		emp.PayType,
+		emp.WageRate,
+		emp.Province,
+		emp.SiteID)
+	if err != nil {
+		return fmt.Errorf("failed to update employee: %w", constraintError(err, "employee", emp.EmployeeID,
+			&ForeignKeyError{Field: "dept_id", Entity: "department", ID: emp.DeptID}))
+	}
+	return pdb.requireUpdated(ctx, res, "employees", "emp_id", emp.EmployeeID, "employee")
+}
+
+// DeleteDepartment deletes a department; its employees and their records are removed by cascade
+func (pdb *sqlPayrollDB) DeleteDepartment(ctx context.Context, deptID int) error {
+	res, err := pdb.db.ExecContext(ctx, "DELETE FROM departments WHERE dept_id = $1", deptID)
+	if err != nil {
+		return fmt.Errorf("failed to delete department: %w", err)
+	}
+	return requireAffected(res, "department", deptID)
+}
+
+// DeleteEmployee deletes an employee; their payroll records are removed by cascade
+func (pdb *sqlPayrollDB) DeleteEmployee(ctx context.Context, empID int) error {
+	res, err := pdb.db.ExecContext(ctx, "DELETE FROM employees WHERE emp_id = $1", empID)
+	if err != nil {
+		return fmt.Errorf("failed to delete employee: %w", err)
+	}
+	return requireAffected(res, "employee", empID)
+}
+
+// requireAffected turns a statement that touched no rows into ErrNotFound
+func requireAffected(res sql.Result, what string, id any) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &NotFoundError{Entity: what, ID: id}
+	}
+	return nil
+}
+
+// requireUpdated distinguishes a missing row (ErrNotFound) from a stale version (ErrConflict)
+// when an optimistic update touched no rows
+func (pdb *sqlPayrollDB) requireUpdated(ctx context.Context, res sql.Result, table, idColumn string, id int, what string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := pdb.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+table+" WHERE "+idColumn+" = $1)", id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check %s: %w", what, err)
+	}
+	if !exists {
+		return &NotFoundError{Entity: what, ID: id}
+	}
+	return &ConflictError{Entity: what, ID: id}
+}
+
+// AddPayroll adds a new payroll record to the database and returns its payroll_id
+func (pdb *sqlPayrollDB) AddPayroll(ctx context.Context, payroll Payroll) (int, error) {
+	payroll = payroll.withRunType()
+	var id int
+	err := pdb.db.QueryRowContext(ctx, `
     INSERT INTO payroll (
         emp_id, 
         pay_month, 
@@ -171,10 +482,13 @@ func (pdb *PostgresPayrollDB) AddPayroll(ctx context.Context, payroll Payroll) e
         tax_amount,  -- เพิ่ม tax_amount ที่นี่
         total_additions, 
         total_deductions, 
-        net_salary
+        net_salary,
+        run_type,
+        period_start,
+        period_end
     ) VALUES (
-        $1, $2, $3, $4, $5, $6, $7, $8
-    )`,
+        $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+    ) RETURNING payroll_id`,
 		payroll.EmpID,
 		payroll.PayMonth,
 		payroll.PayDate,
@@ -182,15 +496,20 @@ func (pdb *PostgresPayrollDB) AddPayroll(ctx context.Context, payroll Payroll) e
 		payroll.TaxAmount, // ส่ง tax_amount
 		payroll.TotalAdditions,
 		payroll.TotalDeductions,
-		payroll.NetSalary)
+		payroll.NetSalary,
+		payroll.RunType,
+		payroll.PayMonth.Start(),
+		payroll.PayMonth.End()).Scan(&id)
 
-	return err
+	if err != nil {
+		return 0, fmt.Errorf("failed to add payroll: %w", constraintError(err, "payroll", payroll.runKey(),
+			&ForeignKeyError{Field: "emp_id", Entity: "employee", ID: payroll.EmpID}))
+	}
+	return id, nil
 }
 
-// GetAllPayrolls retrieves all payroll records from the database
-func (pdb *PostgresPayrollDB) GetAllPayrolls(ctx context.Context) ([]Payroll, error) {
-	rows, err := pdb.db.QueryContext(ctx, `
-        SELECT 
+// payrollColumns is the column list matching scanPayroll
+const payrollColumns = `
             payroll_id, 
             emp_id, 
             pay_month, 
@@ -199,41 +518,170 @@ func (pdb *PostgresPayrollDB) GetAllPayrolls(ctx context.Context) ([]Payroll, er
             tax_amount, 
             total_additions, 
             total_deductions, 
-            net_salary 
+            net_salary,
+            run_type,
+            version,
+            status,
+            approved_by,
+            approved_at`
+
+// scanPayroll reads a row selected with payrollColumns
+func scanPayroll(row interface{ Scan(...any) error }) (Payroll, error) {
+	var payroll Payroll
+	var approvedBy sql.NullInt64
+	var approvedAt sql.NullTime
+	err := row.Scan(&payroll.PayrollID, &payroll.EmpID, &payroll.PayMonth, &payroll.PayDate, &payroll.BaseSalary, &payroll.TaxAmount, &payroll.TotalAdditions, &payroll.TotalDeductions, &payroll.NetSalary, &payroll.RunType, &payroll.Version,
+		&payroll.Status, &approvedBy, &approvedAt)
+	if approvedBy.Valid {
+		id := int(approvedBy.Int64)
+		payroll.ApprovedBy = &id
+	}
+	if approvedAt.Valid {
+		payroll.ApprovedAt = &approvedAt.Time
+	}
+	return payroll, err
+}
+
+// GetAllPayrolls retrieves all payroll records from the database
+func (pdb *sqlPayrollDB) GetAllPayrolls(ctx context.Context) ([]Payroll, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+        SELECT `+payrollColumns+`
         FROM payroll 
         ORDER BY payroll_id ASC`)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query payroll: %v", err)
+		return nil, fmt.Errorf("failed to query payroll: %w", err)
 	}
 	defer rows.Close()
 
 	var payrolls []Payroll
 	for rows.Next() {
-		var payroll Payroll
-		if err := rows.Scan(&payroll.PayrollID, &payroll.EmpID, &payroll.PayMonth, &payroll.PayDate, &payroll.BaseSalary, &payroll.TaxAmount, &payroll.TotalAdditions, &payroll.TotalDeductions, &payroll.NetSalary); err != nil {
-			return nil, fmt.Errorf("failed to scan payroll data: %v", err)
+		payroll, err := scanPayroll(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payroll data: %w", err)
 		}
 		payrolls = append(payrolls, payroll)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over payrolls: %v", err)
+		return nil, fmt.Errorf("error iterating over payrolls: %w", err)
 	}
 	return payrolls, nil
 }
 
+// GetPayroll retrieves a single payroll record by ID
+func (pdb *sqlPayrollDB) GetPayroll(ctx context.Context, payrollID int) (Payroll, error) {
+	payroll, err := scanPayroll(pdb.db.QueryRowContext(ctx, "SELECT "+payrollColumns+" FROM payroll WHERE payroll_id = $1", payrollID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Payroll{}, &NotFoundError{Entity: "payroll", ID: payrollID}
+	}
+	if err != nil {
+		return Payroll{}, fmt.Errorf("failed to query payroll: %w", err)
+	}
+	return payroll, nil
+}
+
+// UpdatePayroll replaces a payroll record's amounts and period
+func (pdb *sqlPayrollDB) UpdatePayroll(ctx context.Context, payroll Payroll) error {
+	payroll = payroll.withRunType()
+	res, err := pdb.db.ExecContext(ctx, `
+        UPDATE payroll SET
+            emp_id = $2,
+            pay_month = $3,
+            pay_date = $4,
+            base_salary = $5,
+            tax_amount = $6,
+            total_additions = $7,
+            total_deductions = $8,
+            net_salary = $9,
+            run_type = $11,
+            period_start = $12,
+            period_end = $13,
+            version = version + 1
+        WHERE payroll_id = $1 AND ($10 = 0 OR version = $10) AND status <> $14`,
+		payroll.PayrollID,
+		payroll.EmpID,
+		payroll.PayMonth,
+		payroll.PayDate,
+		payroll.BaseSalary,
+		payroll.TaxAmount,
+		payroll.TotalAdditions,
+		payroll.TotalDeductions,
+		payroll.NetSalary,
+		payroll.Version,
+		payroll.RunType,
+		payroll.PayMonth.Start(),
+		payroll.PayMonth.End(),
+		PayrollApproved)
+	if err != nil {
+		return fmt.Errorf("failed to update payroll: %w", constraintError(err, "payroll", payroll.runKey(),
+			&ForeignKeyError{Field: "emp_id", Entity: "employee", ID: payroll.EmpID}))
+	}
+	return pdb.requirePayrollWritten(ctx, res, payroll.PayrollID)
+}
+
+// DeletePayroll deletes a payroll record that is not approved
+func (pdb *sqlPayrollDB) DeletePayroll(ctx context.Context, payrollID int) error {
+	res, err := pdb.db.ExecContext(ctx, "DELETE FROM payroll WHERE payroll_id = $1 AND status <> $2", payrollID, PayrollApproved)
+	if err != nil {
+		return fmt.Errorf("failed to delete payroll: %w", err)
+	}
+	return pdb.requirePayrollWritten(ctx, res, payrollID)
+}
+
+// requirePayrollWritten tells a missing payroll record (ErrNotFound), an approved one and a stale
+// version (both ErrConflict) apart when a write to it touched no rows
+func (pdb *sqlPayrollDB) requirePayrollWritten(ctx context.Context, res sql.Result, payrollID int) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var status string
+	err = pdb.db.QueryRowContext(ctx, "SELECT status FROM payroll WHERE payroll_id = $1", payrollID).Scan(&status)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return &NotFoundError{Entity: "payroll", ID: payrollID}
+	case err != nil:
+		return fmt.Errorf("failed to check payroll: %w", err)
+	case status == PayrollApproved:
+		return approvedError(payrollID)
+	}
+	return &ConflictError{Entity: "payroll", ID: payrollID}
+}
+
 // Close closes the database connection
-func (pdb *PostgresPayrollDB) Close() error {
-	return pdb.db.Close()
+func (pdb *sqlPayrollDB) Close() error {
+	if pdb.tx != nil {
+		return errors.New("cannot close database from inside a transaction")
+	}
+	return pdb.pool.Close()
 }
 
+// SQLDB returns the connection pool behind a SQL-backed database, for read-only reporting queries,
+// or nil for the in-memory store
+func SQLDB(db PayrollDatabase) *sql.DB {
+	if s, ok := db.(interface{ sqlDB() *sql.DB }); ok {
+		return s.sqlDB()
+	}
+	return nil
+}
+
+func (pdb *sqlPayrollDB) sqlDB() *sql.DB { return pdb.pool }
+
+func (db *encryptedDB) sqlDB() *sql.DB { return SQLDB(db.PayrollDatabase) }
+
 // PayrollSystem represents the main payroll system
 type PayrollSystem struct {
-	db PayrollDatabase
+	db                PayrollDatabase
+	anomalyRules      AnomalyRules
+	minimumWagePolicy MinimumWagePolicy
 }
 
 // NewPayrollSystem creates a new PayrollSystem instance
 func NewPayrollSystem(db PayrollDatabase) *PayrollSystem {
-	return &PayrollSystem{db: db}
+	return &PayrollSystem{db: db, anomalyRules: DefaultAnomalyRules, minimumWagePolicy: MinimumWageBlock}
 }
 
 // GetAllEmployees retrieves all employees from the payroll system
@@ -241,6 +689,11 @@ func (ps *PayrollSystem) GetAllEmployees(ctx context.Context) ([]Employee, error
 	return ps.db.GetAllEmployees(ctx)
 }
 
+// GetEmployee retrieves a single employee from the payroll system
+func (ps *PayrollSystem) GetEmployee(ctx context.Context, empID int) (Employee, error) {
+	return ps.db.GetEmployee(ctx, empID)
+}
+
 // GetAllDepartments retrieves all departments from the payroll system
 func (ps *PayrollSystem) GetAllDepartments(ctx context.Context) ([]Department, error) {
 	return ps.db.GetAllDepartments(ctx)
@@ -248,17 +701,188 @@ func (ps *PayrollSystem) GetAllDepartments(ctx context.Context) ([]Department, e
 
 // AddDepartment adds a new department to the payroll system
 func (ps *PayrollSystem) AddDepartment(ctx context.Context, dept Department) error {
-	return ps.db.AddDepartment(ctx, dept)
+	if err := dept.Validate(); err != nil {
+		return err
+	}
+	return ps.withTx(ctx, func(tps *PayrollSystem) error {
+		if err := tps.db.AddDepartment(ctx, dept); err != nil {
+			return err
+		}
+		added, err := tps.db.GetDepartment(ctx, dept.DeptID)
+		if err != nil {
+			return err
+		}
+		return tps.audit(ctx, "department", dept.DeptID, AuditCreate, nil, added)
+	})
+}
+
+// GetDepartment retrieves a single department from the payroll system
+func (ps *PayrollSystem) GetDepartment(ctx context.Context, deptID int) (Department, error) {
+	return ps.db.GetDepartment(ctx, deptID)
+}
+
+// UpdateDepartment applies an optimistic update to a department and returns the stored result
+func (ps *PayrollSystem) UpdateDepartment(ctx context.Context, dept Department) (Department, error) {
+	if err := dept.Validate(); err != nil {
+		return Department{}, err
+	}
+	var updated Department
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		before, err := tps.db.GetDepartment(ctx, dept.DeptID)
+		if err != nil {
+			return err
+		}
+		if err := tps.db.UpdateDepartment(ctx, dept); err != nil {
+			return err
+		}
+		if updated, err = tps.db.GetDepartment(ctx, dept.DeptID); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "department", dept.DeptID, AuditUpdate, before, updated)
+	})
+	return updated, err
 }
 
 // AddEmployee adds a new employee to the payroll system
 func (ps *PayrollSystem) AddEmployee(ctx context.Context, emp Employee) error {
-	return ps.db.AddEmployee(ctx, emp)
+	if err := emp.Validate(); err != nil {
+		return err
+	}
+	return ps.withTx(ctx, func(tps *PayrollSystem) error {
+		if err := tps.requireMinimumWage(ctx, nil, emp); err != nil {
+			return err
+		}
+		if err := tps.requireWorkSite(ctx, emp.SiteID); err != nil {
+			return err
+		}
+		if err := tps.db.AddEmployee(ctx, emp); err != nil {
+			return err
+		}
+		added, err := tps.db.GetEmployee(ctx, emp.EmployeeID)
+		if err != nil {
+			return err
+		}
+		return tps.audit(ctx, "employee", emp.EmployeeID, AuditCreate, nil, added)
+	})
 }
 
-// AddPayroll adds a new payroll entry to the payroll system
-func (ps *PayrollSystem) AddPayroll(ctx context.Context, payroll Payroll) error {
-	return ps.db.AddPayroll(ctx, payroll)
+// UpdateEmployee applies an optimistic update to an employee and returns the stored result
+func (ps *PayrollSystem) UpdateEmployee(ctx context.Context, emp Employee) (Employee, error) {
+	if err := emp.Validate(); err != nil {
+		return Employee{}, err
+	}
+	var updated Employee
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		var err error
+		updated, err = tps.updateEmployee(ctx, emp)
+		return err
+	})
+	return updated, err
+}
+
+// updateEmployee stores and audits a validated employee update inside a transaction
+func (ps *PayrollSystem) updateEmployee(ctx context.Context, emp Employee) (Employee, error) {
+	before, err := ps.db.GetEmployee(ctx, emp.EmployeeID)
+	if err != nil {
+		return Employee{}, err
+	}
+	if before.AnonymisedAt != nil {
+		return Employee{}, &ValidationError{Fields: []FieldError{{Field: "emp_id", Message: "the employee has been anonymised and can no longer be changed"}}}
+	}
+	if err := ps.requireMinimumWage(ctx, &before, emp); err != nil {
+		return Employee{}, err
+	}
+	if err := ps.requireWorkSite(ctx, emp.SiteID); err != nil {
+		return Employee{}, err
+	}
+	if err := ps.db.UpdateEmployee(ctx, emp); err != nil {
+		return Employee{}, err
+	}
+	updated, err := ps.db.GetEmployee(ctx, emp.EmployeeID)
+	if err != nil {
+		return Employee{}, err
+	}
+	return updated, ps.audit(ctx, "employee", emp.EmployeeID, AuditUpdate, before, updated)
+}
+
+// DeleteDepartment deletes a department and, by cascade, its employees
+func (ps *PayrollSystem) DeleteDepartment(ctx context.Context, deptID int) error {
+	return ps.withTx(ctx, func(tps *PayrollSystem) error {
+		before, err := tps.db.GetDepartment(ctx, deptID)
+		if err != nil {
+			return err
+		}
+		if err := tps.db.DeleteDepartment(ctx, deptID); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "department", deptID, AuditDelete, before, nil)
+	})
+}
+
+// DeleteEmployee deletes an employee and, by cascade, their payroll records
+func (ps *PayrollSystem) DeleteEmployee(ctx context.Context, empID int) error {
+	return ps.withTx(ctx, func(tps *PayrollSystem) error {
+		before, err := tps.db.GetEmployee(ctx, empID)
+		if err != nil {
+			return err
+		}
+		if err := tps.db.DeleteEmployee(ctx, empID); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "employee", empID, AuditDelete, before, nil)
+	})
+}
+
+// AddPayroll adds a new payroll entry to the payroll system and returns the stored record
+func (ps *PayrollSystem) AddPayroll(ctx context.Context, payroll Payroll) (Payroll, error) {
+	if err := payroll.Validate(); err != nil {
+		return Payroll{}, err
+	}
+	var added Payroll
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		var err error
+		added, err = tps.addPayroll(ctx, payroll)
+		return err
+	})
+	return added, err
+}
+
+// addPayroll stores and audits a validated payroll record inside a transaction
+func (ps *PayrollSystem) addPayroll(ctx context.Context, payroll Payroll) (Payroll, error) {
+	id, err := ps.db.AddPayroll(ctx, payroll)
+	if err != nil {
+		return Payroll{}, err
+	}
+	added, err := ps.db.GetPayroll(ctx, id)
+	if err != nil {
+		return Payroll{}, err
+	}
+	return added, ps.audit(ctx, "payroll", id, AuditCreate, nil, added)
+}
+
+// AddPayrolls records a whole pay run atomically; either every record is stored or none is
+func (ps *PayrollSystem) AddPayrolls(ctx context.Context, payrolls []Payroll) error {
+	// Report every invalid record up front, with fields prefixed by their index in the batch
+	invalid := &ValidationError{}
+	for i, p := range payrolls {
+		var ve *ValidationError
+		if err := p.Validate(); errors.As(err, &ve) {
+			for _, f := range ve.Fields {
+				invalid.Add(fmt.Sprintf("[%d].%s", i, f.Field), "%s", f.Message)
+			}
+		}
+	}
+	if err := invalid.Err(); err != nil {
+		return err
+	}
+	return ps.withTx(ctx, func(tps *PayrollSystem) error {
+		for i, p := range payrolls {
+			if _, err := tps.addPayroll(ctx, p); err != nil {
+				return fmt.Errorf("payroll record %d (emp_id %d): %w", i, p.EmpID, err)
+			}
+		}
+		return nil
+	})
 }
 
 // GetAllPayrolls retrieves all payroll records from the payroll system
@@ -266,6 +890,55 @@ func (ps *PayrollSystem) GetAllPayrolls(ctx context.Context) ([]Payroll, error)
 	return ps.db.GetAllPayrolls(ctx)
 }
 
+// GetPayroll retrieves a single payroll record from the payroll system
+func (ps *PayrollSystem) GetPayroll(ctx context.Context, payrollID int) (Payroll, error) {
+	return ps.db.GetPayroll(ctx, payrollID)
+}
+
+// UpdatePayroll applies an optimistic update to a payroll record and returns the stored result;
+// updating an approved record fails with ErrConflict
+func (ps *PayrollSystem) UpdatePayroll(ctx context.Context, payroll Payroll) (Payroll, error) {
+	if err := payroll.Validate(); err != nil {
+		return Payroll{}, err
+	}
+	var updated Payroll
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		before, err := tps.db.GetPayroll(ctx, payroll.PayrollID)
+		if err != nil {
+			return err
+		}
+		if before.Status == PayrollApproved {
+			return approvedError(payroll.PayrollID)
+		}
+		if err := tps.db.UpdatePayroll(ctx, payroll); err != nil {
+			return err
+		}
+		if updated, err = tps.db.GetPayroll(ctx, payroll.PayrollID); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "payroll", payroll.PayrollID, AuditUpdate, before, updated)
+	})
+	return updated, err
+}
+
+// DeletePayroll deletes a payroll record from the payroll system; deleting an approved record
+// fails with ErrConflict
+func (ps *PayrollSystem) DeletePayroll(ctx context.Context, payrollID int) error {
+	return ps.withTx(ctx, func(tps *PayrollSystem) error {
+		before, err := tps.db.GetPayroll(ctx, payrollID)
+		if err != nil {
+			return err
+		}
+		if before.Status == PayrollApproved {
+			return approvedError(payrollID)
+		}
+		if err := tps.db.DeletePayroll(ctx, payrollID); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "payroll", payrollID, AuditDelete, before, nil)
+	})
+}
+
 // Close closes the payroll system and its database connection
 func (ps *PayrollSystem) Close() error {
 	return ps.db.Close()
@@ -0,0 +1,357 @@
+package payroll
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// PeriodKind is how long a pay period lasts
+type PeriodKind string
+
+const (
+	// Monthly periods run from the first to the last day of a calendar month
+	Monthly PeriodKind = "monthly"
+	// SemiMonthly periods run from the 1st to the 15th and from the 16th to the end of a month
+	SemiMonthly PeriodKind = "semi_monthly"
+	// Weekly periods are ISO weeks, Monday to Sunday
+	Weekly PeriodKind = "weekly"
+	// Biweekly periods are fortnights, Monday to the Sunday of the week after, counted from
+	// Monday 1 January 2024 and numbered within the year they start in
+	Biweekly PeriodKind = "biweekly"
+)
+
+// PeriodKinds lists every kind of pay period
+var PeriodKinds = []PeriodKind{Monthly, SemiMonthly, Weekly, Biweekly}
+
+// PerYear is the number of periods of the kind paid in a year, which annual amounts such as tax
+// are spread over; a year with a 53rd week or a 27th fortnight is spread the same way
+func (k PeriodKind) PerYear() int {
+	switch k {
+	case SemiMonthly:
+		return 24
+	case Weekly:
+		return 52
+	case Biweekly:
+		return 26
+	}
+	return 12
+}
+
+// fortnightEpoch is the Monday the first fortnight of biweekly pay starts on
+var fortnightEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Period is the pay period a payroll record covers. Its text form is what JSON, URLs and the
+// database hold:
+//
+//   - "2026-01" for a month
+//   - "2026-01-H1" and "2026-01-H2" for the halves of a month
+//   - "2026-W05" for an ISO week
+//   - "2026-F03" for the third fortnight starting in 2026
+//
+// Periods compare with ==; the zero Period is empty.
+type Period struct {
+	kind  PeriodKind
+	year  int // the ISO week-numbering year of a weekly period
+	month time.Month
+	n     int // the half of a semi-monthly period, the week of a weekly one or the fortnight of a biweekly one
+}
+
+// MonthPeriod is the monthly period of a calendar month
+func MonthPeriod(year int, month time.Month) Period {
+	return Period{kind: Monthly, year: year, month: month}
+}
+
+// PeriodOf returns the period of a kind that contains day
+func PeriodOf(kind PeriodKind, day time.Time) Period {
+	switch kind {
+	case SemiMonthly:
+		half := 1
+		if day.Day() > 15 {
+			half = 2
+		}
+		return Period{kind: SemiMonthly, year: day.Year(), month: day.Month(), n: half}
+	case Weekly:
+		year, week := day.ISOWeek()
+		return Period{kind: Weekly, year: year, n: week}
+	case Biweekly:
+		days := int(DateOf(day).Sub(fortnightEpoch).Hours() / 24)
+		start := DateOf(fortnightEpoch.AddDate(0, 0, floorDiv(days, 14)*14))
+		first := firstFortnight(start.Year())
+		return Period{kind: Biweekly, year: start.Year(), n: int(start.Sub(first.Time).Hours()/24)/14 + 1}
+	}
+	return MonthPeriod(day.Year(), day.Month())
+}
+
+// firstFortnight is the first day of the first fortnight that starts in a year
+func firstFortnight(year int) Date {
+	days := int(time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Sub(fortnightEpoch).Hours() / 24)
+	return DateOf(fortnightEpoch.AddDate(0, 0, -floorDiv(-days, 14)*14))
+}
+
+// floorDiv divides rounding towards minus infinity, so days before fortnightEpoch fall in the
+// fortnight they belong to
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+var (
+	monthPattern = regexp.MustCompile(`^(\d{4})-(\d{2})(?:-H([12]))?$`)
+	weekPattern  = regexp.MustCompile(`^(\d{4})-([WF])(\d{2})$`)
+)
+
+// ParsePeriod reads the text form of a period
+func ParsePeriod(s string) (Period, error) {
+	if m := monthPattern.FindStringSubmatch(s); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		if year > 0 && month >= 1 && month <= 12 {
+			if m[3] == "" {
+				return MonthPeriod(year, time.Month(month)), nil
+			}
+			half, _ := strconv.Atoi(m[3])
+			return Period{kind: SemiMonthly, year: year, month: time.Month(month), n: half}, nil
+		}
+	}
+	if m := weekPattern.FindStringSubmatch(s); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[3])
+		if m[2] == "F" {
+			if p := (Period{kind: Biweekly, year: year, n: week}); year > 0 && week >= 1 && p.Start().Year() == year {
+				return p, nil
+			}
+			return Period{}, fmt.Errorf("invalid pay period %q: %d has no fortnight %d", s, year, week)
+		}
+		// 28 December is always in the last ISO week of its year
+		if _, weeks := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek(); year > 0 && week >= 1 && week <= weeks {
+			return Period{kind: Weekly, year: year, n: week}, nil
+		}
+	}
+	return Period{}, fmt.Errorf("invalid pay period %q: use a month such as 2026-01, a half month such as 2026-01-H1 or an ISO week such as 2026-W05 or a fortnight such as 2026-F03", s)
+}
+
+// MustParsePeriod is ParsePeriod for periods known to be valid; it panics on an error
+func MustParsePeriod(s string) Period {
+	p, err := ParsePeriod(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Kind says whether p is a month, half a month, a week or a fortnight; it is empty for the zero Period
+func (p Period) Kind() PeriodKind { return p.kind }
+
+// IsZero reports whether p is the empty period
+func (p Period) IsZero() bool { return p.kind == "" }
+
+func (p Period) String() string {
+	switch p.kind {
+	case Monthly:
+		return fmt.Sprintf("%04d-%02d", p.year, p.month)
+	case SemiMonthly:
+		return fmt.Sprintf("%04d-%02d-H%d", p.year, p.month, p.n)
+	case Weekly:
+		return fmt.Sprintf("%04d-W%02d", p.year, p.n)
+	case Biweekly:
+		return fmt.Sprintf("%04d-F%02d", p.year, p.n)
+	}
+	return ""
+}
+
+// Start is the first day of the period
+func (p Period) Start() Date {
+	switch p.kind {
+	case SemiMonthly:
+		if p.n == 2 {
+			return NewDate(p.year, p.month, 16)
+		}
+	case Weekly:
+		// 4 January is always in week 1, which starts on the Monday before it
+		jan4 := time.Date(p.year, time.January, 4, 0, 0, 0, 0, time.UTC)
+		monday := jan4.AddDate(0, 0, -(int(jan4.Weekday())+6)%7)
+		return DateOf(monday.AddDate(0, 0, (p.n-1)*7))
+	case Biweekly:
+		return DateOf(firstFortnight(p.year).AddDate(0, 0, (p.n-1)*14))
+	case "":
+		return Date{}
+	}
+	return NewDate(p.year, p.month, 1)
+}
+
+// End is the last day of the period
+func (p Period) End() Date {
+	switch p.kind {
+	case Monthly:
+		return DateOf(p.Start().AddDate(0, 1, -1))
+	case SemiMonthly:
+		if p.n == 1 {
+			return NewDate(p.year, p.month, 15)
+		}
+		return DateOf(NewDate(p.year, p.month, 1).AddDate(0, 1, -1))
+	case Weekly:
+		return DateOf(p.Start().AddDate(0, 0, 6))
+	case Biweekly:
+		return DateOf(p.Start().AddDate(0, 0, 13))
+	}
+	return Date{}
+}
+
+// Next is the period of the same kind that follows p
+func (p Period) Next() Period { return PeriodOf(p.kind, p.End().AddDate(0, 0, 1)) }
+
+// Prev is the period of the same kind before p
+func (p Period) Prev() Period { return PeriodOf(p.kind, p.Start().AddDate(0, 0, -1)) }
+
+// Month is the calendar month p starts in, which is the month its records are reported and taxed in
+func (p Period) Month() Period {
+	if p.IsZero() {
+		return p
+	}
+	start := p.Start()
+	return MonthPeriod(start.Year(), start.Month())
+}
+
+func (p Period) MarshalText() ([]byte, error) { return []byte(p.String()), nil }
+
+func (p *Period) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		*p = Period{}
+		return nil
+	}
+	v, err := ParsePeriod(string(b))
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+// UnmarshalParam reads a period from a query parameter
+func (p *Period) UnmarshalParam(s string) error { return p.UnmarshalText([]byte(s)) }
+
+// Value stores the text form of p
+func (p Period) Value() (driver.Value, error) { return p.String(), nil }
+
+// Scan reads a period stored as text; NULL and empty text are the zero Period
+func (p *Period) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*p = Period{}
+		return nil
+	case string:
+		return p.UnmarshalText([]byte(v))
+	case []byte:
+		return p.UnmarshalText(v)
+	}
+	return fmt.Errorf("cannot scan %T into a pay period", src)
+}
+
+// Date is a calendar day, such as a pay date, held as midnight UTC. Its text form is "2006-01-02";
+// the zero Date is empty, which is null in JSON and NULL in the database.
+type Date struct{ time.Time }
+
+// NewDate returns the date of a year, month and day
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// DateOf returns the calendar day of t in t's location
+func DateOf(t time.Time) Date { return NewDate(t.Date()) }
+
+// ParseDate reads a "2006-01-02" date
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD", s)
+	}
+	return Date{t}, nil
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(time.DateOnly)
+}
+
+// Before reports whether d is an earlier day than e
+func (d Date) Before(e Date) bool { return d.Time.Before(e.Time) }
+
+// After reports whether d is a later day than e
+func (d Date) After(e Date) bool { return d.Time.After(e.Time) }
+
+func (d Date) MarshalText() ([]byte, error) { return []byte(d.String()), nil }
+
+func (d *Date) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		*d = Date{}
+		return nil
+	}
+	v, err := ParseDate(string(b))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+func (d *Date) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*d = Date{}
+		return nil
+	}
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return fmt.Errorf("invalid date %s: use a YYYY-MM-DD string", b)
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+// UnmarshalParam reads a date from a query parameter
+func (d *Date) UnmarshalParam(s string) error { return d.UnmarshalText([]byte(s)) }
+
+// Value stores d as "2006-01-02" text, which Postgres reads into a DATE column and SQLite
+// compares in date order
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.String(), nil
+}
+
+// Scan reads a DATE column or date text; NULL and empty text are the zero Date
+func (d *Date) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Date{}
+		return nil
+	case time.Time:
+		*d = DateOf(v)
+		return nil
+	case []byte:
+		src = string(v)
+	}
+	s, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into a date", src)
+	}
+	// A driver may hand back a DATE column as a timestamp
+	if len(s) > len(time.DateOnly) {
+		s = s[:len(time.DateOnly)]
+	}
+	return d.UnmarshalText([]byte(s))
+}
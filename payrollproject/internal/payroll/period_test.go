@@ -0,0 +1,110 @@
+package payroll
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPeriodTextAndDays(t *testing.T) {
+	for _, tc := range []struct {
+		text       string
+		kind       PeriodKind
+		start, end string
+		next       string
+	}{
+		{"2026-01", Monthly, "2026-01-01", "2026-01-31", "2026-02"},
+		{"2024-02", Monthly, "2024-02-01", "2024-02-29", "2024-03"},
+		{"2026-02-H1", SemiMonthly, "2026-02-01", "2026-02-15", "2026-02-H2"},
+		{"2026-02-H2", SemiMonthly, "2026-02-16", "2026-02-28", "2026-03-H1"},
+		{"2026-W01", Weekly, "2025-12-29", "2026-01-04", "2026-W02"},
+		{"2026-W53", Weekly, "2026-12-28", "2027-01-03", "2027-W01"},
+		{"2025-F26", Biweekly, "2025-12-29", "2026-01-11", "2026-F01"},
+		{"2026-F01", Biweekly, "2026-01-12", "2026-01-25", "2026-F02"},
+		{"2023-F26", Biweekly, "2023-12-18", "2023-12-31", "2024-F01"},
+	} {
+		p, err := ParsePeriod(tc.text)
+		if err != nil {
+			t.Fatalf("ParsePeriod(%q): %v", tc.text, err)
+		}
+		if p.String() != tc.text || p.Kind() != tc.kind || p.Start().String() != tc.start || p.End().String() != tc.end {
+			t.Errorf("%s = %s %s from %s to %s", tc.text, p, p.Kind(), p.Start(), p.End())
+		}
+		if next := p.Next(); next.String() != tc.next || next.Prev() != p {
+			t.Errorf("%s: next = %s, its previous = %s", tc.text, next, next.Prev())
+		}
+	}
+	for _, bad := range []string{"", "banana", "2026-13", "2026-1", "2026-01-H3", "2025-W53", "2025-F27", "2026-F00", "มกราคม 2569"} {
+		if _, err := ParsePeriod(bad); err == nil {
+			t.Errorf("ParsePeriod(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestPayrollJSONDates(t *testing.T) {
+	var p Payroll
+	if err := json.Unmarshal([]byte(`{"pay_month":"2026-01-H2","pay_date":"2026-01-31"}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.PayMonth != MustParsePeriod("2026-01-H2") || p.PayDate != NewDate(2026, 1, 31) {
+		t.Fatalf("decoded %s paid %s", p.PayMonth, p.PayDate)
+	}
+	p.PayDate = Date{}
+	b, _ := json.Marshal(p)
+	var fields map[string]any
+	json.Unmarshal(b, &fields)
+	if fields["pay_month"] != "2026-01-H2" || fields["pay_date"] != nil {
+		t.Fatalf("encoded pay_month %v, pay_date %v", fields["pay_month"], fields["pay_date"])
+	}
+	if err := json.Unmarshal([]byte(`{"pay_month":"01/2026"}`), &p); err == nil {
+		t.Fatal("a pay month in another format was accepted")
+	}
+}
+
+func TestParseLegacyPeriodsAndDates(t *testing.T) {
+	for in, want := range map[string]string{
+		"2026-01":     "2026-01",
+		"2026-1":      "2026-01",
+		"2026/01":     "2026-01",
+		"01/2569":     "2026-01",
+		"202601":      "2026-01",
+		"มกราคม 2569": "2026-01",
+		"ม.ค. 69":     "2026-01",
+		"มี.ค.2569":   "2026-03",
+		"พฤศจิกายน ๒๕๖๘": "2025-11",
+		"January 2026": "2026-01",
+		"sept 2026":    "2026-09",
+		"2569-02":      "2026-02",
+		"25/12/2568":   "2025-12",
+		"2026-01-H1":   "2026-01-H1",
+	} {
+		got, err := parseLegacyPeriod(in)
+		if err != nil || got.String() != want {
+			t.Errorf("parseLegacyPeriod(%q) = %s, %v; want %s", in, got, err, want)
+		}
+	}
+	for in, want := range map[string]string{
+		"2026-01-25":           "2026-01-25",
+		"2026-01-25T00:00:00Z": "2026-01-25",
+		"25/01/2569":           "2026-01-25",
+		"25/01/2026":           "2026-01-25",
+		"25 ม.ค. 69":           "2026-01-25",
+		"25 มกราคม 2569":       "2026-01-25",
+		"25 January 2026":      "2026-01-25",
+		"2569/01/25":           "2026-01-25",
+		"2569-01-25":           "2026-01-25",
+		"20260125":             "2026-01-25",
+	} {
+		got, err := parseLegacyDate(in)
+		if err != nil || got.String() != want {
+			t.Errorf("parseLegacyDate(%q) = %s, %v; want %s", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "soon", "13/2026", "31/02/2569", "มกราคม"} {
+		if p, err := parseLegacyPeriod(bad); err == nil {
+			t.Errorf("parseLegacyPeriod(%q) = %s", bad, p)
+		}
+		if d, err := parseLegacyDate(bad); err == nil {
+			t.Errorf("parseLegacyDate(%q) = %s", bad, d)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package payroll
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PeriodMigration is the schema version that gives payroll records date-typed pay periods;
+// NormalisePayPeriods has to run before it
+const PeriodMigration = 14
+
+// monthNames maps the lower-case month names and abbreviations found in old records to months
+var monthNames = map[string]time.Month{}
+
+// monthNamesByLength lists monthNames longest first, so "มี.ค." is matched before "ม.ค." could be
+var monthNamesByLength []string
+
+func init() {
+	thai := [][]string{
+		{"มกราคม", "ม.ค.", "มค"}, {"กุมภาพันธ์", "ก.พ.", "กพ"}, {"มีนาคม", "มี.ค.", "มีค"},
+		{"เมษายน", "เม.ย.", "เมย"}, {"พฤษภาคม", "พ.ค.", "พค"}, {"มิถุนายน", "มิ.ย.", "มิย"},
+		{"กรกฎาคม", "ก.ค.", "กค"}, {"สิงหาคม", "ส.ค.", "สค"}, {"กันยายน", "ก.ย.", "กย"},
+		{"ตุลาคม", "ต.ค.", "ตค"}, {"พฤศจิกายน", "พ.ย.", "พย"}, {"ธันวาคม", "ธ.ค.", "ธค"},
+	}
+	for i, names := range thai {
+		for _, name := range names {
+			monthNames[name] = time.Month(i + 1)
+		}
+	}
+	for m := time.January; m <= time.December; m++ {
+		name := strings.ToLower(m.String())
+		monthNames[name] = m
+		monthNames[name[:3]] = m
+	}
+	monthNames["sept"] = time.September
+	for name := range monthNames {
+		monthNamesByLength = append(monthNamesByLength, name)
+	}
+	sort.Slice(monthNamesByLength, func(i, j int) bool {
+		a, b := monthNamesByLength[i], monthNamesByLength[j]
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+}
+
+// legacyFields splits a date written by hand into its numbers, with month names replaced by "#"
+// and the month number. thai reports whether the text is Thai, whose two-digit years are
+// Buddhist-era.
+func legacyFields(s string) (fields []string, thai bool) {
+	s = strings.Map(func(r rune) rune {
+		if r >= '๐' && r <= '๙' {
+			return '0' + (r - '๐')
+		}
+		return unicode.ToLower(r)
+	}, strings.TrimSpace(s))
+	for _, r := range s {
+		if unicode.Is(unicode.Thai, r) {
+			thai = true
+			break
+		}
+	}
+	for _, name := range monthNamesByLength {
+		s = strings.ReplaceAll(s, name, fmt.Sprintf(" #%d ", monthNames[name]))
+	}
+	fields = strings.FieldsFunc(s, func(r rune) bool { return r != '#' && (r < '0' || r > '9') })
+	return fields, thai
+}
+
+// legacyYear reads a four-digit year, or a two-digit one in the current century; Buddhist-era
+// years (2400 and later) are converted to the Gregorian calendar
+func legacyYear(s string, thai bool) (int, bool) {
+	year, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+	switch {
+	case len(s) == 2 && thai:
+		year += 2500
+	case len(s) == 2:
+		year += 2000
+	case len(s) != 4:
+		return 0, false
+	}
+	if year >= 2400 {
+		year -= 543
+	}
+	return year, year >= 1900 && year <= 2200
+}
+
+// legacyMonth reads a month number or a "#"-marked month name
+func legacyMonth(s string) (time.Month, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(s, "#"))
+	if err != nil || len(s) > 3 || n < 1 || n > 12 {
+		return 0, false
+	}
+	return time.Month(n), true
+}
+
+// parseLegacyDate reads a date in the formats old records were written in: 2026-01-25,
+// 25/01/2569, 25 ม.ค. 69, 25 January 2026 or 20260125, with or without a time of day
+func parseLegacyDate(s string) (Date, error) {
+	if len(s) >= 10 {
+		if d, err := ParseDate(s[:10]); err == nil && d.Year() < 2400 && (len(s) == 10 || s[10] == 'T' || s[10] == ' ') {
+			return d, nil
+		}
+	}
+	fields, thai := legacyFields(s)
+	if len(fields) == 1 && len(fields[0]) == 8 {
+		f := fields[0]
+		fields = []string{f[:4], f[4:6], f[6:]}
+	}
+	if len(fields) != 3 {
+		return Date{}, fmt.Errorf("cannot read %q as a date", s)
+	}
+	y, m, d := fields[2], fields[1], fields[0]
+	if len(fields[0]) == 4 {
+		y, d = fields[0], fields[2]
+	}
+	year, ok := legacyYear(y, thai)
+	month, mok := legacyMonth(m)
+	day, err := strconv.Atoi(d)
+	if !ok || !mok || err != nil || strings.HasPrefix(d, "#") {
+		return Date{}, fmt.Errorf("cannot read %q as a date", s)
+	}
+	date := NewDate(year, month, day)
+	if date.Day() != day {
+		return Date{}, fmt.Errorf("%q is not a day of the calendar", s)
+	}
+	return date, nil
+}
+
+// parseLegacyPeriod reads a pay month in the formats old records were written in: 2026-01,
+// 2026/1, 01/2569, มกราคม 2569, ม.ค. 69, January 2026 or 202601; a date stands for its month
+func parseLegacyPeriod(s string) (Period, error) {
+	if p, err := ParsePeriod(strings.TrimSpace(s)); err == nil && p.Start().Year() < 2400 {
+		return p, nil
+	}
+	fields, thai := legacyFields(s)
+	if len(fields) == 1 && len(fields[0]) == 6 {
+		fields = []string{fields[0][:4], fields[0][4:]}
+	}
+	switch len(fields) {
+	case 2:
+		y, m := fields[1], fields[0]
+		if len(fields[0]) == 4 || strings.HasPrefix(fields[1], "#") {
+			y, m = fields[0], fields[1]
+		}
+		year, ok := legacyYear(y, thai)
+		month, mok := legacyMonth(m)
+		if ok && mok {
+			return MonthPeriod(year, month), nil
+		}
+	case 3:
+		if d, err := parseLegacyDate(s); err == nil {
+			return MonthPeriod(d.Year(), d.Month()), nil
+		}
+	}
+	return Period{}, fmt.Errorf("cannot read %q as a pay month", s)
+}
+
+// NormalisePayPeriods rewrites the pay month and pay date of every payroll record in the text
+// forms ParsePeriod and ParseDate read, so migration PeriodMigration can derive date columns from
+// them. Earlier versions stored whatever clients sent, so Thai month names and abbreviations,
+// Buddhist-era years and day/month/year dates are understood. Two records of one employee's pay run
+// that turn out to be for the same month, such as "ม.ค. 69" and "2026-01", are told apart by keeping
+// the later one as an off-cycle run. Records it cannot read, and a third record for the same month,
+// stop the migration and are listed to be corrected by hand.
+func NormalisePayPeriods(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "SELECT payroll_id, emp_id, pay_month, pay_date, run_type FROM payroll ORDER BY payroll_id")
+	if err != nil {
+		return fmt.Errorf("failed to query payroll: %w", err)
+	}
+	type change struct {
+		id       int
+		payMonth string
+		payDate  any
+		runType  string
+		moved    bool // to an off-cycle run
+	}
+	type run struct {
+		empID   int
+		period  string
+		runType string
+	}
+	var changes []change
+	var unreadable []string
+	paid := map[run]int{} // the record paying each run, once pay months are normalised
+	for rows.Next() {
+		var id, empID int
+		var payMonth, payDate sql.NullString
+		var runType string
+		if err := rows.Scan(&id, &empID, &payMonth, &payDate, &runType); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan payroll: %w", err)
+		}
+		period, err := parseLegacyPeriod(payMonth.String)
+		key := run{empID, period.String(), runType}
+		if err != nil {
+			unreadable = append(unreadable, fmt.Sprintf("payroll %d: pay_month %q", id, payMonth.String))
+		} else {
+			if other, ok := paid[key]; ok {
+				key.runType = RunOffCycle
+				if _, ok := paid[key]; ok {
+					unreadable = append(unreadable, fmt.Sprintf("payroll %d: pay_month %q pays employee %d for %s again, like payroll %d",
+						id, payMonth.String, empID, period, other))
+				}
+			}
+			paid[key] = id
+		}
+		var date Date
+		if strings.TrimSpace(payDate.String) != "" {
+			if date, err = parseLegacyDate(payDate.String); err != nil {
+				unreadable = append(unreadable, fmt.Sprintf("payroll %d: pay_date %q", id, payDate.String))
+			}
+		}
+		newDate, _ := date.Value()
+		moved := key.runType != runType
+		if period.String() != payMonth.String || (payDate.Valid && (date.IsZero() || date.String() != payDate.String)) || moved {
+			changes = append(changes, change{id, period.String(), newDate, key.runType, moved})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read payroll: %w", err)
+	}
+	if len(unreadable) > 0 {
+		return fmt.Errorf("cannot migrate the pay period of some payroll records; correct them and migrate again: %s",
+			strings.Join(unreadable, "; "))
+	}
+	// Records moved to an off-cycle run go first, so no record takes a run another still holds
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].moved && !changes[j].moved })
+	for _, c := range changes {
+		if _, err := tx.ExecContext(ctx, "UPDATE payroll SET pay_month = $2, pay_date = $3, run_type = $4 WHERE payroll_id = $1",
+			c.id, c.payMonth, c.payDate, c.runType); err != nil {
+			return fmt.Errorf("failed to rewrite the pay period of payroll %d: %w", c.id, err)
+		}
+	}
+	return nil
+}
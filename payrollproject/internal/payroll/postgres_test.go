@@ -0,0 +1,66 @@
+package payroll
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+
+	"payrollproject/internal/migrate"
+)
+
+// TestPostgresPayrollDBConformance runs against the database in PAYROLL_TEST_POSTGRES_DSN; its tables are truncated.
+func TestPostgresPayrollDBConformance(t *testing.T) {
+	dsn := os.Getenv("PAYROLL_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("PAYROLL_TEST_POSTGRES_DSN not set")
+	}
+
+	pdb, err := NewPostgresPayrollDB(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pdb.Close() })
+
+	m, err := migrate.New(pdb.pool, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	runConformance(t, func(t *testing.T) PayrollDatabase {
+		resetPostgres(t, pdb.pool)
+		return pdb
+	})
+}
+
+// resetPostgres empties every table but the migration history, so each subtest starts from an empty
+// database like the other implementations. The tables are read from the catalog rather than named,
+// so one added by a later migration is reset too.
+func resetPostgres(t *testing.T, db *sql.DB) {
+	t.Helper()
+	rows, err := db.Query(`
+        SELECT quote_ident(table_name) FROM information_schema.tables
+        WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' AND table_name <> 'schema_migrations'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("TRUNCATE " + strings.Join(tables, ", ") + " RESTART IDENTITY CASCADE"); err != nil {
+		t.Fatal(err)
+	}
+}
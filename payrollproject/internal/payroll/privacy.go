@@ -0,0 +1,227 @@
+package payroll
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// anonymisedName replaces the name of an anonymised employee
+func anonymisedName(empID int) string { return fmt.Sprintf("Anonymised employee %d", empID) }
+
+// AnonymiseEmployee erases an employee's name, phone number, bank details and national ID, and the
+// same fields in their profile change requests and the reasons given for leave. Pay, tax and
+// allowance records are kept. An employee can only be anonymised once.
+func (pdb *sqlPayrollDB) AnonymiseEmployee(ctx context.Context, empID int, at time.Time) error {
+	res, err := pdb.db.ExecContext(ctx, `
+        UPDATE employees SET
+            emp_name = $2,
+            phone_number = '',
+            bank_account = '',
+            account_num = '',
+            national_id = '',
+            anonymised_at = $3,
+            version = version + 1
+        WHERE emp_id = $1 AND anonymised_at IS NULL`, empID, anonymisedName(empID), at)
+	if err != nil {
+		return fmt.Errorf("failed to anonymise employee: %w", err)
+	}
+	if err := pdb.requireUpdated(ctx, res, "employees", "emp_id", empID, "employee"); err != nil {
+		return err
+	}
+	if _, err := pdb.db.ExecContext(ctx, `
+        UPDATE profile_change_requests SET phone_number = NULL, bank_account = NULL, account_num = NULL
+        WHERE emp_id = $1`, empID); err != nil {
+		return fmt.Errorf("failed to anonymise profile changes: %w", err)
+	}
+	if _, err := pdb.db.ExecContext(ctx, "UPDATE leave_requests SET reason = '' WHERE emp_id = $1", empID); err != nil {
+		return fmt.Errorf("failed to anonymise leave requests: %w", err)
+	}
+	return nil
+}
+
+// SubjectExport is everything held about one employee, as returned to them or on their behalf
+// under a PDPA data subject access request
+type SubjectExport struct {
+	GeneratedAt           time.Time              `json:"generated_at"`
+	Employee              Employee               `json:"employee"`
+	Accounts              []User                 `json:"accounts"`
+	Payrolls              []Payroll              `json:"payrolls"`
+	AllowanceDeclarations []AllowanceDeclaration `json:"allowance_declarations"`
+	ProfileChanges        []ProfileChange        `json:"profile_changes"`
+	LeaveRequests         []LeaveRequest         `json:"leave_requests"`
+	AuditEntries          []AuditEntry           `json:"audit_entries"` // changes to any of the records above
+}
+
+// ExportSubject gathers everything held about an employee. The export is itself recorded in the
+// audit log.
+func (ps *PayrollSystem) ExportSubject(ctx context.Context, empID int) (SubjectExport, error) {
+	var x SubjectExport
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		var err error
+		if x.Employee, err = tps.db.GetEmployee(ctx, empID); err != nil {
+			return err
+		}
+		users, err := tps.db.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+		x.Accounts = []User{}
+		for _, u := range users {
+			if u.EmpID != nil && *u.EmpID == empID {
+				x.Accounts = append(x.Accounts, u)
+			}
+		}
+		q := PayrollQuery{ListOptions: ListOptions{Sort: "pay_month"}, EmpID: empID}
+		if x.Payrolls, err = tps.allPayrolls(ctx, q); err != nil {
+			return err
+		}
+		if x.AllowanceDeclarations, err = tps.db.ListAllowanceDeclarations(ctx, empID); err != nil {
+			return err
+		}
+		if x.ProfileChanges, err = tps.db.ListProfileChanges(ctx, RequestQuery{EmpID: empID}); err != nil {
+			return err
+		}
+		if x.LeaveRequests, err = tps.db.ListLeaveRequests(ctx, RequestQuery{EmpID: empID}); err != nil {
+			return err
+		}
+
+		records := []AuditQuery{{Entity: "employee", EntityID: fmt.Sprint(empID)}}
+		for _, p := range x.Payrolls {
+			records = append(records, AuditQuery{Entity: "payroll", EntityID: fmt.Sprint(p.PayrollID)})
+		}
+		for _, d := range x.AllowanceDeclarations {
+			records = append(records, AuditQuery{Entity: "allowance_declaration", EntityID: fmt.Sprintf("%d/%d", empID, d.TaxYear)})
+		}
+		for _, c := range x.ProfileChanges {
+			records = append(records, AuditQuery{Entity: "profile_change", EntityID: fmt.Sprint(c.ChangeID)})
+		}
+		for _, l := range x.LeaveRequests {
+			records = append(records, AuditQuery{Entity: "leave_request", EntityID: fmt.Sprint(l.LeaveID)})
+		}
+		x.AuditEntries = []AuditEntry{}
+		for _, q := range records {
+			entries, err := tps.allAudit(ctx, q)
+			if err != nil {
+				return err
+			}
+			x.AuditEntries = append(x.AuditEntries, entries...)
+		}
+
+		x.GeneratedAt = time.Now().UTC()
+		return tps.audit(ctx, "employee", empID, AuditExport, nil, nil)
+	})
+	return x, err
+}
+
+// allPayrolls reads every page of a payroll query
+func (ps *PayrollSystem) allPayrolls(ctx context.Context, q PayrollQuery) ([]Payroll, error) {
+	q.Limit = MaxPageSize
+	payrolls := []Payroll{}
+	for {
+		page, err := ps.db.ListPayrolls(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		payrolls = append(payrolls, page.Items...)
+		if page.NextCursor == "" {
+			return payrolls, nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// allAudit reads every page of an audit log query
+func (ps *PayrollSystem) allAudit(ctx context.Context, q AuditQuery) ([]AuditEntry, error) {
+	q.Limit = MaxPageSize
+	var entries []AuditEntry
+	for {
+		page, err := ps.db.ListAudit(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, page.Items...)
+		if page.NextCursor == "" {
+			return entries, nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// RetentionPolicy says how long former employees' personal data is kept after their end date
+type RetentionPolicy struct {
+	Years int
+	AsOf  time.Time // the day the policy is applied on; zero means today
+}
+
+// RetentionCandidate is a former employee whose retention period has passed
+type RetentionCandidate struct {
+	EmpID       int    `json:"emp_id"`
+	EndDate     string `json:"end_date"`
+	RetainUntil string `json:"retain_until"`
+}
+
+// RetentionReport lists the former employees a retention run anonymises, or would anonymise on a
+// dry run
+type RetentionReport struct {
+	RunAt          time.Time            `json:"run_at"`
+	DryRun         bool                 `json:"dry_run"`
+	RetentionYears int                  `json:"retention_years"`
+	Due            []RetentionCandidate `json:"due"`
+	Anonymised     int                  `json:"anonymised"`
+}
+
+// ApplyRetention anonymises every former employee whose end date is more than p.Years before
+// p.AsOf, each in its own audited transaction. With dryRun set it only reports who is due.
+func (ps *PayrollSystem) ApplyRetention(ctx context.Context, p RetentionPolicy, dryRun bool) (RetentionReport, error) {
+	if p.Years < 1 {
+		return RetentionReport{}, &ValidationError{Fields: []FieldError{{Field: "retention_years", Message: "must be at least 1"}}}
+	}
+	asOf := p.AsOf
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	report := RetentionReport{RunAt: time.Now().UTC(), DryRun: dryRun, RetentionYears: p.Years, Due: []RetentionCandidate{}}
+
+	emps, err := ps.db.GetAllEmployees(ctx)
+	if err != nil {
+		return RetentionReport{}, err
+	}
+	today := asOf.Format("2006-01-02")
+	for _, emp := range emps {
+		if emp.EndDate == "" || emp.AnonymisedAt != nil {
+			continue
+		}
+		end, err := time.Parse("2006-01-02", emp.EndDate)
+		if err != nil {
+			return RetentionReport{}, fmt.Errorf("employee %d has an invalid end date %q: %w", emp.EmployeeID, emp.EndDate, err)
+		}
+		until := end.AddDate(p.Years, 0, 0).Format("2006-01-02")
+		if until >= today {
+			continue
+		}
+		report.Due = append(report.Due, RetentionCandidate{EmpID: emp.EmployeeID, EndDate: emp.EndDate, RetainUntil: until})
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	for _, c := range report.Due {
+		err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+			if err := tps.db.AnonymiseEmployee(ctx, c.EmpID, report.RunAt); err != nil {
+				return err
+			}
+			after, err := tps.db.GetEmployee(ctx, c.EmpID)
+			if err != nil {
+				return err
+			}
+			// The personal fields being erased are deliberately not copied into the audit log
+			return tps.audit(ctx, "employee", c.EmpID, AuditAnonymise, nil, after)
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to anonymise employee %d: %w", c.EmpID, err)
+		}
+		report.Anonymised++
+	}
+	return report, nil
+}
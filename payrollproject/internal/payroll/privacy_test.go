@@ -0,0 +1,156 @@
+package payroll
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyRetentionAnonymisesFormerEmployees(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	for _, emp := range []Employee{
+		{EmployeeID: 2, EndDate: "2015-12-31"},
+		{EmployeeID: 3, EndDate: "2016-06-30"},
+	} {
+		emp.EmpName, emp.PhoneNumber, emp.DeptID, emp.BaseSalary = "Former", "0812345678", 1, 20000
+		emp.BankAccount, emp.AccountNum = "Government Savings Bank", "123456789012"
+		if err := ps.AddEmployee(ctx, emp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	addApproved(t, ps, Payroll{EmpID: 2, PayMonth: MustParsePeriod("2015-12"), BaseSalary: 20000, TaxAmount: 100})
+
+	policy := RetentionPolicy{Years: 10, AsOf: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}
+	report, err := ps.ApplyRetention(ctx, policy, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Due) != 1 || report.Due[0].EmpID != 2 || report.Due[0].RetainUntil != "2025-12-31" || report.Anonymised != 0 {
+		t.Fatalf("dry run report = %+v", report)
+	}
+	if emp, _ := ps.GetEmployee(ctx, 2); emp.AnonymisedAt != nil {
+		t.Fatal("dry run anonymised the employee")
+	}
+
+	if report, err = ps.ApplyRetention(ctx, policy, false); err != nil || report.Anonymised != 1 {
+		t.Fatalf("apply = %+v, %v", report, err)
+	}
+	emp, _ := ps.GetEmployee(ctx, 2)
+	if emp.AnonymisedAt == nil || emp.EmpName == "Former" || emp.AccountNum != "" {
+		t.Fatalf("employee after retention = %+v", emp)
+	}
+	if cert, err := ps.TaxCertificate(ctx, 2, 2015); err != nil || cert.TaxWithheld != 100 {
+		t.Fatalf("tax certificate after retention = %+v, %v", cert, err)
+	}
+	if _, err := ps.UpdateEmployee(ctx, emp); !errors.Is(err, ErrValidation) {
+		t.Fatalf("updating an anonymised employee error = %v, want ErrValidation", err)
+	}
+	page, _ := ps.ListAudit(ctx, AuditQuery{Entity: "employee", EntityID: "2"})
+	if last := page.Items[len(page.Items)-1]; last.Action != AuditAnonymise || last.Before != nil {
+		t.Fatalf("anonymise audit entry = %+v", last)
+	}
+
+	if report, _ = ps.ApplyRetention(ctx, policy, false); len(report.Due) != 0 {
+		t.Fatalf("second run = %+v, want nothing due", report)
+	}
+	if _, err := ps.ApplyRetention(ctx, RetentionPolicy{}, true); !errors.Is(err, ErrValidation) {
+		t.Fatalf("zero-year policy error = %v, want ErrValidation", err)
+	}
+}
+
+func TestAuditLogKeepsNoPersonalData(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	emp, _ := ps.GetEmployee(ctx, 1)
+	emp.EmpName, emp.PhoneNumber, emp.NationalID, emp.EndDate = "Somchai Jaidee", "0898765432", "1101700207030", "2015-12-31"
+	if _, err := ps.UpdateEmployee(ctx, emp); err != nil {
+		t.Fatal(err)
+	}
+	account := "987654321098"
+	change, err := ps.RequestProfileChange(ctx, ProfileChange{EmpID: 1, AccountNum: &account})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.ReviewProfileChange(ctx, change.ChangeID, Review{Status: RequestApproved}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.RequestLeave(ctx, LeaveRequest{EmpID: 1, LeaveType: "sick", StartDate: "2015-12-01", EndDate: "2015-12-02",
+		Reason: "Hospital visit"}); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := ps.ListAudit(ctx, AuditQuery{Entity: "employee", EntityID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := page.Items[1].Changes["emp_name"]; string(c.Before) != `"[redacted]"` || string(c.After) != `"[redacted: changed]"` {
+		t.Fatalf("emp_name change = %s -> %s, want the redaction markers", c.Before, c.After)
+	}
+
+	if _, err := ps.ApplyRetention(ctx, RetentionPolicy{Years: 10, AsOf: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}, false); err != nil {
+		t.Fatal(err)
+	}
+	page, err = ps.ListAudit(ctx, AuditQuery{ListOptions: ListOptions{Limit: MaxPageSize}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range page.Items {
+		entry, _ := json.Marshal(e)
+		for _, personal := range []string{"A", "Somchai", "0812345678", "0898765432", "Government Savings Bank", "123456789012", "9012",
+			"987654321098", "1098", "1101700207030", "7030", "Hospital"} {
+			if strings.Contains(string(entry), `"`+personal) || strings.Contains(string(entry), personal+`"`) {
+				t.Errorf("audit entry %d still holds %q: %s", e.AuditID, personal, entry)
+			}
+		}
+	}
+	if v, _ := ps.VerifyAudit(ctx); !v.Valid {
+		t.Fatalf("audit chain after retention = %+v", v)
+	}
+}
+
+func TestExportSubjectGathersRecords(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	if _, err := ps.AddPayroll(ctx, Payroll{EmpID: 1, PayMonth: MustParsePeriod("2026-01"), BaseSalary: 30000}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.SaveAllowanceDeclaration(ctx, AllowanceDeclaration{EmpID: 1, TaxYear: 2026}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.RequestLeave(ctx, LeaveRequest{EmpID: 1, LeaveType: "vacation", StartDate: "2026-04-13", EndDate: "2026-04-15"}); err != nil {
+		t.Fatal(err)
+	}
+	empID := 1
+	if _, err := ps.AddUser(ctx, User{Username: "a", PasswordHash: "h", EmpID: &empID}); err != nil {
+		t.Fatal(err)
+	}
+
+	x, err := ps.ExportSubject(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Employee.AccountNum != "123456789012" || len(x.Accounts) != 1 || len(x.Payrolls) != 1 ||
+		len(x.AllowanceDeclarations) != 1 || len(x.LeaveRequests) != 1 || len(x.ProfileChanges) != 0 {
+		t.Fatalf("unexpected export: %+v", x)
+	}
+	entities := map[string]bool{}
+	for _, e := range x.AuditEntries {
+		entities[e.Entity] = true
+	}
+	for _, want := range []string{"employee", "payroll", "allowance_declaration", "leave_request"} {
+		if !entities[want] {
+			t.Errorf("export has no audit entries for %s", want)
+		}
+	}
+	page, _ := ps.ListAudit(ctx, AuditQuery{Entity: "employee", EntityID: "1"})
+	if last := page.Items[len(page.Items)-1]; last.Action != AuditExport {
+		t.Fatalf("last employee audit entry = %+v, want the export", last)
+	}
+	if _, err := ps.ExportSubject(ctx, 99); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("unknown employee error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,58 @@
+package payroll
+
+import (
+	"context"
+	"embed"
+	"encoding/csv"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// publicHolidays holds the Thai public holidays the cabinet announced for each year, substitution
+// days included, one YYYY.csv file a year with a "date" and a "name" column
+//
+//go:embed publicholidays/*.csv
+var publicHolidays embed.FS
+
+// EnsurePublicHolidays loads the Thai public holidays shipped with the program into every year that
+// has no public holiday yet, so working days are counted right before HR imports a calendar. A year
+// that has public holidays already, imported or entered by hand, is left alone.
+func (ps *PayrollSystem) EnsurePublicHolidays(ctx context.Context) error {
+	files, err := fs.Glob(publicHolidays, "publicholidays/*.csv")
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		year, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "publicholidays/"), ".csv"))
+		if err != nil {
+			return fmt.Errorf("public holidays %s: the file is not named after a year", name)
+		}
+		existing, err := ps.ListHolidays(ctx, HolidayQuery{Year: year, Kind: HolidayPublic})
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			continue
+		}
+
+		f, err := publicHolidays.Open(name)
+		if err != nil {
+			return err
+		}
+		table, err := csv.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("public holidays of %d: %w", year, err)
+		}
+		holidays, err := HolidaysFromTable(table)
+		if err != nil {
+			return fmt.Errorf("public holidays of %d: %w", year, err)
+		}
+		if _, err := ps.ImportHolidays(ctx, HolidayPublic, "", holidays, false); err != nil {
+			return fmt.Errorf("public holidays of %d: %w", year, err)
+		}
+	}
+	return nil
+}
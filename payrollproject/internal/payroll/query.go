@@ -0,0 +1,252 @@
+package payroll
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultAuditPageSize is the page size of the audit log when a query sets no limit; other
+	// lists return every row unless asked for a page
+	DefaultAuditPageSize = 50
+	// MaxPageSize caps the limit a client may request
+	MaxPageSize = 500
+)
+
+// ErrInvalidQuery is returned for unknown sort fields, bad cursors or contradictory filters
+var ErrInvalidQuery = errors.New("invalid query")
+
+// ListOptions controls sorting and cursor pagination of a list query
+type ListOptions struct {
+	Sort   string `form:"sort"`   // field name, prefixed with "-" for descending
+	Limit  int    `form:"limit"`  // 0 returns every row, in one page
+	Cursor string `form:"cursor"` // opaque value from a previous page's NextCursor, under the same sort
+}
+
+// EmployeeQuery filters the employee list
+type EmployeeQuery struct {
+	ListOptions
+	EmpID     int      `form:"emp_id"`
+	DeptID    int      `form:"dept_id"`
+	Position  string   `form:"position"`
+	MinSalary *float64 `form:"min_salary"`
+	MaxSalary *float64 `form:"max_salary"`
+}
+
+// PayrollQuery filters the payroll list. PayMonth matches one pay period exactly; the inclusive
+// PayMonthFrom and PayMonthTo bounds match every period that starts between the first day of From
+// and the last day of To, whatever its kind.
+type PayrollQuery struct {
+	ListOptions
+	EmpID        int      `form:"emp_id"`
+	DeptID       int      `form:"dept_id"`
+	PayMonth     Period   `form:"pay_month"`
+	PayMonthFrom Period   `form:"pay_month_from"`
+	PayMonthTo   Period   `form:"pay_month_to"`
+	MinSalary    *float64 `form:"min_salary"`
+	MaxSalary    *float64 `form:"max_salary"`
+	Status       string   `form:"status"` // draft or approved; empty lists both
+}
+
+// Page is one page of a list query
+type Page[T any] struct {
+	Items      []T
+	NextCursor string // empty on the last page
+	Total      int    // number of rows matching the filters, ignoring pagination
+}
+
+// sortField maps a public sort name to its SQL expression and the matching value on a record
+type sortField[T any] struct {
+	expr string
+	key  func(T) any
+}
+
+var employeeSorts = map[string]sortField[Employee]{
+	"emp_id":        {"e.emp_id", func(e Employee) any { return e.EmployeeID }},
+	"emp_name":      {"COALESCE(e.emp_name, '')", func(e Employee) any { return e.EmpName }},
+	"dept_id":       {"e.dept_id", func(e Employee) any { return e.DeptID }},
+	"position_name": {"COALESCE(e.position_name, '')", func(e Employee) any { return e.PositionName }},
+	"base_salary":   {"COALESCE(e.base_salary, 0)", func(e Employee) any { return e.BaseSalary }},
+}
+
+var payrollSorts = map[string]sortField[Payroll]{
+	"payroll_id":  {"p.payroll_id", func(p Payroll) any { return p.PayrollID }},
+	"emp_id":      {"p.emp_id", func(p Payroll) any { return p.EmpID }},
+	"pay_month":   {"p.period_start", func(p Payroll) any { return p.PayMonth.Start().String() }},
+	"pay_date":    {"COALESCE(CAST(p.pay_date AS VARCHAR(10)), '')", func(p Payroll) any { return p.PayDate.String() }},
+	"base_salary": {"COALESCE(p.base_salary, 0)", func(p Payroll) any { return p.BaseSalary }},
+	"net_salary":  {"COALESCE(p.net_salary, 0)", func(p Payroll) any { return p.NetSalary }},
+}
+
+// resolvedSort is a validated sort with the ID field used as tie-breaker
+type resolvedSort[T any] struct {
+	name  string // the sort parameter, with the default filled in
+	field sortField[T]
+	id    sortField[T]
+	desc  bool
+}
+
+// resolveSort validates the sort parameter against the allowed fields
+func resolveSort[T any](sort string, fields map[string]sortField[T], idName string) (resolvedSort[T], error) {
+	desc := strings.HasPrefix(sort, "-")
+	name := strings.TrimPrefix(sort, "-")
+	if name == "" {
+		name = idName
+	}
+	field, ok := fields[name]
+	if !ok {
+		return resolvedSort[T]{}, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, name)
+	}
+	canonical := name
+	if desc {
+		canonical = "-" + name
+	}
+	return resolvedSort[T]{name: canonical, field: field, id: fields[idName], desc: desc}, nil
+}
+
+// normalizeLimit applies the maximum page size; 0 stays unlimited
+func normalizeLimit(limit int) (int, error) {
+	switch {
+	case limit < 0:
+		return 0, fmt.Errorf("%w: limit must not be negative", ErrInvalidQuery)
+	case limit > MaxPageSize:
+		return MaxPageSize, nil
+	}
+	return limit, nil
+}
+
+// pageCursor is the position after the last row of a page: the sort it was issued under, and the
+// row's sort value and ID
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Value any    `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// encodeCursor builds the opaque cursor pointing after item
+func encodeCursor[T any](s resolvedSort[T], item T) string {
+	b, _ := json.Marshal(pageCursor{Sort: s.name, Value: s.field.key(item), ID: s.id.key(item).(int)})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor parses a cursor, returning nil for an empty one; a cursor issued under another
+// sort than s is rejected, as its value cannot be compared with the sort field
+func decodeCursor[T any](cursor string, s resolvedSort[T]) (*pageCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var c pageCursor
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	if c.Sort != s.name {
+		return nil, fmt.Errorf("%w: cursor was issued for sort %q, not %q", ErrInvalidQuery, c.Sort, s.name)
+	}
+	if n, ok := c.Value.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			c.Value = i
+		} else if f, err := n.Float64(); err == nil {
+			c.Value = f
+		}
+	}
+	return &c, nil
+}
+
+// queryBuilder collects WHERE conditions whose values are always bound as arguments.
+// Conditions are written with ? placeholders and renumbered to $n as they are added.
+type queryBuilder struct {
+	conds []string
+	args  []any
+}
+
+// where adds a condition; each ? in cond is bound to the next value in args
+func (b *queryBuilder) where(cond string, args ...any) {
+	var sb strings.Builder
+	i := 0
+	for _, r := range cond {
+		if r == '?' && i < len(args) {
+			b.args = append(b.args, args[i])
+			sb.WriteString("$" + strconv.Itoa(len(b.args)))
+			i++
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	b.conds = append(b.conds, sb.String())
+}
+
+// bind adds a value outside the WHERE clause (e.g. LIMIT) and returns its placeholder
+func (b *queryBuilder) bind(v any) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// clause renders the WHERE clause, or an empty string when there are no conditions
+func (b *queryBuilder) clause() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// clone copies the builder so page conditions can be added without affecting the count query
+func (b *queryBuilder) clone() *queryBuilder {
+	return &queryBuilder{conds: append([]string(nil), b.conds...), args: append([]any(nil), b.args...)}
+}
+
+// after adds the keyset condition for rows following the cursor in sort order
+func after[T any](b *queryBuilder, s resolvedSort[T], c *pageCursor) {
+	op := ">"
+	if s.desc {
+		op = "<"
+	}
+	b.where("("+s.field.expr+", "+s.id.expr+") "+op+" (?, ?)", c.Value, c.ID)
+}
+
+// orderBy renders the ORDER BY clause for a resolved sort
+func orderBy[T any](s resolvedSort[T]) string {
+	dir := " ASC"
+	if s.desc {
+		dir = " DESC"
+	}
+	return " ORDER BY " + s.field.expr + dir + ", " + s.id.expr + dir
+}
+
+// compareValues orders two sort values of the same field; numbers compare numerically
+func compareValues(a, b any) int {
+	if as, ok := a.(string); ok {
+		bs, _ := b.(string)
+		return strings.Compare(as, bs)
+	}
+	af, bf := toFloat(a), toFloat(b)
+	switch {
+	case af < bf:
+		return -1
+	case af > bf:
+		return 1
+	}
+	return 0
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
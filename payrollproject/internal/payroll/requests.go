@@ -0,0 +1,441 @@
+package payroll
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Request review states shared by profile change and leave requests
+const (
+	RequestPending   = "pending"
+	RequestApproved  = "approved"
+	RequestRejected  = "rejected"
+	RequestCancelled = "cancelled" // withdrawn by the employee before review
+)
+
+// Review records who decided a pending request and when
+type Review struct {
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requested_at"`
+	ReviewedBy  *int       `json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote  string     `json:"review_note,omitempty" validate:"max=500"`
+}
+
+// validate checks a decision before it is recorded
+func (r Review) validate() error {
+	switch r.Status {
+	case RequestApproved, RequestRejected, RequestCancelled:
+	default:
+		return &ValidationError{Fields: []FieldError{{Field: "status", Message: "must be one of approved rejected cancelled"}}}
+	}
+	return validateStruct(r)
+}
+
+// ProfileChange is an employee's request to change their own contact or bank details. Only the
+// fields that are set change; HR applies them to the employee record by approving the request.
+type ProfileChange struct {
+	ChangeID    int     `json:"change_id"`
+	EmpID       int     `json:"emp_id" validate:"gt=0"`
+	PhoneNumber *string `json:"phone_number,omitempty"`
+	BankAccount *string `json:"bank_account,omitempty"`
+	AccountNum  *string `json:"account_num,omitempty"`
+	Review
+}
+
+// Apply returns emp with the requested changes made
+func (c ProfileChange) Apply(emp Employee) Employee {
+	if c.PhoneNumber != nil {
+		emp.PhoneNumber = *c.PhoneNumber
+	}
+	if c.BankAccount != nil {
+		emp.BankAccount = *c.BankAccount
+	}
+	if c.AccountNum != nil {
+		emp.AccountNum = *c.AccountNum
+	}
+	return emp
+}
+
+// LeaveRequest is an employee's request for time off; both dates are inclusive
+type LeaveRequest struct {
+	LeaveID   int    `json:"leave_id"`
+	EmpID     int    `json:"emp_id" validate:"gt=0"`
+	LeaveType string `json:"leave_type" validate:"oneof=sick personal vacation maternity ordination military other"`
+	StartDate string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" validate:"required,datetime=2006-01-02"`
+	Reason    string `json:"reason" validate:"max=500"`
+	Review
+}
+
+// Validate checks a leave request before it is stored
+func (l LeaveRequest) Validate() error {
+	if err := validateStruct(l); err != nil {
+		return err
+	}
+	if l.EndDate < l.StartDate {
+		return &ValidationError{Fields: []FieldError{{Field: "end_date", Message: "must not be before start_date"}}}
+	}
+	return nil
+}
+
+// overlaps reports whether two leave requests share a day
+func (l LeaveRequest) overlaps(other LeaveRequest) bool {
+	return l.StartDate <= other.EndDate && other.StartDate <= l.EndDate
+}
+
+// RequestQuery filters the profile change and leave request lists; zero values match everything
+type RequestQuery struct {
+	EmpID  int    `form:"emp_id"`
+	DeptID int    `form:"dept_id"`
+	Status string `form:"status"`
+}
+
+// reviewColumns is the column list matching scanReview
+const reviewColumns = "r.status, r.requested_at, r.reviewed_by, r.reviewed_at, r.review_note"
+
+func scanReview(r *Review) []any {
+	return []any{&r.Status, &r.RequestedAt, nullIntScanner{&r.ReviewedBy}, nullTimeScanner{&r.ReviewedAt}, &r.ReviewNote}
+}
+
+// nullIntScanner scans a nullable integer column into a *int
+type nullIntScanner struct{ dst **int }
+
+func (s nullIntScanner) Scan(src any) error {
+	var n sql.NullInt64
+	if err := n.Scan(src); err != nil {
+		return err
+	}
+	*s.dst = nil
+	if n.Valid {
+		v := int(n.Int64)
+		*s.dst = &v
+	}
+	return nil
+}
+
+// nullTimeScanner scans a nullable timestamp column into a *time.Time
+type nullTimeScanner struct{ dst **time.Time }
+
+func (s nullTimeScanner) Scan(src any) error {
+	var t sql.NullTime
+	if err := t.Scan(src); err != nil {
+		return err
+	}
+	*s.dst = nil
+	if t.Valid {
+		*s.dst = &t.Time
+	}
+	return nil
+}
+
+// AddProfileChange stores a pending profile change request and returns its ID
+func (pdb *sqlPayrollDB) AddProfileChange(ctx context.Context, c ProfileChange) (int, error) {
+	var id int
+	err := pdb.db.QueryRowContext(ctx, `
+        INSERT INTO profile_change_requests (emp_id, phone_number, bank_account, account_num, status, requested_at)
+        VALUES ($1, $2, $3, $4, $5, $6) RETURNING change_id`,
+		c.EmpID, nullableString(c.PhoneNumber), nullableString(c.BankAccount), nullableString(c.AccountNum),
+		RequestPending, time.Now().UTC()).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add profile change: %w",
+			constraintError(err, "profile change", c.EmpID, &ForeignKeyError{Field: "emp_id", Entity: "employee", ID: c.EmpID}))
+	}
+	return id, nil
+}
+
+const profileChangeColumns = "r.change_id, r.emp_id, r.phone_number, r.bank_account, r.account_num, " + reviewColumns
+
+func scanProfileChange(row interface{ Scan(...any) error }) (ProfileChange, error) {
+	var c ProfileChange
+	var phone, bank, account sql.NullString
+	err := row.Scan(append([]any{&c.ChangeID, &c.EmpID, &phone, &bank, &account}, scanReview(&c.Review)...)...)
+	c.PhoneNumber, c.BankAccount, c.AccountNum = stringPtr(phone), stringPtr(bank), stringPtr(account)
+	return c, err
+}
+
+// GetProfileChange retrieves a profile change request
+func (pdb *sqlPayrollDB) GetProfileChange(ctx context.Context, changeID int) (ProfileChange, error) {
+	c, err := scanProfileChange(pdb.db.QueryRowContext(ctx,
+		"SELECT "+profileChangeColumns+" FROM profile_change_requests r WHERE r.change_id = $1", changeID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ProfileChange{}, &NotFoundError{Entity: "profile change", ID: changeID}
+	}
+	if err != nil {
+		return ProfileChange{}, fmt.Errorf("failed to query profile change: %w", err)
+	}
+	return c, nil
+}
+
+// ListProfileChanges retrieves the profile change requests matching q, oldest first
+func (pdb *sqlPayrollDB) ListProfileChanges(ctx context.Context, q RequestQuery) ([]ProfileChange, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+        SELECT `+profileChangeColumns+`
+        FROM profile_change_requests r JOIN employees e ON e.emp_id = r.emp_id
+        WHERE ($1 = 0 OR r.emp_id = $1) AND ($2 = 0 OR e.dept_id = $2) AND ($3 = '' OR r.status = $3)
+        ORDER BY r.change_id`, q.EmpID, q.DeptID, q.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query profile changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []ProfileChange{}
+	for rows.Next() {
+		c, err := scanProfileChange(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan profile change: %w", err)
+		}
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over profile changes: %w", err)
+	}
+	return changes, nil
+}
+
+// ReviewProfileChange records the decision on a pending profile change request; a request that
+// was already decided fails with ErrConflict
+func (pdb *sqlPayrollDB) ReviewProfileChange(ctx context.Context, changeID int, r Review) error {
+	return pdb.review(ctx, "profile_change_requests", "change_id", changeID, r, "profile change")
+}
+
+// AddLeaveRequest stores a pending leave request and returns its ID
+func (pdb *sqlPayrollDB) AddLeaveRequest(ctx context.Context, l LeaveRequest) (int, error) {
+	var id int
+	err := pdb.db.QueryRowContext(ctx, `
+        INSERT INTO leave_requests (emp_id, leave_type, start_date, end_date, reason, status, requested_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING leave_id`,
+		l.EmpID, l.LeaveType, l.StartDate, l.EndDate, l.Reason, RequestPending, time.Now().UTC()).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add leave request: %w",
+			constraintError(err, "leave request", l.EmpID, &ForeignKeyError{Field: "emp_id", Entity: "employee", ID: l.EmpID}))
+	}
+	return id, nil
+}
+
+const leaveColumns = "r.leave_id, r.emp_id, r.leave_type, r.start_date, r.end_date, r.reason, " + reviewColumns
+
+func scanLeaveRequest(row interface{ Scan(...any) error }) (LeaveRequest, error) {
+	var l LeaveRequest
+	err := row.Scan(append([]any{&l.LeaveID, &l.EmpID, &l.LeaveType, &l.StartDate, &l.EndDate, &l.Reason}, scanReview(&l.Review)...)...)
+	return l, err
+}
+
+// GetLeaveRequest retrieves a leave request
+func (pdb *sqlPayrollDB) GetLeaveRequest(ctx context.Context, leaveID int) (LeaveRequest, error) {
+	l, err := scanLeaveRequest(pdb.db.QueryRowContext(ctx,
+		"SELECT "+leaveColumns+" FROM leave_requests r WHERE r.leave_id = $1", leaveID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return LeaveRequest{}, &NotFoundError{Entity: "leave request", ID: leaveID}
+	}
+	if err != nil {
+		return LeaveRequest{}, fmt.Errorf("failed to query leave request: %w", err)
+	}
+	return l, nil
+}
+
+// ListLeaveRequests retrieves the leave requests matching q, ordered by start date
+func (pdb *sqlPayrollDB) ListLeaveRequests(ctx context.Context, q RequestQuery) ([]LeaveRequest, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+        SELECT `+leaveColumns+`
+        FROM leave_requests r JOIN employees e ON e.emp_id = r.emp_id
+        WHERE ($1 = 0 OR r.emp_id = $1) AND ($2 = 0 OR e.dept_id = $2) AND ($3 = '' OR r.status = $3)
+        ORDER BY r.start_date, r.leave_id`, q.EmpID, q.DeptID, q.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leave requests: %w", err)
+	}
+	defer rows.Close()
+
+	leaves := []LeaveRequest{}
+	for rows.Next() {
+		l, err := scanLeaveRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan leave request: %w", err)
+		}
+		leaves = append(leaves, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over leave requests: %w", err)
+	}
+	return leaves, nil
+}
+
+// ReviewLeaveRequest records the decision on a pending leave request; a request that was already
+// decided fails with ErrConflict
+func (pdb *sqlPayrollDB) ReviewLeaveRequest(ctx context.Context, leaveID int, r Review) error {
+	return pdb.review(ctx, "leave_requests", "leave_id", leaveID, r, "leave request")
+}
+
+// review moves a pending request in table to r.Status
+func (pdb *sqlPayrollDB) review(ctx context.Context, table, idColumn string, id int, r Review, what string) error {
+	res, err := pdb.db.ExecContext(ctx, `
+        UPDATE `+table+` SET status = $2, reviewed_by = $3, reviewed_at = $4, review_note = $5
+        WHERE `+idColumn+` = $1 AND status = $6`,
+		id, r.Status, nullableID(r.ReviewedBy), reviewTime(r), r.ReviewNote, RequestPending)
+	if err != nil {
+		return fmt.Errorf("failed to review %s: %w", what, err)
+	}
+	return pdb.requireUpdated(ctx, res, table, idColumn, id, what)
+}
+
+// reviewTime is when r was decided, defaulting to now
+func reviewTime(r Review) time.Time {
+	if r.ReviewedAt != nil {
+		return r.ReviewedAt.UTC()
+	}
+	return time.Now().UTC()
+}
+
+// nullableString stores a missing optional string as NULL
+func nullableString(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+func stringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// RequestProfileChange checks that the requested details would leave a valid employee record and
+// files the request for HR to review
+func (ps *PayrollSystem) RequestProfileChange(ctx context.Context, c ProfileChange) (ProfileChange, error) {
+	if c.PhoneNumber == nil && c.BankAccount == nil && c.AccountNum == nil {
+		return ProfileChange{}, &ValidationError{Fields: []FieldError{{Field: "phone_number", Message: "at least one of phone_number, bank_account or account_num is required"}}}
+	}
+	var stored ProfileChange
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		emp, err := tps.db.GetEmployee(ctx, c.EmpID)
+		if err != nil {
+			return err
+		}
+		if err := c.Apply(emp).Validate(); err != nil {
+			return err
+		}
+		id, err := tps.db.AddProfileChange(ctx, c)
+		if err != nil {
+			return err
+		}
+		if stored, err = tps.db.GetProfileChange(ctx, id); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "profile_change", id, AuditCreate, nil, stored)
+	})
+	return stored, err
+}
+
+// GetProfileChange retrieves a profile change request
+func (ps *PayrollSystem) GetProfileChange(ctx context.Context, changeID int) (ProfileChange, error) {
+	return ps.db.GetProfileChange(ctx, changeID)
+}
+
+// ListProfileChanges retrieves the profile change requests matching q
+func (ps *PayrollSystem) ListProfileChanges(ctx context.Context, q RequestQuery) ([]ProfileChange, error) {
+	return ps.db.ListProfileChanges(ctx, q)
+}
+
+// ReviewProfileChange decides a pending profile change request. Approving it applies the change
+// to the employee record in the same transaction, revalidating it against the current record.
+func (ps *PayrollSystem) ReviewProfileChange(ctx context.Context, changeID int, r Review) (ProfileChange, error) {
+	if err := r.validate(); err != nil {
+		return ProfileChange{}, err
+	}
+	var reviewed ProfileChange
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		before, err := tps.db.GetProfileChange(ctx, changeID)
+		if err != nil {
+			return err
+		}
+		if err := tps.db.ReviewProfileChange(ctx, changeID, r); err != nil {
+			return err
+		}
+		if reviewed, err = tps.db.GetProfileChange(ctx, changeID); err != nil {
+			return err
+		}
+		if err := tps.audit(ctx, "profile_change", changeID, reviewAction(r.Status), before, reviewed); err != nil || r.Status != RequestApproved {
+			return err
+		}
+		emp, err := tps.db.GetEmployee(ctx, reviewed.EmpID)
+		if err != nil {
+			return err
+		}
+		emp = reviewed.Apply(emp)
+		if err := emp.Validate(); err != nil {
+			return err
+		}
+		_, err = tps.updateEmployee(ctx, emp)
+		return err
+	})
+	return reviewed, err
+}
+
+// RequestLeave files a leave request, rejecting one that overlaps the employee's pending or
+// approved leave
+func (ps *PayrollSystem) RequestLeave(ctx context.Context, l LeaveRequest) (LeaveRequest, error) {
+	if err := l.Validate(); err != nil {
+		return LeaveRequest{}, err
+	}
+	var stored LeaveRequest
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		if _, err := tps.db.GetEmployee(ctx, l.EmpID); err != nil {
+			return err
+		}
+		existing, err := tps.db.ListLeaveRequests(ctx, RequestQuery{EmpID: l.EmpID})
+		if err != nil {
+			return err
+		}
+		for _, other := range existing {
+			if (other.Status == RequestPending || other.Status == RequestApproved) && l.overlaps(other) {
+				return &ValidationError{Fields: []FieldError{{Field: "start_date", Message: fmt.Sprintf("overlaps leave request %d", other.LeaveID)}}}
+			}
+		}
+		id, err := tps.db.AddLeaveRequest(ctx, l)
+		if err != nil {
+			return err
+		}
+		if stored, err = tps.db.GetLeaveRequest(ctx, id); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "leave_request", id, AuditCreate, nil, stored)
+	})
+	return stored, err
+}
+
+// GetLeaveRequest retrieves a leave request
+func (ps *PayrollSystem) GetLeaveRequest(ctx context.Context, leaveID int) (LeaveRequest, error) {
+	return ps.db.GetLeaveRequest(ctx, leaveID)
+}
+
+// ListLeaveRequests retrieves the leave requests matching q
+func (ps *PayrollSystem) ListLeaveRequests(ctx context.Context, q RequestQuery) ([]LeaveRequest, error) {
+	return ps.db.ListLeaveRequests(ctx, q)
+}
+
+// ReviewLeaveRequest approves, rejects or cancels a pending leave request
+func (ps *PayrollSystem) ReviewLeaveRequest(ctx context.Context, leaveID int, r Review) (LeaveRequest, error) {
+	if err := r.validate(); err != nil {
+		return LeaveRequest{}, err
+	}
+	var reviewed LeaveRequest
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		before, err := tps.db.GetLeaveRequest(ctx, leaveID)
+		if err != nil {
+			return err
+		}
+		if err := tps.db.ReviewLeaveRequest(ctx, leaveID, r); err != nil {
+			return err
+		}
+		if reviewed, err = tps.db.GetLeaveRequest(ctx, leaveID); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "leave_request", leaveID, reviewAction(r.Status), before, reviewed)
+	})
+	return reviewed, err
+}
@@ -0,0 +1,152 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"payrollproject/internal/tax"
+)
+
+func newSelfServiceSystem(t *testing.T) *PayrollSystem {
+	t.Helper()
+	ctx := context.Background()
+	ps := NewPayrollSystem(NewMemoryPayrollDB())
+	if err := ps.AddDepartment(ctx, Department{DeptID: 1, DeptName: "Ops"}); err != nil {
+		t.Fatal(err)
+	}
+	err := ps.AddEmployee(ctx, Employee{EmployeeID: 1, EmpName: "A", PhoneNumber: "0812345678", DeptID: 1, BaseSalary: 30000,
+		BankAccount: "Government Savings Bank", AccountNum: "123456789012"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ps
+}
+
+func TestReviewProfileChangeAppliesOnApproval(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+
+	if _, err := ps.RequestProfileChange(ctx, ProfileChange{EmpID: 1}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("empty change error = %v, want ErrValidation", err)
+	}
+	bad := "123"
+	if _, err := ps.RequestProfileChange(ctx, ProfileChange{EmpID: 1, PhoneNumber: &bad}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("invalid phone error = %v, want ErrValidation", err)
+	}
+
+	phone := "0898765432"
+	c, err := ps.RequestProfileChange(ctx, ProfileChange{EmpID: 1, PhoneNumber: &phone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emp, _ := ps.GetEmployee(ctx, 1); emp.PhoneNumber != "0812345678" {
+		t.Fatalf("phone changed before approval: %q", emp.PhoneNumber)
+	}
+	if _, err := ps.ReviewProfileChange(ctx, c.ChangeID, Review{Status: RequestPending}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("pending review error = %v, want ErrValidation", err)
+	}
+	if _, err := ps.ReviewProfileChange(ctx, c.ChangeID, Review{Status: RequestApproved}); err != nil {
+		t.Fatal(err)
+	}
+	if emp, _ := ps.GetEmployee(ctx, 1); emp.PhoneNumber != phone {
+		t.Fatalf("phone after approval = %q, want %q", emp.PhoneNumber, phone)
+	}
+}
+
+func TestRequestLeaveRejectsOverlap(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+
+	if _, err := ps.RequestLeave(ctx, LeaveRequest{EmpID: 1, LeaveType: "sick", StartDate: "2026-03-05", EndDate: "2026-03-04"}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("reversed dates error = %v, want ErrValidation", err)
+	}
+	first, err := ps.RequestLeave(ctx, LeaveRequest{EmpID: 1, LeaveType: "vacation", StartDate: "2026-03-02", EndDate: "2026-03-06"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	overlapping := LeaveRequest{EmpID: 1, LeaveType: "sick", StartDate: "2026-03-06", EndDate: "2026-03-06"}
+	if _, err := ps.RequestLeave(ctx, overlapping); !errors.Is(err, ErrValidation) {
+		t.Fatalf("overlapping leave error = %v, want ErrValidation", err)
+	}
+	if _, err := ps.ReviewLeaveRequest(ctx, first.LeaveID, Review{Status: RequestCancelled}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.RequestLeave(ctx, overlapping); err != nil {
+		t.Fatalf("leave after cancelling the overlap: %v", err)
+	}
+}
+
+// addApproved stores payroll records and approves them
+func addApproved(t *testing.T, ps *PayrollSystem, payrolls ...Payroll) {
+	t.Helper()
+	ctx := context.Background()
+	for _, p := range payrolls {
+		added, err := ps.AddPayroll(ctx, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ps.ApprovePayroll(ctx, added.PayrollID, 0, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTaxCertificateSumsTheYear(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+
+	addApproved(t, ps,
+		Payroll{EmpID: 1, PayMonth: MustParsePeriod("2025-12"), BaseSalary: 30000, TaxAmount: 500},
+		Payroll{EmpID: 1, PayMonth: MustParsePeriod("2026-01"), BaseSalary: 30000, TotalAdditions: 2000, TaxAmount: 600},
+		Payroll{EmpID: 1, PayMonth: MustParsePeriod("2026-02"), BaseSalary: 10000, TaxAmount: 100},
+		// A bonus run pays no further social security
+		Payroll{EmpID: 1, PayMonth: MustParsePeriod("2026-02"), RunType: RunBonus, BaseSalary: 30000, TaxAmount: 3000},
+	)
+	// A draft is still under review and is left off the certificate
+	if _, err := ps.AddPayroll(ctx, Payroll{EmpID: 1, PayMonth: MustParsePeriod("2026-03"), BaseSalary: 30000, TaxAmount: 600}); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := ps.TaxCertificate(ctx, 1, 2026)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cert.Payrolls) != 3 || cert.Income != 72000 || cert.TaxWithheld != 3700 || cert.SocialSecurity != 1250 {
+		t.Fatalf("unexpected certificate: %+v", cert)
+	}
+	if _, err := ps.TaxCertificate(ctx, 1, 2024); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("empty year error = %v, want ErrNotFound", err)
+	}
+
+	// An employee moved from monthly to weekly pay during the year is still capped at the legal maximum
+	for m := time.January; m <= time.December; m++ {
+		addApproved(t, ps, Payroll{EmpID: 1, PayMonth: MonthPeriod(2027, m), BaseSalary: 30000})
+	}
+	addApproved(t, ps, Payroll{EmpID: 1, PayMonth: MustParsePeriod("2027-W52"), BaseSalary: 7000})
+	if cert, err = ps.TaxCertificate(ctx, 1, 2027); err != nil || cert.SocialSecurity != 12*tax.SocialSecurityMonthlyCap {
+		t.Fatalf("social security on the 2027 certificate = %.2f, %v, want %.2f", cert.SocialSecurity, err, 12*tax.SocialSecurityMonthlyCap)
+	}
+}
+
+func TestTaxCertificateOfBiweeklyPay(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	if err := ps.AddEmployee(ctx, Employee{EmployeeID: 2, EmpName: "B", PhoneNumber: "0898765432", DeptID: 1, BaseSalary: 32500,
+		PayFrequency: Biweekly, BankAccount: "Government Savings Bank", AccountNum: "210987654321"}); err != nil {
+		t.Fatal(err)
+	}
+	for n := 1; n <= 26; n++ {
+		addApproved(t, ps, Payroll{EmpID: 2, PayMonth: MustParsePeriod(fmt.Sprintf("2026-F%02d", n)), BaseSalary: 15000, TaxAmount: 10})
+	}
+	cert, err := ps.TaxCertificate(ctx, 2, 2026)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each fortnight pays 346.15, a twenty-sixth of the 9,000 a year, rather than a month's 750
+	if len(cert.Payrolls) != 26 || cert.Income != 390000 || math.Round(cert.SocialSecurity*100) != 899990 {
+		t.Fatalf("unexpected certificate: income %.2f, social security %.2f from %d records", cert.Income, cert.SocialSecurity, len(cert.Payrolls))
+	}
+}
@@ -0,0 +1,246 @@
+package payroll
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Scope limits which rows a role's permissions apply to
+type Scope string
+
+const (
+	// ScopeAll grants the permissions over every row
+	ScopeAll Scope = "all"
+	// ScopeDepartment grants them over the department of the user's own employee record
+	ScopeDepartment Scope = "department"
+	// ScopeSelf grants them over the user's own employee record only
+	ScopeSelf Scope = "self"
+)
+
+// Role is a named set of permissions over a scope of rows
+type Role struct {
+	RoleName    string   `json:"role_name" validate:"required,max=50"`
+	Description string   `json:"description" validate:"max=255"`
+	Scope       Scope    `json:"scope" validate:"oneof=all department self"`
+	Permissions []string `json:"permissions" validate:"dive,required,max=50"`
+}
+
+// Validate checks a role before it is stored
+func (r Role) Validate() error { return validateStruct(r) }
+
+// Payroll approval states
+const (
+	PayrollDraft    = "draft"
+	PayrollApproved = "approved"
+)
+
+// approvedError reports a write to an approved payroll record, which is final
+func approvedError(payrollID int) error {
+	return &ConflictError{Entity: "payroll", ID: payrollID, Reason: "is approved and can no longer be changed"}
+}
+
+// ListRoles retrieves every role with its permissions, ordered by name
+func (pdb *sqlPayrollDB) ListRoles(ctx context.Context) ([]Role, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+        SELECT r.role_name, r.description, r.scope, COALESCE(rp.permission, '')
+        FROM roles r LEFT JOIN role_permissions rp ON rp.role_name = r.role_name
+        ORDER BY r.role_name, rp.permission`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []Role
+	for rows.Next() {
+		var r Role
+		var perm string
+		if err := rows.Scan(&r.RoleName, &r.Description, &r.Scope, &perm); err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		if n := len(roles); n == 0 || roles[n-1].RoleName != r.RoleName {
+			r.Permissions = []string{}
+			roles = append(roles, r)
+		}
+		if perm != "" {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, perm)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over roles: %w", err)
+	}
+	return roles, nil
+}
+
+// GetRole retrieves a role with its permissions
+func (pdb *sqlPayrollDB) GetRole(ctx context.Context, name string) (Role, error) {
+	r := Role{RoleName: name, Permissions: []string{}}
+	err := pdb.db.QueryRowContext(ctx, "SELECT description, scope FROM roles WHERE role_name = $1", name).
+		Scan(&r.Description, &r.Scope)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Role{}, &NotFoundError{Entity: "role", ID: name}
+	}
+	if err != nil {
+		return Role{}, fmt.Errorf("failed to query role: %w", err)
+	}
+	rows, err := pdb.db.QueryContext(ctx, "SELECT permission FROM role_permissions WHERE role_name = $1 ORDER BY permission", name)
+	if err != nil {
+		return Role{}, fmt.Errorf("failed to query role permissions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var perm string
+		if err := rows.Scan(&perm); err != nil {
+			return Role{}, fmt.Errorf("failed to scan role permission: %w", err)
+		}
+		r.Permissions = append(r.Permissions, perm)
+	}
+	return r, rows.Err()
+}
+
+// SaveRole creates a role or replaces its description, scope and permissions
+func (pdb *sqlPayrollDB) SaveRole(ctx context.Context, r Role) error {
+	_, err := pdb.db.ExecContext(ctx, `
+        INSERT INTO roles (role_name, description, scope) VALUES ($1, $2, $3)
+        ON CONFLICT (role_name) DO UPDATE SET description = EXCLUDED.description, scope = EXCLUDED.scope`,
+		r.RoleName, r.Description, r.Scope)
+	if err != nil {
+		return fmt.Errorf("failed to save role: %w", constraintError(err, "role", r.RoleName, nil))
+	}
+	if _, err := pdb.db.ExecContext(ctx, "DELETE FROM role_permissions WHERE role_name = $1", r.RoleName); err != nil {
+		return fmt.Errorf("failed to save role permissions: %w", err)
+	}
+	for _, perm := range r.Permissions {
+		if _, err := pdb.db.ExecContext(ctx, "INSERT INTO role_permissions (role_name, permission) VALUES ($1, $2)", r.RoleName, perm); err != nil {
+			return fmt.Errorf("failed to save role permissions: %w", constraintError(err, "role permission", perm, nil))
+		}
+	}
+	return nil
+}
+
+// DeleteRole deletes a role; users holding it lose its permissions
+func (pdb *sqlPayrollDB) DeleteRole(ctx context.Context, name string) error {
+	res, err := pdb.db.ExecContext(ctx, "DELETE FROM roles WHERE role_name = $1", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &NotFoundError{Entity: "role", ID: name}
+	}
+	return nil
+}
+
+// ApprovePayroll marks a draft payroll record approved by a user, checking its version like an update
+func (pdb *sqlPayrollDB) ApprovePayroll(ctx context.Context, payrollID, version, userID int) error {
+	res, err := pdb.db.ExecContext(ctx, `
+        UPDATE payroll SET status = $2, approved_by = $3, approved_at = $4, version = version + 1
+        WHERE payroll_id = $1 AND status = $5 AND ($6 = 0 OR version = $6)`,
+		payrollID, PayrollApproved, userID, time.Now().UTC(), PayrollDraft, version)
+	if err != nil {
+		return fmt.Errorf("failed to approve payroll: %w", err)
+	}
+	return pdb.requireUpdated(ctx, res, "payroll", "payroll_id", payrollID, "payroll")
+}
+
+// ListRoles retrieves every role
+func (ps *PayrollSystem) ListRoles(ctx context.Context) ([]Role, error) {
+	return ps.db.ListRoles(ctx)
+}
+
+// GetRole retrieves a role
+func (ps *PayrollSystem) GetRole(ctx context.Context, name string) (Role, error) {
+	return ps.db.GetRole(ctx, name)
+}
+
+// SaveRole validates and stores a role with its permission list
+func (ps *PayrollSystem) SaveRole(ctx context.Context, r Role) (Role, error) {
+	if err := r.Validate(); err != nil {
+		return Role{}, err
+	}
+	r.Permissions = uniqueSorted(r.Permissions)
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		var before any
+		existing, err := tps.db.GetRole(ctx, r.RoleName)
+		if err == nil {
+			before = existing
+		} else if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if err := tps.db.SaveRole(ctx, r); err != nil {
+			return err
+		}
+		action := AuditUpdate
+		if before == nil {
+			action = AuditCreate
+		}
+		return tps.audit(ctx, "role", r.RoleName, action, before, r)
+	})
+	return r, err
+}
+
+// DeleteRole deletes a role
+func (ps *PayrollSystem) DeleteRole(ctx context.Context, name string) error {
+	return ps.withTx(ctx, func(tps *PayrollSystem) error {
+		before, err := tps.db.GetRole(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err := tps.db.DeleteRole(ctx, name); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "role", name, AuditDelete, before, nil)
+	})
+}
+
+// ApprovePayroll approves a draft payroll record and returns the stored result; approving a
+// record that is already approved, or whose version is stale, fails with ErrConflict. A record the
+// pre-approval checks find an error in fails with an AnomalyError. The checks need not run again
+// afterwards: UpdatePayroll and DeletePayroll refuse approved records.
+func (ps *PayrollSystem) ApprovePayroll(ctx context.Context, payrollID, version, userID int) (Payroll, error) {
+	var approved Payroll
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		before, err := tps.db.GetPayroll(ctx, payrollID)
+		if err != nil {
+			return err
+		}
+		if before.Status == PayrollDraft {
+			blocking, err := tps.blockingAnomalies(ctx, before)
+			if err != nil {
+				return err
+			}
+			if len(blocking) > 0 {
+				return &AnomalyError{PayrollID: payrollID, Anomalies: blocking}
+			}
+		}
+		if err := tps.db.ApprovePayroll(ctx, payrollID, version, userID); err != nil {
+			return err
+		}
+		if approved, err = tps.db.GetPayroll(ctx, payrollID); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "payroll", payrollID, AuditApprove, before, approved)
+	})
+	return approved, err
+}
+
+// uniqueSorted returns the distinct values of s in order
+func uniqueSorted(s []string) []string {
+	out := make([]string, 0, len(s))
+	seen := make(map[string]bool, len(s))
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
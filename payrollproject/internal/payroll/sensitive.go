@@ -0,0 +1,207 @@
+package payroll
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// FieldCipher encrypts sensitive employee fields at rest. Decrypt must return values that were
+// stored before encryption was enabled unchanged.
+type FieldCipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(stored string) (string, error)
+}
+
+// encryptedDB wraps a PayrollDatabase so that bank details and national IDs are encrypted on the
+// way in and decrypted on the way out; the underlying store only ever sees ciphertext
+type encryptedDB struct {
+	PayrollDatabase
+	cipher FieldCipher
+}
+
+// NewEncryptedPayrollDB returns db with the bank_account, account_num and national_id of employees
+// and of profile change requests encrypted by c
+func NewEncryptedPayrollDB(db PayrollDatabase, c FieldCipher) PayrollDatabase {
+	return &encryptedDB{PayrollDatabase: db, cipher: c}
+}
+
+// employeeFields are the sensitive fields of an employee
+func employeeFields(emp *Employee) []*string {
+	return []*string{&emp.BankAccount, &emp.AccountNum, &emp.NationalID}
+}
+
+// profileChangeFields are the sensitive fields a profile change carries. The pointers are replaced
+// with copies first, so rewriting the fields never reaches a value the caller or the store shares.
+func profileChangeFields(c *ProfileChange) []*string {
+	var fields []*string
+	for _, f := range []**string{&c.BankAccount, &c.AccountNum} {
+		if *f != nil {
+			v := **f
+			*f = &v
+			fields = append(fields, &v)
+		}
+	}
+	return fields
+}
+
+func (db *encryptedDB) encrypt(fields []*string) error {
+	for _, f := range fields {
+		stored, err := db.cipher.Encrypt(*f)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt field: %w", err)
+		}
+		*f = stored
+	}
+	return nil
+}
+
+func (db *encryptedDB) decrypt(fields []*string) error {
+	for _, f := range fields {
+		plain, err := db.cipher.Decrypt(*f)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt field: %w", err)
+		}
+		*f = plain
+	}
+	return nil
+}
+
+func (db *encryptedDB) decryptEmployees(emps []Employee) error {
+	for i := range emps {
+		if err := db.decrypt(employeeFields(&emps[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *encryptedDB) GetAllEmployees(ctx context.Context) ([]Employee, error) {
+	emps, err := db.PayrollDatabase.GetAllEmployees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return emps, db.decryptEmployees(emps)
+}
+
+func (db *encryptedDB) GetEmployee(ctx context.Context, empID int) (Employee, error) {
+	emp, err := db.PayrollDatabase.GetEmployee(ctx, empID)
+	if err != nil {
+		return Employee{}, err
+	}
+	return emp, db.decrypt(employeeFields(&emp))
+}
+
+func (db *encryptedDB) ListEmployees(ctx context.Context, q EmployeeQuery) (Page[Employee], error) {
+	page, err := db.PayrollDatabase.ListEmployees(ctx, q)
+	if err != nil {
+		return Page[Employee]{}, err
+	}
+	return page, db.decryptEmployees(page.Items)
+}
+
+func (db *encryptedDB) AddEmployee(ctx context.Context, emp Employee) error {
+	if err := db.encrypt(employeeFields(&emp)); err != nil {
+		return err
+	}
+	return db.PayrollDatabase.AddEmployee(ctx, emp)
+}
+
+func (db *encryptedDB) UpdateEmployee(ctx context.Context, emp Employee) error {
+	if err := db.encrypt(employeeFields(&emp)); err != nil {
+		return err
+	}
+	return db.PayrollDatabase.UpdateEmployee(ctx, emp)
+}
+
+func (db *encryptedDB) AddProfileChange(ctx context.Context, c ProfileChange) (int, error) {
+	if err := db.encrypt(profileChangeFields(&c)); err != nil {
+		return 0, err
+	}
+	return db.PayrollDatabase.AddProfileChange(ctx, c)
+}
+
+func (db *encryptedDB) GetProfileChange(ctx context.Context, changeID int) (ProfileChange, error) {
+	c, err := db.PayrollDatabase.GetProfileChange(ctx, changeID)
+	if err != nil {
+		return ProfileChange{}, err
+	}
+	return c, db.decrypt(profileChangeFields(&c))
+}
+
+func (db *encryptedDB) ListProfileChanges(ctx context.Context, q RequestQuery) ([]ProfileChange, error) {
+	changes, err := db.PayrollDatabase.ListProfileChanges(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	for i := range changes {
+		if err := db.decrypt(profileChangeFields(&changes[i])); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+func (db *encryptedDB) WithTx(ctx context.Context, fn func(PayrollDatabase) error) error {
+	return db.PayrollDatabase.WithTx(ctx, func(tx PayrollDatabase) error {
+		return fn(&encryptedDB{PayrollDatabase: tx, cipher: db.cipher})
+	})
+}
+
+// MaskAccountNumber hides all but four digits of a bank account number. Ten-digit numbers keep the
+// usual xxx-x-xxxxx-x grouping, e.g. xxx-x-x1234-x.
+func MaskAccountNumber(s string) string {
+	n := len(s)
+	switch {
+	case s == "":
+		return ""
+	case n == 10:
+		return "xxx-x-x" + s[5:9] + "-x"
+	case n > 5:
+		return strings.Repeat("x", n-5) + s[n-5:n-1] + "x"
+	}
+	return strings.Repeat("x", n)
+}
+
+// MaskNationalID hides all but the last four digits of a national ID, keeping the x-xxxx-xxxxx-xx-x
+// grouping of a 13-digit ID
+func MaskNationalID(s string) string {
+	n := len(s)
+	switch {
+	case s == "":
+		return ""
+	case n == 13:
+		return "x-xxxx-xxxx" + s[9:10] + "-" + s[10:12] + "-" + s[12:]
+	case n > 4:
+		return strings.Repeat("x", n-4) + s[n-4:]
+	}
+	return strings.Repeat("x", n)
+}
+
+// Masked returns the employee with account_num and national_id masked
+func (e Employee) Masked() Employee {
+	e.AccountNum = MaskAccountNumber(e.AccountNum)
+	e.NationalID = MaskNationalID(e.NationalID)
+	return e
+}
+
+// KeepMasked returns e with any field still holding the mask of current's value (a client writing
+// back a record it read masked) restored to current's value
+func (e Employee) KeepMasked(current Employee) Employee {
+	if e.AccountNum != "" && e.AccountNum == MaskAccountNumber(current.AccountNum) {
+		e.AccountNum = current.AccountNum
+	}
+	if e.NationalID != "" && e.NationalID == MaskNationalID(current.NationalID) {
+		e.NationalID = current.NationalID
+	}
+	return e
+}
+
+// Masked returns the change with account_num masked
+func (c ProfileChange) Masked() ProfileChange {
+	if c.AccountNum != nil {
+		masked := MaskAccountNumber(*c.AccountNum)
+		c.AccountNum = &masked
+	}
+	return c
+}
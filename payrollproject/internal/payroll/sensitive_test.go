@@ -0,0 +1,105 @@
+package payroll
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"payrollproject/internal/keyring"
+)
+
+func TestEncryptedDBConformance(t *testing.T) {
+	key, err := keyring.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr, err := keyring.Load("", "k1:"+key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runConformance(t, func(t *testing.T) PayrollDatabase {
+		return NewEncryptedPayrollDB(NewMemoryPayrollDB(), kr)
+	})
+}
+
+func TestEncryptedDBStoresCiphertext(t *testing.T) {
+	ctx := context.Background()
+	key, err := keyring.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr, err := keyring.Load("", "k1:"+key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemoryPayrollDB()
+	ps := NewPayrollSystem(NewEncryptedPayrollDB(store, kr))
+	if err := ps.AddDepartment(ctx, Department{DeptID: 1, DeptName: "Ops"}); err != nil {
+		t.Fatal(err)
+	}
+	emp := Employee{EmployeeID: 1, EmpName: "A", PhoneNumber: "0812345678", DeptID: 1, BaseSalary: 30000,
+		BankAccount: "Kasikorn Bank", AccountNum: "1234567890", NationalID: "1101700207030"}
+	if err := ps.AddEmployee(ctx, emp); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := store.state.employees[1]
+	for _, v := range []string{raw.BankAccount, raw.AccountNum, raw.NationalID} {
+		if keyring.KeyID(v) != "k1" {
+			t.Fatalf("stored field %q is not encrypted", v)
+		}
+	}
+	got, err := ps.GetEmployee(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.BankAccount != emp.BankAccount || got.AccountNum != emp.AccountNum || got.NationalID != emp.NationalID {
+		t.Fatalf("decrypted employee = %+v", got)
+	}
+
+	num := "0987654321"
+	c, err := ps.RequestProfileChange(ctx, ProfileChange{EmpID: 1, AccountNum: &num})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if num != "0987654321" || *c.AccountNum != num {
+		t.Fatalf("profile change account = %q, caller's value = %q", *c.AccountNum, num)
+	}
+	if raw := store.state.changes[c.ChangeID]; keyring.KeyID(*raw.AccountNum) != "k1" {
+		t.Fatalf("stored profile change account %q is not encrypted", *raw.AccountNum)
+	}
+
+	// Audit snapshots keep neither plaintext nor ciphertext, only redaction markers
+	page, _ := ps.ListAudit(ctx, AuditQuery{Entity: "employee", EntityID: "1"})
+	after := string(page.Items[0].After)
+	if strings.Contains(after, "6789") || strings.Contains(after, "k1") || !strings.Contains(after, `"account_num":"[redacted]"`) {
+		t.Fatalf("audit snapshot = %s", after)
+	}
+}
+
+func TestMasking(t *testing.T) {
+	for in, want := range map[string]string{
+		"1234567890":      "xxx-x-x6789-x",
+		"123456789012":    "xxxxxxx8901x",
+		"1234":            "xxxx",
+		"":                "",
+		"123456789012345": "xxxxxxxxxx1234x",
+	} {
+		if got := MaskAccountNumber(in); got != want {
+			t.Errorf("MaskAccountNumber(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := MaskNationalID("1101700207030"); got != "x-xxxx-xxxx7-03-0" {
+		t.Errorf("MaskNationalID = %q", got)
+	}
+
+	current := Employee{AccountNum: "1234567890", NationalID: "1101700207030"}
+	written := current.Masked()
+	if kept := written.KeepMasked(current); kept.AccountNum != current.AccountNum || kept.NationalID != current.NationalID {
+		t.Errorf("KeepMasked of an unchanged record = %+v", kept)
+	}
+	written.AccountNum = "1111111111"
+	if kept := written.KeepMasked(current); kept.AccountNum != "1111111111" {
+		t.Errorf("KeepMasked replaced a new account number: %+v", kept)
+	}
+}
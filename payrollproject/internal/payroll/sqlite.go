@@ -0,0 +1,36 @@
+package payroll
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLitePayrollDB is the PayrollDatabase backed by a single SQLite file, for offices without Postgres
+type SQLitePayrollDB struct {
+	*sqlPayrollDB
+}
+
+// SQLiteDSN builds the connection string for a SQLite database file with foreign keys enforced
+func SQLiteDSN(path string) string {
+	return "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+}
+
+// NewSQLitePayrollDB opens (creating if needed) the SQLite database at path
+func NewSQLitePayrollDB(path string) (*SQLitePayrollDB, error) {
+	db, err := sql.Open("sqlite", SQLiteDSN(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// SQLite has a single writer; one connection serializes access within the process
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &SQLitePayrollDB{&sqlPayrollDB{db: db, pool: db, dialect: dialectSQLite}}, nil
+}
@@ -0,0 +1,143 @@
+package payroll
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"payrollproject/internal/migrate"
+)
+
+func TestSQLitePayrollDBConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T) PayrollDatabase {
+		db, err := NewSQLitePayrollDB(filepath.Join(t.TempDir(), "payroll.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		m, err := migrate.New(db.pool, "sqlite")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.Up(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		// Start empty like the other implementations; the migration seeds sample data
+		if _, err := db.pool.Exec("DELETE FROM departments"); err != nil {
+			t.Fatal(err)
+		}
+		return db
+	})
+}
+
+// beforePeriodMigration returns a SQLite database migrated up to just before PeriodMigration, whose
+// payroll holds one record of an employee for each of payMonths, as earlier versions stored them
+func beforePeriodMigration(t *testing.T, payMonths ...string) (*SQLitePayrollDB, *migrate.Migrator) {
+	t.Helper()
+	ctx := context.Background()
+	db, err := NewSQLitePayrollDB(filepath.Join(t.TempDir(), "payroll.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	m, err := migrate.New(db.pool, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Before(PeriodMigration, NormalisePayPeriods)
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Down(ctx, len(statuses)-PeriodMigration+1); err != nil {
+		t.Fatal(err)
+	}
+
+	var empID int
+	if err := db.pool.QueryRow("SELECT MIN(emp_id) FROM employees").Scan(&empID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.pool.Exec("DELETE FROM payroll"); err != nil {
+		t.Fatal(err)
+	}
+	for _, payMonth := range payMonths {
+		if _, err := db.pool.Exec(`INSERT INTO payroll (emp_id, pay_month, base_salary, tax_amount, total_additions, total_deductions, net_salary)
+            VALUES ($1, $2, 30000, 0, 0, 750, 29250)`, empID, payMonth); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db, m
+}
+
+func TestSQLiteMigrationTellsDuplicatePayMonthsApart(t *testing.T) {
+	ctx := context.Background()
+	// Before pay months were normalised, the same month could be written in Thai or in ISO form
+	db, m := beforePeriodMigration(t, "ม.ค. 69", "2026-01")
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	payrolls, err := db.GetAllPayrolls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payrolls) != 2 || payrolls[0].PayMonth != payrolls[1].PayMonth || payrolls[0].RunType != RunRegular || payrolls[1].RunType != RunOffCycle {
+		t.Fatalf("payrolls after migrating = %+v, want the second January record kept as off-cycle", payrolls)
+	}
+}
+
+func TestNormalisePayPeriodsKeepsPayRunsUnique(t *testing.T) {
+	ctx := context.Background()
+	// Databases migrated by earlier versions already have the unique index on the raw pay month
+	const index = "CREATE UNIQUE INDEX payroll_emp_month_run ON payroll (emp_id, pay_month, run_type)"
+	normalise := func(db *SQLitePayrollDB) error {
+		tx, err := db.pool.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+		if err := NormalisePayPeriods(ctx, tx); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	db, _ := beforePeriodMigration(t, "2026-01", "มกราคม 2569")
+	if _, err := db.pool.Exec(index); err != nil {
+		t.Fatal(err)
+	}
+	if err := normalise(db); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.pool.Query("SELECT pay_month, run_type FROM payroll ORDER BY payroll_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var runs []string
+	for rows.Next() {
+		var payMonth, runType string
+		if err := rows.Scan(&payMonth, &runType); err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, payMonth+" "+runType)
+	}
+	if strings.Join(runs, ", ") != "2026-01 regular, 2026-01 off_cycle" {
+		t.Fatalf("runs = %v, want the Thai record kept as an off-cycle run", runs)
+	}
+
+	// A third record for the month has no run left and is reported instead of breaking the index
+	db, _ = beforePeriodMigration(t, "ม.ค. 69", "2026-01", "01/2569")
+	if _, err := db.pool.Exec(index); err != nil {
+		t.Fatal(err)
+	}
+	err = normalise(db)
+	if err == nil || !strings.Contains(err.Error(), `pay_month "01/2569" pays employee`) || strings.Contains(err.Error(), "constraint") {
+		t.Fatalf("third record error = %v, want it listed", err)
+	}
+}
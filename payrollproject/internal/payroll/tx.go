@@ -0,0 +1,87 @@
+package payroll
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// maxTxAttempts bounds how many times a transaction is retried after a serialization failure
+const maxTxAttempts = 3
+
+// dbtx is the subset of *sql.DB and *sql.Tx used by sqlPayrollDB queries
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// WithTx runs fn against a transaction-scoped PayrollDatabase at serializable isolation.
+// The transaction commits when fn returns nil and rolls back otherwise; serialization
+// failures, deadlocks and busy errors are retried. Nested calls join the outer transaction.
+func (pdb *sqlPayrollDB) WithTx(ctx context.Context, fn func(PayrollDatabase) error) error {
+	if pdb.tx != nil {
+		return fn(pdb)
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = pdb.runTx(ctx, fn)
+		if !pdb.isRetryable(err) || attempt == maxTxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt*attempt) * 10 * time.Millisecond):
+		}
+	}
+	return err
+}
+
+// runTx executes a single attempt of fn inside a new transaction
+func (pdb *sqlPayrollDB) runTx(ctx context.Context, fn func(PayrollDatabase) error) error {
+	tx, err := pdb.pool.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&sqlPayrollDB{db: tx, pool: pdb.pool, tx: tx, dialect: pdb.dialect}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// isRetryable reports whether err is a serialization failure, deadlock or busy database
+func (pdb *sqlPayrollDB) isRetryable(err error) bool {
+	if pdb.dialect == dialectSQLite {
+		var sqliteErr *sqlite.Error
+		if !errors.As(err, &sqliteErr) {
+			return false
+		}
+		code := sqliteErr.Code() & 0xff // primary result code
+		return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+// withTx runs fn against a PayrollSystem bound to a single transaction
+func (ps *PayrollSystem) withTx(ctx context.Context, fn func(tps *PayrollSystem) error) error {
+	return ps.db.WithTx(ctx, func(tdb PayrollDatabase) error {
+		tps := *ps
+		tps.db = tdb
+		return fn(&tps)
+	})
+}
@@ -0,0 +1,326 @@
+package payroll
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// User is an account that can sign in to the API. EmpID links the account to the employee it
+// belongs to, which department- and self-scoped roles are resolved against.
+type User struct {
+	UserID       int       `json:"user_id"`
+	Username     string    `json:"username" validate:"required,max=100"`
+	PasswordHash string    `json:"-"`
+	EmpID        *int      `json:"emp_id,omitempty" validate:"omitempty,gt=0"`
+	Roles        []string  `json:"roles"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Session is one login: it holds the hash of the current refresh token and is revoked on logout
+type Session struct {
+	SessionID   string
+	UserID      int
+	RefreshHash string // hex sha256 of the current refresh token secret
+	ExpiresAt   time.Time
+	RevokedAt   *time.Time
+}
+
+// Active reports whether the session can still be used at t
+func (s Session) Active(t time.Time) bool {
+	return s.RevokedAt == nil && t.Before(s.ExpiresAt)
+}
+
+// AddUser creates a user with its roles; the username must be unique
+func (pdb *sqlPayrollDB) AddUser(ctx context.Context, u User) error {
+	err := pdb.db.QueryRowContext(ctx, "INSERT INTO users (username, password_hash, emp_id) VALUES ($1, $2, $3) RETURNING user_id",
+		u.Username, u.PasswordHash, nullableID(u.EmpID)).Scan(&u.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to add user: %w", constraintError(err, "user", u.Username, userEmployeeRef(u)))
+	}
+	return pdb.insertUserRoles(ctx, u)
+}
+
+// UpdateUser changes a user's employee link and replaces their roles; the password is left alone
+func (pdb *sqlPayrollDB) UpdateUser(ctx context.Context, u User) error {
+	res, err := pdb.db.ExecContext(ctx, "UPDATE users SET emp_id = $2 WHERE user_id = $1", u.UserID, nullableID(u.EmpID))
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", constraintError(err, "user", u.UserID, userEmployeeRef(u)))
+	}
+	if err := requireAffected(res, "user", u.UserID); err != nil {
+		return err
+	}
+	if _, err := pdb.db.ExecContext(ctx, "DELETE FROM user_roles WHERE user_id = $1", u.UserID); err != nil {
+		return fmt.Errorf("failed to update user roles: %w", err)
+	}
+	return pdb.insertUserRoles(ctx, u)
+}
+
+func (pdb *sqlPayrollDB) insertUserRoles(ctx context.Context, u User) error {
+	for _, role := range u.Roles {
+		if _, err := pdb.db.ExecContext(ctx, "INSERT INTO user_roles (user_id, role_name) VALUES ($1, $2)", u.UserID, role); err != nil {
+			return fmt.Errorf("failed to add user role %q: %w", role, err)
+		}
+	}
+	return nil
+}
+
+// ListUsers retrieves every user with their roles, ordered by user_id
+func (pdb *sqlPayrollDB) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := pdb.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users ORDER BY user_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	index := map[int]int{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		index[u.UserID] = len(users)
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over users: %w", err)
+	}
+
+	roleRows, err := pdb.db.QueryContext(ctx, "SELECT user_id, role_name FROM user_roles ORDER BY role_name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user roles: %w", err)
+	}
+	defer roleRows.Close()
+	for roleRows.Next() {
+		var userID int
+		var role string
+		if err := roleRows.Scan(&userID, &role); err != nil {
+			return nil, fmt.Errorf("failed to scan user role: %w", err)
+		}
+		if i, ok := index[userID]; ok {
+			users[i].Roles = append(users[i].Roles, role)
+		}
+	}
+	return users, roleRows.Err()
+}
+
+// GetUserByUsername retrieves a user for login
+func (pdb *sqlPayrollDB) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	return pdb.getUser(ctx, "username = $1", username)
+}
+
+// GetUser retrieves a user by ID
+func (pdb *sqlPayrollDB) GetUser(ctx context.Context, userID int) (User, error) {
+	return pdb.getUser(ctx, "user_id = $1", userID)
+}
+
+// userColumns is the column list matching scanUser
+const userColumns = "user_id, username, password_hash, emp_id, created_at"
+
+// scanUser reads a row selected with userColumns; Roles is left empty
+func scanUser(row interface{ Scan(...any) error }) (User, error) {
+	u := User{Roles: []string{}}
+	var empID sql.NullInt64
+	err := row.Scan(&u.UserID, &u.Username, &u.PasswordHash, &empID, &u.CreatedAt)
+	if empID.Valid {
+		id := int(empID.Int64)
+		u.EmpID = &id
+	}
+	return u, err
+}
+
+func (pdb *sqlPayrollDB) getUser(ctx context.Context, where string, arg any) (User, error) {
+	u, err := scanUser(pdb.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE "+where, arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, &NotFoundError{Entity: "user", ID: arg}
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("failed to query user: %w", err)
+	}
+	rows, err := pdb.db.QueryContext(ctx, "SELECT role_name FROM user_roles WHERE user_id = $1 ORDER BY role_name", u.UserID)
+	if err != nil {
+		return User{}, fmt.Errorf("failed to query user roles: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return User{}, fmt.Errorf("failed to scan user role: %w", err)
+		}
+		u.Roles = append(u.Roles, role)
+	}
+	return u, rows.Err()
+}
+
+// nullableID stores a missing optional ID as NULL
+func nullableID(id *int) any {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+// userEmployeeRef describes the foreign key from a user to their employee record
+func userEmployeeRef(u User) *ForeignKeyError {
+	if u.EmpID == nil {
+		return nil
+	}
+	return &ForeignKeyError{Field: "emp_id", Entity: "employee", ID: *u.EmpID}
+}
+
+// AddSession stores a new login session
+func (pdb *sqlPayrollDB) AddSession(ctx context.Context, s Session) error {
+	_, err := pdb.db.ExecContext(ctx, `
+        INSERT INTO auth_sessions (session_id, user_id, refresh_hash, expires_at)
+        VALUES ($1, $2, $3, $4)`, s.SessionID, s.UserID, s.RefreshHash, s.ExpiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to add session: %w", err)
+	}
+	return nil
+}
+
+// GetSession retrieves a session by ID
+func (pdb *sqlPayrollDB) GetSession(ctx context.Context, sessionID string) (Session, error) {
+	s := Session{SessionID: sessionID}
+	var revoked sql.NullTime
+	err := pdb.db.QueryRowContext(ctx, `
+        SELECT user_id, refresh_hash, expires_at, revoked_at
+        FROM auth_sessions WHERE session_id = $1`, sessionID).Scan(&s.UserID, &s.RefreshHash, &s.ExpiresAt, &revoked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Session{}, &NotFoundError{Entity: "session", ID: sessionID}
+	}
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to query session: %w", err)
+	}
+	if revoked.Valid {
+		s.RevokedAt = &revoked.Time
+	}
+	return s, nil
+}
+
+// RotateSession replaces the refresh token hash if oldHash is still current and the session is not revoked;
+// otherwise it returns ErrConflict
+func (pdb *sqlPayrollDB) RotateSession(ctx context.Context, sessionID, oldHash, newHash string, expiresAt time.Time) error {
+	res, err := pdb.db.ExecContext(ctx, `
+        UPDATE auth_sessions SET refresh_hash = $3, expires_at = $4
+        WHERE session_id = $1 AND refresh_hash = $2 AND revoked_at IS NULL`, sessionID, oldHash, newHash, expiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to rotate session: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &ConflictError{Entity: "session", ID: sessionID}
+	}
+	return nil
+}
+
+// RevokeSession marks a session revoked; revoking twice is not an error
+func (pdb *sqlPayrollDB) RevokeSession(ctx context.Context, sessionID string) error {
+	_, err := pdb.db.ExecContext(ctx, `
+        UPDATE auth_sessions SET revoked_at = $2
+        WHERE session_id = $1 AND revoked_at IS NULL`, sessionID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// AddUser creates a user with its roles and returns the stored record
+func (ps *PayrollSystem) AddUser(ctx context.Context, u User) (User, error) {
+	u.Roles = uniqueSorted(u.Roles)
+	if err := ps.validateUser(ctx, u); err != nil {
+		return User{}, err
+	}
+	var added User
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		if err := tps.db.AddUser(ctx, u); err != nil {
+			return err
+		}
+		var err error
+		if added, err = tps.db.GetUserByUsername(ctx, u.Username); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "user", added.UserID, AuditCreate, nil, added)
+	})
+	return added, err
+}
+
+// UpdateUser changes a user's employee link and roles and returns the stored record
+func (ps *PayrollSystem) UpdateUser(ctx context.Context, u User) (User, error) {
+	u.Roles = uniqueSorted(u.Roles)
+	if err := ps.validateUser(ctx, u); err != nil {
+		return User{}, err
+	}
+	var updated User
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		before, err := tps.db.GetUser(ctx, u.UserID)
+		if err != nil {
+			return err
+		}
+		if err := tps.db.UpdateUser(ctx, u); err != nil {
+			return err
+		}
+		if updated, err = tps.db.GetUser(ctx, u.UserID); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "user", u.UserID, AuditUpdate, before, updated)
+	})
+	return updated, err
+}
+
+// ListUsers retrieves every user
+func (ps *PayrollSystem) ListUsers(ctx context.Context) ([]User, error) {
+	return ps.db.ListUsers(ctx)
+}
+
+// validateUser checks the struct tags and that every role exists
+func (ps *PayrollSystem) validateUser(ctx context.Context, u User) error {
+	if err := validateStruct(u); err != nil {
+		return err
+	}
+	invalid := &ValidationError{}
+	for _, role := range u.Roles {
+		if _, err := ps.db.GetRole(ctx, role); errors.Is(err, ErrNotFound) {
+			invalid.Add("roles", "role %q does not exist", role)
+		} else if err != nil {
+			return err
+		}
+	}
+	return invalid.Err()
+}
+
+// GetUserByUsername retrieves a user for login
+func (ps *PayrollSystem) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	return ps.db.GetUserByUsername(ctx, username)
+}
+
+// GetUser retrieves a user by ID
+func (ps *PayrollSystem) GetUser(ctx context.Context, userID int) (User, error) {
+	return ps.db.GetUser(ctx, userID)
+}
+
+// AddSession stores a new login session
+func (ps *PayrollSystem) AddSession(ctx context.Context, s Session) error {
+	return ps.db.AddSession(ctx, s)
+}
+
+// GetSession retrieves a session by ID
+func (ps *PayrollSystem) GetSession(ctx context.Context, sessionID string) (Session, error) {
+	return ps.db.GetSession(ctx, sessionID)
+}
+
+// RotateSession swaps the session's refresh token hash, failing with ErrConflict if oldHash is stale
+func (ps *PayrollSystem) RotateSession(ctx context.Context, sessionID, oldHash, newHash string, expiresAt time.Time) error {
+	return ps.db.RotateSession(ctx, sessionID, oldHash, newHash, expiresAt)
+}
+
+// RevokeSession ends a session
+func (ps *PayrollSystem) RevokeSession(ctx context.Context, sessionID string) error {
+	return ps.db.RevokeSession(ctx, sessionID)
+}
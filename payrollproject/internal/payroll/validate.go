@@ -0,0 +1,200 @@
+package payroll
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate checks the `validate` struct tags on domain types; PayrollSystem runs it before every write
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Report fields by their JSON names so messages match the request body
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	_ = v.RegisterValidation("thai_phone", func(fl validator.FieldLevel) bool {
+		return IsThaiPhoneNumber(fl.Field().String())
+	})
+	_ = v.RegisterValidation("thai_national_id", func(fl validator.FieldLevel) bool {
+		return IsThaiNationalID(fl.Field().String())
+	})
+	_ = v.RegisterValidation("thai_province", func(fl validator.FieldLevel) bool {
+		return IsThaiProvince(fl.Field().String())
+	})
+	v.RegisterStructValidation(validateEmployee, Employee{})
+	return v
+}
+
+var (
+	thaiMobile   = regexp.MustCompile(`^0[689]\d{8}$`)
+	thaiLandline = regexp.MustCompile(`^0[2-7]\d{7}$`)
+	separators   = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "")
+)
+
+// IsThaiPhoneNumber reports whether s is a Thai mobile (10 digits) or landline (9 digits) number.
+// Spaces, dashes and a +66 country prefix are accepted.
+func IsThaiPhoneNumber(s string) bool {
+	digits := separators.Replace(s)
+	if rest, ok := strings.CutPrefix(digits, "+66"); ok {
+		digits = "0" + rest
+	}
+	return thaiMobile.MatchString(digits) || thaiLandline.MatchString(digits)
+}
+
+// IsThaiNationalID reports whether s is a 13-digit Thai national ID with a valid check digit.
+// Dashes and spaces are accepted.
+func IsThaiNationalID(s string) bool {
+	digits := separators.Replace(s)
+	if len(digits) != 13 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 13; i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return false
+		}
+		if i < 12 {
+			sum += int(digits[i]-'0') * (13 - i)
+		}
+	}
+	return (11-sum%11)%10 == int(digits[12]-'0')
+}
+
+// bankAccountDigits is the account number length of each Thai bank, keyed by lower-case English name,
+// abbreviation or Thai name without the "ธนาคาร" prefix
+var bankAccountDigits = map[string]int{
+	"bangkok bank": 10, "bbl": 10, "กรุงเทพ": 10,
+	"kasikorn bank": 10, "kasikornbank": 10, "kbank": 10, "กสิกรไทย": 10,
+	"krungthai bank": 10, "ktb": 10, "กรุงไทย": 10,
+	"siam commercial bank": 10, "scb": 10, "ไทยพาณิชย์": 10,
+	"krungsri bank": 10, "bank of ayudhya": 10, "bay": 10, "กรุงศรีอยุธยา": 10, "กรุงศรี": 10,
+	"ttb": 10, "tmbthanachart bank": 10, "ทหารไทยธนชาต": 10,
+	"uob": 10, "cimb thai": 10, "kiatnakin phatra bank": 10, "kkp": 10, "tisco bank": 10,
+	"land and houses bank": 10, "lh bank": 10,
+	"government savings bank": 12, "gsb": 12, "ออมสิน": 12,
+	"baac": 12, "ธ.ก.ส.": 12, "เพื่อการเกษตรและสหกรณ์การเกษตร": 12,
+	"government housing bank": 12, "ghb": 12, "อาคารสงเคราะห์": 12,
+}
+
+// BankAccountDigits returns the account number length for a bank name, or 0 if the bank is not known
+func BankAccountDigits(bank string) int {
+	name := strings.ToLower(strings.TrimSpace(bank))
+	name = strings.TrimSpace(strings.TrimPrefix(name, "ธนาคาร"))
+	return bankAccountDigits[name]
+}
+
+// validateEmployee runs the employee checks that span more than one field
+func validateEmployee(sl validator.StructLevel) {
+	emp := sl.Current().Interface().(Employee)
+	validatePay(sl, emp)
+	validateBankAccount(sl, emp)
+}
+
+// validatePay requires the amount an employee's pay type is worked out from: a base salary for
+// salaried staff and a wage rate for hourly and daily staff
+func validatePay(sl validator.StructLevel, emp Employee) {
+	switch emp.PayType {
+	case "", PaySalaried:
+		if emp.BaseSalary <= 0 {
+			sl.ReportError(emp.BaseSalary, "base_salary", "BaseSalary", "gt", "0")
+		}
+	case PayHourly, PayDaily:
+		if emp.WageRate <= 0 {
+			sl.ReportError(emp.WageRate, "wage_rate", "WageRate", "gt", "0")
+		}
+	}
+}
+
+// validateBankAccount checks the account number against the employee's bank; unknown banks accept 10 to 15 digits
+func validateBankAccount(sl validator.StructLevel, emp Employee) {
+	if emp.AccountNum == "" {
+		if emp.BankAccount != "" {
+			sl.ReportError(emp.AccountNum, "account_num", "AccountNum", "required", "")
+		}
+		return
+	}
+	digits := separators.Replace(emp.AccountNum)
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			sl.ReportError(emp.AccountNum, "account_num", "AccountNum", "numeric", "")
+			return
+		}
+	}
+	if want := BankAccountDigits(emp.BankAccount); want > 0 {
+		if len(digits) != want {
+			sl.ReportError(emp.AccountNum, "account_num", "AccountNum", "bank_account_len", strconv.Itoa(want))
+		}
+	} else if len(digits) < 10 || len(digits) > 15 {
+		sl.ReportError(emp.AccountNum, "account_num", "AccountNum", "bank_account_len", "10 to 15")
+	}
+}
+
+// validateStruct runs the struct tags on v and returns a ValidationError listing every failed field
+func validateStruct(v any) error {
+	err := validate.Struct(v)
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+	ve := &ValidationError{}
+	for _, fe := range fieldErrs {
+		ve.Fields = append(ve.Fields, FieldError{Field: fe.Field(), Message: fieldMessage(fe)})
+	}
+	return ve
+}
+
+// fieldMessage describes a failed validation tag in words
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "lte":
+		return "must be at most " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param() + " characters"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "numeric":
+		return "must contain only digits"
+	case "datetime":
+		return "must be formatted as " + layoutName(fe.Param())
+	case "thai_phone":
+		return "must be a Thai phone number such as 0812345678 or 021234567"
+	case "thai_national_id":
+		return "must be a 13-digit national ID with a valid check digit"
+	case "thai_province":
+		return "must be the Thai name of a province, such as กรุงเทพมหานคร or เชียงใหม่"
+	case "bank_account_len":
+		return "must be " + fe.Param() + " digits for this bank"
+	}
+	return "is invalid"
+}
+
+// layoutName turns a Go time layout into the pattern users know
+func layoutName(layout string) string {
+	return strings.NewReplacer("2006", "YYYY", "01", "MM", "02", "DD").Replace(layout)
+}
+
+// Validate checks a department before it is stored
+func (d Department) Validate() error { return validateStruct(d) }
+
+// Validate checks an employee before it is stored
+func (e Employee) Validate() error { return validateStruct(e) }
+
+// Validate checks a payroll record before it is stored
+func (p Payroll) Validate() error { return validateStruct(p) }
@@ -0,0 +1,72 @@
+package payroll
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsThaiNationalID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"1101700203450":     true,
+		"1-1017-00203-45-0": true,
+		"1101700203451":     false, // wrong check digit
+		"110170020345":      false,
+		"110170020345x":     false,
+	} {
+		if got := IsThaiNationalID(id); got != want {
+			t.Errorf("IsThaiNationalID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestIsThaiPhoneNumber(t *testing.T) {
+	for phone, want := range map[string]bool{
+		"0812345678":    true,
+		"081-234-5678":  true,
+		"+66812345678":  true,
+		"021234567":     true,
+		"123":           false,
+		"0112345678":    false,
+		"08123456789":   false,
+		"02-123-45678x": false,
+	} {
+		if got := IsThaiPhoneNumber(phone); got != want {
+			t.Errorf("IsThaiPhoneNumber(%q) = %v, want %v", phone, got, want)
+		}
+	}
+}
+
+func TestEmployeeValidate(t *testing.T) {
+	valid := Employee{EmployeeID: 1, EmpName: "A", PhoneNumber: "0812345678", DeptID: 1, BaseSalary: 30000,
+		BankAccount: "Government Savings Bank", AccountNum: "123456789012", NationalID: "1101700203450"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid employee: %v", err)
+	}
+
+	invalid := Employee{EmployeeID: 1, PhoneNumber: "123", DeptID: 1, BaseSalary: -1,
+		BankAccount: "Kasikorn Bank", AccountNum: "12345", NationalID: "1101700203451"}
+	var ve *ValidationError
+	if err := invalid.Validate(); !errors.As(err, &ve) {
+		t.Fatalf("Validate() error = %v, want ValidationError", err)
+	}
+	fields := map[string]bool{}
+	for _, f := range ve.Fields {
+		fields[f.Field] = true
+	}
+	for _, want := range []string{"emp_name", "phone_number", "base_salary", "account_num", "national_id"} {
+		if !fields[want] {
+			t.Errorf("missing field error for %s in %+v", want, ve.Fields)
+		}
+	}
+}
+
+func TestPayrollValidatePeriod(t *testing.T) {
+	p := Payroll{EmpID: 1, PayMonth: MustParsePeriod("2026-01"), PayDate: NewDate(2026, 1, 31)}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("valid payroll: %v", err)
+	}
+	p.PayMonth = Period{}
+	if err := p.Validate(); !errors.Is(err, ErrValidation) {
+		t.Fatalf("empty pay_month %q error = %v, want ErrValidation", p.PayMonth, err)
+	}
+}
@@ -0,0 +1,719 @@
+package payroll
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"payrollproject/internal/ical"
+)
+
+// WorkWeek is the days of the week a site works, in weekday order. Its text form lists them by
+// three-letter name, such as "mon,tue,wed,thu,fri".
+type WorkWeek []time.Weekday
+
+var weekdayNames = [...]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// DefaultWorkWeek is Monday to Friday, the week of employees without a work site
+var DefaultWorkWeek = WorkWeek{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// ParseWorkWeek reads a comma-separated list of weekday names, in English and either full or by
+// their first three letters
+func ParseWorkWeek(s string) (WorkWeek, error) {
+	var w WorkWeek
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		i := slices.Index(weekdayNames[:], name)
+		for d := time.Sunday; d <= time.Saturday && i < 0; d++ {
+			if strings.EqualFold(d.String(), name) {
+				i = int(d)
+			}
+		}
+		if i < 0 {
+			return nil, fmt.Errorf("%q is not a day of the week: use mon, tue, wed, thu, fri, sat or sun", name)
+		}
+		if !slices.Contains(w, time.Weekday(i)) {
+			w = append(w, time.Weekday(i))
+		}
+	}
+	slices.Sort(w)
+	return w, nil
+}
+
+// Works reports whether the week includes a weekday
+func (w WorkWeek) Works(d time.Weekday) bool { return slices.Contains(w, d) }
+
+func (w WorkWeek) String() string {
+	names := make([]string, len(w))
+	for i, d := range w {
+		names[i] = weekdayNames[d]
+	}
+	return strings.Join(names, ",")
+}
+
+func (w WorkWeek) MarshalJSON() ([]byte, error) {
+	if len(w) == 0 {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(strings.Split(w.String(), ","))
+}
+
+func (w *WorkWeek) UnmarshalJSON(b []byte) error {
+	var names []string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return errors.New(`work days must be a list of weekday names such as ["mon", "tue"]`)
+	}
+	v, err := ParseWorkWeek(strings.Join(names, ","))
+	if err != nil {
+		return err
+	}
+	*w = v
+	return nil
+}
+
+// Value stores the week in its text form
+func (w WorkWeek) Value() (driver.Value, error) { return w.String(), nil }
+
+// Scan reads the text form of a week
+func (w *WorkWeek) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a work week", src)
+	}
+	v, err := ParseWorkWeek(s)
+	if err != nil {
+		return err
+	}
+	*w = v
+	return nil
+}
+
+// WorkSite is a place employees work from, with its own working week and company holidays
+type WorkSite struct {
+	SiteID   string   `json:"site_id" validate:"required,max=50"`
+	SiteName string   `json:"site_name" validate:"required,max=100"`
+	WorkDays WorkWeek `json:"work_days" validate:"min=1"`
+}
+
+// Validate checks a work site before it is stored
+func (s WorkSite) Validate() error { return validateStruct(s) }
+
+// Holiday kinds
+const (
+	HolidayPublic  = "public"  // a Thai public holiday, observed at every site
+	HolidayCompany = "company" // a holiday the company gives, at every site or at one
+)
+
+// Holiday is a day off besides the rest days of the working week. A public holiday on a rest day
+// is not moved; the substitution day the government announces is a holiday of its own.
+type Holiday struct {
+	HolidayID int    `json:"holiday_id"`
+	Date      Date   `json:"date" validate:"required"`
+	Name      string `json:"name" validate:"required,max=200"`
+	Kind      string `json:"kind" validate:"oneof=public company"`
+	SiteID    string `json:"site_id" validate:"max=50"` // empty for every site
+}
+
+// Validate checks a holiday before it is stored
+func (h Holiday) Validate() error {
+	if err := validateStruct(h); err != nil {
+		return err
+	}
+	if h.Kind == HolidayPublic && h.SiteID != "" {
+		return &ValidationError{Fields: []FieldError{{Field: "site_id", Message: "must be empty for a public holiday, which every site observes"}}}
+	}
+	return nil
+}
+
+// HolidaySet holds the names of holidays by day
+type HolidaySet map[Date]string
+
+func newHolidaySet(holidays []Holiday) HolidaySet {
+	set := make(HolidaySet, len(holidays))
+	for _, h := range holidays {
+		if name, ok := set[h.Date]; ok && name != h.Name {
+			set[h.Date] = name + ", " + h.Name
+		} else {
+			set[h.Date] = h.Name
+		}
+	}
+	return set
+}
+
+// Has reports whether a day is a holiday
+func (s HolidaySet) Has(d Date) bool {
+	_, ok := s[d]
+	return ok
+}
+
+// HolidayQuery selects holidays by year or by a range of days
+type HolidayQuery struct {
+	Year   int    `form:"year"`
+	From   Date   `form:"from"`
+	To     Date   `form:"to"`
+	SiteID string `form:"site_id"` // the holidays a site observes: its own and those of every site
+	Kind   string `form:"kind"`
+}
+
+// WorkingDays is the working time of a site over a range of days
+type WorkingDays struct {
+	SiteID       string    `json:"site_id"` // empty for the default week and company-wide holidays
+	Start        Date      `json:"start"`
+	End          Date      `json:"end"`
+	WorkDays     WorkWeek  `json:"work_days"`
+	CalendarDays int       `json:"calendar_days"`
+	WorkingDays  int       `json:"working_days"`
+	Holidays     []Holiday `json:"holidays"` // those in the range, on rest days too
+	Dates        []Date    `json:"dates"`    // the working days
+}
+
+// maxWorkingDaysSpan limits the range a working-day query covers
+const maxWorkingDaysSpan = 400
+
+// ListHolidays reads the holidays between two days, inclusive, by date and site
+func (pdb *sqlPayrollDB) ListHolidays(ctx context.Context, from, to Date) ([]Holiday, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+        SELECT holiday_id, holiday_date, holiday_name, kind, site_id FROM holidays
+        WHERE holiday_date >= $1 AND holiday_date <= $2
+        ORDER BY holiday_date, site_id`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query holidays: %w", err)
+	}
+	defer rows.Close()
+
+	var holidays []Holiday
+	for rows.Next() {
+		var h Holiday
+		if err := rows.Scan(&h.HolidayID, &h.Date, &h.Name, &h.Kind, &h.SiteID); err != nil {
+			return nil, fmt.Errorf("failed to scan holiday: %w", err)
+		}
+		holidays = append(holidays, h)
+	}
+	return holidays, rows.Err()
+}
+
+// GetHoliday retrieves a holiday
+func (pdb *sqlPayrollDB) GetHoliday(ctx context.Context, holidayID int) (Holiday, error) {
+	var h Holiday
+	err := pdb.db.QueryRowContext(ctx, `
+        SELECT holiday_id, holiday_date, holiday_name, kind, site_id FROM holidays WHERE holiday_id = $1`, holidayID).
+		Scan(&h.HolidayID, &h.Date, &h.Name, &h.Kind, &h.SiteID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Holiday{}, &NotFoundError{Entity: "holiday", ID: holidayID}
+	}
+	if err != nil {
+		return Holiday{}, fmt.Errorf("failed to get holiday: %w", err)
+	}
+	return h, nil
+}
+
+// SaveHoliday stores a holiday and returns its ID; a site has one holiday a day, so one already on
+// the day is replaced
+func (pdb *sqlPayrollDB) SaveHoliday(ctx context.Context, h Holiday) (int, error) {
+	var id int
+	err := pdb.db.QueryRowContext(ctx, `
+        INSERT INTO holidays (holiday_date, holiday_name, kind, site_id) VALUES ($1, $2, $3, $4)
+        ON CONFLICT (holiday_date, site_id) DO UPDATE SET holiday_name = excluded.holiday_name, kind = excluded.kind
+        RETURNING holiday_id`, h.Date, h.Name, h.Kind, h.SiteID).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save holiday: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteHoliday deletes a holiday
+func (pdb *sqlPayrollDB) DeleteHoliday(ctx context.Context, holidayID int) error {
+	res, err := pdb.db.ExecContext(ctx, "DELETE FROM holidays WHERE holiday_id = $1", holidayID)
+	if err != nil {
+		return fmt.Errorf("failed to delete holiday: %w", err)
+	}
+	return requireAffected(res, "holiday", holidayID)
+}
+
+// ListWorkSites reads the work sites by ID
+func (pdb *sqlPayrollDB) ListWorkSites(ctx context.Context) ([]WorkSite, error) {
+	rows, err := pdb.db.QueryContext(ctx, "SELECT site_id, site_name, work_days FROM work_sites ORDER BY site_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query work sites: %w", err)
+	}
+	defer rows.Close()
+
+	var sites []WorkSite
+	for rows.Next() {
+		var s WorkSite
+		if err := rows.Scan(&s.SiteID, &s.SiteName, &s.WorkDays); err != nil {
+			return nil, fmt.Errorf("failed to scan work site: %w", err)
+		}
+		sites = append(sites, s)
+	}
+	return sites, rows.Err()
+}
+
+// GetWorkSite retrieves a work site
+func (pdb *sqlPayrollDB) GetWorkSite(ctx context.Context, siteID string) (WorkSite, error) {
+	var s WorkSite
+	err := pdb.db.QueryRowContext(ctx, "SELECT site_id, site_name, work_days FROM work_sites WHERE site_id = $1", siteID).
+		Scan(&s.SiteID, &s.SiteName, &s.WorkDays)
+	if errors.Is(err, sql.ErrNoRows) {
+		return WorkSite{}, &NotFoundError{Entity: "work site", ID: siteID}
+	}
+	if err != nil {
+		return WorkSite{}, fmt.Errorf("failed to get work site: %w", err)
+	}
+	return s, nil
+}
+
+// SaveWorkSite stores a work site, replacing the one with its ID
+func (pdb *sqlPayrollDB) SaveWorkSite(ctx context.Context, s WorkSite) error {
+	_, err := pdb.db.ExecContext(ctx, `
+        INSERT INTO work_sites (site_id, site_name, work_days) VALUES ($1, $2, $3)
+        ON CONFLICT (site_id) DO UPDATE SET site_name = excluded.site_name, work_days = excluded.work_days`,
+		s.SiteID, s.SiteName, s.WorkDays)
+	if err != nil {
+		return fmt.Errorf("failed to save work site: %w", err)
+	}
+	return nil
+}
+
+// DeleteWorkSite deletes a work site
+func (pdb *sqlPayrollDB) DeleteWorkSite(ctx context.Context, siteID string) error {
+	res, err := pdb.db.ExecContext(ctx, "DELETE FROM work_sites WHERE site_id = $1", siteID)
+	if err != nil {
+		return fmt.Errorf("failed to delete work site: %w", err)
+	}
+	return requireAffected(res, "work site", siteID)
+}
+
+// ListHolidays returns the holidays a query selects, by date
+func (ps *PayrollSystem) ListHolidays(ctx context.Context, q HolidayQuery) ([]Holiday, error) {
+	v := &ValidationError{}
+	switch {
+	case q.Year != 0 && (!q.From.IsZero() || !q.To.IsZero()):
+		v.Add("year", "give a year or a range of days, not both")
+	case q.Year != 0 && (q.Year < 2000 || q.Year > 2200):
+		v.Add("year", "must be a Gregorian year between 2000 and 2200")
+	case q.Year != 0:
+		q.From, q.To = NewDate(q.Year, time.January, 1), NewDate(q.Year, time.December, 31)
+	case q.From.IsZero() || q.To.IsZero():
+		v.Add("year", "give a year, or from and to")
+	case q.To.Before(q.From):
+		v.Add("to", "must not be before from")
+	}
+	if q.Kind != "" && q.Kind != HolidayPublic && q.Kind != HolidayCompany {
+		v.Add("kind", "must be one of public, company")
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	holidays, err := ps.db.ListHolidays(ctx, q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	out := []Holiday{}
+	for _, h := range holidays {
+		if (q.SiteID == "" || h.SiteID == "" || h.SiteID == q.SiteID) && (q.Kind == "" || h.Kind == q.Kind) {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+// holidaysAt returns the holidays a site observes between two days: those of every site and, unless
+// siteID is empty, its own
+func (ps *PayrollSystem) holidaysAt(ctx context.Context, siteID string, from, to Date) ([]Holiday, error) {
+	holidays, err := ps.db.ListHolidays(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	out := []Holiday{}
+	for _, h := range holidays {
+		if h.SiteID == "" || h.SiteID == siteID {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+// AddHoliday validates and stores a holiday, replacing the one its site already has that day
+func (ps *PayrollSystem) AddHoliday(ctx context.Context, h Holiday) (Holiday, error) {
+	if err := h.Validate(); err != nil {
+		return Holiday{}, err
+	}
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		if err := tps.requireWorkSite(ctx, h.SiteID); err != nil {
+			return err
+		}
+		var err error
+		h, err = tps.saveHoliday(ctx, h)
+		return err
+	})
+	return h, err
+}
+
+// saveHoliday stores and audits a validated holiday inside a transaction
+func (ps *PayrollSystem) saveHoliday(ctx context.Context, h Holiday) (Holiday, error) {
+	existing, err := ps.db.ListHolidays(ctx, h.Date, h.Date)
+	if err != nil {
+		return Holiday{}, err
+	}
+	var before any
+	action := AuditCreate
+	for _, e := range existing {
+		if e.SiteID == h.SiteID {
+			before, action = e, AuditUpdate
+		}
+	}
+	if h.HolidayID, err = ps.db.SaveHoliday(ctx, h); err != nil {
+		return Holiday{}, err
+	}
+	return h, ps.audit(ctx, "holiday", h.HolidayID, action, before, h)
+}
+
+// DeleteHoliday deletes a holiday
+func (ps *PayrollSystem) DeleteHoliday(ctx context.Context, holidayID int) error {
+	return ps.withTx(ctx, func(tps *PayrollSystem) error {
+		before, err := tps.db.GetHoliday(ctx, holidayID)
+		if err != nil {
+			return err
+		}
+		if err := tps.db.DeleteHoliday(ctx, holidayID); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "holiday", holidayID, AuditDelete, before, nil)
+	})
+}
+
+// HolidayImportReport is the outcome of a holiday import, or what it would be on a dry run
+type HolidayImportReport struct {
+	DryRun   bool      `json:"dry_run"`
+	Years    []int     `json:"years"`
+	Added    int       `json:"added"`
+	Updated  int       `json:"updated"`
+	Removed  int       `json:"removed"`
+	Holidays []Holiday `json:"holidays"`
+}
+
+// ImportHolidays replaces the holidays of a kind and site in every year the list covers with the
+// list, so a year's announced public holidays can be loaded again when they change. The holidays
+// are given the kind and site; they are all stored, or none is.
+func (ps *PayrollSystem) ImportHolidays(ctx context.Context, kind, siteID string, holidays []Holiday, dryRun bool) (HolidayImportReport, error) {
+	report := HolidayImportReport{DryRun: dryRun, Years: []int{}, Holidays: []Holiday{}}
+	v := &ValidationError{}
+	if len(holidays) == 0 {
+		v.Add("file", "the file has no holidays")
+	}
+	seen := map[Date]int{}
+	for i := range holidays {
+		holidays[i].HolidayID = 0
+		holidays[i].Kind, holidays[i].SiteID = kind, siteID
+		h := holidays[i]
+		if err := h.Validate(); err != nil {
+			var ve *ValidationError
+			if !errors.As(err, &ve) {
+				return report, err
+			}
+			for _, f := range ve.Fields {
+				v.Add(fmt.Sprintf("holidays[%d].%s", i, f.Field), "%s", f.Message)
+			}
+			continue
+		}
+		if first, dup := seen[h.Date]; dup {
+			v.Add(fmt.Sprintf("holidays[%d].date", i), "%s is also holidays[%d]", h.Date, first)
+		}
+		seen[h.Date] = i
+		if !slices.Contains(report.Years, h.Date.Year()) {
+			report.Years = append(report.Years, h.Date.Year())
+		}
+	}
+	if err := v.Err(); err != nil {
+		return report, err
+	}
+	sort.Ints(report.Years)
+	sort.SliceStable(holidays, func(i, j int) bool { return holidays[i].Date.Before(holidays[j].Date) })
+
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		if err := tps.requireWorkSite(ctx, siteID); err != nil {
+			return err
+		}
+		for _, year := range report.Years {
+			existing, err := tps.db.ListHolidays(ctx, NewDate(year, time.January, 1), NewDate(year, time.December, 31))
+			if err != nil {
+				return err
+			}
+			for _, e := range existing {
+				if e.SiteID != siteID {
+					continue
+				}
+				if _, kept := seen[e.Date]; kept {
+					report.Updated++
+					continue
+				}
+				if e.Kind != kind {
+					continue
+				}
+				report.Removed++
+				if dryRun {
+					continue
+				}
+				if err := tps.db.DeleteHoliday(ctx, e.HolidayID); err != nil {
+					return err
+				}
+				if err := tps.audit(ctx, "holiday", e.HolidayID, AuditDelete, e, nil); err != nil {
+					return err
+				}
+			}
+		}
+		report.Added = len(holidays) - report.Updated
+		if dryRun {
+			report.Holidays = holidays
+			return nil
+		}
+		for _, h := range holidays {
+			saved, err := tps.saveHoliday(ctx, h)
+			if err != nil {
+				return err
+			}
+			report.Holidays = append(report.Holidays, saved)
+		}
+		return nil
+	})
+	if err != nil {
+		return HolidayImportReport{DryRun: dryRun, Years: []int{}, Holidays: []Holiday{}}, err
+	}
+	return report, nil
+}
+
+// HolidaysFromEvents lists the days of calendar events as holidays named by the event summary
+func HolidaysFromEvents(events []ical.Event) []Holiday {
+	var holidays []Holiday
+	for _, e := range events {
+		for _, day := range e.Days() {
+			holidays = append(holidays, Holiday{Date: DateOf(day), Name: strings.TrimSpace(e.Summary)})
+		}
+	}
+	return holidays
+}
+
+// HolidaysFromTable reads holidays from a table whose header names a date column ("date" or
+// "วันที่") and a name column ("name", "holiday" or "วันหยุด"). Dates may be written as ParseDate
+// reads them or in the forms old records use, Thai dates with Buddhist-era years included.
+func HolidaysFromTable(table [][]string) ([]Holiday, error) {
+	if len(table) == 0 {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "file", Message: "the file is empty"}}}
+	}
+	dateCol, nameCol := -1, -1
+	for i, h := range table[0] {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "date", "วันที่":
+			dateCol = i
+		case "name", "holiday", "วันหยุด", "ชื่อวันหยุด":
+			nameCol = i
+		}
+	}
+	if dateCol < 0 || nameCol < 0 {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "file", Message: `the header must name a "date" and a "name" column`}}}
+	}
+
+	v := &ValidationError{}
+	var holidays []Holiday
+	for i, row := range table[1:] {
+		if blankRow(row) {
+			continue
+		}
+		cell := func(col int) string {
+			if col < len(row) {
+				return strings.TrimSpace(row[col])
+			}
+			return ""
+		}
+		day, err := parseLegacyDate(cell(dateCol))
+		if err != nil {
+			v.Add(fmt.Sprintf("row %d.date", i+2), "%s", err)
+			continue
+		}
+		holidays = append(holidays, Holiday{Date: day, Name: cell(nameCol)})
+	}
+	return holidays, v.Err()
+}
+
+// ListWorkSites returns the work sites by ID
+func (ps *PayrollSystem) ListWorkSites(ctx context.Context) ([]WorkSite, error) {
+	sites, err := ps.db.ListWorkSites(ctx)
+	if sites == nil && err == nil {
+		sites = []WorkSite{}
+	}
+	return sites, err
+}
+
+// GetWorkSite retrieves a work site
+func (ps *PayrollSystem) GetWorkSite(ctx context.Context, siteID string) (WorkSite, error) {
+	return ps.db.GetWorkSite(ctx, siteID)
+}
+
+// SaveWorkSite validates and stores a work site, creating it or replacing the one with its ID
+func (ps *PayrollSystem) SaveWorkSite(ctx context.Context, s WorkSite) (WorkSite, error) {
+	if err := s.Validate(); err != nil {
+		return WorkSite{}, err
+	}
+	err := ps.withTx(ctx, func(tps *PayrollSystem) error {
+		var before any
+		action := AuditCreate
+		if stored, err := tps.db.GetWorkSite(ctx, s.SiteID); err == nil {
+			before, action = stored, AuditUpdate
+		} else if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if err := tps.db.SaveWorkSite(ctx, s); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "work_site", s.SiteID, action, before, s)
+	})
+	return s, err
+}
+
+// DeleteWorkSite deletes a work site and its own holidays; a site employees still work at cannot be
+// deleted
+func (ps *PayrollSystem) DeleteWorkSite(ctx context.Context, siteID string) error {
+	return ps.withTx(ctx, func(tps *PayrollSystem) error {
+		before, err := tps.db.GetWorkSite(ctx, siteID)
+		if err != nil {
+			return err
+		}
+		emps, err := tps.db.GetAllEmployees(ctx)
+		if err != nil {
+			return err
+		}
+		staff := 0
+		for _, emp := range emps {
+			if emp.SiteID == siteID {
+				staff++
+			}
+		}
+		if staff > 0 {
+			return &ValidationError{Fields: []FieldError{{Field: "site_id",
+				Message: fmt.Sprintf("%d employees work at site %s; move them to another site first", staff, siteID)}}}
+		}
+		holidays, err := tps.db.ListHolidays(ctx, NewDate(1900, time.January, 1), NewDate(2200, time.December, 31))
+		if err != nil {
+			return err
+		}
+		for _, h := range holidays {
+			if h.SiteID != siteID {
+				continue
+			}
+			if err := tps.db.DeleteHoliday(ctx, h.HolidayID); err != nil {
+				return err
+			}
+			if err := tps.audit(ctx, "holiday", h.HolidayID, AuditDelete, h, nil); err != nil {
+				return err
+			}
+		}
+		if err := tps.db.DeleteWorkSite(ctx, siteID); err != nil {
+			return err
+		}
+		return tps.audit(ctx, "work_site", siteID, AuditDelete, before, nil)
+	})
+}
+
+// requireWorkSite refuses a site ID that is neither empty nor a stored work site
+func (ps *PayrollSystem) requireWorkSite(ctx context.Context, siteID string) error {
+	if siteID == "" {
+		return nil
+	}
+	if _, err := ps.db.GetWorkSite(ctx, siteID); errors.Is(err, ErrNotFound) {
+		return &ValidationError{Fields: []FieldError{{Field: "site_id", Message: fmt.Sprintf("work site %q does not exist", siteID)}}}
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// WorkingDaysBetween works out the working days of a site between two days, inclusive: the days of
+// its working week that are not holidays there. An empty site ID stands for the default week and
+// the holidays of every site.
+func (ps *PayrollSystem) WorkingDaysBetween(ctx context.Context, siteID string, start, end Date) (WorkingDays, error) {
+	v := &ValidationError{}
+	switch {
+	case start.IsZero() || end.IsZero():
+		v.Add("from", "give the first and last day")
+	case end.Before(start):
+		v.Add("to", "must not be before from")
+	case end.Sub(start.Time) > maxWorkingDaysSpan*24*time.Hour:
+		v.Add("to", "must be at most %d days after from", maxWorkingDaysSpan)
+	}
+	if err := v.Err(); err != nil {
+		return WorkingDays{}, err
+	}
+	week := DefaultWorkWeek
+	if siteID != "" {
+		site, err := ps.db.GetWorkSite(ctx, siteID)
+		if err != nil {
+			return WorkingDays{}, err
+		}
+		week = site.WorkDays
+	}
+	holidays, err := ps.holidaysAt(ctx, siteID, start, end)
+	if err != nil {
+		return WorkingDays{}, err
+	}
+	off := newHolidaySet(holidays)
+
+	wd := WorkingDays{SiteID: siteID, Start: start, End: end, WorkDays: week, Holidays: holidays, Dates: []Date{}}
+	for d := start; !d.After(end); d = DateOf(d.AddDate(0, 0, 1)) {
+		wd.CalendarDays++
+		if week.Works(d.Weekday()) && !off.Has(d) {
+			wd.Dates = append(wd.Dates, d)
+		}
+	}
+	wd.WorkingDays = len(wd.Dates)
+	return wd, nil
+}
+
+// companyHolidays returns the holidays of every site from the start of the year before one to the
+// end of the year after it, which pay dates are moved off
+func (ps *PayrollSystem) companyHolidays(ctx context.Context, year int) (HolidaySet, error) {
+	holidays, err := ps.holidaysAt(ctx, "", NewDate(year-1, time.January, 1), NewDate(year+1, time.December, 31))
+	if err != nil {
+		return nil, err
+	}
+	return newHolidaySet(holidays), nil
+}
+
+// PaySchedule lists the periods of a frequency paid in a year with their pay dates, moved off
+// weekends and the holidays of every site
+func (ps *PayrollSystem) PaySchedule(ctx context.Context, frequency PeriodKind, year int) ([]ScheduledPeriod, error) {
+	calendar, err := ps.GetPayCalendar(ctx, frequency)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := ps.companyHolidays(ctx, year)
+	if err != nil {
+		return nil, err
+	}
+	return calendar.Schedule(year, holidays), nil
+}
+
+// sortHolidays orders holidays by date and site, as the database lists them
+func sortHolidays(holidays []Holiday) {
+	sort.Slice(holidays, func(i, j int) bool {
+		if !holidays[i].Date.Equal(holidays[j].Date.Time) {
+			return holidays[i].Date.Before(holidays[j].Date)
+		}
+		return holidays[i].SiteID < holidays[j].SiteID
+	})
+}
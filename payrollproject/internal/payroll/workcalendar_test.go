@@ -0,0 +1,193 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWorkingDaysAndHolidays(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	week, err := ParseWorkWeek("mon,tue,wed,thu,fri,sat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.SaveWorkSite(ctx, WorkSite{SiteID: "bkk-wh", SiteName: "Bangkok warehouse", WorkDays: week}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.ImportHolidays(ctx, HolidayPublic, "", []Holiday{
+		{Date: NewDate(2026, time.April, 13), Name: "วันสงกรานต์"},
+		{Date: NewDate(2026, time.April, 14), Name: "วันสงกรานต์"},
+		{Date: NewDate(2026, time.April, 15), Name: "วันสงกรานต์"},
+	}, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.AddHoliday(ctx, Holiday{Date: NewDate(2026, time.April, 17), Name: "Stocktake", Kind: HolidayCompany, SiteID: "bkk-wh"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.AddHoliday(ctx, Holiday{Date: NewDate(2026, time.April, 17), Name: "Songkran", Kind: HolidayPublic, SiteID: "bkk-wh"}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("public holiday of one site error = %v, want ErrValidation", err)
+	}
+	if _, err := ps.AddHoliday(ctx, Holiday{Date: NewDate(2026, time.April, 17), Name: "Stocktake", Kind: HolidayCompany, SiteID: "cnx"}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("holiday of an unknown site error = %v, want ErrValidation", err)
+	}
+
+	// April 2026 has 22 weekdays; the site also works its 4 Saturdays and is closed for stocktake
+	for _, tc := range []struct {
+		site string
+		want int
+	}{{"", 19}, {"bkk-wh", 22}} {
+		wd, err := ps.WorkingDaysBetween(ctx, tc.site, NewDate(2026, time.April, 1), NewDate(2026, time.April, 30))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wd.CalendarDays != 30 || wd.WorkingDays != tc.want || len(wd.Dates) != tc.want {
+			t.Errorf("working days at %q = %d of %d, want %d", tc.site, wd.WorkingDays, wd.CalendarDays, tc.want)
+		}
+	}
+	if _, err := ps.WorkingDaysBetween(ctx, "", NewDate(2026, time.April, 30), NewDate(2026, time.April, 1)); !errors.Is(err, ErrValidation) {
+		t.Fatalf("reversed range error = %v, want ErrValidation", err)
+	}
+
+	// A salaried leaver is paid for the working days up to their last day, on a pay date moved off
+	// the company holiday at the end of the month
+	if _, err := ps.AddHoliday(ctx, Holiday{Date: NewDate(2026, time.April, 30), Name: "Company day", Kind: HolidayCompany}); err != nil {
+		t.Fatal(err)
+	}
+	emp, err := ps.GetEmployee(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	emp.EndDate = "2026-04-10"
+	if emp, err = ps.UpdateEmployee(ctx, emp); err != nil {
+		t.Fatal(err)
+	}
+	calc, err := ps.CalculatePayroll(ctx, PayInput{EmpID: 1, PayMonth: MustParsePeriod("2026-04")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calc.WorkingDays != 18 || calc.DaysPaid != 8 || calc.Earnings != 13333.33 {
+		t.Errorf("leaver pay = %v for %d of %d working days", calc.Earnings, calc.DaysPaid, calc.WorkingDays)
+	}
+	if calc.Payroll.PayDate != NewDate(2026, time.April, 29) {
+		t.Errorf("pay date = %s, want 2026-04-29", calc.Payroll.PayDate)
+	}
+
+	emp.SiteID = "bkk-wh"
+	if emp, err = ps.UpdateEmployee(ctx, emp); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.DeleteWorkSite(ctx, "bkk-wh"); !errors.Is(err, ErrValidation) {
+		t.Fatalf("deleting a staffed site error = %v, want ErrValidation", err)
+	}
+	emp.SiteID = "nowhere"
+	if _, err := ps.UpdateEmployee(ctx, emp); !errors.Is(err, ErrValidation) {
+		t.Fatalf("unknown work site error = %v, want ErrValidation", err)
+	}
+}
+
+func TestImportHolidays(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	table := [][]string{
+		{"วันที่", "วันหยุด"},
+		{"01/01/2570", "วันขึ้นปีใหม่"},
+		{"2027-04-13", "วันสงกรานต์"},
+		{"", ""},
+	}
+	holidays, err := HolidaysFromTable(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(holidays) != 2 || holidays[0].Date != NewDate(2027, time.January, 1) {
+		t.Fatalf("holidays from table = %+v", holidays)
+	}
+	if _, err := HolidaysFromTable([][]string{{"day", "name"}}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("table without a date column error = %v, want ErrValidation", err)
+	}
+
+	report, err := ps.ImportHolidays(ctx, HolidayPublic, "", holidays, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Added != 2 || len(report.Years) != 1 || report.Years[0] != 2027 {
+		t.Fatalf("dry run report = %+v", report)
+	}
+	if stored, _ := ps.ListHolidays(ctx, HolidayQuery{Year: 2027}); len(stored) != 0 {
+		t.Fatalf("dry run stored %+v", stored)
+	}
+	if _, err := ps.ImportHolidays(ctx, HolidayPublic, "", holidays, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.AddHoliday(ctx, Holiday{Date: NewDate(2027, time.May, 4), Name: "Founders' day", Kind: HolidayCompany}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A revised announcement replaces the year's public holidays, leaving company ones alone
+	report, err = ps.ImportHolidays(ctx, HolidayPublic, "", []Holiday{
+		{Date: NewDate(2027, time.January, 1), Name: "วันขึ้นปีใหม่"},
+		{Date: NewDate(2027, time.April, 14), Name: "วันสงกรานต์"},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Added != 1 || report.Updated != 1 || report.Removed != 1 {
+		t.Fatalf("replacement report = %+v", report)
+	}
+	stored, err := ps.ListHolidays(ctx, HolidayQuery{Year: 2027})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 3 || stored[1].Date != NewDate(2027, time.April, 14) || stored[2].Kind != HolidayCompany {
+		t.Fatalf("holidays of 2027 = %+v", stored)
+	}
+	if public, _ := ps.ListHolidays(ctx, HolidayQuery{Year: 2027, Kind: HolidayPublic}); len(public) != 2 {
+		t.Fatalf("public holidays of 2027 = %+v", public)
+	}
+
+	if _, err := ps.ImportHolidays(ctx, HolidayPublic, "", []Holiday{
+		{Date: NewDate(2027, time.January, 1), Name: "A"},
+		{Date: NewDate(2027, time.January, 1), Name: "B"},
+	}, false); !errors.Is(err, ErrValidation) {
+		t.Fatalf("repeated date error = %v, want ErrValidation", err)
+	}
+	if _, err := ps.ListHolidays(ctx, HolidayQuery{}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("unbounded holiday query error = %v, want ErrValidation", err)
+	}
+}
+
+func TestEnsurePublicHolidays(t *testing.T) {
+	ctx := context.Background()
+	ps := newSelfServiceSystem(t)
+	// A year HR has imported already keeps its own list
+	if _, err := ps.ImportHolidays(ctx, HolidayPublic, "", []Holiday{{Date: NewDate(2025, time.January, 1), Name: "New Year"}}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.EnsurePublicHolidays(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Running it again at the next start changes nothing
+	if err := ps.EnsurePublicHolidays(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := ps.WorkingDaysBetween(ctx, "", NewDate(2026, time.January, 1), NewDate(2026, time.January, 31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wd.WorkingDays != 21 || wd.Dates[0] != NewDate(2026, time.January, 2) || len(wd.Holidays) != 1 || wd.Holidays[0].Name != "วันขึ้นปีใหม่" {
+		t.Fatalf("January 2026 = %+v, want New Year's Day off", wd)
+	}
+	holidays, err := ps.ListHolidays(ctx, HolidayQuery{Year: 2026, Kind: HolidayPublic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(holidays) != 21 {
+		t.Errorf("2026 has %d public holidays, want 21", len(holidays))
+	}
+	if holidays, _ = ps.ListHolidays(ctx, HolidayQuery{Year: 2025}); len(holidays) != 1 || holidays[0].Name != "New Year" {
+		t.Errorf("2025 holidays = %+v, want the imported list kept", holidays)
+	}
+}
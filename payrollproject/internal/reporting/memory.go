@@ -0,0 +1,127 @@
+package reporting
+
+import (
+	"context"
+	"sort"
+
+	"payrollproject/internal/payroll"
+)
+
+// memoryStore sums the records of a database that cannot run SQL, following the queries of sqlStore
+type memoryStore struct {
+	db payroll.PayrollDatabase
+}
+
+// inRange returns the payroll records of q's months and departments with the employees they belong to
+func (s *memoryStore) inRange(ctx context.Context, q Query) ([]payroll.Payroll, map[int]payroll.Employee, error) {
+	emps, err := s.db.GetAllEmployees(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[int]payroll.Employee, len(emps))
+	for _, emp := range emps {
+		byID[emp.EmployeeID] = emp
+	}
+	all, err := s.db.GetAllPayrolls(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var payrolls []payroll.Payroll
+	for _, p := range all {
+		emp, ok := byID[p.EmpID]
+		start := p.PayMonth.Start()
+		if ok && !start.Before(q.From.Start()) && !start.After(q.To.End()) && (q.DeptID == 0 || emp.DeptID == q.DeptID) {
+			payrolls = append(payrolls, p)
+		}
+	}
+	return payrolls, byID, nil
+}
+
+func (s *memoryStore) labourCost(ctx context.Context, q Query) ([]LabourCost, error) {
+	payrolls, emps, err := s.inRange(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	depts, err := s.db.GetAllDepartments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deptNames := make(map[int]string, len(depts))
+	for _, d := range depts {
+		deptNames[d.DeptID] = d.DeptName
+	}
+
+	type key struct {
+		month  payroll.Period
+		deptID int
+	}
+	costs := map[key]*LabourCost{}
+	// regularPay sums each employee's regular base pay in a month, which social security is capped on
+	regularPay := map[key]map[int]float64{}
+	for _, p := range payrolls {
+		deptID := emps[p.EmpID].DeptID
+		k := key{p.PayMonth.Month(), deptID}
+		c := costs[k]
+		if c == nil {
+			c = &LabourCost{PayMonth: k.month, DeptID: deptID, DeptName: deptNames[deptID]}
+			costs[k], regularPay[k] = c, map[int]float64{}
+		}
+		c.Gross += p.BaseSalary + p.TotalAdditions
+		// Everyone paid in the month counts, even by a bonus or off-cycle run alone
+		regularPay[k][p.EmpID] += 0
+		if p.RunType != payroll.RunRegular {
+			continue
+		}
+		regularPay[k][p.EmpID] += p.BaseSalary
+		d, err := s.db.GetAllowanceDeclaration(ctx, p.EmpID, taxYear(p.PayMonth))
+		if err != nil {
+			return nil, err
+		}
+		if d != nil {
+			c.ProvidentFund += d.ProvidentFund / float64(p.PayMonth.Kind().PerYear())
+		}
+	}
+
+	out := make([]LabourCost, 0, len(costs))
+	for k, c := range costs {
+		c.Employees = len(regularPay[k])
+		for _, pay := range regularPay[k] {
+			c.EmployerSocialSecurity += employerSocialSecurity(pay)
+		}
+		out = append(out, *c)
+	}
+	sortByMonth(out, func(c LabourCost) (payroll.Period, int) { return c.PayMonth, c.DeptID })
+	return out, nil
+}
+
+func (s *memoryStore) salaryByPosition(ctx context.Context, q Query) ([]PositionSalary, error) {
+	payrolls, emps, err := s.inRange(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	positions := map[string]*PositionSalary{}
+	paid := map[string]map[int]bool{}
+	rows := map[string]int{}
+	for _, p := range payrolls {
+		name := emps[p.EmpID].PositionName
+		ps := positions[name]
+		if ps == nil {
+			ps = &PositionSalary{PositionName: name, MinSalary: p.BaseSalary, MaxSalary: p.BaseSalary}
+			positions[name], paid[name] = ps, map[int]bool{}
+		}
+		paid[name][p.EmpID] = true
+		rows[name]++
+		ps.AverageSalary += p.BaseSalary
+		ps.MinSalary = min(ps.MinSalary, p.BaseSalary)
+		ps.MaxSalary = max(ps.MaxSalary, p.BaseSalary)
+	}
+
+	out := make([]PositionSalary, 0, len(positions))
+	for name, ps := range positions {
+		ps.Employees = len(paid[name])
+		ps.AverageSalary /= float64(rows[name])
+		out = append(out, *ps)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PositionName < out[j].PositionName })
+	return out, nil
+}
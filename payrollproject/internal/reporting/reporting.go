@@ -0,0 +1,288 @@
+// Package reporting aggregates payroll records into labour cost, headcount and salary reports per
+// department and month. SQL-backed databases aggregate in the query; the in-memory store used for
+// demos is summed in Go with the same rules.
+package reporting
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"payrollproject/internal/payroll"
+	"payrollproject/internal/tax"
+)
+
+// MaxMonths caps the range of a report
+const MaxMonths = 60
+
+// Query selects the pay months a report covers, both inclusive, and optionally one department.
+// Records of semi-monthly and weekly pay periods count in the month their period starts in.
+type Query struct {
+	From   payroll.Period `form:"from"` // YYYY-MM; defaults to eleven months before To
+	To     payroll.Period `form:"to"`   // YYYY-MM; defaults to the current month
+	DeptID int            `form:"dept_id"`
+}
+
+// normalise fills in the default range and checks the months
+func (q *Query) normalise(now time.Time) error {
+	v := &payroll.ValidationError{}
+	if q.To.IsZero() {
+		q.To = payroll.PeriodOf(payroll.Monthly, now)
+	}
+	toOK := q.To.Kind() == payroll.Monthly
+	if !toOK {
+		v.Add("to", "must be a month, formatted as YYYY-MM")
+	}
+	if q.From.IsZero() && toOK {
+		q.From = payroll.PeriodOf(payroll.Monthly, q.To.Start().AddDate(0, -11, 0))
+	}
+	fromOK := q.From.Kind() == payroll.Monthly
+	if !fromOK {
+		v.Add("from", "must be a month, formatted as YYYY-MM")
+	}
+	if toOK && fromOK {
+		switch months := monthsBetween(q.From.Start().Time, q.To.Start().Time); {
+		case months < 0:
+			v.Add("from", "must not be after to")
+		case months >= MaxMonths:
+			v.Add("from", "a report covers at most %d months", MaxMonths)
+		}
+	}
+	if q.DeptID < 0 {
+		v.Add("dept_id", "must not be negative")
+	}
+	return v.Err()
+}
+
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+}
+
+// months lists the pay months from q.From to q.To
+func (q Query) months() []payroll.Period {
+	var months []payroll.Period
+	for m := q.From; !m.Start().After(q.To.Start()); m = m.Next() {
+		months = append(months, m)
+	}
+	return months
+}
+
+// LabourCost is what a department's staff cost in one pay month. Employees are counted in the
+// department they belong to now.
+type LabourCost struct {
+	PayMonth               payroll.Period `json:"pay_month"`
+	DeptID                 int            `json:"dept_id"`
+	DeptName               string         `json:"dept_name"`
+	Employees              int            `json:"employees"`                // employees with a payroll record in the month
+	Gross                  float64        `json:"gross"`                    // base salary plus additions
+	EmployerSocialSecurity float64        `json:"employer_social_security"` // the employer's matching Social Security Fund contribution on regular pay
+	ProvidentFund          float64        `json:"provident_fund"`           // the employer's match of the provident fund contributions employees declared, from regular runs
+	Total                  float64        `json:"total"`
+}
+
+// Headcount is the number of employees a department paid in one month, and the change from the
+// month before
+type Headcount struct {
+	PayMonth  payroll.Period `json:"pay_month"`
+	DeptID    int            `json:"dept_id"`
+	DeptName  string         `json:"dept_name"`
+	Headcount int            `json:"headcount"`
+	Change    int            `json:"change"`
+}
+
+// PositionSalary summarises the monthly base salaries paid to one position over the range
+type PositionSalary struct {
+	PositionName  string  `json:"position_name"`
+	Employees     int     `json:"employees"`
+	AverageSalary float64 `json:"average_salary"`
+	MinSalary     float64 `json:"min_salary"`
+	MaxSalary     float64 `json:"max_salary"`
+}
+
+// Variance compares a department's labour cost with the month before
+type Variance struct {
+	PayMonth      payroll.Period `json:"pay_month"`
+	DeptID        int            `json:"dept_id"`
+	DeptName      string         `json:"dept_name"`
+	Total         float64        `json:"total"`
+	PreviousTotal float64        `json:"previous_total"`
+	Change        float64        `json:"change"`
+	ChangePercent *float64       `json:"change_percent"` // null when the previous month cost nothing
+}
+
+// store runs the aggregations a report is built from
+type store interface {
+	// labourCost returns a row for every department and month with payroll records, by month then department
+	labourCost(ctx context.Context, q Query) ([]LabourCost, error)
+	// salaryByPosition returns a row for every position paid in the range, by position name
+	salaryByPosition(ctx context.Context, q Query) ([]PositionSalary, error)
+}
+
+// Reporter builds the reports
+type Reporter struct {
+	store store
+	now   func() time.Time
+}
+
+// New returns a Reporter over db, aggregating in SQL when db is backed by a SQL database
+func New(db payroll.PayrollDatabase) *Reporter {
+	r := &Reporter{now: time.Now}
+	if pool := payroll.SQLDB(db); pool != nil {
+		r.store = &sqlStore{db: pool}
+	} else {
+		r.store = &memoryStore{db: db}
+	}
+	return r
+}
+
+// LabourCost reports the monthly labour cost of each department
+func (r *Reporter) LabourCost(ctx context.Context, q Query) ([]LabourCost, error) {
+	if err := q.normalise(r.now()); err != nil {
+		return nil, err
+	}
+	rows, err := r.store.labourCost(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].round()
+	}
+	return rows, nil
+}
+
+// Headcount reports each department's paid headcount for every month of the range, with the change
+// from the month before; a month in which a department paid nobody has a headcount of 0
+func (r *Reporter) Headcount(ctx context.Context, q Query) ([]Headcount, error) {
+	if err := q.normalise(r.now()); err != nil {
+		return nil, err
+	}
+	q.From = q.From.Prev()
+	costs, err := r.store.labourCost(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	var out []Headcount
+	for _, s := range byDepartment(costs, q.months()) {
+		// The first month is the one before the range, fetched for the change
+		for i := 1; i < len(s.months); i++ {
+			c := s.months[i]
+			out = append(out, Headcount{PayMonth: s.month[i], DeptID: s.deptID, DeptName: s.deptName,
+				Headcount: c.Employees, Change: c.Employees - s.months[i-1].Employees})
+		}
+	}
+	sortByMonth(out, func(h Headcount) (payroll.Period, int) { return h.PayMonth, h.DeptID })
+	return orEmpty(out), nil
+}
+
+// SalaryByPosition reports the average, lowest and highest monthly base salary paid to each position
+func (r *Reporter) SalaryByPosition(ctx context.Context, q Query) ([]PositionSalary, error) {
+	if err := q.normalise(r.now()); err != nil {
+		return nil, err
+	}
+	rows, err := r.store.salaryByPosition(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].AverageSalary = round2(rows[i].AverageSalary)
+	}
+	return rows, nil
+}
+
+// Variance reports the month-over-month change in each department's labour cost
+func (r *Reporter) Variance(ctx context.Context, q Query) ([]Variance, error) {
+	if err := q.normalise(r.now()); err != nil {
+		return nil, err
+	}
+	q.From = q.From.Prev()
+	costs, err := r.store.labourCost(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	for i := range costs {
+		costs[i].round()
+	}
+	var out []Variance
+	for _, s := range byDepartment(costs, q.months()) {
+		for i := 1; i < len(s.months); i++ {
+			c := s.months[i]
+			prev := s.months[i-1].Total
+			v := Variance{PayMonth: s.month[i], DeptID: s.deptID, DeptName: s.deptName,
+				Total: c.Total, PreviousTotal: prev, Change: round2(c.Total - prev)}
+			if prev != 0 {
+				pct := round2((c.Total - prev) / prev * 100)
+				v.ChangePercent = &pct
+			}
+			out = append(out, v)
+		}
+	}
+	sortByMonth(out, func(v Variance) (payroll.Period, int) { return v.PayMonth, v.DeptID })
+	return orEmpty(out), nil
+}
+
+// series is one department's labour cost for every month of a range, zero where it paid nobody
+type series struct {
+	deptID   int
+	deptName string
+	month    []payroll.Period
+	months   []LabourCost
+}
+
+func byDepartment(costs []LabourCost, months []payroll.Period) []*series {
+	index := make(map[payroll.Period]int, len(months))
+	for i, m := range months {
+		index[m] = i
+	}
+	var out []*series
+	depts := map[int]*series{}
+	for _, c := range costs {
+		s := depts[c.DeptID]
+		if s == nil {
+			s = &series{deptID: c.DeptID, deptName: c.DeptName, month: months, months: make([]LabourCost, len(months))}
+			depts[c.DeptID] = s
+			out = append(out, s)
+		}
+		if i, ok := index[c.PayMonth]; ok {
+			s.months[i] = c
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].deptID < out[j].deptID })
+	return out
+}
+
+func sortByMonth[T any](rows []T, key func(T) (payroll.Period, int)) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		mi, di := key(rows[i])
+		mj, dj := key(rows[j])
+		if mi != mj {
+			return mi.Start().Before(mj.Start())
+		}
+		return di < dj
+	})
+}
+
+func orEmpty[T any](rows []T) []T {
+	if rows == nil {
+		return []T{}
+	}
+	return rows
+}
+
+func (c *LabourCost) round() {
+	c.Gross = round2(c.Gross)
+	c.EmployerSocialSecurity = round2(c.EmployerSocialSecurity)
+	c.ProvidentFund = round2(c.ProvidentFund)
+	c.Total = round2(c.Gross + c.EmployerSocialSecurity + c.ProvidentFund)
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
+
+// employerSocialSecurity is the employer's contribution for an employee's regular base pay in a
+// month, which matches the employee's
+func employerSocialSecurity(monthlyPay float64) float64 {
+	return math.Min(monthlyPay*tax.SocialSecurityRate, tax.SocialSecurityMonthlyCap)
+}
+
+// taxYear is the tax year of a pay period, the year it starts in
+func taxYear(p payroll.Period) int { return p.Start().Year() }
@@ -0,0 +1,212 @@
+package reporting
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"payrollproject/internal/migrate"
+	"payrollproject/internal/payroll"
+)
+
+// seed pays three employees in two departments from December 2025 to March 2026
+func seed(t *testing.T, db payroll.PayrollDatabase) {
+	ctx := context.Background()
+	ps := payroll.NewPayrollSystem(db)
+	for _, d := range []payroll.Department{{DeptID: 1, DeptName: "Ops"}, {DeptID: 2, DeptName: "Sales"}} {
+		if err := ps.AddDepartment(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	emps := []payroll.Employee{
+		{EmployeeID: 1, EmpName: "A", PhoneNumber: "0812345678", DeptID: 1, PositionName: "Engineer", BaseSalary: 30000},
+		{EmployeeID: 2, EmpName: "B", PhoneNumber: "0823456789", DeptID: 1, PositionName: "Engineer", BaseSalary: 20000},
+		{EmployeeID: 3, EmpName: "C", PhoneNumber: "0834567890", DeptID: 2, PositionName: "Clerk", BaseSalary: 10000},
+	}
+	for _, emp := range emps {
+		if err := ps.AddEmployee(ctx, emp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ps.SaveAllowanceDeclaration(ctx, payroll.AllowanceDeclaration{EmpID: 1, TaxYear: 2026, ProvidentFund: 12000}); err != nil {
+		t.Fatal(err)
+	}
+	paid := map[string][]int{"2025-12": {3}, "2026-01": {1, 3}, "2026-02": {1, 2}, "2026-03": {1, 2, 3}}
+	for month, ids := range paid {
+		period := payroll.MustParsePeriod(month)
+		for _, id := range ids {
+			p := payroll.Payroll{EmpID: id, PayMonth: period, PayDate: payroll.DateOf(period.Start().AddDate(0, 0, 24)),
+				BaseSalary: emps[id-1].BaseSalary, NetSalary: emps[id-1].BaseSalary}
+			if id == 1 {
+				p.TotalAdditions = 1000
+			}
+			if _, err := ps.AddPayroll(ctx, p); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func newSQLite(t *testing.T) payroll.PayrollDatabase {
+	db, err := payroll.NewSQLitePayrollDB(filepath.Join(t.TempDir(), "payroll.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	pool := payroll.SQLDB(db)
+	m, err := migrate.New(pool, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// The migration seeds sample departments
+	if _, err := pool.Exec("DELETE FROM departments"); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestReportsAgreeAcrossStores(t *testing.T) {
+	ctx := context.Background()
+	memory := payroll.NewMemoryPayrollDB()
+	seed(t, memory)
+	sqlite := newSQLite(t)
+	seed(t, sqlite)
+	mr, sr := New(memory), New(sqlite)
+	if _, ok := sr.store.(*sqlStore); !ok {
+		t.Fatalf("SQLite reporter store = %T, want *sqlStore", sr.store)
+	}
+	q := Query{From: payroll.MustParsePeriod("2026-01"), To: payroll.MustParsePeriod("2026-03")}
+
+	costs, err := mr.LabourCost(ctx, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(costs) != 5 {
+		t.Fatalf("labour cost rows = %+v", costs)
+	}
+	// 30,000 + 1,000 additions, 750 capped social security, 12,000 / 12 provident fund
+	want := LabourCost{PayMonth: payroll.MustParsePeriod("2026-01"), DeptID: 1, DeptName: "Ops", Employees: 1, Gross: 31000, EmployerSocialSecurity: 750, ProvidentFund: 1000, Total: 32750}
+	if costs[0] != want {
+		t.Errorf("Ops January = %+v, want %+v", costs[0], want)
+	}
+	if got, err := sr.LabourCost(ctx, q); err != nil || !reflect.DeepEqual(got, costs) {
+		t.Errorf("SQLite labour cost = %+v, %v; memory = %+v", got, err, costs)
+	}
+
+	heads, err := mr.Headcount(ctx, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sales paid nobody in February
+	wantSales := []int{1, 0, 1}
+	for _, h := range heads {
+		if h.DeptID == 2 {
+			m := map[string]int{"2026-01": 0, "2026-02": 1, "2026-03": 2}[h.PayMonth.String()]
+			if h.Headcount != wantSales[m] {
+				t.Errorf("Sales headcount in %s = %d, want %d", h.PayMonth, h.Headcount, wantSales[m])
+			}
+		}
+	}
+	if len(heads) != 6 {
+		t.Errorf("headcount rows = %+v", heads)
+	}
+	if got, err := sr.Headcount(ctx, q); err != nil || !reflect.DeepEqual(got, heads) {
+		t.Errorf("SQLite headcount = %+v, %v; memory = %+v", got, err, heads)
+	}
+
+	positions, err := mr.SalaryByPosition(ctx, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPositions := []PositionSalary{
+		{PositionName: "Clerk", Employees: 1, AverageSalary: 10000, MinSalary: 10000, MaxSalary: 10000},
+		{PositionName: "Engineer", Employees: 2, AverageSalary: 26000, MinSalary: 20000, MaxSalary: 30000},
+	}
+	if !reflect.DeepEqual(positions, wantPositions) {
+		t.Errorf("salary by position = %+v, want %+v", positions, wantPositions)
+	}
+	if got, err := sr.SalaryByPosition(ctx, q); err != nil || !reflect.DeepEqual(got, positions) {
+		t.Errorf("SQLite salary by position = %+v, %v; memory = %+v", got, err, positions)
+	}
+
+	variance, err := mr.Variance(ctx, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := variance[1]; v.DeptID != 2 || v.PreviousTotal != 10500 || v.Change != 0 || v.ChangePercent == nil || *v.ChangePercent != 0 {
+		t.Errorf("Sales January variance = %+v", v)
+	}
+	if v := variance[0]; v.DeptID != 1 || v.ChangePercent != nil || v.Change != 32750 {
+		t.Errorf("Ops January variance = %+v, want no percentage against an empty December", v)
+	}
+	if got, err := sr.Variance(ctx, q); err != nil || !reflect.DeepEqual(got, variance) {
+		t.Errorf("SQLite variance = %+v, %v; memory = %+v", got, err, variance)
+	}
+}
+
+func TestLabourCostCapsSocialSecurityPerMonth(t *testing.T) {
+	ctx := context.Background()
+	memory := payroll.NewMemoryPayrollDB()
+	sqlite := newSQLite(t)
+	for _, db := range []payroll.PayrollDatabase{memory, sqlite} {
+		ps := payroll.NewPayrollSystem(db)
+		if err := ps.AddDepartment(ctx, payroll.Department{DeptID: 1, DeptName: "Ops"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := ps.AddEmployee(ctx, payroll.Employee{EmployeeID: 1, EmpName: "A", PhoneNumber: "0812345678", DeptID: 1,
+			BaseSalary: 43333.33, PayFrequency: payroll.Biweekly}); err != nil {
+			t.Fatal(err)
+		}
+		if err := ps.SaveAllowanceDeclaration(ctx, payroll.AllowanceDeclaration{EmpID: 1, TaxYear: 2026, ProvidentFund: 26000}); err != nil {
+			t.Fatal(err)
+		}
+		// Both fortnights start in February, and a bonus is paid with the first
+		for _, p := range []payroll.Payroll{
+			{EmpID: 1, PayMonth: payroll.MustParsePeriod("2026-F03"), BaseSalary: 20000},
+			{EmpID: 1, PayMonth: payroll.MustParsePeriod("2026-F03"), RunType: payroll.RunBonus, TotalAdditions: 10000},
+			{EmpID: 1, PayMonth: payroll.MustParsePeriod("2026-F04"), BaseSalary: 20000},
+		} {
+			if _, err := ps.AddPayroll(ctx, p); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	q := Query{From: payroll.MustParsePeriod("2026-02"), To: payroll.MustParsePeriod("2026-02")}
+	costs, err := New(memory).LabourCost(ctx, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Social security is capped at 750 on the month's 40,000 of regular pay; each regular fortnight
+	// pays a twenty-sixth of the year's provident fund and the bonus none
+	want := LabourCost{PayMonth: q.From, DeptID: 1, DeptName: "Ops", Employees: 1, Gross: 50000, EmployerSocialSecurity: 750, ProvidentFund: 2000, Total: 52750}
+	if len(costs) != 1 || costs[0] != want {
+		t.Fatalf("labour cost = %+v, want %+v", costs, want)
+	}
+	if got, err := New(sqlite).LabourCost(ctx, q); err != nil || !reflect.DeepEqual(got, costs) {
+		t.Errorf("SQLite labour cost = %+v, %v; memory = %+v", got, err, costs)
+	}
+}
+
+func TestQueryDefaultsAndLimits(t *testing.T) {
+	now := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	q := Query{}
+	if err := q.normalise(now); err != nil || q.From.String() != "2025-04" || q.To.String() != "2026-03" {
+		t.Errorf("default query = %+v, %v", q, err)
+	}
+	for _, bad := range []Query{
+		{From: payroll.MustParsePeriod("2026-01-H1")},
+		{From: payroll.MustParsePeriod("2026-03"), To: payroll.MustParsePeriod("2026-01")},
+		{From: payroll.MustParsePeriod("2020-01"), To: payroll.MustParsePeriod("2026-01")},
+		{DeptID: -1},
+	} {
+		if err := bad.normalise(now); !errors.Is(err, payroll.ErrValidation) {
+			t.Errorf("%+v: error = %v, want ErrValidation", bad, err)
+		}
+	}
+}
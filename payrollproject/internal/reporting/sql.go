@@ -0,0 +1,93 @@
+package reporting
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"payrollproject/internal/tax"
+)
+
+// sqlStore aggregates in queries that run unchanged on Postgres and SQLite
+type sqlStore struct {
+	db *sql.DB
+}
+
+// payMonth is the YYYY-MM month a payroll record's period starts in; a DATE cast to text is
+// YYYY-MM-DD on both databases
+const payMonth = "SUBSTR(CAST(p.period_start AS VARCHAR(10)), 1, 7)"
+
+// periodsPerYear is the number of pay periods a year of the kind of p.pay_month, the PerYear of its
+// Period: "2026-W05" is weekly, "2026-F03" biweekly and "2026-01-H1" semi-monthly
+const periodsPerYear = `(CASE WHEN p.pay_month LIKE '%-W%' THEN 52 WHEN p.pay_month LIKE '%-F%' THEN 26
+        WHEN p.pay_month LIKE '%-H%' THEN 24 ELSE 12 END)`
+
+// labourCost sums each employee's month first, so social security is capped on their regular base
+// pay of the whole month however many pay periods it held
+func (s *sqlStore) labourCost(ctx context.Context, q Query) ([]LabourCost, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT m.pay_month, m.dept_id, m.dept_name,
+               COUNT(*),
+               SUM(m.gross),
+               SUM(CASE WHEN m.regular_pay * $4 > $5 THEN $5 ELSE m.regular_pay * $4 END),
+               SUM(m.provident_fund)
+        FROM (
+            SELECT `+payMonth+` AS pay_month, d.dept_id, d.dept_name, p.emp_id,
+                   SUM(COALESCE(p.base_salary, 0) + COALESCE(p.total_additions, 0)) AS gross,
+                   SUM(CASE WHEN p.run_type = 'regular' THEN COALESCE(p.base_salary, 0) ELSE 0 END) AS regular_pay,
+                   SUM(CASE WHEN p.run_type = 'regular' THEN COALESCE(a.provident_fund, 0) / `+periodsPerYear+` ELSE 0 END) AS provident_fund
+            FROM payroll p
+            JOIN employees e ON e.emp_id = p.emp_id
+            JOIN departments d ON d.dept_id = e.dept_id
+            LEFT JOIN allowance_declarations a
+                   ON a.emp_id = p.emp_id AND a.tax_year = CAST(SUBSTR(`+payMonth+`, 1, 4) AS INTEGER)
+            WHERE p.period_start BETWEEN $1 AND $2 AND ($3 = 0 OR d.dept_id = $3)
+            GROUP BY `+payMonth+`, d.dept_id, d.dept_name, p.emp_id
+        ) m
+        GROUP BY m.pay_month, m.dept_id, m.dept_name
+        ORDER BY m.pay_month, m.dept_id`,
+		q.From.Start(), q.To.End(), q.DeptID, tax.SocialSecurityRate, tax.SocialSecurityMonthlyCap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query labour cost: %w", err)
+	}
+	defer rows.Close()
+
+	costs := []LabourCost{}
+	for rows.Next() {
+		var c LabourCost
+		if err := rows.Scan(&c.PayMonth, &c.DeptID, &c.DeptName, &c.Employees,
+			&c.Gross, &c.EmployerSocialSecurity, &c.ProvidentFund); err != nil {
+			return nil, fmt.Errorf("failed to scan labour cost: %w", err)
+		}
+		costs = append(costs, c)
+	}
+	return costs, rows.Err()
+}
+
+func (s *sqlStore) salaryByPosition(ctx context.Context, q Query) ([]PositionSalary, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT COALESCE(e.position_name, ''),
+               COUNT(DISTINCT p.emp_id),
+               AVG(COALESCE(p.base_salary, 0)),
+               MIN(COALESCE(p.base_salary, 0)),
+               MAX(COALESCE(p.base_salary, 0))
+        FROM payroll p
+        JOIN employees e ON e.emp_id = p.emp_id
+        WHERE p.period_start BETWEEN $1 AND $2 AND ($3 = 0 OR e.dept_id = $3)
+        GROUP BY COALESCE(e.position_name, '')
+        ORDER BY COALESCE(e.position_name, '')`, q.From.Start(), q.To.End(), q.DeptID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query salaries by position: %w", err)
+	}
+	defer rows.Close()
+
+	positions := []PositionSalary{}
+	for rows.Next() {
+		var p PositionSalary
+		if err := rows.Scan(&p.PositionName, &p.Employees, &p.AverageSalary, &p.MinSalary, &p.MaxSalary); err != nil {
+			return nil, fmt.Errorf("failed to scan salaries by position: %w", err)
+		}
+		positions = append(positions, p)
+	}
+	return positions, rows.Err()
+}
@@ -0,0 +1,101 @@
+// Package sheets reads tables from CSV and Excel (XLSX) files, as exported by spreadsheets and
+// HR systems, into rows of strings.
+package sheets
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Formats
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+// ErrUnsupportedFormat is returned for a file that is neither CSV nor XLSX
+var ErrUnsupportedFormat = errors.New("unsupported file format: upload a .csv or .xlsx file")
+
+// FormatOf picks the format from a file name, falling back to the content type
+func FormatOf(filename, contentType string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return CSV, nil
+	case ".xlsx":
+		return XLSX, nil
+	}
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return CSV, nil
+	case strings.HasPrefix(contentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"):
+		return XLSX, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Read returns the rows of a CSV file, or of the first sheet of an XLSX workbook. Trailing empty
+// cells are trimmed, so rows may be shorter than the header.
+func Read(r io.Reader, format string) ([][]string, error) {
+	switch format {
+	case CSV:
+		return readCSV(r)
+	case XLSX:
+		return readXLSX(r)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// Excel writes a byte order mark at the start of UTF-8 CSV files
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	for i, row := range rows {
+		rows[i] = trimTrailing(row)
+	}
+	return rows, nil
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX: %w", err)
+	}
+	defer f.Close()
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("invalid XLSX: the workbook has no sheets")
+	}
+	rows, err := f.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX: %w", err)
+	}
+	for i, row := range rows {
+		rows[i] = trimTrailing(row)
+	}
+	return rows, nil
+}
+
+func trimTrailing(row []string) []string {
+	n := len(row)
+	for n > 0 && strings.TrimSpace(row[n-1]) == "" {
+		n--
+	}
+	return row[:n]
+}
@@ -0,0 +1,161 @@
+package sheets
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReadCSV(t *testing.T) {
+	rows, err := Read(strings.NewReader("\ufeffemp_id,emp_name,,\n1, \"Somchai, J\"\n\n2,B,x\n"), CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"emp_id", "emp_name"}, {"1", "Somchai, J"}, {"2", "B", "x"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %q, want %q", rows, want)
+	}
+	if _, err := Read(strings.NewReader("a,\"b\n"), CSV); err == nil {
+		t.Fatal("unterminated quote was accepted")
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	for cell, v := range map[string]any{"A1": "emp_id", "B1": "base_salary", "A2": 1, "B2": 30000.5} {
+		if err := f.SetCellValue("Sheet1", cell, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := Read(&buf, XLSX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"emp_id", "base_salary"}, {"1", "30000.5"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %q, want %q", rows, want)
+	}
+	if _, err := Read(strings.NewReader("not a zip"), XLSX); err == nil {
+		t.Fatal("a non-XLSX file was accepted")
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	for _, tc := range []struct{ name, contentType, want string }{
+		{"staff.CSV", "", CSV},
+		{"staff.xlsx", "application/octet-stream", XLSX},
+		{"upload", "text/csv; charset=utf-8", CSV},
+	} {
+		if got, err := FormatOf(tc.name, tc.contentType); err != nil || got != tc.want {
+			t.Errorf("FormatOf(%q, %q) = %q, %v", tc.name, tc.contentType, got, err)
+		}
+	}
+	if _, err := FormatOf("staff.xls", ""); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("FormatOf(.xls) error = %v", err)
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	cols := []Column{{Header: "emp_id", Kind: Integer}, {Header: "emp_name"}, {Header: "net_salary", Kind: Money}}
+	for _, format := range []string{CSV, XLSX} {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Sheet("Ops", cols); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteRow(1, "สมชาย", 29250.5); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteTotals(nil, "Total", 29250.5); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := Read(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := [][]string{{"emp_id", "emp_name", "net_salary"}, {"1", "สมชาย", "29250.50"}, {"", "Total", "29250.50"}}
+		if format == XLSX {
+			// Excel shows the amounts with the sheet's number format
+			want[1][2], want[2][2] = "29,250.50", "29,250.50"
+		}
+		if !reflect.DeepEqual(rows, want) {
+			t.Errorf("%s rows = %q, want %q", format, rows, want)
+		}
+	}
+}
+
+func TestWorkbookSheets(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, XLSX)
+	for _, name := range []string{"Sales/Marketing", "sales_marketing", "An unusually long department name here"} {
+		if err := w.Sheet(name, []Column{{Header: "a"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	want := []string{"Sales_Marketing", "sales_marketing (2)", "An unusually long department na"}
+	if got := f.GetSheetList(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("sheets = %q, want %q", got, want)
+	}
+
+	csvw, _ := NewWriter(&bytes.Buffer{}, CSV)
+	_ = csvw.Sheet("a", nil)
+	if err := csvw.Sheet("b", nil); err == nil {
+		t.Fatal("a CSV file accepted a second sheet")
+	}
+}
+
+func TestWriteEscapesFormulas(t *testing.T) {
+	cols := []Column{{Header: "emp_name"}, {Header: "net_salary", Kind: Money}}
+	for _, format := range []string{CSV, XLSX} {
+		var buf bytes.Buffer
+		w, _ := NewWriter(&buf, format)
+		if err := w.Sheet("Ops", cols); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{`=HYPERLINK("http://example.com","A")`, "+1", "-1", "@SUM(A1)", "\tA", "A=B"} {
+			if err := w.WriteRow(name, -5.0); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := Read(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, row := range rows[1:] {
+			got = append(got, row[0])
+		}
+		want := []string{`'=HYPERLINK("http://example.com","A")`, "'+1", "'-1", "'@SUM(A1)", "'\tA", "A=B"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s names = %q, want %q", format, got, want)
+		}
+		if amount := rows[1][1]; amount != "-5.00" {
+			t.Errorf("%s amount = %q, want a number", format, amount)
+		}
+	}
+}
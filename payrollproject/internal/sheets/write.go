@@ -0,0 +1,285 @@
+package sheets
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Kind says how a column's values are written
+type Kind int
+
+const (
+	// Text is written as is
+	Text Kind = iota
+	// Integer is a whole number such as an ID
+	Integer
+	// Money is an amount in baht: two decimals, with thousands separators in a workbook
+	Money
+)
+
+// Column describes one column of a sheet
+type Column struct {
+	Header string
+	Kind   Kind
+	Width  float64 // in characters; zero picks a width from the kind
+}
+
+// Writer writes tables row by row as they are produced, so an export never holds all of its rows
+// in memory. A CSV file holds a single sheet; a workbook holds any number.
+type Writer interface {
+	// Sheet starts a new sheet and writes its header row
+	Sheet(name string, cols []Column) error
+	// WriteRow writes one row of values in column order; nil leaves a cell empty
+	WriteRow(values ...any) error
+	// WriteTotals writes a row of totals, set in bold in a workbook
+	WriteTotals(values ...any) error
+	// Close finishes the file. Nothing of a workbook reaches the underlying writer before Close.
+	Close() error
+}
+
+// ContentType is the media type of a format
+func ContentType(format string) string {
+	if format == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// NewWriter returns a Writer producing the format on w
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{w: w}, nil
+	case XLSX:
+		return newXLSXWriter(w)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+type csvWriter struct {
+	w  io.Writer
+	cw *csv.Writer
+}
+
+func (cw *csvWriter) Sheet(_ string, cols []Column) error {
+	if cw.cw != nil {
+		return errors.New("a CSV file holds a single sheet")
+	}
+	// The byte order mark makes Excel read the file as UTF-8, so Thai text survives
+	if _, err := io.WriteString(cw.w, "\ufeff"); err != nil {
+		return err
+	}
+	cw.cw = csv.NewWriter(cw.w)
+	header := make([]string, len(cols))
+	for i, col := range cols {
+		header[i] = col.Header
+	}
+	return cw.cw.Write(header)
+}
+
+func (cw *csvWriter) WriteRow(values ...any) error {
+	if cw.cw == nil {
+		return errors.New("WriteRow before Sheet")
+	}
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = csvCell(v)
+	}
+	if err := cw.cw.Write(record); err != nil {
+		return err
+	}
+	return cw.cw.Error()
+}
+
+func (cw *csvWriter) WriteTotals(values ...any) error { return cw.WriteRow(values...) }
+
+func (cw *csvWriter) Close() error {
+	if cw.cw == nil {
+		return nil
+	}
+	cw.cw.Flush()
+	return cw.cw.Error()
+}
+
+func csvCell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case *float64:
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', 2, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// escapeFormula keeps a spreadsheet from running text as a formula: a cell starting with one of
+// = + - @ tab or carriage return gets a leading apostrophe, which shows it as text
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+type xlsxWriter struct {
+	w      io.Writer
+	f      *excelize.File
+	sw     *excelize.StreamWriter
+	cols   []Column
+	row    int
+	sheets map[string]bool
+	styles struct{ header, money, total, totalMoney int }
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	xw := &xlsxWriter{w: w, f: excelize.NewFile(), sheets: map[string]bool{}}
+	// Thai spreadsheets use the same grouping as en-US: 1,234,567.89
+	money := "#,##0.00"
+	styles := []struct {
+		id    *int
+		style excelize.Style
+	}{
+		{&xw.styles.header, excelize.Style{Font: &excelize.Font{Bold: true},
+			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"DDEBF7"}}}},
+		{&xw.styles.money, excelize.Style{CustomNumFmt: &money}},
+		{&xw.styles.total, excelize.Style{Font: &excelize.Font{Bold: true}}},
+		{&xw.styles.totalMoney, excelize.Style{Font: &excelize.Font{Bold: true}, CustomNumFmt: &money}},
+	}
+	for _, s := range styles {
+		id, err := xw.f.NewStyle(&s.style)
+		if err != nil {
+			xw.f.Close()
+			return nil, err
+		}
+		*s.id = id
+	}
+	return xw, nil
+}
+
+// sheetName makes name a valid, unique worksheet name: at most 31 characters, none of []:*?/\
+func (xw *xlsxWriter) sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "Sheet"
+	}
+	base := []rune(name)
+	if len(base) > 31 {
+		base = base[:31]
+	}
+	name = string(base)
+	for n := 2; xw.sheets[strings.ToLower(name)]; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		cut := base
+		if len(cut)+len(suffix) > 31 {
+			cut = cut[:31-len(suffix)]
+		}
+		name = string(cut) + suffix
+	}
+	xw.sheets[strings.ToLower(name)] = true
+	return name
+}
+
+func (xw *xlsxWriter) Sheet(name string, cols []Column) error {
+	if xw.sw != nil {
+		if err := xw.sw.Flush(); err != nil {
+			return err
+		}
+	}
+	name = xw.sheetName(name)
+	if len(xw.sheets) == 1 {
+		if err := xw.f.SetSheetName("Sheet1", name); err != nil {
+			return err
+		}
+	} else if _, err := xw.f.NewSheet(name); err != nil {
+		return err
+	}
+	sw, err := xw.f.NewStreamWriter(name)
+	if err != nil {
+		return err
+	}
+	xw.sw, xw.cols, xw.row = sw, cols, 1
+	header := make([]any, len(cols))
+	for i, col := range cols {
+		width := col.Width
+		if width == 0 {
+			width = map[Kind]float64{Text: 20, Integer: 10, Money: 14}[col.Kind]
+		}
+		if err := sw.SetColWidth(i+1, i+1, width); err != nil {
+			return err
+		}
+		header[i] = excelize.Cell{StyleID: xw.styles.header, Value: col.Header}
+	}
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+	return xw.setRow(header)
+}
+
+func (xw *xlsxWriter) WriteRow(values ...any) error {
+	return xw.write(values, 0, xw.styles.money)
+}
+
+func (xw *xlsxWriter) WriteTotals(values ...any) error {
+	return xw.write(values, xw.styles.total, xw.styles.totalMoney)
+}
+
+func (xw *xlsxWriter) write(values []any, style, moneyStyle int) error {
+	if xw.sw == nil {
+		return errors.New("WriteRow before Sheet")
+	}
+	cells := make([]any, len(values))
+	for i, v := range values {
+		if p, ok := v.(*float64); ok {
+			v = nil
+			if p != nil {
+				v = *p
+			}
+		}
+		if s, ok := v.(string); ok {
+			v = escapeFormula(s)
+		}
+		cell := excelize.Cell{StyleID: style, Value: v}
+		if i < len(xw.cols) && xw.cols[i].Kind == Money {
+			cell.StyleID = moneyStyle
+		}
+		cells[i] = cell
+	}
+	return xw.setRow(cells)
+}
+
+func (xw *xlsxWriter) setRow(cells []any) error {
+	axis, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+	xw.row++
+	return xw.sw.SetRow(axis, cells)
+}
+
+func (xw *xlsxWriter) Close() error {
+	defer xw.f.Close()
+	if xw.sw != nil {
+		if err := xw.sw.Flush(); err != nil {
+			return err
+		}
+	}
+	_, err := xw.f.WriteTo(xw.w)
+	return err
+}
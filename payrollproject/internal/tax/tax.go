@@ -0,0 +1,181 @@
+package tax
+
+import "math"
+
+// Statutory allowance caps per tax year (baht), following the Revenue Department ล.ย.01 categories
+const (
+	PersonalAllowance           = 60000.0
+	SpouseAllowance             = 60000.0
+	ChildAllowance              = 30000.0
+	ChildBornFrom2018Allowance  = 60000.0 // second and later children born from 2018
+	ParentAllowance             = 30000.0
+	MaxParents                  = 4
+	DisabledDependantAllowance  = 60000.0
+	LifeInsuranceCap            = 100000.0
+	HealthInsuranceCap          = 25000.0
+	LifeAndHealthInsuranceCap   = 100000.0
+	ParentHealthInsuranceCap    = 15000.0
+	HomeLoanInterestCap         = 100000.0
+	SSFRate                     = 0.30
+	SSFCap                      = 200000.0
+	RMFRate                     = 0.30
+	RMFCap                      = 500000.0
+	ProvidentFundRate           = 0.15
+	ProvidentFundCap            = 500000.0
+	RetirementCombinedCap       = 500000.0
+	DonationRate                = 0.10
+	EmploymentExpenseRate       = 0.50
+	EmploymentExpenseCap        = 100000.0
+	SocialSecurityRate          = 0.05
+	SocialSecurityMonthlyCap    = 750.0
+	EducationDonationMultiplier = 2.0
+)
+
+// Bracket is one step of the progressive personal income tax table
+type Bracket struct {
+	UpTo float64 // upper bound of net income for this bracket, math.Inf(1) for the last one
+	Rate float64
+}
+
+// Brackets is the personal income tax table applied to net income
+var Brackets = []Bracket{
+	{UpTo: 150000, Rate: 0},
+	{UpTo: 300000, Rate: 0.05},
+	{UpTo: 500000, Rate: 0.10},
+	{UpTo: 750000, Rate: 0.15},
+	{UpTo: 1000000, Rate: 0.20},
+	{UpTo: 2000000, Rate: 0.25},
+	{UpTo: 5000000, Rate: 0.30},
+	{UpTo: math.Inf(1), Rate: 0.35},
+}
+
+// Allowances holds the amounts an employee declares for a tax year
+type Allowances struct {
+	HasSpouse               bool
+	NumChildren             int
+	NumChildrenBornFrom2018 int // second and later children born from 2018, counted within NumChildren
+	NumParents              int
+	NumDisabledDependants   int
+	LifeInsurance           float64
+	HealthInsurance         float64
+	ParentHealthInsurance   float64
+	HomeLoanInterest        float64
+	SSF                     float64
+	RMF                     float64
+	ProvidentFund           float64
+	Donations               float64
+	EducationDonations      float64
+}
+
+// Result is the breakdown of an annual tax calculation
+type Result struct {
+	AnnualIncome       float64 `json:"annual_income"`
+	EmploymentExpense  float64 `json:"employment_expense"`
+	SocialSecurity     float64 `json:"social_security"`
+	TotalAllowances    float64 `json:"total_allowances"`
+	Donations          float64 `json:"donations"`
+	TaxableIncome      float64 `json:"taxable_income"`
+	AnnualTax          float64 `json:"annual_tax"`
+	MonthlyWithholding float64 `json:"monthly_withholding"`
+	PeriodWithholding  float64 `json:"period_withholding"` // the tax withheld from each pay period's pay; the monthly withholding for monthly pay
+}
+
+// SocialSecurityContribution returns the employee's annual social security contribution for a monthly salary
+func SocialSecurityContribution(monthlySalary float64) float64 {
+	return math.Min(monthlySalary*SocialSecurityRate, SocialSecurityMonthlyCap) * 12
+}
+
+// SocialSecurityPeriodContribution returns the employee's social security contribution from the pay
+// of one of periodsPerYear pay periods, with the monthly cap spread over the periods of a year
+func SocialSecurityPeriodContribution(periodPay float64, periodsPerYear int) float64 {
+	return round2(math.Min(periodPay*SocialSecurityRate, SocialSecurityMonthlyCap*12/float64(periodsPerYear)))
+}
+
+// Compute calculates annual tax and monthly withholding for a monthly salary and declared allowances
+func Compute(monthlySalary float64, a Allowances) Result {
+	return ComputePeriod(monthlySalary, 12, a)
+}
+
+// ComputePeriod calculates annual tax for pay of periodPay in each of periodsPerYear pay periods,
+// such as 26 fortnights, and the withholding of each period. The pay is annualised as if every
+// period of the year paid the same, the way the Revenue Department has employers estimate the tax
+// of staff whose pay varies.
+func ComputePeriod(periodPay float64, periodsPerYear int, a Allowances) Result {
+	res := annual(periodPay*float64(periodsPerYear), SocialSecurityContribution(periodPay*(float64(periodsPerYear)/12)), a)
+	res.MonthlyWithholding = round2(res.AnnualTax / 12)
+	res.PeriodWithholding = round2(res.AnnualTax / float64(periodsPerYear))
+	return res
+}
+
+// OneOffWithholding returns the tax to withhold from extra pay, such as commission or overtime, paid
+// once on top of periodPay in one of periodsPerYear pay periods. Like a bonus it is not annualised:
+// the withholding is the tax the extra adds to the year's estimated income.
+func OneOffWithholding(periodPay float64, periodsPerYear int, a Allowances, extra float64) float64 {
+	if extra <= 0 {
+		return 0
+	}
+	regular := ComputePeriod(periodPay, periodsPerYear, a)
+	return round2(annual(regular.AnnualIncome+extra, regular.SocialSecurity, a).AnnualTax - regular.AnnualTax)
+}
+
+// annual calculates the tax on a year's income given the social security paid from it
+func annual(income, socialSecurity float64, a Allowances) Result {
+	res := Result{
+		AnnualIncome:      income,
+		EmploymentExpense: math.Min(income*EmploymentExpenseRate, EmploymentExpenseCap),
+		SocialSecurity:    socialSecurity,
+	}
+
+	res.TotalAllowances = PersonalAllowance + res.SocialSecurity + deductibleAllowances(income, a)
+
+	// Donations are capped at 10% of income remaining after expenses and all other allowances
+	beforeDonations := math.Max(income-res.EmploymentExpense-res.TotalAllowances, 0)
+	donations := math.Min(a.Donations+a.EducationDonations*EducationDonationMultiplier, beforeDonations*DonationRate)
+	res.Donations = donations
+
+	res.TaxableIncome = math.Max(beforeDonations-donations, 0)
+	res.AnnualTax = round2(ProgressiveTax(res.TaxableIncome))
+	return res
+}
+
+// ProgressiveTax applies the bracket table to net taxable income
+func ProgressiveTax(net float64) float64 {
+	var tax, lower float64
+	for _, b := range Brackets {
+		if net <= lower {
+			break
+		}
+		tax += (math.Min(net, b.UpTo) - lower) * b.Rate
+		lower = b.UpTo
+	}
+	return tax
+}
+
+// deductibleAllowances sums the declared allowances after applying their statutory caps
+func deductibleAllowances(income float64, a Allowances) float64 {
+	var total float64
+
+	if a.HasSpouse {
+		total += SpouseAllowance
+	}
+	bornFrom2018 := min(a.NumChildrenBornFrom2018, a.NumChildren)
+	total += float64(a.NumChildren-bornFrom2018)*ChildAllowance + float64(bornFrom2018)*ChildBornFrom2018Allowance
+	total += float64(min(a.NumParents, MaxParents)) * ParentAllowance
+	total += float64(a.NumDisabledDependants) * DisabledDependantAllowance
+
+	health := math.Min(a.HealthInsurance, HealthInsuranceCap)
+	total += math.Min(math.Min(a.LifeInsurance, LifeInsuranceCap)+health, LifeAndHealthInsuranceCap)
+	total += math.Min(a.ParentHealthInsurance, ParentHealthInsuranceCap)
+	total += math.Min(a.HomeLoanInterest, HomeLoanInterestCap)
+
+	ssf := math.Min(a.SSF, math.Min(income*SSFRate, SSFCap))
+	rmf := math.Min(a.RMF, math.Min(income*RMFRate, RMFCap))
+	pvd := math.Min(a.ProvidentFund, math.Min(income*ProvidentFundRate, ProvidentFundCap))
+	total += math.Min(ssf+rmf+pvd, RetirementCombinedCap)
+
+	return total
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,122 @@
+package tax
+
+import (
+	"math"
+	"testing"
+)
+
+func TestProgressiveTax(t *testing.T) {
+	for _, tc := range []struct {
+		net, want float64
+	}{
+		{-1000, 0},
+		{0, 0},
+		{150000, 0},
+		{150100, 5},
+		{300000, 7500},
+		{500000, 27500},
+		{750000, 65000},
+		{1000000, 115000},
+		{2000000, 365000},
+		{5000000, 1265000},
+		{6000000, 1615000},
+	} {
+		if got := ProgressiveTax(tc.net); math.Abs(got-tc.want) > 1e-6 {
+			t.Errorf("ProgressiveTax(%v) = %v, want %v", tc.net, got, tc.want)
+		}
+	}
+}
+
+func TestDeductibleAllowances(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		income float64
+		a      Allowances
+		want   float64
+	}{
+		{"none", 600000, Allowances{}, 0},
+		{"spouse", 600000, Allowances{HasSpouse: true}, 60000},
+		{"children born from 2018", 600000, Allowances{NumChildren: 2, NumChildrenBornFrom2018: 1}, 90000},
+		{"born from 2018 counted within children", 600000, Allowances{NumChildren: 3, NumChildrenBornFrom2018: 5}, 180000},
+		{"parents capped at four", 600000, Allowances{NumParents: 6}, 120000},
+		{"life insurance cap", 600000, Allowances{LifeInsurance: 150000}, 100000},
+		{"health insurance cap", 600000, Allowances{LifeInsurance: 50000, HealthInsurance: 40000}, 75000},
+		{"life and health combined cap", 600000, Allowances{LifeInsurance: 90000, HealthInsurance: 40000}, 100000},
+		{"parent health insurance cap", 600000, Allowances{ParentHealthInsurance: 20000}, 15000},
+		{"home loan interest cap", 600000, Allowances{HomeLoanInterest: 150000}, 100000},
+		{"SSF rate of income", 500000, Allowances{SSF: 300000}, 150000},
+		{"SSF cap", 1000000, Allowances{SSF: 300000}, 200000},
+		{"provident fund rate of income", 1000000, Allowances{ProvidentFund: 200000}, 150000},
+		{"retirement combined cap", 2000000, Allowances{SSF: 300000, RMF: 300000, ProvidentFund: 200000}, 500000},
+	} {
+		if got := deductibleAllowances(tc.income, tc.a); got != tc.want {
+			t.Errorf("%s: deductibleAllowances = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSocialSecurity(t *testing.T) {
+	for _, tc := range []struct {
+		monthly, want float64
+	}{
+		{10000, 6000},
+		{15000, 9000},
+		{50000, 9000},
+	} {
+		if got := SocialSecurityContribution(tc.monthly); got != tc.want {
+			t.Errorf("SocialSecurityContribution(%v) = %v, want %v", tc.monthly, got, tc.want)
+		}
+	}
+	if got := SocialSecurityPeriodContribution(40000, 26); got != 346.15 {
+		t.Errorf("biweekly contribution = %v, want the monthly cap spread over 26 periods", got)
+	}
+}
+
+func TestComputePeriod(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		pay          float64
+		periods      int
+		a            Allowances
+		taxable, tax float64
+		period       float64
+	}{
+		// 600,000 less 100,000 expenses, 60,000 personal allowance and 9,000 social security
+		{name: "monthly", pay: 50000, periods: 12, taxable: 431000, tax: 20600, period: 1716.67},
+		{name: "spouse", pay: 50000, periods: 12, a: Allowances{HasSpouse: true}, taxable: 371000, tax: 14600, period: 1216.67},
+		// Donations are capped at 10% of the income left after the other allowances
+		{name: "donation cap", pay: 50000, periods: 12, a: Allowances{Donations: 100000}, taxable: 387900, tax: 16290, period: 1357.5},
+		{name: "biweekly", pay: 25000, periods: 26, taxable: 481000, tax: 25600, period: 984.62},
+		{name: "within the tax-free band", pay: 15000, periods: 12, taxable: 21000, tax: 0, period: 0},
+	} {
+		res := ComputePeriod(tc.pay, tc.periods, tc.a)
+		if res.TaxableIncome != tc.taxable || res.AnnualTax != tc.tax || res.PeriodWithholding != tc.period {
+			t.Errorf("%s: taxable %v, tax %v, per period %v; want %v, %v, %v", tc.name,
+				res.TaxableIncome, res.AnnualTax, res.PeriodWithholding, tc.taxable, tc.tax, tc.period)
+		}
+	}
+	if res := Compute(50000, Allowances{}); res.MonthlyWithholding != 1716.67 || res.PeriodWithholding != res.MonthlyWithholding {
+		t.Errorf("Compute = %+v", res)
+	}
+}
+
+func TestOneOffWithholding(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		pay     float64
+		periods int
+		extra   float64
+		want    float64
+	}{
+		// 10,000 more on a taxable 431,000 falls in the 10% band
+		{name: "commission", pay: 50000, periods: 12, extra: 10000, want: 1000},
+		// Crossing into the 15% band at 500,000 taxes the part above it at 15%
+		{name: "across a band", pay: 50000, periods: 12, extra: 100000, want: 11550},
+		{name: "within the tax-free band", pay: 15000, periods: 12, extra: 5000, want: 0},
+		{name: "no extra", pay: 50000, periods: 12, want: 0},
+	} {
+		if got := OneOffWithholding(tc.pay, tc.periods, Allowances{}, tc.extra); got != tc.want {
+			t.Errorf("%s: withholding %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}